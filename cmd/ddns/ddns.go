@@ -10,6 +10,7 @@ import (
 	"github.com/favonia/cloudflare-ddns/internal/config"
 	"github.com/favonia/cloudflare-ddns/internal/cron"
 	"github.com/favonia/cloudflare-ddns/internal/monitor"
+	"github.com/favonia/cloudflare-ddns/internal/netevent"
 	"github.com/favonia/cloudflare-ddns/internal/notifier"
 	"github.com/favonia/cloudflare-ddns/internal/pp"
 	"github.com/favonia/cloudflare-ddns/internal/setter"
@@ -46,7 +47,7 @@ func initConfig(ppfmt pp.PP) (*config.Config, setter.Setter, bool) {
 	}
 
 	// Get the setter
-	s, ok := setter.New(ppfmt, h)
+	s, ok := setter.New(ppfmt, h, c.RecordOwnership)
 	if !ok {
 		return c, nil, false
 	}
@@ -68,6 +69,11 @@ func main() {
 }
 
 func realMain() int {
+	// Run the diagnostic subcommand "detect" instead of updating DNS records
+	if len(os.Args) > 1 && os.Args[1] == "detect" {
+		return detectMain(os.Args[2:])
+	}
+
 	// Get the contexts and start catching SIGINT and SIGTERM
 	ctx := context.Background()
 	sig := signal.Setup()
@@ -114,6 +120,26 @@ func realMain() int {
 		ppfmt.Noticef(pp.EmojiMute, "Quiet mode enabled")
 	}
 
+	// Watch network address changes so that updates can start without waiting for UPDATE_CRON
+	var addressChanges <-chan struct{}
+	if c.UpdateOnChange {
+		if w, ok := netevent.New(ppfmt, c.ChangeDebounce, c.ChangeMinInterval); ok {
+			defer w.Close()
+			addressChanges = w.C
+		} else {
+			ppfmt.Noticef(pp.EmojiWarning, "Falling back to checking the IP addresses only according to UPDATE_CRON")
+		}
+	}
+
+	// Remember new IP addresses waiting to be confirmed across rounds
+	holdDown := updater.NewHoldDown()
+
+	// Remember the lasting disagreements among providers across rounds
+	disagreements := updater.NewDisagreements()
+
+	// Remember when the heartbeat TXT record was last written
+	heartbeat := updater.NewHeartbeat(Version)
+
 	first := true
 	for {
 		// The next time to run the updater.
@@ -127,7 +153,7 @@ func realMain() int {
 			// Improve readability of the logging by separating each round of checks with blank lines.
 			ppfmt.BlankLineIfVerbose()
 
-			msg := updater.UpdateIPs(ctxWithSignals, ppfmt, c, s)
+			msg := updater.UpdateIPs(ctxWithSignals, ppfmt, c, s, holdDown, disagreements, heartbeat)
 			c.Monitor.Ping(ctx, ppfmt, msg.MonitorMessage)
 			c.Notifier.Send(ctx, ppfmt, msg.NotifierMessage)
 		}
@@ -167,8 +193,8 @@ func realMain() int {
 		cron.PrintCountdown(ppfmt, "Checking the IP addresses", time.Now(), next)
 
 	signaled:
-		// Wait for the next signal or the alarm, whichever comes first
-		if sig.WaitForSignalsUntil(ppfmt, next) {
+		// Wait for the next signal, the next change of network addresses, or the alarm, whichever comes first
+		if sig.WaitForSignalsOrEventsUntil(ppfmt, next, addressChanges) {
 			stopUpdating(ctx, ppfmt, c, s)
 			c.Monitor.Exit(ctx, ppfmt, "Stopped")
 			if c.UpdateCron != nil {
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/favonia/cloudflare-ddns/internal/config"
+	"github.com/favonia/cloudflare-ddns/internal/detect"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/signal"
+)
+
+// detectMain runs the subcommand "detect", which compares the IP addresses detected by all known providers
+// without touching DNS records or WAF lists. The results go to stdout and the logging goes to stderr.
+func detectMain(args []string) int {
+	flags := flag.NewFlagSet("detect", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	useJSON := flags.Bool("json", false, "print the results in JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2 //nolint:mnd
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background())
+	defer cancel()
+
+	ppfmt, ok := config.SetupPP(os.Stderr)
+	if !ok {
+		return 1
+	}
+
+	if flags.NArg() > 0 {
+		ppfmt.Noticef(pp.EmojiUserError, "Unexpected arguments for the subcommand detect: %q", flags.Args())
+		return 2 //nolint:mnd
+	}
+
+	ppfmt.Infof(pp.EmojiStar, "%s", formatName())
+
+	// Read only the settings about the detection; no API token is needed
+	c := config.Default()
+	if !c.ReadDetectionEnv(ppfmt) {
+		ppfmt.Infof(pp.EmojiBye, "Bye!")
+		return 1
+	}
+
+	ppfmt.Infof(pp.EmojiInternet, "Running all the providers . . .")
+	results := detect.Run(ctx, detect.Candidates(c.Provider, detect.Interfaces(ppfmt)),
+		c.AllowedIPs, c.DetectionTimeout)
+
+	var err error
+	if *useJSON {
+		err = detect.PrintJSON(os.Stdout, results)
+	} else {
+		err = detect.PrintTable(os.Stdout, results)
+	}
+	if err != nil {
+		ppfmt.Noticef(pp.EmojiError, "Failed to print the results: %v", err)
+		return 1
+	}
+
+	return 0
+}
@@ -33,6 +33,7 @@ type RecordParams struct {
 	TTL
 	Proxied bool
 	Comment string
+	Tags    []string
 }
 
 // Record represents a DNS record.
@@ -42,6 +43,14 @@ type Record struct {
 	RecordParams
 }
 
+// TXTRecord represents a TXT record.
+type TXTRecord struct {
+	ID
+	Content string
+	Comment string
+	Tags    []string
+}
+
 // WAFListItem bundles an ID and an IP range, representing an item in a WAF list.
 type WAFListItem struct {
 	ID
@@ -62,11 +71,12 @@ const (
 // A Handle represents a generic API to update DNS records and WAF lists.
 // Currently, the only implementation is Cloudflare.
 type Handle interface {
-	// ListRecords lists all matching DNS records.
+	// ListRecords lists all matching DNS records. Mismatched parameters of the records
+	// owned by the updater according to ownership are reported as hints.
 	//
 	// The second return value indicates whether the list was cached.
 	ListRecords(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type, domain domain.Domain,
-		expectedParams RecordParams,
+		expectedParams RecordParams, ownership Ownership,
 	) ([]Record, bool, bool)
 
 	// UpdateRecord updates one DNS record.
@@ -81,6 +91,31 @@ type Handle interface {
 	// DeleteRecord deletes one DNS record, assuming we will not update or create any DNS records.
 	DeleteRecord(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type, domain domain.Domain, id ID, mode DeletionMode) bool
 
+	// ListSVCBRecords lists all HTTPS and SVCB records of a domain. Unlike [Handle.ListRecords],
+	// it neither checks the ownership nor reports mismatched parameters, because these records
+	// are never created or deleted by the updater; only their hints are rewritten.
+	//
+	// The second return value indicates whether the list was cached.
+	ListSVCBRecords(ctx context.Context, ppfmt pp.PP, domain domain.Domain) ([]SVCBRecord, bool, bool)
+
+	// UpdateSVCBRecord updates one HTTPS or SVCB record, replacing its priority, target, and SvcParams
+	// and keeping its tags.
+	UpdateSVCBRecord(ctx context.Context, ppfmt pp.PP, domain domain.Domain, r SVCBRecord) bool
+
+	// ListTXTRecords lists all TXT records of a domain. Like [Handle.ListSVCBRecords],
+	// it neither checks the ownership nor reports mismatched parameters.
+	//
+	// The second return value indicates whether the list was cached.
+	ListTXTRecords(ctx context.Context, ppfmt pp.PP, domain domain.Domain) ([]TXTRecord, bool, bool)
+
+	// UpdateTXTRecord updates one TXT record, replacing its content and keeping its tags.
+	UpdateTXTRecord(ctx context.Context, ppfmt pp.PP, domain domain.Domain, r TXTRecord) bool
+
+	// CreateTXTRecord creates one TXT record with the TTL, comment, and tags in params.
+	// It returns the ID of the new record.
+	CreateTXTRecord(ctx context.Context, ppfmt pp.PP, domain domain.Domain,
+		content string, params RecordParams) (ID, bool)
+
 	// ListWAFListItems retrieves a WAF list with IP rages.
 	// It creates an empty WAF list with IP ranges if it does not already exist yet.
 	// The first return value is the ID of the list.
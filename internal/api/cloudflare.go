@@ -24,7 +24,9 @@ type CloudflareCache = struct {
 	listZones      *ttlcache.Cache[string, []ID] // zone names to zone IDs
 	zoneIDOfDomain *ttlcache.Cache[string, ID]   // domain names to their zone IDs
 	// records of domains
-	listRecords map[ipnet.Type]*ttlcache.Cache[string, *[]Record] // domain names to records.
+	listRecords     map[ipnet.Type]*ttlcache.Cache[string, *[]Record] // domain names to records.
+	listSVCBRecords *ttlcache.Cache[string, *[]SVCBRecord]            // domain names to HTTPS/SVCB records.
+	listTXTRecords  *ttlcache.Cache[string, *[]TXTRecord]             // domain names to TXT records.
 	// lists to list IDs
 	listLists *ttlcache.Cache[ID, *[]WAFListMeta] // account IDs to list names to list IDs and other meta information
 	listID    *ttlcache.Cache[WAFList, ID]        // lists to list IDs
@@ -77,9 +79,11 @@ func (t CloudflareAuth) New(ppfmt pp.PP, cacheExpiration time.Duration) (Handle,
 				ipnet.IP4: newCache[string, *[]Record](cacheExpiration),
 				ipnet.IP6: newCache[string, *[]Record](cacheExpiration),
 			},
-			listLists:     newCache[ID, *[]WAFListMeta](cacheExpiration),
-			listID:        newCache[WAFList, ID](cacheExpiration),
-			listListItems: newCache[WAFList, *[]WAFListItem](cacheExpiration),
+			listSVCBRecords: newCache[string, *[]SVCBRecord](cacheExpiration),
+			listTXTRecords:  newCache[string, *[]TXTRecord](cacheExpiration),
+			listLists:       newCache[ID, *[]WAFListMeta](cacheExpiration),
+			listID:          newCache[WAFList, ID](cacheExpiration),
+			listListItems:   newCache[WAFList, *[]WAFListItem](cacheExpiration),
 		},
 	}
 
@@ -93,6 +97,8 @@ func (h CloudflareHandle) FlushCache() {
 	for _, cache := range h.cache.listRecords {
 		cache.DeleteAll()
 	}
+	h.cache.listSVCBRecords.DeleteAll()
+	h.cache.listTXTRecords.DeleteAll()
 	h.cache.listLists.DeleteAll()
 	h.cache.listID.DeleteAll()
 	h.cache.listListItems.DeleteAll()
@@ -105,3 +111,11 @@ func DescribeFreeFormString(str string) string {
 	}
 	return strconv.Quote(str)
 }
+
+// DescribeTags quotes a list of tags for printing.
+func DescribeTags(tags []string) string {
+	if len(tags) == 0 {
+		return "empty"
+	}
+	return pp.JoinMap(strconv.Quote, tags)
+}
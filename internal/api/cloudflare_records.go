@@ -56,6 +56,18 @@ func hintMismatchedComment(ppfmt pp.PP, ipNet ipnet.Type, domain domain.Domain,
 	)
 }
 
+func hintMismatchedTags(ppfmt pp.PP, ipNet ipnet.Type, domain domain.Domain, id ID, current, expected []string) {
+	ppfmt.Noticef(pp.EmojiUserWarning,
+		`The tags for %s record of %s (ID: %s) are %s. However, they are expected to be %s. You can either change the tags in the Cloudflare dashboard at https://dash.cloudflare.com or change the value of RECORD_TAGS to match the current tags.`, //nolint:lll
+		ipNet.RecordType(), domain.Describe(), id, DescribeTags(current), DescribeTags(expected),
+	)
+}
+
+// sameTags checks whether two lists of tags are the same up to reordering.
+func sameTags(tags1, tags2 []string) bool {
+	return slices.Equal(slices.Sorted(slices.Values(tags1)), slices.Sorted(slices.Values(tags2)))
+}
+
 // ListZones returns a list of zone IDs with the zone name.
 func (h CloudflareHandle) ListZones(ctx context.Context, ppfmt pp.PP, name string) ([]ID, bool) {
 	// WithZoneFilters does not work with the empty zone name,
@@ -140,7 +152,7 @@ zoneSearch:
 
 // ListRecords calls cloudflare.ListDNSRecords.
 func (h CloudflareHandle) ListRecords(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type, domain domain.Domain,
-	expectedParams RecordParams,
+	expectedParams RecordParams, ownership Ownership,
 ) ([]Record, bool, bool) {
 	if rmap := h.cache.listRecords[ipNet].Get(domain.DNSNameASCII()); rmap != nil {
 		return *rmap.Value(), true, true
@@ -177,26 +189,30 @@ func (h CloudflareHandle) ListRecords(ctx context.Context, ppfmt pp.PP, ipNet ip
 			return nil, false, false
 		}
 
-		if TTL(r.TTL) != expectedParams.TTL {
-			hintMismatchedTTL(ppfmt, ipNet, domain, id, TTL(r.TTL), expectedParams.TTL)
-		}
-		currentProxied := r.Proxied != nil && *r.Proxied // by default, proxied = false
-		if currentProxied != expectedParams.Proxied {
-			hintMismatchedProxied(ppfmt, ipNet, domain, id, currentProxied, expectedParams.Proxied)
+		params := RecordParams{
+			TTL:     TTL(r.TTL),
+			Proxied: r.Proxied != nil && *r.Proxied, // by default, proxied = false
+			Comment: r.Comment,
+			Tags:    r.Tags,
 		}
-		if r.Comment != expectedParams.Comment {
-			hintMismatchedComment(ppfmt, ipNet, domain, id, r.Comment, expectedParams.Comment)
+
+		// Records not owned by the updater are left alone, and so are their parameters.
+		if ownership.Owns(params, expectedParams) {
+			if params.TTL != expectedParams.TTL {
+				hintMismatchedTTL(ppfmt, ipNet, domain, id, params.TTL, expectedParams.TTL)
+			}
+			if params.Proxied != expectedParams.Proxied {
+				hintMismatchedProxied(ppfmt, ipNet, domain, id, params.Proxied, expectedParams.Proxied)
+			}
+			if params.Comment != expectedParams.Comment {
+				hintMismatchedComment(ppfmt, ipNet, domain, id, params.Comment, expectedParams.Comment)
+			}
+			if !sameTags(params.Tags, expectedParams.Tags) {
+				hintMismatchedTags(ppfmt, ipNet, domain, id, params.Tags, expectedParams.Tags)
+			}
 		}
 
-		rs = append(rs, Record{
-			ID: ID(r.ID),
-			IP: ip,
-			RecordParams: RecordParams{
-				TTL:     TTL(r.TTL),
-				Proxied: r.Proxied != nil && *r.Proxied,
-				Comment: r.Comment,
-			},
-		})
+		rs = append(rs, Record{ID: id, IP: ip, RecordParams: params})
 	}
 
 	h.cache.listRecords[ipNet].DeleteExpired()
@@ -246,6 +262,8 @@ func (h CloudflareHandle) UpdateRecord(ctx context.Context, ppfmt pp.PP,
 	params := cloudflare.UpdateDNSRecordParams{
 		ID:      string(id),
 		Content: ip.String(),
+		// The API always sets the tags, so the current tags have to be sent back to keep them.
+		Tags: currentParams.Tags,
 	}
 
 	r, err := h.cf.UpdateDNSRecord(ctx, cloudflare.ZoneIdentifier(string(zone)), params)
@@ -269,11 +287,15 @@ func (h CloudflareHandle) UpdateRecord(ctx context.Context, ppfmt pp.PP,
 	if r.Comment != currentParams.Comment && r.Comment != expectedParams.Comment {
 		hintMismatchedComment(ppfmt, ipNet, domain, id, r.Comment, expectedParams.Comment)
 	}
+	if !sameTags(r.Tags, currentParams.Tags) && !sameTags(r.Tags, expectedParams.Tags) {
+		hintMismatchedTags(ppfmt, ipNet, domain, id, r.Tags, expectedParams.Tags)
+	}
 
 	updatedParams := RecordParams{
 		TTL:     TTL(r.TTL),
 		Proxied: updatedProxied,
 		Comment: r.Comment,
+		Tags:    r.Tags,
 	}
 
 	if rs := h.cache.listRecords[ipNet].Get(domain.DNSNameASCII()); rs != nil {
@@ -308,6 +330,7 @@ func (h CloudflareHandle) CreateRecord(ctx context.Context, ppfmt pp.PP,
 		TTL:     params.TTL.Int(),
 		Proxied: &params.Proxied,
 		Comment: params.Comment,
+		Tags:    params.Tags,
 	}
 
 	res, err := h.cf.CreateDNSRecord(ctx, cloudflare.ZoneIdentifier(string(zone)), ps)
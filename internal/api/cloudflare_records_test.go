@@ -449,6 +449,7 @@ func TestListRecords(t *testing.T) {
 				TTL:     100,
 				Proxied: true,
 				Comment: "hello",
+				Tags:    []string{"owner:ddns"},
 			},
 			[]api.Record{{"record1", mustIP("::1"), params}},
 			true,
@@ -468,6 +469,11 @@ func TestListRecords(t *testing.T) {
 					"AAAA", "sub.test.org", api.ID("record1"),
 					"empty", `"hello"`,
 				)
+				ppfmt.EXPECT().Noticef(pp.EmojiUserWarning,
+					`The tags for %s record of %s (ID: %s) are %s. However, they are expected to be %s. You can either change the tags in the Cloudflare dashboard at https://dash.cloudflare.com or change the value of RECORD_TAGS to match the current tags.`,
+					"AAAA", "sub.test.org", api.ID("record1"),
+					"empty", `"owner:ddns"`,
+				)
 			},
 			true,
 			nil,
@@ -491,7 +497,7 @@ func TestListRecords(t *testing.T) {
 			lrh := newListRecordsHandler(t, mux, ipnet.IP6, tc.recordDomain, tc.records)
 			lrh.setRequestLimit(tc.listRequestLimit)
 
-			rs, cached, ok := h.ListRecords(context.Background(), mockPP, ipnet.IP6, tc.input, tc.expectedParams)
+			rs, cached, ok := h.ListRecords(context.Background(), mockPP, ipnet.IP6, tc.input, tc.expectedParams, api.OwnAllRecords)
 			require.Equal(t, tc.ok, ok)
 			require.False(t, cached)
 			require.Equal(t, tc.expected, rs)
@@ -503,7 +509,7 @@ func TestListRecords(t *testing.T) {
 			if tc.prepareMocksForCached != nil {
 				tc.prepareMocksForCached(mockPP)
 			}
-			rs, cached, ok = h.ListRecords(context.Background(), mockPP, ipnet.IP6, tc.input, tc.expectedParams)
+			rs, cached, ok = h.ListRecords(context.Background(), mockPP, ipnet.IP6, tc.input, tc.expectedParams, api.OwnAllRecords)
 			require.Equal(t, tc.ok, ok)
 			require.Equal(t, tc.cached, cached)
 			require.Equal(t, tc.expected, rs)
@@ -511,6 +517,33 @@ func TestListRecords(t *testing.T) {
 	}
 }
 
+func TestListRecordsForeign(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mux, h, ok := newHandle(t, mockPP)
+	require.True(t, ok)
+
+	zh := newZonesHandler(t, mux, map[string][]string{"test.org": {"active"}})
+	zh.setRequestLimit(2)
+
+	lrh := newListRecordsHandler(t, mux, ipnet.IP6, "sub.test.org", []formattedRecord{{"record1", "::1"}})
+	lrh.setRequestLimit(1)
+
+	params := api.RecordParams{TTL: api.TTLAuto, Proxied: false, Comment: ""}
+	expectedParams := api.RecordParams{TTL: 100, Proxied: true, Comment: "hello"}
+
+	// the only record is not owned by the updater, and thus no hints should be printed
+	rs, cached, ok := h.ListRecords(context.Background(), mockPP, ipnet.IP6, domain.FQDN("sub.test.org"),
+		expectedParams, api.OwnRecordsByComment)
+	require.True(t, ok)
+	require.False(t, cached)
+	require.Equal(t, []api.Record{{"record1", mustIP("::1"), params}}, rs)
+	require.True(t, zh.isExhausted())
+	require.True(t, lrh.isExhausted())
+}
+
 func envelopDNSRecordResponse(record cloudflare.DNSRecord) cloudflare.DNSRecordResponse {
 	return cloudflare.DNSRecordResponse{
 		Result:     record,
@@ -613,9 +646,9 @@ func TestDeleteRecord(t *testing.T) {
 				if tc.prepareMocks != nil {
 					tc.prepareMocks(mockPP)
 				}
-				h.ListRecords(context.Background(), mockPP, ipnet.IP6, domain.FQDN("sub.test.org"), params)
+				h.ListRecords(context.Background(), mockPP, ipnet.IP6, domain.FQDN("sub.test.org"), params, api.OwnAllRecords)
 				_ = h.DeleteRecord(context.Background(), mockPP, ipnet.IP6, domain.FQDN("sub.test.org"), "record1", false)
-				rs, cached, ok := h.ListRecords(context.Background(), mockPP, ipnet.IP6, domain.FQDN("sub.test.org"), params)
+				rs, cached, ok := h.ListRecords(context.Background(), mockPP, ipnet.IP6, domain.FQDN("sub.test.org"), params, api.OwnAllRecords)
 				require.Equal(t, tc.ok, ok)
 				require.True(t, cached)
 				require.Empty(t, rs)
@@ -655,8 +688,11 @@ func newUpdateRecordHandler(t *testing.T, mux *http.ServeMux, id string, ip stri
 				return
 			}
 
+			response := mockDNSRecordResponse("record1", ipnet.IP6, "sub.test.org", "::2")
+			response.Result.Tags = record.Tags
+
 			w.Header().Set("Content-Type", "application/json")
-			err := json.NewEncoder(w).Encode(mockDNSRecordResponse("record1", ipnet.IP6, "sub.test.org", "::2"))
+			err := json.NewEncoder(w).Encode(response)
 			assert.NoError(t, err)
 		})
 
@@ -706,6 +742,13 @@ func TestUpdateRecord(t *testing.T) {
 				ppfmt.EXPECT().Noticef(pp.EmojiError, "Failed to update a stale %s record of %s (ID: %s): %v", "AAAA", "sub.test.org", api.ID("record1"), gomock.Any())
 			},
 		},
+		"keep-tags": {
+			2, 0, 1,
+			api.RecordParams{TTL: api.TTLAuto, Proxied: false, Comment: "", Tags: []string{"env:home"}},
+			api.RecordParams{TTL: api.TTLAuto, Proxied: false, Comment: "", Tags: []string{"owner:ddns"}},
+			true,
+			nil, nil,
+		},
 		"mismatched-attributes": {
 			2, 0, 1,
 			api.RecordParams{
@@ -773,10 +816,10 @@ func TestUpdateRecord(t *testing.T) {
 				if tc.prepareMocksForCached != nil {
 					tc.prepareMocksForCached(mockPP)
 				}
-				h.ListRecords(context.Background(), mockPP, ipnet.IP6, domain.FQDN("sub.test.org"), params)
+				h.ListRecords(context.Background(), mockPP, ipnet.IP6, domain.FQDN("sub.test.org"), params, api.OwnAllRecords)
 				_ = h.UpdateRecord(context.Background(), mockPP, ipnet.IP6, domain.FQDN("sub.test.org"),
 					"record1", mustIP("::2"), params, tc.expectedParams)
-				rs, cached, ok := h.ListRecords(context.Background(), mockPP, ipnet.IP6, domain.FQDN("sub.test.org"), params)
+				rs, cached, ok := h.ListRecords(context.Background(), mockPP, ipnet.IP6, domain.FQDN("sub.test.org"), params, api.OwnAllRecords)
 				require.Equal(t, tc.ok, ok)
 				require.True(t, cached)
 				require.Equal(t, []api.Record{{"record1", mustIP("::2"), params}}, rs)
@@ -816,7 +859,8 @@ func newCreateRecordHandler(t *testing.T, mux *http.ServeMux, id string, ipNet i
 				!assert.Equal(t, ip, record.Content) ||
 				!assert.Equal(t, 1, record.TTL) ||
 				!assert.False(t, *record.Proxied) ||
-				!assert.Equal(t, "", record.Comment) {
+				!assert.Equal(t, "", record.Comment) ||
+				!assert.Equal(t, []string{"owner:ddns"}, record.Tags) {
 				w.WriteHeader(http.StatusBadRequest)
 				return
 			}
@@ -833,7 +877,7 @@ func newCreateRecordHandler(t *testing.T, mux *http.ServeMux, id string, ipNet i
 func TestCreateRecord(t *testing.T) {
 	t.Parallel()
 
-	params := api.RecordParams{TTL: api.TTLAuto, Proxied: false, Comment: ""}
+	params := api.RecordParams{TTL: api.TTLAuto, Proxied: false, Comment: "", Tags: []string{"owner:ddns"}}
 
 	for name, tc := range map[string]struct {
 		zoneRequestLimit   int
@@ -882,12 +926,12 @@ func TestCreateRecord(t *testing.T) {
 			crh := newCreateRecordHandler(t, mux, "record1", ipnet.IP6, "sub.test.org", "::1")
 			crh.setRequestLimit(tc.createRequestLimit)
 
-			h.ListRecords(context.Background(), mockPP, ipnet.IP6, domain.FQDN("sub.test.org"), params)
+			h.ListRecords(context.Background(), mockPP, ipnet.IP6, domain.FQDN("sub.test.org"), params, api.OwnAllRecords)
 			actualID, ok := h.CreateRecord(context.Background(), mockPP, ipnet.IP6, domain.FQDN("sub.test.org"), mustIP("::1"), params)
 			require.Equal(t, tc.ok, ok)
 			if ok {
 				require.Equal(t, api.ID("record1"), actualID)
-				rs, cached, ok := h.ListRecords(context.Background(), mockPP, ipnet.IP6, domain.FQDN("sub.test.org"), params)
+				rs, cached, ok := h.ListRecords(context.Background(), mockPP, ipnet.IP6, domain.FQDN("sub.test.org"), params, api.OwnAllRecords)
 				require.True(t, ok)
 				require.True(t, cached)
 				require.Equal(t, []api.Record{{"record1", mustIP("::1"), params}}, rs)
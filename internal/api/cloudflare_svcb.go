@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/jellydator/ttlcache/v3"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// parseSVCBData parses the "data" field of an HTTPS or SVCB record returned by Cloudflare.
+func parseSVCBData(data any) (uint16, string, string, bool) {
+	fields, ok := data.(map[string]any)
+	if !ok {
+		return 0, "", "", false
+	}
+	priority, ok := fields["priority"].(float64)
+	if !ok || priority < 0 || priority > 65535 {
+		return 0, "", "", false
+	}
+	target, ok := fields["target"].(string)
+	if !ok {
+		return 0, "", "", false
+	}
+	value, _ := fields["value"].(string) // the value is omitted when there are no SvcParams
+	return uint16(priority), target, value, true
+}
+
+// ListSVCBRecords calls cloudflare.ListDNSRecords and keeps only HTTPS and SVCB records.
+func (h CloudflareHandle) ListSVCBRecords(ctx context.Context, ppfmt pp.PP, domain domain.Domain,
+) ([]SVCBRecord, bool, bool) {
+	if rs := h.cache.listSVCBRecords.Get(domain.DNSNameASCII()); rs != nil {
+		return *rs.Value(), true, true
+	}
+
+	zone, ok := h.ZoneIDOfDomain(ctx, ppfmt, domain)
+	if !ok {
+		return nil, false, false
+	}
+
+	//nolint:exhaustruct // Other fields are intentionally unspecified
+	raw, _, err := h.cf.ListDNSRecords(ctx,
+		cloudflare.ZoneIdentifier(string(zone)),
+		cloudflare.ListDNSRecordsParams{Name: domain.DNSNameASCII()})
+	if err != nil {
+		ppfmt.Noticef(pp.EmojiError, "Failed to retrieve HTTPS/SVCB records of %s: %v", domain.Describe(), err)
+		hintRecordPermission(ppfmt, err)
+		return nil, false, false
+	}
+
+	rs := make([]SVCBRecord, 0, len(raw))
+	for _, r := range raw {
+		if r.Type != "HTTPS" && r.Type != "SVCB" {
+			continue
+		}
+
+		priority, target, params, ok := parseSVCBData(r.Data)
+		if !ok {
+			ppfmt.Noticef(pp.EmojiImpossible,
+				"Failed to parse the data of an %s record of %s (ID: %s)",
+				r.Type, domain.Describe(), r.ID)
+			return nil, false, false
+		}
+
+		rs = append(rs, SVCBRecord{
+			ID:       ID(r.ID),
+			Type:     r.Type,
+			Priority: priority,
+			Target:   target,
+			Params:   params,
+			Tags:     r.Tags,
+		})
+	}
+
+	h.cache.listSVCBRecords.DeleteExpired()
+	h.cache.listSVCBRecords.Set(domain.DNSNameASCII(), &rs, ttlcache.DefaultTTL)
+
+	return rs, false, true
+}
+
+// UpdateSVCBRecord calls cloudflare.UpdateDNSRecord to replace the SvcParams of an HTTPS or SVCB record.
+func (h CloudflareHandle) UpdateSVCBRecord(ctx context.Context, ppfmt pp.PP, domain domain.Domain, r SVCBRecord,
+) bool {
+	zone, ok := h.ZoneIDOfDomain(ctx, ppfmt, domain)
+	if !ok {
+		return false
+	}
+
+	//nolint:exhaustruct // Other fields are intentionally omitted
+	params := cloudflare.UpdateDNSRecordParams{
+		ID:   string(r.ID),
+		Type: r.Type,
+		Data: map[string]any{
+			"priority": r.Priority,
+			"target":   r.Target,
+			"value":    r.Params,
+		},
+		// The API always sets the tags, so the current tags have to be sent back to keep them.
+		Tags: r.Tags,
+	}
+
+	if _, err := h.cf.UpdateDNSRecord(ctx, cloudflare.ZoneIdentifier(string(zone)), params); err != nil {
+		ppfmt.Noticef(pp.EmojiError, "Failed to update the %s record of %s (ID: %s): %v",
+			r.Type, domain.Describe(), r.ID, err)
+		hintRecordPermission(ppfmt, err)
+
+		h.cache.listSVCBRecords.Delete(domain.DNSNameASCII())
+
+		return false
+	}
+
+	if rs := h.cache.listSVCBRecords.Get(domain.DNSNameASCII()); rs != nil {
+		for i, cached := range *rs.Value() {
+			if cached.ID == r.ID {
+				(*rs.Value())[i] = r
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,275 @@
+// vim: nowrap
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func mockSVCBRecord(id string, recordType string, domain string, data any) cloudflare.DNSRecord {
+	return cloudflare.DNSRecord{ //nolint:exhaustruct
+		ID:   id,
+		Type: recordType,
+		Name: domain,
+		Data: data,
+		TTL:  1,
+	}
+}
+
+func newListSVCBRecordsHandler(t *testing.T, mux *http.ServeMux, domain string, rs []cloudflare.DNSRecord) httpHandler {
+	t.Helper()
+
+	var requestLimit int
+
+	mux.HandleFunc(fmt.Sprintf("GET /zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			if !checkRequestLimit(t, &requestLimit) || !checkToken(t, r) {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, err := w.Write([]byte(`{"success":false,"errors":[{"code":9109,"message":"Invalid access token"}],"messages":[],"result":null}`))
+				assert.NoError(t, err)
+				return
+			}
+
+			if !assert.Equal(t, url.Values{
+				"name":     {domain},
+				"page":     {"1"},
+				"per_page": {strconv.Itoa(dnsRecordPageSize)},
+			}, r.URL.Query()) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			err := json.NewEncoder(w).Encode(cloudflare.DNSListResponse{
+				Result:     rs,
+				ResultInfo: mockResultInfo(len(rs), dnsRecordPageSize),
+				Response:   mockResponse(),
+			})
+			assert.NoError(t, err)
+		})
+
+	return httpHandler{requestLimit: &requestLimit}
+}
+
+func TestListSVCBRecords(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		zoneRequestLimit int
+		records          []cloudflare.DNSRecord
+		listRequestLimit int
+		expected         []api.SVCBRecord
+		ok               bool
+		prepareMocks     func(*mocks.MockPP)
+	}{
+		"success": {
+			2,
+			[]cloudflare.DNSRecord{
+				mockSVCBRecord("record1", "HTTPS", "sub.test.org", map[string]any{"priority": 1, "target": ".", "value": `alpn="h2" ipv4hint="1.1.1.1"`}),
+				mockDNSRecord("record2", ipnet.IP4, "sub.test.org", "1.1.1.1"),
+				mockSVCBRecord("record3", "SVCB", "sub.test.org", map[string]any{"priority": 0, "target": "other.test.org"}),
+			},
+			1,
+			[]api.SVCBRecord{
+				{ID: "record1", Type: "HTTPS", Priority: 1, Target: ".", Params: `alpn="h2" ipv4hint="1.1.1.1"`},
+				{ID: "record3", Type: "SVCB", Priority: 0, Target: "other.test.org", Params: ""},
+			},
+			true,
+			nil,
+		},
+		"invalid-data": {
+			2,
+			[]cloudflare.DNSRecord{
+				mockSVCBRecord("record1", "HTTPS", "sub.test.org", map[string]any{"priority": "high", "target": "."}),
+			},
+			1,
+			nil,
+			false,
+			func(ppfmt *mocks.MockPP) {
+				ppfmt.EXPECT().Noticef(pp.EmojiImpossible, "Failed to parse the data of an %s record of %s (ID: %s)", "HTTPS", "sub.test.org", "record1")
+			},
+		},
+		"list-fails": {
+			2,
+			nil,
+			0,
+			nil,
+			false,
+			func(ppfmt *mocks.MockPP) {
+				ppfmt.EXPECT().Noticef(pp.EmojiError, "Failed to retrieve HTTPS/SVCB records of %s: %v", "sub.test.org", gomock.Any())
+				ppfmt.EXPECT().NoticeOncef(pp.MessageRecordPermission, pp.EmojiHint, `Double check your API token. Make sure you granted the "Edit" permission of "Zone - DNS"`)
+			},
+		},
+		"zone-fails": {
+			0,
+			nil,
+			0,
+			nil,
+			false,
+			func(ppfmt *mocks.MockPP) {
+				ppfmt.EXPECT().Noticef(pp.EmojiError, "Failed to check the existence of a zone named %s: %v", "sub.test.org", gomock.Any())
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMocks != nil {
+				tc.prepareMocks(mockPP)
+			}
+
+			mux, h, ok := newHandle(t, mockPP)
+			require.True(t, ok)
+
+			zh := newZonesHandler(t, mux, map[string][]string{"test.org": {"active"}})
+			zh.setRequestLimit(tc.zoneRequestLimit)
+
+			lh := newListSVCBRecordsHandler(t, mux, "sub.test.org", tc.records)
+			lh.setRequestLimit(tc.listRequestLimit)
+
+			rs, cached, ok := h.ListSVCBRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"))
+			require.Equal(t, tc.ok, ok)
+			require.False(t, cached)
+			require.Equal(t, tc.expected, rs)
+			require.True(t, zh.isExhausted())
+			require.True(t, lh.isExhausted())
+
+			if ok {
+				rs, cached, ok = h.ListSVCBRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"))
+				require.True(t, ok)
+				require.True(t, cached)
+				require.Equal(t, tc.expected, rs)
+			}
+		})
+	}
+}
+
+func newUpdateSVCBRecordHandler(t *testing.T, mux *http.ServeMux, id string, data map[string]any, tags []string) httpHandler {
+	t.Helper()
+
+	var requestLimit int
+
+	mux.HandleFunc(fmt.Sprintf("PATCH /zones/%s/dns_records/%s", mockID("test.org", 0), id),
+		func(w http.ResponseWriter, r *http.Request) {
+			if !checkRequestLimit(t, &requestLimit) || !checkToken(t, r) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			var record cloudflare.DNSRecord
+			if err := json.NewDecoder(r.Body).Decode(&record); !assert.NoError(t, err) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if !assert.Equal(t, "HTTPS", record.Type) ||
+				!assert.Equal(t, data, record.Data) ||
+				!assert.Equal(t, tags, record.Tags) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			updated := mockSVCBRecord(id, "HTTPS", "sub.test.org", data)
+			updated.Tags = tags
+
+			w.Header().Set("Content-Type", "application/json")
+			err := json.NewEncoder(w).Encode(envelopDNSRecordResponse(updated))
+			assert.NoError(t, err)
+		})
+
+	return httpHandler{requestLimit: &requestLimit}
+}
+
+func TestUpdateSVCBRecord(t *testing.T) {
+	t.Parallel()
+
+	// The tags must be sent back, or the API would remove them.
+	tags := []string{"owner:web"}
+	oldRecord := api.SVCBRecord{ID: "record1", Type: "HTTPS", Priority: 1, Target: ".", Params: `alpn="h2" ipv4hint="1.1.1.1"`, Tags: tags}
+	newRecord := api.SVCBRecord{ID: "record1", Type: "HTTPS", Priority: 1, Target: ".", Params: `alpn="h2" ipv4hint="2.2.2.2"`, Tags: tags}
+
+	for name, tc := range map[string]struct {
+		zoneRequestLimit   int
+		updateRequestLimit int
+		ok                 bool
+		prepareMocks       func(*mocks.MockPP)
+	}{
+		"success": {2, 1, true, nil},
+		"update-fails": {
+			2, 0, false,
+			func(ppfmt *mocks.MockPP) {
+				ppfmt.EXPECT().Noticef(pp.EmojiError, "Failed to update the %s record of %s (ID: %s): %v", "HTTPS", "sub.test.org", api.ID("record1"), gomock.Any())
+			},
+		},
+		"zone-fails": {
+			0, 0, false,
+			func(ppfmt *mocks.MockPP) {
+				ppfmt.EXPECT().Noticef(pp.EmojiError, "Failed to check the existence of a zone named %s: %v", "sub.test.org", gomock.Any())
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMocks != nil {
+				tc.prepareMocks(mockPP)
+			}
+
+			mux, h, ok := newHandle(t, mockPP)
+			require.True(t, ok)
+
+			zh := newZonesHandler(t, mux, map[string][]string{"test.org": {"active"}})
+			zh.setRequestLimit(tc.zoneRequestLimit)
+
+			listed := mockSVCBRecord("record1", "HTTPS", "sub.test.org", map[string]any{"priority": 1, "target": ".", "value": oldRecord.Params})
+			listed.Tags = tags
+			lh := newListSVCBRecordsHandler(t, mux, "sub.test.org", []cloudflare.DNSRecord{listed})
+			lh.setRequestLimit(1)
+
+			uh := newUpdateSVCBRecordHandler(t, mux, "record1", map[string]any{"priority": 1.0, "target": ".", "value": newRecord.Params}, newRecord.Tags)
+			uh.setRequestLimit(tc.updateRequestLimit)
+
+			if tc.zoneRequestLimit > 0 {
+				rs, _, ok := h.ListSVCBRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"))
+				require.True(t, ok)
+				require.Equal(t, []api.SVCBRecord{oldRecord}, rs)
+			}
+
+			ok = h.UpdateSVCBRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), newRecord)
+			require.Equal(t, tc.ok, ok)
+			require.True(t, zh.isExhausted())
+			require.True(t, uh.isExhausted())
+
+			if tc.zoneRequestLimit > 0 {
+				// The cache should be updated on success and invalidated on failure.
+				lh.setRequestLimit(1)
+				rs, cached, ok := h.ListSVCBRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"))
+				require.True(t, ok)
+				require.Equal(t, tc.ok, cached)
+				if tc.ok {
+					require.Equal(t, []api.SVCBRecord{newRecord}, rs)
+				}
+			}
+		})
+	}
+}
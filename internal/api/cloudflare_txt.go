@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/jellydator/ttlcache/v3"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// ListTXTRecords calls cloudflare.ListDNSRecords to list TXT records.
+func (h CloudflareHandle) ListTXTRecords(ctx context.Context, ppfmt pp.PP, domain domain.Domain,
+) ([]TXTRecord, bool, bool) {
+	if rs := h.cache.listTXTRecords.Get(domain.DNSNameASCII()); rs != nil {
+		return *rs.Value(), true, true
+	}
+
+	zone, ok := h.ZoneIDOfDomain(ctx, ppfmt, domain)
+	if !ok {
+		return nil, false, false
+	}
+
+	//nolint:exhaustruct // Other fields are intentionally unspecified
+	raw, _, err := h.cf.ListDNSRecords(ctx,
+		cloudflare.ZoneIdentifier(string(zone)),
+		cloudflare.ListDNSRecordsParams{
+			Name: domain.DNSNameASCII(),
+			Type: "TXT",
+		})
+	if err != nil {
+		ppfmt.Noticef(pp.EmojiError, "Failed to retrieve TXT records of %s: %v", domain.Describe(), err)
+		hintRecordPermission(ppfmt, err)
+		return nil, false, false
+	}
+
+	rs := make([]TXTRecord, 0, len(raw))
+	for _, r := range raw {
+		rs = append(rs, TXTRecord{ID: ID(r.ID), Content: r.Content, Comment: r.Comment, Tags: r.Tags})
+	}
+
+	h.cache.listTXTRecords.DeleteExpired()
+	h.cache.listTXTRecords.Set(domain.DNSNameASCII(), &rs, ttlcache.DefaultTTL)
+
+	return rs, false, true
+}
+
+// UpdateTXTRecord calls cloudflare.UpdateDNSRecord to replace the content of a TXT record.
+func (h CloudflareHandle) UpdateTXTRecord(ctx context.Context, ppfmt pp.PP, domain domain.Domain, r TXTRecord,
+) bool {
+	zone, ok := h.ZoneIDOfDomain(ctx, ppfmt, domain)
+	if !ok {
+		return false
+	}
+
+	//nolint:exhaustruct // Other fields are intentionally omitted
+	params := cloudflare.UpdateDNSRecordParams{
+		ID:      string(r.ID),
+		Type:    "TXT",
+		Content: r.Content,
+		// The API always sets the tags, so the current tags have to be sent back to keep them.
+		Tags: r.Tags,
+	}
+
+	if _, err := h.cf.UpdateDNSRecord(ctx, cloudflare.ZoneIdentifier(string(zone)), params); err != nil {
+		ppfmt.Noticef(pp.EmojiError, "Failed to update the TXT record of %s (ID: %s): %v", domain.Describe(), r.ID, err)
+		hintRecordPermission(ppfmt, err)
+
+		h.cache.listTXTRecords.Delete(domain.DNSNameASCII())
+
+		return false
+	}
+
+	if rs := h.cache.listTXTRecords.Get(domain.DNSNameASCII()); rs != nil {
+		for i, cached := range *rs.Value() {
+			if cached.ID == r.ID {
+				(*rs.Value())[i] = r
+			}
+		}
+	}
+
+	return true
+}
+
+// CreateTXTRecord calls cloudflare.CreateDNSRecord to add a new TXT record.
+func (h CloudflareHandle) CreateTXTRecord(ctx context.Context, ppfmt pp.PP, domain domain.Domain,
+	content string, params RecordParams,
+) (ID, bool) {
+	zone, ok := h.ZoneIDOfDomain(ctx, ppfmt, domain)
+	if !ok {
+		return "", false
+	}
+
+	//nolint:exhaustruct // Other fields are intentionally omitted
+	ps := cloudflare.CreateDNSRecordParams{
+		Name:    domain.DNSNameASCII(),
+		Type:    "TXT",
+		Content: content,
+		TTL:     params.TTL.Int(),
+		Comment: params.Comment,
+		Tags:    params.Tags,
+	}
+
+	res, err := h.cf.CreateDNSRecord(ctx, cloudflare.ZoneIdentifier(string(zone)), ps)
+	if err != nil {
+		ppfmt.Noticef(pp.EmojiError, "Failed to add a new TXT record of %s: %v", domain.Describe(), err)
+		hintRecordPermission(ppfmt, err)
+
+		h.cache.listTXTRecords.Delete(domain.DNSNameASCII())
+
+		return "", false
+	}
+
+	if rs := h.cache.listTXTRecords.Get(domain.DNSNameASCII()); rs != nil {
+		*rs.Value() = append([]TXTRecord{{
+			ID: ID(res.ID), Content: content, Comment: params.Comment, Tags: params.Tags,
+		}}, *rs.Value()...)
+	}
+
+	return ID(res.ID), true
+}
@@ -0,0 +1,375 @@
+// vim: nowrap
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+func mockTXTRecord(id string, domain string, content string) cloudflare.DNSRecord {
+	return cloudflare.DNSRecord{ //nolint:exhaustruct
+		ID:      id,
+		Type:    "TXT",
+		Name:    domain,
+		Content: content,
+		TTL:     1,
+	}
+}
+
+func newListTXTRecordsHandler(t *testing.T, mux *http.ServeMux, domain string, rs []cloudflare.DNSRecord) httpHandler {
+	t.Helper()
+
+	var requestLimit int
+
+	mux.HandleFunc(fmt.Sprintf("GET /zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			if !checkRequestLimit(t, &requestLimit) || !checkToken(t, r) {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, err := w.Write([]byte(`{"success":false,"errors":[{"code":9109,"message":"Invalid access token"}],"messages":[],"result":null}`))
+				assert.NoError(t, err)
+				return
+			}
+
+			if !assert.Equal(t, url.Values{
+				"name":     {domain},
+				"page":     {"1"},
+				"per_page": {strconv.Itoa(dnsRecordPageSize)},
+				"type":     {"TXT"},
+			}, r.URL.Query()) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			err := json.NewEncoder(w).Encode(cloudflare.DNSListResponse{
+				Result:     rs,
+				ResultInfo: mockResultInfo(len(rs), dnsRecordPageSize),
+				Response:   mockResponse(),
+			})
+			assert.NoError(t, err)
+		})
+
+	return httpHandler{requestLimit: &requestLimit}
+}
+
+func TestListTXTRecords(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		zoneRequestLimit int
+		records          []cloudflare.DNSRecord
+		listRequestLimit int
+		expected         []api.TXTRecord
+		ok               bool
+		prepareMocks     func(*mocks.MockPP)
+	}{
+		"success": {
+			2,
+			[]cloudflare.DNSRecord{
+				mockTXTRecord("record1", "sub.test.org", `"v=spf1 ip4:1.1.1.1 -all"`),
+				func() cloudflare.DNSRecord {
+					r := mockTXTRecord("record2", "sub.test.org", "hello")
+					r.Comment = "heartbeat"
+					r.Tags = []string{"name:value"}
+					return r
+				}(),
+			},
+			1,
+			[]api.TXTRecord{
+				{ID: "record1", Content: `"v=spf1 ip4:1.1.1.1 -all"`},
+				{ID: "record2", Content: "hello", Comment: "heartbeat", Tags: []string{"name:value"}},
+			},
+			true,
+			nil,
+		},
+		"list-fails": {
+			2,
+			nil,
+			0,
+			nil,
+			false,
+			func(ppfmt *mocks.MockPP) {
+				ppfmt.EXPECT().Noticef(pp.EmojiError, "Failed to retrieve TXT records of %s: %v", "sub.test.org", gomock.Any())
+				ppfmt.EXPECT().NoticeOncef(pp.MessageRecordPermission, pp.EmojiHint, `Double check your API token. Make sure you granted the "Edit" permission of "Zone - DNS"`)
+			},
+		},
+		"zone-fails": {
+			0,
+			nil,
+			0,
+			nil,
+			false,
+			func(ppfmt *mocks.MockPP) {
+				ppfmt.EXPECT().Noticef(pp.EmojiError, "Failed to check the existence of a zone named %s: %v", "sub.test.org", gomock.Any())
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMocks != nil {
+				tc.prepareMocks(mockPP)
+			}
+
+			mux, h, ok := newHandle(t, mockPP)
+			require.True(t, ok)
+
+			zh := newZonesHandler(t, mux, map[string][]string{"test.org": {"active"}})
+			zh.setRequestLimit(tc.zoneRequestLimit)
+
+			lh := newListTXTRecordsHandler(t, mux, "sub.test.org", tc.records)
+			lh.setRequestLimit(tc.listRequestLimit)
+
+			rs, cached, ok := h.ListTXTRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"))
+			require.Equal(t, tc.ok, ok)
+			require.False(t, cached)
+			require.Equal(t, tc.expected, rs)
+			require.True(t, zh.isExhausted())
+			require.True(t, lh.isExhausted())
+
+			if ok {
+				rs, cached, ok = h.ListTXTRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"))
+				require.True(t, ok)
+				require.True(t, cached)
+				require.Equal(t, tc.expected, rs)
+			}
+		})
+	}
+}
+
+func newUpdateTXTRecordHandler(t *testing.T, mux *http.ServeMux, id string, content string, tags []string) httpHandler {
+	t.Helper()
+
+	var requestLimit int
+
+	mux.HandleFunc(fmt.Sprintf("PATCH /zones/%s/dns_records/%s", mockID("test.org", 0), id),
+		func(w http.ResponseWriter, r *http.Request) {
+			if !checkRequestLimit(t, &requestLimit) || !checkToken(t, r) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			var record cloudflare.DNSRecord
+			if err := json.NewDecoder(r.Body).Decode(&record); !assert.NoError(t, err) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if !assert.Equal(t, "TXT", record.Type) ||
+				!assert.Equal(t, content, record.Content) ||
+				!assert.Equal(t, tags, record.Tags) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			updated := mockTXTRecord(id, "sub.test.org", content)
+			updated.Tags = tags
+
+			w.Header().Set("Content-Type", "application/json")
+			err := json.NewEncoder(w).Encode(envelopDNSRecordResponse(updated))
+			assert.NoError(t, err)
+		})
+
+	return httpHandler{requestLimit: &requestLimit}
+}
+
+func TestUpdateTXTRecord(t *testing.T) {
+	t.Parallel()
+
+	// The tags must be sent back, or the API would remove them.
+	tags := []string{"owner:mail", "env:home"}
+	oldRecord := api.TXTRecord{ID: "record1", Content: "v=spf1 ip4:1.1.1.1 -all", Tags: tags}
+	newRecord := api.TXTRecord{ID: "record1", Content: "v=spf1 ip4:2.2.2.2 -all", Tags: tags}
+
+	for name, tc := range map[string]struct {
+		zoneRequestLimit   int
+		updateRequestLimit int
+		ok                 bool
+		prepareMocks       func(*mocks.MockPP)
+	}{
+		"success": {2, 1, true, nil},
+		"update-fails": {
+			2, 0, false,
+			func(ppfmt *mocks.MockPP) {
+				ppfmt.EXPECT().Noticef(pp.EmojiError, "Failed to update the TXT record of %s (ID: %s): %v", "sub.test.org", api.ID("record1"), gomock.Any())
+			},
+		},
+		"zone-fails": {
+			0, 0, false,
+			func(ppfmt *mocks.MockPP) {
+				ppfmt.EXPECT().Noticef(pp.EmojiError, "Failed to check the existence of a zone named %s: %v", "sub.test.org", gomock.Any())
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMocks != nil {
+				tc.prepareMocks(mockPP)
+			}
+
+			mux, h, ok := newHandle(t, mockPP)
+			require.True(t, ok)
+
+			zh := newZonesHandler(t, mux, map[string][]string{"test.org": {"active"}})
+			zh.setRequestLimit(tc.zoneRequestLimit)
+
+			listed := mockTXTRecord("record1", "sub.test.org", oldRecord.Content)
+			listed.Tags = tags
+			lh := newListTXTRecordsHandler(t, mux, "sub.test.org", []cloudflare.DNSRecord{listed})
+			lh.setRequestLimit(1)
+
+			uh := newUpdateTXTRecordHandler(t, mux, "record1", newRecord.Content, newRecord.Tags)
+			uh.setRequestLimit(tc.updateRequestLimit)
+
+			if tc.zoneRequestLimit > 0 {
+				rs, _, ok := h.ListTXTRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"))
+				require.True(t, ok)
+				require.Equal(t, []api.TXTRecord{oldRecord}, rs)
+			}
+
+			ok = h.UpdateTXTRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), newRecord)
+			require.Equal(t, tc.ok, ok)
+			require.True(t, zh.isExhausted())
+			require.True(t, uh.isExhausted())
+
+			if tc.zoneRequestLimit > 0 {
+				// The cache should be updated on success and invalidated on failure.
+				lh.setRequestLimit(1)
+				rs, cached, ok := h.ListTXTRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"))
+				require.True(t, ok)
+				require.Equal(t, tc.ok, cached)
+				if tc.ok {
+					require.Equal(t, []api.TXTRecord{newRecord}, rs)
+				}
+			}
+		})
+	}
+}
+
+func newCreateTXTRecordHandler(t *testing.T, mux *http.ServeMux, id string, domain string, content string, params api.RecordParams) httpHandler {
+	t.Helper()
+
+	var requestLimit int
+
+	mux.HandleFunc(fmt.Sprintf("POST /zones/%s/dns_records", mockID("test.org", 0)),
+		func(w http.ResponseWriter, r *http.Request) {
+			if !checkRequestLimit(t, &requestLimit) || !checkToken(t, r) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			var record cloudflare.DNSRecord
+			if err := json.NewDecoder(r.Body).Decode(&record); !assert.NoError(t, err) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if !assert.Equal(t, domain, record.Name) ||
+				!assert.Equal(t, "TXT", record.Type) ||
+				!assert.Equal(t, content, record.Content) ||
+				!assert.Equal(t, params.TTL.Int(), record.TTL) ||
+				!assert.Equal(t, params.Comment, record.Comment) ||
+				!assert.Equal(t, params.Tags, record.Tags) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			record.ID = id
+
+			w.Header().Set("Content-Type", "application/json")
+			err := json.NewEncoder(w).Encode(envelopDNSRecordResponse(record))
+			assert.NoError(t, err)
+		})
+
+	return httpHandler{requestLimit: &requestLimit}
+}
+
+func TestCreateTXTRecord(t *testing.T) {
+	t.Parallel()
+
+	const content = "ips=1.1.1.1 time=2006-01-02T15:04:05Z version=1.0.0"
+	params := api.RecordParams{TTL: 300, Proxied: false, Comment: "hello", Tags: []string{"name:value"}}
+
+	for name, tc := range map[string]struct {
+		zoneRequestLimit   int
+		createRequestLimit int
+		ok                 bool
+		prepareMocks       func(*mocks.MockPP)
+	}{
+		"success": {2, 1, true, nil},
+		"create-fails": {
+			2, 0, false,
+			func(ppfmt *mocks.MockPP) {
+				ppfmt.EXPECT().Noticef(pp.EmojiError, "Failed to add a new TXT record of %s: %v", "sub.test.org", gomock.Any())
+			},
+		},
+		"zone-fails": {
+			0, 0, false,
+			func(ppfmt *mocks.MockPP) {
+				ppfmt.EXPECT().Noticef(pp.EmojiError, "Failed to check the existence of a zone named %s: %v", "sub.test.org", gomock.Any())
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMocks != nil {
+				tc.prepareMocks(mockPP)
+			}
+
+			mux, h, ok := newHandle(t, mockPP)
+			require.True(t, ok)
+
+			zh := newZonesHandler(t, mux, map[string][]string{"test.org": {"active"}})
+			zh.setRequestLimit(tc.zoneRequestLimit)
+
+			lh := newListTXTRecordsHandler(t, mux, "sub.test.org", []cloudflare.DNSRecord{})
+			lh.setRequestLimit(1)
+
+			ch := newCreateTXTRecordHandler(t, mux, "record1", "sub.test.org", content, params)
+			ch.setRequestLimit(tc.createRequestLimit)
+
+			if tc.zoneRequestLimit > 0 {
+				rs, _, ok := h.ListTXTRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"))
+				require.True(t, ok)
+				require.Empty(t, rs)
+			}
+
+			id, ok := h.CreateTXTRecord(context.Background(), mockPP, domain.FQDN("sub.test.org"), content, params)
+			require.Equal(t, tc.ok, ok)
+			require.True(t, zh.isExhausted())
+			require.True(t, ch.isExhausted())
+
+			if tc.ok {
+				require.Equal(t, api.ID("record1"), id)
+
+				// The new record should be in the cache.
+				rs, cached, ok := h.ListTXTRecords(context.Background(), mockPP, domain.FQDN("sub.test.org"))
+				require.True(t, ok)
+				require.True(t, cached)
+				require.Equal(t, []api.TXTRecord{{ID: "record1", Content: content, Comment: "hello", Tags: []string{"name:value"}}}, rs)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package api
+
+// An Ownership decides which DNS records of a managed domain are owned by the updater.
+// The updater never updates or deletes records that it does not own.
+type Ownership int
+
+const (
+	// OwnAllRecords means all DNS records of a managed domain are owned by the updater.
+	OwnAllRecords Ownership = iota
+	// OwnRecordsByComment means only DNS records with the expected comment are owned by the updater.
+	OwnRecordsByComment
+)
+
+// String gives the value of RECORD_OWNERSHIP representing the ownership mode.
+func (o Ownership) String() string {
+	switch o {
+	case OwnRecordsByComment:
+		return "comment"
+	default:
+		return "all"
+	}
+}
+
+// Describe converts an Ownership into a human-readable, user-friendly description
+// that is suitable for printing.
+func (o Ownership) Describe() string {
+	switch o {
+	case OwnRecordsByComment:
+		return "records with the expected comment"
+	default:
+		return "all records"
+	}
+}
+
+// Owns checks whether a DNS record with the given parameters is owned by the updater,
+// where expectedParams are the parameters of new records.
+func (o Ownership) Owns(params, expectedParams RecordParams) bool {
+	switch o {
+	case OwnRecordsByComment:
+		return params.Comment == expectedParams.Comment
+	default:
+		return true
+	}
+}
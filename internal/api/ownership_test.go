@@ -0,0 +1,50 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+)
+
+func TestOwnershipString(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		ownership api.Ownership
+		str       string
+		desc      string
+	}{
+		"all":     {api.OwnAllRecords, "all", "all records"},
+		"comment": {api.OwnRecordsByComment, "comment", "records with the expected comment"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.str, tc.ownership.String())
+			require.Equal(t, tc.desc, tc.ownership.Describe())
+		})
+	}
+}
+
+func TestOwnershipOwns(t *testing.T) {
+	t.Parallel()
+
+	expected := api.RecordParams{TTL: api.TTLAuto, Proxied: false, Comment: "ddns"}
+	for name, tc := range map[string]struct {
+		ownership api.Ownership
+		params    api.RecordParams
+		owned     bool
+	}{
+		"all/same":        {api.OwnAllRecords, api.RecordParams{TTL: 300, Proxied: true, Comment: "ddns"}, true},
+		"all/different":   {api.OwnAllRecords, api.RecordParams{TTL: 300, Proxied: true, Comment: "manual"}, true},
+		"comment/same":    {api.OwnRecordsByComment, api.RecordParams{TTL: 300, Proxied: true, Comment: "ddns"}, true},
+		"comment/other":   {api.OwnRecordsByComment, api.RecordParams{TTL: api.TTLAuto, Proxied: false, Comment: "manual"}, false},
+		"comment/empty":   {api.OwnRecordsByComment, api.RecordParams{TTL: api.TTLAuto, Proxied: false, Comment: ""}, false},
+		"comment/partial": {api.OwnRecordsByComment, api.RecordParams{TTL: api.TTLAuto, Proxied: false, Comment: "ddns!"}, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.owned, tc.ownership.Owns(tc.params, expected))
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/netip"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SVCBRecord represents an HTTPS or SVCB record (RFC 9460).
+type SVCBRecord struct {
+	ID
+	Type     string // HTTPS or SVCB
+	Priority uint16 // 0 means the alias mode
+	Target   string // the target name; "." means the owner name of the record itself
+	Params   string // the SvcParams in the presentation format, such as `alpn="h2" ipv4hint="192.0.2.1"`
+	Tags     []string
+}
+
+// splitSvcParams splits the SvcParams in the presentation format into individual parameters
+// such as `alpn="h2,h3"`, keeping each of them as it is. Spaces within quotes do not split parameters.
+func splitSvcParams(params string) []string {
+	var fields []string
+	var current strings.Builder
+	quoted, escaped := false, false
+	for _, r := range params {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case !quoted && unicode.IsSpace(r):
+			if current.Len() > 0 {
+				fields = append(fields, current.String())
+				current.Reset()
+			}
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if current.Len() > 0 {
+		fields = append(fields, current.String())
+	}
+	return fields
+}
+
+// svcParamKeyNumbers are the numbers of the registered SvcParamKeys.
+//
+//nolint:gochecknoglobals
+var svcParamKeyNumbers = map[string]int{
+	"mandatory":       0,
+	"alpn":            1,
+	"no-default-alpn": 2,
+	"port":            3,
+	"ipv4hint":        4,
+	"ech":             5,
+	"ipv6hint":        6,
+	"dohpath":         7,
+	"ohttp":           8,
+}
+
+// svcParamKeyNumber gives the number of a SvcParamKey, such as 4 for "ipv4hint" and 65001 for "key65001".
+// Unknown keys are placed after all others.
+func svcParamKeyNumber(key string) int {
+	if n, found := svcParamKeyNumbers[key]; found {
+		return n
+	}
+	if digits, found := strings.CutPrefix(key, "key"); found {
+		if n, err := strconv.ParseUint(digits, 10, 16); err == nil {
+			return int(n)
+		}
+	}
+	return math.MaxInt
+}
+
+// GetSVCBHint finds the SvcParam with the key (ipv4hint or ipv6hint) and parses its IP addresses.
+// The second return value indicates whether the SvcParam exists. Unparsable addresses are skipped.
+func GetSVCBHint(params string, key string) ([]netip.Addr, bool) {
+	for _, param := range splitSvcParams(params) {
+		k, value, _ := strings.Cut(param, "=")
+		if k != key {
+			continue
+		}
+
+		var ips []netip.Addr
+		for _, raw := range strings.Split(strings.Trim(value, `"`), ",") {
+			if ip, err := netip.ParseAddr(strings.TrimSpace(raw)); err == nil {
+				ips = append(ips, ip)
+			}
+		}
+		return ips, true
+	}
+	return nil, false
+}
+
+// SetSVCBHint sets the SvcParam with the key (ipv4hint or ipv6hint) to the IP addresses,
+// keeping all other SvcParams as they are. If the SvcParam does not exist,
+// it is inserted according to the order of the keys.
+func SetSVCBHint(params string, key string, ips []netip.Addr) string {
+	values := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		values = append(values, ip.String())
+	}
+	hint := fmt.Sprintf(`%s="%s"`, key, strings.Join(values, ","))
+
+	fields := splitSvcParams(params)
+	position := len(fields)
+	for i, param := range fields {
+		k, _, _ := strings.Cut(param, "=")
+		if k == key {
+			fields[i] = hint
+			return strings.Join(fields, " ")
+		}
+		if position == len(fields) && svcParamKeyNumber(k) > svcParamKeyNumber(key) {
+			position = i
+		}
+	}
+
+	fields = append(fields[:position], append([]string{hint}, fields[position:]...)...)
+	return strings.Join(fields, " ")
+}
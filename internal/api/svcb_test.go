@@ -0,0 +1,62 @@
+// vim: nowrap
+package api_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+)
+
+func TestGetSVCBHint(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		params   string
+		key      string
+		expected []netip.Addr
+		found    bool
+	}{
+		"empty":     {``, "ipv4hint", nil, false},
+		"missing":   {`alpn="h2,h3" ipv6hint="::1"`, "ipv4hint", nil, false},
+		"quoted":    {`alpn="h2,h3" ipv4hint="1.1.1.1,2.2.2.2"`, "ipv4hint", []netip.Addr{mustIP("1.1.1.1"), mustIP("2.2.2.2")}, true},
+		"unquoted":  {`ipv6hint=::1,::2 port=8443`, "ipv6hint", []netip.Addr{mustIP("::1"), mustIP("::2")}, true},
+		"spaces":    {`alpn="h2 h3" ipv4hint="1.1.1.1"`, "ipv4hint", []netip.Addr{mustIP("1.1.1.1")}, true},
+		"trap":      {`key65001="ipv4hint=1.1.1.1" ipv4hint="2.2.2.2"`, "ipv4hint", []netip.Addr{mustIP("2.2.2.2")}, true},
+		"escaped":   {`key65001="a\" ipv4hint=1.1.1.1" ipv4hint="2.2.2.2"`, "ipv4hint", []netip.Addr{mustIP("2.2.2.2")}, true},
+		"illformed": {`ipv4hint="1.1.1.1,hello"`, "ipv4hint", []netip.Addr{mustIP("1.1.1.1")}, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ips, found := api.GetSVCBHint(tc.params, tc.key)
+			require.Equal(t, tc.expected, ips)
+			require.Equal(t, tc.found, found)
+		})
+	}
+}
+
+func TestSetSVCBHint(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		params   string
+		key      string
+		ips      []netip.Addr
+		expected string
+	}{
+		"empty":    {``, "ipv4hint", []netip.Addr{mustIP("1.1.1.1")}, `ipv4hint="1.1.1.1"`},
+		"replace":  {`alpn="h2 h3"  ipv4hint="1.1.1.1" ech="abc"`, "ipv4hint", []netip.Addr{mustIP("2.2.2.2"), mustIP("3.3.3.3")}, `alpn="h2 h3" ipv4hint="2.2.2.2,3.3.3.3" ech="abc"`},
+		"insert":   {`alpn="h2" port=443 ech="abc" ipv6hint="::1"`, "ipv4hint", []netip.Addr{mustIP("1.1.1.1")}, `alpn="h2" port=443 ipv4hint="1.1.1.1" ech="abc" ipv6hint="::1"`},
+		"insert/6": {`alpn="h2" ipv4hint="1.1.1.1" key65001=x`, "ipv6hint", []netip.Addr{mustIP("::1")}, `alpn="h2" ipv4hint="1.1.1.1" ipv6hint="::1" key65001=x`},
+		"append":   {`alpn="h2" foo`, "ipv6hint", []netip.Addr{mustIP("::1")}, `alpn="h2" ipv6hint="::1" foo`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.expected, api.SetSVCBHint(tc.params, tc.key, tc.ips))
+		})
+	}
+}
@@ -2,6 +2,7 @@
 package config
 
 import (
+	"net/netip"
 	"time"
 
 	"github.com/favonia/cloudflare-ddns/internal/api"
@@ -13,26 +14,48 @@ import (
 	"github.com/favonia/cloudflare-ddns/internal/provider"
 )
 
+// DomainProvider is a provider used by some domains instead of the default one (see [Config.Provider]).
+type DomainProvider struct {
+	Provider provider.Provider
+	Domains  []domain.Domain
+}
+
 // Config holds the configuration of the updater except for the timezone.
 // (The timezone is handled directly by the standard library reading the TZ environment variable.)
 type Config struct {
-	Auth               api.Auth
-	Provider           map[ipnet.Type]provider.Provider
-	Domains            map[ipnet.Type][]domain.Domain
-	WAFLists           []api.WAFList
-	UpdateCron         cron.Schedule
-	UpdateOnStart      bool
-	DeleteOnStop       bool
-	CacheExpiration    time.Duration
-	TTL                api.TTL
-	ProxiedTemplate    string
-	Proxied            map[domain.Domain]bool
-	RecordComment      string
-	WAFListDescription string
-	DetectionTimeout   time.Duration
-	UpdateTimeout      time.Duration
-	Monitor            monitor.Monitor
-	Notifier           notifier.Notifier
+	Auth                    api.Auth
+	Provider                map[ipnet.Type]provider.Provider
+	DomainProviderTemplates map[ipnet.Type]string
+	DomainProviders         map[ipnet.Type][]DomainProvider
+	DetectionBinding        map[ipnet.Type]provider.Binding
+	AllowedIPs              map[ipnet.Type]ipnet.Policy
+	Domains                 map[ipnet.Type][]domain.Domain
+	IP6Suffixes             map[domain.Domain]netip.Prefix
+	WAFLists                []api.WAFList
+	UpdateSVCBHints         bool
+	SPFDomains              []domain.Domain
+	HeartbeatDomain         domain.Domain
+	HeartbeatInterval       time.Duration
+	UpdateCron              cron.Schedule
+	UpdateOnStart           bool
+	DeleteOnStop            bool
+	UpdateOnChange          bool
+	ChangeDebounce          time.Duration
+	ChangeMinInterval       time.Duration
+	HoldDownRounds          int
+	HoldDownPeriod          time.Duration
+	CacheExpiration         time.Duration
+	TTL                     api.TTL
+	ProxiedTemplate         string
+	Proxied                 map[domain.Domain]bool
+	RecordComment           string
+	RecordTags              []string
+	RecordOwnership         api.Ownership
+	WAFListDescription      string
+	DetectionTimeout        time.Duration
+	UpdateTimeout           time.Duration
+	Monitor                 monitor.Monitor
+	Notifier                notifier.Notifier
 }
 
 // Default gives the default configuration.
@@ -43,19 +66,35 @@ func Default() *Config {
 			ipnet.IP4: provider.NewCloudflareTrace(),
 			ipnet.IP6: provider.NewCloudflareTrace(),
 		},
+		DomainProviderTemplates: map[ipnet.Type]string{},
+		DomainProviders:         map[ipnet.Type][]DomainProvider{},
+		DetectionBinding:        map[ipnet.Type]provider.Binding{},
+		AllowedIPs:              map[ipnet.Type]ipnet.Policy{},
 		Domains: map[ipnet.Type][]domain.Domain{
 			ipnet.IP4: nil,
 			ipnet.IP6: nil,
 		},
+		IP6Suffixes:        map[domain.Domain]netip.Prefix{},
 		WAFLists:           nil,
+		UpdateSVCBHints:    false,
+		SPFDomains:         nil,
+		HeartbeatDomain:    nil,
+		HeartbeatInterval:  0,
 		UpdateCron:         cron.MustNew("@every 5m"),
 		UpdateOnStart:      true,
 		DeleteOnStop:       false,
+		UpdateOnChange:     false,
+		ChangeDebounce:     time.Second * 5,
+		ChangeMinInterval:  time.Minute,
+		HoldDownRounds:     1,
+		HoldDownPeriod:     0,
 		CacheExpiration:    time.Hour * 6,
 		TTL:                api.TTLAuto,
 		ProxiedTemplate:    "false",
 		Proxied:            map[domain.Domain]bool{},
 		RecordComment:      "",
+		RecordTags:         nil,
+		RecordOwnership:    api.OwnAllRecords,
 		WAFListDescription: "",
 		DetectionTimeout:   time.Second * 5,
 		UpdateTimeout:      time.Second * 30,
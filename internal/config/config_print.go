@@ -3,7 +3,11 @@ package config
 
 import (
 	"fmt"
+	"maps"
+	"net/netip"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/favonia/cloudflare-ddns/internal/api"
@@ -39,6 +43,55 @@ func computeInverseMap[V comparable](m map[domain.Domain]V) ([]V, map[V][]domain
 	return vals, inverse
 }
 
+func describeIP6Suffixes(m map[domain.Domain]netip.Prefix) string {
+	domains := slices.Collect(maps.Keys(m))
+	domain.SortDomains(domains)
+
+	descriptions := make([]string, 0, len(domains))
+	for _, dom := range domains {
+		descriptions = append(descriptions, dom.Describe()+"="+m[dom].String())
+	}
+	return pp.Join(descriptions)
+}
+
+func describeDomainProviders(groups []DomainProvider) string {
+	m := map[domain.Domain]provider.Provider{}
+	for _, group := range groups {
+		for _, dom := range group.Domains {
+			m[dom] = group.Provider
+		}
+	}
+	domains := slices.Collect(maps.Keys(m))
+	domain.SortDomains(domains)
+
+	descriptions := make([]string, 0, len(domains))
+	for _, dom := range domains {
+		descriptions = append(descriptions, dom.Describe()+"="+provider.Name(m[dom]))
+	}
+	return pp.Join(descriptions)
+}
+
+func describeHoldDown(rounds int, period time.Duration) string {
+	var conditions []string
+	if rounds > 1 {
+		conditions = append(conditions, fmt.Sprintf("detected in %d rounds in a row", rounds))
+	}
+	if period > 0 {
+		conditions = append(conditions, fmt.Sprintf("stable for %v", period))
+	}
+	if len(conditions) == 0 {
+		return "none"
+	}
+	return strings.Join(conditions, " and ")
+}
+
+func describeHeartbeatInterval(interval time.Duration) string {
+	if interval == 0 {
+		return "every successful round"
+	}
+	return fmt.Sprintf("every %v or when the IP addresses change", interval)
+}
+
 // Print prints the Config on the screen.
 func (c *Config) Print(ppfmt pp.PP) {
 	if !ppfmt.IsShowing(pp.Info) {
@@ -59,15 +112,36 @@ func (c *Config) Print(ppfmt pp.PP) {
 		if p != nil {
 			item(ipNet.Describe()+"-enabled domains:", "%s", pp.JoinMap(domain.Domain.Describe, c.Domains[ipNet]))
 			item(ipNet.Describe()+" provider:", "%s", provider.Name(p))
+			if groups := c.DomainProviders[ipNet]; len(groups) > 0 {
+				item(ipNet.Describe()+" domain providers:", "%s", describeDomainProviders(groups))
+			}
+			if policy, ok := c.AllowedIPs[ipNet]; ok && !policy.AllowsAll() {
+				item("Allowed "+ipNet.Describe()+" addresses:", "%s", policy.Describe())
+			}
+			if ipNet == ipnet.IP6 && len(c.IP6Suffixes) > 0 {
+				item("IPv6 suffixes:", "%s", describeIP6Suffixes(c.IP6Suffixes))
+			}
 		}
 	}
 	item("WAF lists:", "%s", pp.JoinMap(api.WAFList.Describe, c.WAFLists))
+	item("Sync HTTPS/SVCB hints?", "%t", c.UpdateSVCBHints)
+	item("SPF domains:", "%s", pp.JoinMap(domain.Domain.Describe, c.SPFDomains))
+	if c.HeartbeatDomain != nil {
+		item("Heartbeat TXT record:", "%s", c.HeartbeatDomain.Describe())
+		item("Heartbeat interval:", "%s", describeHeartbeatInterval(c.HeartbeatInterval))
+	}
 
 	section("Scheduling:")
 	item("Timezone:", "%s", cron.DescribeLocation(time.Local))
 	item("Update schedule:", "%s", cron.DescribeSchedule(c.UpdateCron))
 	item("Update on start?", "%t", c.UpdateOnStart)
 	item("Delete on stop?", "%t", c.DeleteOnStop)
+	item("Watch address changes?", "%t", c.UpdateOnChange)
+	if c.UpdateOnChange {
+		item("Debounce period:", "%v", c.ChangeDebounce)
+		item("Minimum update interval:", "%v", c.ChangeMinInterval)
+	}
+	item("Hold-down of new IPs:", "%s", describeHoldDown(c.HoldDownRounds, c.HoldDownPeriod))
 	item("Cache expiration:", "%v", c.CacheExpiration)
 
 	section("Parameters of new DNS records and WAF lists:")
@@ -78,6 +152,8 @@ func (c *Config) Print(ppfmt pp.PP) {
 		item("Unproxied domains:", "%s", pp.JoinMap(domain.Domain.Describe, inverseMap[false]))
 	}
 	item("DNS record comment:", "%s", describeComment(c.RecordComment))
+	item("DNS record tags:", "%s", pp.JoinMap(strconv.Quote, c.RecordTags))
+	item("Owned DNS records:", "%s", c.RecordOwnership.Describe())
 	item("WAF list description:", "%s", describeComment(c.WAFListDescription))
 
 	section("Timeouts:")
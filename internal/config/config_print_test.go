@@ -1,15 +1,19 @@
 package config_test
 
 import (
+	"net/netip"
 	"testing"
+	"time"
 
 	"go.uber.org/mock/gomock"
 
+	"github.com/favonia/cloudflare-ddns/internal/api"
 	"github.com/favonia/cloudflare-ddns/internal/config"
 	"github.com/favonia/cloudflare-ddns/internal/domain"
 	"github.com/favonia/cloudflare-ddns/internal/ipnet"
 	"github.com/favonia/cloudflare-ddns/internal/mocks"
 	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
 )
 
 func printItem(t *testing.T, ppfmt *mocks.MockPP, key string, value any) *mocks.PPInfofCall {
@@ -36,17 +40,23 @@ func TestPrintDefault(t *testing.T) {
 		printItem(t, innerMockPP, "IPv6-enabled domains:", "(none)"),
 		printItem(t, innerMockPP, "IPv6 provider:", "cloudflare.trace"),
 		printItem(t, innerMockPP, "WAF lists:", "(none)"),
+		printItem(t, innerMockPP, "Sync HTTPS/SVCB hints?", "false"),
+		printItem(t, innerMockPP, "SPF domains:", "(none)"),
 		mockPP.EXPECT().Infof(pp.EmojiConfig, "%s", "Scheduling:"),
 		printItem(t, innerMockPP, "Timezone:", gomock.AnyOf("UTC (currently UTC+00)", "Local (currently UTC+00)")),
 		printItem(t, innerMockPP, "Update schedule:", "@every 5m"),
 		printItem(t, innerMockPP, "Update on start?", "true"),
 		printItem(t, innerMockPP, "Delete on stop?", "false"),
+		printItem(t, innerMockPP, "Watch address changes?", "false"),
+		printItem(t, innerMockPP, "Hold-down of new IPs:", "none"),
 		printItem(t, innerMockPP, "Cache expiration:", "6h0m0s"),
 		mockPP.EXPECT().Infof(pp.EmojiConfig, "%s", "Parameters of new DNS records and WAF lists:"),
 		printItem(t, innerMockPP, "TTL:", "1 (auto)"),
 		printItem(t, innerMockPP, "Proxied domains:", "(none)"),
 		printItem(t, innerMockPP, "Unproxied domains:", "(none)"),
 		printItem(t, innerMockPP, "DNS record comment:", "(empty)"),
+		printItem(t, innerMockPP, "DNS record tags:", "(none)"),
+		printItem(t, innerMockPP, "Owned DNS records:", "all records"),
 		printItem(t, innerMockPP, "WAF list description:", "(empty)"),
 		mockPP.EXPECT().Infof(pp.EmojiConfig, "%s", "Timeouts:"),
 		printItem(t, innerMockPP, "IP detection:", "5s"),
@@ -71,20 +81,33 @@ func TestPrintValues(t *testing.T) {
 		mockPP.EXPECT().Infof(pp.EmojiConfig, "%s", "Domains, IP providers, and WAF lists:"),
 		printItem(t, innerMockPP, "IPv4-enabled domains:", "test4.org, *.test4.org"),
 		printItem(t, innerMockPP, "IPv4 provider:", "cloudflare.trace"),
+		printItem(t, innerMockPP, "IPv4 domain providers:", "*.test4.org=local"),
+		printItem(t, innerMockPP, "Allowed IPv4 addresses:", "public,10.0.0.0/8"),
 		printItem(t, innerMockPP, "IPv6-enabled domains:", "test6.org, *.test6.org"),
 		printItem(t, innerMockPP, "IPv6 provider:", "cloudflare.trace"),
+		printItem(t, innerMockPP, "IPv6 suffixes:", "*.test6.org=::5/64, test6.org=::1:2:3:4/64"),
 		printItem(t, innerMockPP, "WAF lists:", "(none)"),
+		printItem(t, innerMockPP, "Sync HTTPS/SVCB hints?", "false"),
+		printItem(t, innerMockPP, "SPF domains:", "(none)"),
+		printItem(t, innerMockPP, "Heartbeat TXT record:", "_ddns.test4.org"),
+		printItem(t, innerMockPP, "Heartbeat interval:", "every 1h0m0s or when the IP addresses change"),
 		mockPP.EXPECT().Infof(pp.EmojiConfig, "%s", "Scheduling:"),
 		printItem(t, innerMockPP, "Timezone:", gomock.AnyOf("UTC (currently UTC+00)", "Local (currently UTC+00)")),
 		printItem(t, innerMockPP, "Update schedule:", "@every 5m"),
 		printItem(t, innerMockPP, "Update on start?", "true"),
 		printItem(t, innerMockPP, "Delete on stop?", "false"),
+		printItem(t, innerMockPP, "Watch address changes?", "true"),
+		printItem(t, innerMockPP, "Debounce period:", "5s"),
+		printItem(t, innerMockPP, "Minimum update interval:", "1m0s"),
+		printItem(t, innerMockPP, "Hold-down of new IPs:", "detected in 3 rounds in a row and stable for 10m0s"),
 		printItem(t, innerMockPP, "Cache expiration:", "6h0m0s"),
 		mockPP.EXPECT().Infof(pp.EmojiConfig, "%s", "Parameters of new DNS records and WAF lists:"),
 		printItem(t, innerMockPP, "TTL:", "30000"),
 		printItem(t, innerMockPP, "Proxied domains:", "a, b"),
 		printItem(t, innerMockPP, "Unproxied domains:", "c, d"),
 		printItem(t, innerMockPP, "DNS record comment:", "\"Created by Cloudflare DDNS\""),
+		printItem(t, innerMockPP, "DNS record tags:", `"env:home", "owner:ddns"`),
+		printItem(t, innerMockPP, "Owned DNS records:", "records with the expected comment"),
 		printItem(t, innerMockPP, "WAF list description:", "(empty)"),
 		mockPP.EXPECT().Infof(pp.EmojiConfig, "%s", "Timeouts:"),
 		printItem(t, innerMockPP, "IP detection:", "5s"),
@@ -99,7 +122,16 @@ func TestPrintValues(t *testing.T) {
 
 	c.Domains[ipnet.IP4] = []domain.Domain{domain.FQDN("test4.org"), domain.Wildcard("test4.org")}
 	c.Domains[ipnet.IP6] = []domain.Domain{domain.FQDN("test6.org"), domain.Wildcard("test6.org")}
-
+	c.DomainProviders[ipnet.IP4] = []config.DomainProvider{{Provider: provider.NewLocal(), Domains: []domain.Domain{domain.Wildcard("test4.org")}}}
+	c.AllowedIPs[ipnet.IP4] = ipnet.Policy{Public: true, Prefixes: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}}
+	c.IP6Suffixes[domain.Wildcard("test6.org")] = netip.MustParsePrefix("::5/64")
+	c.IP6Suffixes[domain.FQDN("test6.org")] = netip.MustParsePrefix("::1:2:3:4/64")
+	c.HeartbeatDomain = domain.FQDN("_ddns.test4.org")
+	c.HeartbeatInterval = time.Hour
+
+	c.UpdateOnChange = true
+	c.HoldDownRounds = 3
+	c.HoldDownPeriod = 10 * time.Minute
 	c.TTL = 30000
 
 	c.Proxied = map[domain.Domain]bool{}
@@ -109,6 +141,8 @@ func TestPrintValues(t *testing.T) {
 	c.Proxied[domain.FQDN("d")] = false
 
 	c.RecordComment = "Created by Cloudflare DDNS"
+	c.RecordTags = []string{"env:home", "owner:ddns"}
+	c.RecordOwnership = api.OwnRecordsByComment
 
 	m := mocks.NewMockMonitor(mockCtrl)
 	m.EXPECT().Describe(gomock.Any()).
@@ -142,17 +176,23 @@ func TestPrintEmpty(t *testing.T) {
 		mockPP.EXPECT().Indent().Return(innerMockPP),
 		mockPP.EXPECT().Infof(pp.EmojiConfig, "%s", "Domains, IP providers, and WAF lists:"),
 		printItem(t, innerMockPP, "WAF lists:", "(none)"),
+		printItem(t, innerMockPP, "Sync HTTPS/SVCB hints?", "false"),
+		printItem(t, innerMockPP, "SPF domains:", "(none)"),
 		mockPP.EXPECT().Infof(pp.EmojiConfig, "%s", "Scheduling:"),
 		printItem(t, innerMockPP, "Timezone:", gomock.AnyOf("UTC (currently UTC+00)", "Local (currently UTC+00)")),
 		printItem(t, innerMockPP, "Update schedule:", "@once"),
 		printItem(t, innerMockPP, "Update on start?", "false"),
 		printItem(t, innerMockPP, "Delete on stop?", "false"),
+		printItem(t, innerMockPP, "Watch address changes?", "false"),
+		printItem(t, innerMockPP, "Hold-down of new IPs:", "none"),
 		printItem(t, innerMockPP, "Cache expiration:", "0s"),
 		mockPP.EXPECT().Infof(pp.EmojiConfig, "%s", "Parameters of new DNS records and WAF lists:"),
 		printItem(t, innerMockPP, "TTL:", "0"),
 		printItem(t, innerMockPP, "Proxied domains:", "(none)"),
 		printItem(t, innerMockPP, "Unproxied domains:", "(none)"),
 		printItem(t, innerMockPP, "DNS record comment:", "(empty)"),
+		printItem(t, innerMockPP, "DNS record tags:", "(none)"),
+		printItem(t, innerMockPP, "Owned DNS records:", "all records"),
 		printItem(t, innerMockPP, "WAF list description:", "(empty)"),
 		mockPP.EXPECT().Infof(pp.EmojiConfig, "%s", "Timeouts:"),
 		printItem(t, innerMockPP, "IP detection:", "0s"),
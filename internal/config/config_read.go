@@ -1,6 +1,8 @@
 package config
 
 import (
+	"strings"
+
 	"github.com/favonia/cloudflare-ddns/internal/api"
 	"github.com/favonia/cloudflare-ddns/internal/domain"
 	"github.com/favonia/cloudflare-ddns/internal/domainexp"
@@ -22,15 +24,30 @@ func (c *Config) ReadEnv(ppfmt pp.PP) bool {
 
 	if !ReadAuth(ppfmt, &c.Auth) ||
 		!ReadProviderMap(ppfmt, &c.Provider) ||
-		!ReadDomainMap(ppfmt, &c.Domains) ||
+		!ReadDomainProviderTemplateMap(ppfmt, &c.DomainProviderTemplates) ||
+		!ReadDetectionBindingMap(ppfmt, &c.DetectionBinding) ||
+		!BindProviderMap(ppfmt, c.DetectionBinding, &c.Provider) ||
+		!ReadAllowedIPsMap(ppfmt, &c.AllowedIPs) ||
+		!ReadDomainMap(ppfmt, &c.Domains, &c.IP6Suffixes) ||
 		!ReadAndAppendWAFListNames(ppfmt, "WAF_LISTS", &c.WAFLists) ||
+		!ReadBool(ppfmt, "UPDATE_SVCB_HINTS", &c.UpdateSVCBHints) ||
+		!ReadDomains(ppfmt, "SPF_DOMAINS", &c.SPFDomains) ||
+		!ReadDomain(ppfmt, "HEARTBEAT_DOMAIN", &c.HeartbeatDomain) ||
+		!ReadNonnegDuration(ppfmt, "HEARTBEAT_INTERVAL", &c.HeartbeatInterval) ||
 		!ReadCron(ppfmt, "UPDATE_CRON", &c.UpdateCron) ||
 		!ReadBool(ppfmt, "UPDATE_ON_START", &c.UpdateOnStart) ||
 		!ReadBool(ppfmt, "DELETE_ON_STOP", &c.DeleteOnStop) ||
+		!ReadBool(ppfmt, "UPDATE_ON_ADDRESS_CHANGE", &c.UpdateOnChange) ||
+		!ReadNonnegDuration(ppfmt, "ADDRESS_CHANGE_DEBOUNCE", &c.ChangeDebounce) ||
+		!ReadNonnegDuration(ppfmt, "ADDRESS_CHANGE_MIN_INTERVAL", &c.ChangeMinInterval) ||
+		!ReadNonnegInt(ppfmt, "HOLD_DOWN_ROUNDS", &c.HoldDownRounds) ||
+		!ReadNonnegDuration(ppfmt, "HOLD_DOWN_PERIOD", &c.HoldDownPeriod) ||
 		!ReadNonnegDuration(ppfmt, "CACHE_EXPIRATION", &c.CacheExpiration) ||
 		!ReadTTL(ppfmt, "TTL", &c.TTL) ||
 		!ReadString(ppfmt, "PROXIED", &c.ProxiedTemplate) ||
 		!ReadString(ppfmt, "RECORD_COMMENT", &c.RecordComment) ||
+		!ReadTags(ppfmt, "RECORD_TAGS", &c.RecordTags) ||
+		!ReadOwnership(ppfmt, "RECORD_OWNERSHIP", &c.RecordOwnership) ||
 		!ReadString(ppfmt, "WAF_LIST_DESCRIPTION", &c.WAFListDescription) ||
 		!ReadNonnegDuration(ppfmt, "DETECTION_TIMEOUT", &c.DetectionTimeout) ||
 		!ReadNonnegDuration(ppfmt, "UPDATE_TIMEOUT", &c.UpdateTimeout) ||
@@ -43,7 +60,28 @@ func (c *Config) ReadEnv(ppfmt pp.PP) bool {
 	return true
 }
 
-// Normalize checks and normalizes the fields [Config.Provider], [Config.Proxied], and [Config.DeleteOnStop].
+// ReadDetectionEnv calls the relevant readers to read only the environment variables about
+// the detection of IP addresses. It is used by the subcommand "detect", which does not touch DNS records
+// or WAF lists, and thus [Config.Normalize] is not needed.
+func (c *Config) ReadDetectionEnv(ppfmt pp.PP) bool {
+	if ppfmt.IsShowing(pp.Info) {
+		ppfmt.Infof(pp.EmojiEnvVars, "Reading settings . . .")
+		ppfmt = ppfmt.Indent()
+	}
+
+	if !ReadProviderMap(ppfmt, &c.Provider) ||
+		!ReadDetectionBindingMap(ppfmt, &c.DetectionBinding) ||
+		!BindProviderMap(ppfmt, c.DetectionBinding, &c.Provider) ||
+		!ReadAllowedIPsMap(ppfmt, &c.AllowedIPs) ||
+		!ReadNonnegDuration(ppfmt, "DETECTION_TIMEOUT", &c.DetectionTimeout) {
+		return false
+	}
+
+	return true
+}
+
+// Normalize checks and normalizes the fields [Config.Provider], [Config.DomainProviders], [Config.Proxied],
+// and [Config.DeleteOnStop].
 // When any error is reported, the original configuration remain unchanged.
 func (c *Config) Normalize(ppfmt pp.PP) bool {
 	if ppfmt.IsShowing(pp.Info) {
@@ -52,12 +90,14 @@ func (c *Config) Normalize(ppfmt pp.PP) bool {
 	}
 
 	// Step 1: is there something to do?
-	if len(c.Domains[ipnet.IP4]) == 0 && len(c.Domains[ipnet.IP6]) == 0 && len(c.WAFLists) == 0 {
-		ppfmt.Noticef(pp.EmojiUserError, "Nothing was specified in DOMAINS, IP4_DOMAINS, IP6_DOMAINS, or WAF_LISTS")
+	if len(c.Domains[ipnet.IP4]) == 0 && len(c.Domains[ipnet.IP6]) == 0 &&
+		len(c.WAFLists) == 0 && len(c.SPFDomains) == 0 {
+		ppfmt.Noticef(pp.EmojiUserError,
+			"Nothing was specified in DOMAINS, IP4_DOMAINS, IP6_DOMAINS, WAF_LISTS, or SPF_DOMAINS")
 		return false
 	}
 
-	// Part 2: check DELETE_ON_STOP and UpdateOnStart
+	// Part 2: check DELETE_ON_STOP, UpdateOnStart, and hold-down
 	if c.UpdateCron == nil {
 		if !c.UpdateOnStart {
 			ppfmt.Noticef(
@@ -71,6 +111,24 @@ func (c *Config) Normalize(ppfmt pp.PP) bool {
 				"DELETE_ON_STOP=true will immediately delete all domains and WAF lists when UPDATE_CRON=@once")
 			return false
 		}
+		if c.UpdateOnChange {
+			ppfmt.Noticef(
+				pp.EmojiUserError,
+				"UPDATE_ON_ADDRESS_CHANGE=true is incompatible with UPDATE_CRON=@once")
+			return false
+		}
+		if c.HoldDownRounds > 1 {
+			ppfmt.Noticef(
+				pp.EmojiUserError,
+				"HOLD_DOWN_ROUNDS=%d is incompatible with UPDATE_CRON=@once", c.HoldDownRounds)
+			return false
+		}
+		if c.HoldDownPeriod > 0 {
+			ppfmt.Noticef(
+				pp.EmojiUserError,
+				"HOLD_DOWN_PERIOD=%v is incompatible with UPDATE_CRON=@once", c.HoldDownPeriod)
+			return false
+		}
 	}
 
 	// Step 3: normalize domains and providers
@@ -82,9 +140,9 @@ func (c *Config) Normalize(ppfmt pp.PP) bool {
 		if p != nil {
 			domains := c.Domains[ipNet]
 
-			if len(domains) == 0 && len(c.WAFLists) == 0 {
+			if len(domains) == 0 && len(c.WAFLists) == 0 && len(c.SPFDomains) == 0 {
 				ppfmt.Noticef(pp.EmojiUserWarning,
-					"IP%d_PROVIDER was changed to %q because no domains or WAF lists use %s",
+					"IP%d_PROVIDER was changed to %q because no domains, WAF lists, or SPF records use %s",
 					ipNet.Int(), provider.Name(nil), ipNet.Describe())
 
 				continue
@@ -119,6 +177,53 @@ func (c *Config) Normalize(ppfmt pp.PP) bool {
 		}
 	}
 
+	// Step 3.4: check if detection bindings are unused
+	for ipNet := range ipnet.Bindings(c.DetectionBinding) {
+		if providerMap[ipNet] == nil {
+			ppfmt.Noticef(pp.EmojiUserWarning,
+				"IP%d_DETECTION_INTERFACE and IP%d_DETECTION_ADDRESS are ignored because %s is disabled",
+				ipNet.Int(), ipNet.Int(), ipNet.Describe())
+		}
+	}
+
+	// Step 3.5: check if IPv6 suffixes are unused
+	if providerMap[ipnet.IP6] == nil && len(c.IP6Suffixes) > 0 {
+		ppfmt.Noticef(pp.EmojiUserWarning,
+			"IPv6 suffixes of domains are ignored because %s is disabled", ipnet.IP6.Describe())
+	}
+
+	// Step 3.6: check if policies of allowed IP addresses are unused
+	for ipNet := range ipnet.Bindings(c.AllowedIPs) {
+		if providerMap[ipNet] == nil {
+			ppfmt.Noticef(pp.EmojiUserWarning,
+				"IP%d_ALLOWED is ignored because %s is disabled", ipNet.Int(), ipNet.Describe())
+		}
+	}
+
+	// Step 3.7: assign providers to domains according to IP4_DOMAIN_PROVIDERS and IP6_DOMAIN_PROVIDERS
+	//
+	// Note: domainProviderMap stays nil unless some domains use their own providers.
+	var domainProviderMap map[ipnet.Type][]DomainProvider
+	for ipNet, template := range ipnet.Bindings(c.DomainProviderTemplates) {
+		if providerMap[ipNet] == nil {
+			ppfmt.Noticef(pp.EmojiUserWarning,
+				"%s is ignored because %s is disabled", domainProviderKey(ipNet), ipNet.Describe())
+			continue
+		}
+
+		providers, ok := parseDomainProviders(ppfmt, domainProviderKey(ipNet), template,
+			c.Domains[ipNet], c.DetectionBinding[ipNet])
+		if !ok {
+			return false
+		}
+		if len(providers) > 0 {
+			if domainProviderMap == nil {
+				domainProviderMap = map[ipnet.Type][]DomainProvider{}
+			}
+			domainProviderMap[ipNet] = providers
+		}
+	}
+
 	// Step 4: regenerate proxiedMap from [Config.Proxied]
 	proxiedMap := map[domain.Domain]bool{}
 	if len(activeDomainSet) > 0 {
@@ -145,6 +250,23 @@ func (c *Config) Normalize(ppfmt pp.PP) bool {
 			ppfmt.Noticef(pp.EmojiUserWarning,
 				"RECORD_COMMENT=%s is ignored because no domains will be updated", c.RecordComment)
 		}
+		if len(c.RecordTags) > 0 {
+			ppfmt.Noticef(pp.EmojiUserWarning,
+				"RECORD_TAGS=%s is ignored because no domains will be updated", strings.Join(c.RecordTags, ","))
+		}
+		if c.UpdateSVCBHints {
+			ppfmt.Noticef(pp.EmojiUserWarning,
+				"UPDATE_SVCB_HINTS=true is ignored because no domains will be updated")
+		}
+		if c.RecordOwnership != api.OwnAllRecords {
+			ppfmt.Noticef(pp.EmojiUserWarning,
+				"RECORD_OWNERSHIP=%s is ignored because no domains will be updated", c.RecordOwnership)
+		}
+	} else if c.RecordOwnership == api.OwnRecordsByComment && c.RecordComment == "" {
+		ppfmt.Noticef(pp.EmojiUserError,
+			"RECORD_OWNERSHIP=%s requires a non-empty RECORD_COMMENT to mark the DNS records owned by the updater",
+			c.RecordOwnership)
+		return false
 	}
 	if len(c.WAFLists) == 0 { // We are only updating domains
 		if c.WAFListDescription != "" {
@@ -152,9 +274,14 @@ func (c *Config) Normalize(ppfmt pp.PP) bool {
 				"WAF_LIST_DESCRIPTION=%s is ignored because no WAF lists will be updated", c.WAFListDescription)
 		}
 	}
+	if c.HeartbeatDomain == nil && c.HeartbeatInterval != 0 {
+		ppfmt.Noticef(pp.EmojiUserWarning,
+			"HEARTBEAT_INTERVAL=%v is ignored because HEARTBEAT_DOMAIN is not set", c.HeartbeatInterval)
+	}
 
 	// Final Part: override the old values
 	c.Provider = providerMap
+	c.DomainProviders = domainProviderMap
 	c.Proxied = proxiedMap
 
 	return true
@@ -2,6 +2,7 @@
 package config_test
 
 import (
+	"net/netip"
 	"testing"
 	"time"
 
@@ -23,14 +24,26 @@ func unsetAll(t *testing.T) {
 		"CLOUDFLARE_API_TOKEN", "CLOUDFLARE_API_TOKEN_FILE",
 		"CF_API_TOKEN", "CF_API_TOKEN_FILE", "CF_ACCOUNT_ID",
 		"IP4_PROVIDER", "IP6_PROVIDER",
-		"DOMAINS", "IP4_DOMAINS", "IP6_DOMAINS", "WAF_LISTS",
+		"IP4_DOMAIN_PROVIDERS", "IP6_DOMAIN_PROVIDERS",
+		"IP4_DETECTION_INTERFACE", "IP4_DETECTION_ADDRESS",
+		"IP6_DETECTION_INTERFACE", "IP6_DETECTION_ADDRESS",
+		"IP4_ALLOWED", "IP6_ALLOWED",
+		"DOMAINS", "IP4_DOMAINS", "IP6_DOMAINS", "WAF_LISTS", "UPDATE_SVCB_HINTS", "SPF_DOMAINS",
+		"HEARTBEAT_DOMAIN", "HEARTBEAT_INTERVAL",
 		"UPDATE_CRON",
 		"UPDATE_ON_START",
 		"DELETE_ON_STOP",
+		"UPDATE_ON_ADDRESS_CHANGE",
+		"ADDRESS_CHANGE_DEBOUNCE",
+		"ADDRESS_CHANGE_MIN_INTERVAL",
+		"HOLD_DOWN_ROUNDS",
+		"HOLD_DOWN_PERIOD",
 		"CACHE_EXPIRATION",
 		"TTL",
 		"PROXIED",
 		"RECORD_COMMENT",
+		"RECORD_TAGS",
+		"RECORD_OWNERSHIP",
 		"WAF_LIST_DESCRIPTION",
 		"DETECTION_TIMEOUT",
 		"UPDATE_TIMEOUT",
@@ -56,11 +69,19 @@ func TestReadEnvWithOnlyToken(t *testing.T) {
 		mockPP.EXPECT().Indent().Return(innerMockPP),
 		innerMockPP.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%s", "IP4_PROVIDER", "none"),
 		innerMockPP.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%s", "IP6_PROVIDER", "none"),
+		innerMockPP.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%t", "UPDATE_SVCB_HINTS", false),
+		innerMockPP.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%v", "HEARTBEAT_INTERVAL", time.Duration(0)),
 		innerMockPP.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%s", "UPDATE_CRON", "@once"),
 		innerMockPP.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%t", "UPDATE_ON_START", false),
 		innerMockPP.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%t", "DELETE_ON_STOP", false),
+		innerMockPP.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%t", "UPDATE_ON_ADDRESS_CHANGE", false),
+		innerMockPP.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%v", "ADDRESS_CHANGE_DEBOUNCE", time.Duration(0)),
+		innerMockPP.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%v", "ADDRESS_CHANGE_MIN_INTERVAL", time.Duration(0)),
+		innerMockPP.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%d", "HOLD_DOWN_ROUNDS", 0),
+		innerMockPP.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%v", "HOLD_DOWN_PERIOD", time.Duration(0)),
 		innerMockPP.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%v", "CACHE_EXPIRATION", time.Duration(0)),
 		innerMockPP.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%d", "TTL", api.TTL(0)),
+		innerMockPP.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%s", "RECORD_OWNERSHIP", api.OwnAllRecords),
 		innerMockPP.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%v", "DETECTION_TIMEOUT", time.Duration(0)),
 		innerMockPP.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%v", "UPDATE_TIMEOUT", time.Duration(0)),
 	)
@@ -88,6 +109,32 @@ func TestReadEnvEmpty(t *testing.T) {
 	require.False(t, ok)
 }
 
+func TestReadDetectionEnv(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	unsetAll(t)
+	store(t, "IP4_PROVIDER", "local")
+	store(t, "IP4_ALLOWED", "public")
+	store(t, "DETECTION_TIMEOUT", "10s")
+
+	var cfg config.Config
+	mockPP := mocks.NewMockPP(mockCtrl)
+	innerMockPP := mocks.NewMockPP(mockCtrl)
+	gomock.InOrder(
+		mockPP.EXPECT().IsShowing(pp.Info).Return(true),
+		mockPP.EXPECT().Infof(pp.EmojiEnvVars, "Reading settings . . ."),
+		mockPP.EXPECT().Indent().Return(innerMockPP),
+		innerMockPP.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%s", "IP6_PROVIDER", "none"),
+	)
+	ok := cfg.ReadDetectionEnv(mockPP)
+	require.True(t, ok)
+	require.Equal(t, "local", provider.Name(cfg.Provider[ipnet.IP4]))
+	require.Nil(t, cfg.Provider[ipnet.IP6])
+	require.Equal(t, map[ipnet.Type]provider.Binding{}, cfg.DetectionBinding)
+	require.Equal(t, map[ipnet.Type]ipnet.Policy{ipnet.IP4: {Public: true, Prefixes: nil}}, cfg.AllowedIPs)
+	require.Equal(t, 10*time.Second, cfg.DetectionTimeout)
+}
+
 func TestNormalize(t *testing.T) {
 	t.Parallel()
 
@@ -109,7 +156,7 @@ func TestNormalize(t *testing.T) {
 					m.EXPECT().IsShowing(pp.Info).Return(true),
 					m.EXPECT().Infof(pp.EmojiEnvVars, "Checking settings . . ."),
 					m.EXPECT().Indent().Return(m),
-					m.EXPECT().Noticef(pp.EmojiUserError, "Nothing was specified in DOMAINS, IP4_DOMAINS, IP6_DOMAINS, or WAF_LISTS"),
+					m.EXPECT().Noticef(pp.EmojiUserError, "Nothing was specified in DOMAINS, IP4_DOMAINS, IP6_DOMAINS, WAF_LISTS, or SPF_DOMAINS"),
 				)
 			},
 		},
@@ -150,6 +197,63 @@ func TestNormalize(t *testing.T) {
 				)
 			},
 		},
+		"once/update-on-change": {
+			input: &config.Config{ //nolint:exhaustruct
+				Domains: map[ipnet.Type][]domain.Domain{
+					ipnet.IP4: {domain.FQDN("a.b.c")},
+				},
+				UpdateOnStart:  true,
+				UpdateOnChange: true,
+			},
+			ok:       false,
+			expected: nil,
+			prepareMockPP: func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().IsShowing(pp.Info).Return(true),
+					m.EXPECT().Infof(pp.EmojiEnvVars, "Checking settings . . ."),
+					m.EXPECT().Indent().Return(m),
+					m.EXPECT().Noticef(pp.EmojiUserError, "UPDATE_ON_ADDRESS_CHANGE=true is incompatible with UPDATE_CRON=@once"),
+				)
+			},
+		},
+		"once/hold-down-rounds": {
+			input: &config.Config{ //nolint:exhaustruct
+				Domains: map[ipnet.Type][]domain.Domain{
+					ipnet.IP4: {domain.FQDN("a.b.c")},
+				},
+				UpdateOnStart:  true,
+				HoldDownRounds: 3,
+			},
+			ok:       false,
+			expected: nil,
+			prepareMockPP: func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().IsShowing(pp.Info).Return(true),
+					m.EXPECT().Infof(pp.EmojiEnvVars, "Checking settings . . ."),
+					m.EXPECT().Indent().Return(m),
+					m.EXPECT().Noticef(pp.EmojiUserError, "HOLD_DOWN_ROUNDS=%d is incompatible with UPDATE_CRON=@once", 3),
+				)
+			},
+		},
+		"once/hold-down-period": {
+			input: &config.Config{ //nolint:exhaustruct
+				Domains: map[ipnet.Type][]domain.Domain{
+					ipnet.IP4: {domain.FQDN("a.b.c")},
+				},
+				UpdateOnStart:  true,
+				HoldDownPeriod: time.Minute,
+			},
+			ok:       false,
+			expected: nil,
+			prepareMockPP: func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().IsShowing(pp.Info).Return(true),
+					m.EXPECT().Infof(pp.EmojiEnvVars, "Checking settings . . ."),
+					m.EXPECT().Indent().Return(m),
+					m.EXPECT().Noticef(pp.EmojiUserError, "HOLD_DOWN_PERIOD=%v is incompatible with UPDATE_CRON=@once", time.Minute),
+				)
+			},
+		},
 		"nilprovider": {
 			input: &config.Config{ //nolint:exhaustruct
 				UpdateOnStart: true,
@@ -173,6 +277,53 @@ func TestNormalize(t *testing.T) {
 				)
 			},
 		},
+		"ip6/ignored": {
+			input: &config.Config{ //nolint:exhaustruct
+				UpdateOnStart: true,
+				Provider: map[ipnet.Type]provider.Provider{
+					ipnet.IP4: provider.NewCloudflareTrace(),
+					ipnet.IP6: nil,
+				},
+				Domains: map[ipnet.Type][]domain.Domain{
+					ipnet.IP4: {domain.FQDN("a.b.c")},
+					ipnet.IP6: {domain.FQDN("a.b.c")},
+				},
+				DetectionBinding: map[ipnet.Type]provider.Binding{ipnet.IP6: {Interface: "eth0", Address: netip.Addr{}}},
+				AllowedIPs:       map[ipnet.Type]ipnet.Policy{ipnet.IP6: {Public: true, Prefixes: nil}},
+				IP6Suffixes:      map[domain.Domain]netip.Prefix{domain.FQDN("a.b.c"): netip.MustParsePrefix("::1/64")},
+				ProxiedTemplate:  "false",
+				DetectionTimeout: 5 * time.Second,
+			},
+			ok: true,
+			expected: &config.Config{ //nolint:exhaustruct
+				UpdateOnStart: true,
+				Provider: map[ipnet.Type]provider.Provider{
+					ipnet.IP4: provider.NewCloudflareTrace(),
+				},
+				Domains: map[ipnet.Type][]domain.Domain{
+					ipnet.IP4: {domain.FQDN("a.b.c")},
+					ipnet.IP6: {domain.FQDN("a.b.c")},
+				},
+				DetectionBinding: map[ipnet.Type]provider.Binding{ipnet.IP6: {Interface: "eth0", Address: netip.Addr{}}},
+				AllowedIPs:       map[ipnet.Type]ipnet.Policy{ipnet.IP6: {Public: true, Prefixes: nil}},
+				IP6Suffixes:      map[domain.Domain]netip.Prefix{domain.FQDN("a.b.c"): netip.MustParsePrefix("::1/64")},
+				ProxiedTemplate:  "false",
+				Proxied: map[domain.Domain]bool{
+					domain.FQDN("a.b.c"): false,
+				},
+				DetectionTimeout: 5 * time.Second,
+			},
+			prepareMockPP: func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().IsShowing(pp.Info).Return(true),
+					m.EXPECT().Infof(pp.EmojiEnvVars, "Checking settings . . ."),
+					m.EXPECT().Indent().Return(m),
+					m.EXPECT().Noticef(pp.EmojiUserWarning, "IP%d_DETECTION_INTERFACE and IP%d_DETECTION_ADDRESS are ignored because %s is disabled", 6, 6, "IPv6"),
+					m.EXPECT().Noticef(pp.EmojiUserWarning, "IPv6 suffixes of domains are ignored because %s is disabled", "IPv6"),
+					m.EXPECT().Noticef(pp.EmojiUserWarning, "IP%d_ALLOWED is ignored because %s is disabled", 6, "IPv6"),
+				)
+			},
+		},
 		"dns6empty": {
 			input: &config.Config{ //nolint:exhaustruct
 				UpdateOnStart: true,
@@ -206,7 +357,80 @@ func TestNormalize(t *testing.T) {
 					m.EXPECT().IsShowing(pp.Info).Return(true),
 					m.EXPECT().Infof(pp.EmojiEnvVars, "Checking settings . . ."),
 					m.EXPECT().Indent().Return(m),
-					m.EXPECT().Noticef(pp.EmojiUserWarning, "IP%d_PROVIDER was changed to %q because no domains or WAF lists use %s", 6, "none", "IPv6"),
+					m.EXPECT().Noticef(pp.EmojiUserWarning, "IP%d_PROVIDER was changed to %q because no domains, WAF lists, or SPF records use %s", 6, "none", "IPv6"),
+				)
+			},
+		},
+		"spf-only": {
+			input: &config.Config{ //nolint:exhaustruct
+				UpdateOnStart: true,
+				Provider: map[ipnet.Type]provider.Provider{
+					ipnet.IP4: provider.NewCloudflareTrace(),
+					ipnet.IP6: provider.NewCloudflareTrace(),
+				},
+				Domains:          map[ipnet.Type][]domain.Domain{},
+				SPFDomains:       []domain.Domain{domain.FQDN("mail.b.c")},
+				TTL:              api.TTLAuto,
+				ProxiedTemplate:  "false",
+				DetectionTimeout: 5 * time.Second,
+			},
+			ok: true,
+			expected: &config.Config{ //nolint:exhaustruct
+				UpdateOnStart: true,
+				Provider: map[ipnet.Type]provider.Provider{
+					ipnet.IP4: provider.NewCloudflareTrace(),
+					ipnet.IP6: provider.NewCloudflareTrace(),
+				},
+				Domains:          map[ipnet.Type][]domain.Domain{},
+				SPFDomains:       []domain.Domain{domain.FQDN("mail.b.c")},
+				TTL:              api.TTLAuto,
+				ProxiedTemplate:  "false",
+				Proxied:          map[domain.Domain]bool{},
+				DetectionTimeout: 5 * time.Second,
+			},
+			prepareMockPP: func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().IsShowing(pp.Info).Return(true),
+					m.EXPECT().Infof(pp.EmojiEnvVars, "Checking settings . . ."),
+					m.EXPECT().Indent().Return(m),
+				)
+			},
+		},
+		"ignored/heartbeat-interval": {
+			input: &config.Config{ //nolint:exhaustruct
+				UpdateOnStart: true,
+				Provider: map[ipnet.Type]provider.Provider{
+					ipnet.IP4: provider.NewCloudflareTrace(),
+					ipnet.IP6: provider.NewCloudflareTrace(),
+				},
+				Domains:           map[ipnet.Type][]domain.Domain{},
+				SPFDomains:        []domain.Domain{domain.FQDN("mail.b.c")},
+				HeartbeatInterval: time.Hour,
+				TTL:               api.TTLAuto,
+				ProxiedTemplate:   "false",
+				DetectionTimeout:  5 * time.Second,
+			},
+			ok: true,
+			expected: &config.Config{ //nolint:exhaustruct
+				UpdateOnStart: true,
+				Provider: map[ipnet.Type]provider.Provider{
+					ipnet.IP4: provider.NewCloudflareTrace(),
+					ipnet.IP6: provider.NewCloudflareTrace(),
+				},
+				Domains:           map[ipnet.Type][]domain.Domain{},
+				SPFDomains:        []domain.Domain{domain.FQDN("mail.b.c")},
+				HeartbeatInterval: time.Hour,
+				TTL:               api.TTLAuto,
+				ProxiedTemplate:   "false",
+				Proxied:           map[domain.Domain]bool{},
+				DetectionTimeout:  5 * time.Second,
+			},
+			prepareMockPP: func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().IsShowing(pp.Info).Return(true),
+					m.EXPECT().Infof(pp.EmojiEnvVars, "Checking settings . . ."),
+					m.EXPECT().Indent().Return(m),
+					m.EXPECT().Noticef(pp.EmojiUserWarning, "HEARTBEAT_INTERVAL=%v is ignored because HEARTBEAT_DOMAIN is not set", time.Hour),
 				)
 			},
 		},
@@ -227,7 +451,7 @@ func TestNormalize(t *testing.T) {
 					m.EXPECT().IsShowing(pp.Info).Return(true),
 					m.EXPECT().Infof(pp.EmojiEnvVars, "Checking settings . . ."),
 					m.EXPECT().Indent().Return(m),
-					m.EXPECT().Noticef(pp.EmojiUserWarning, "IP%d_PROVIDER was changed to %q because no domains or WAF lists use %s", 6, "none", "IPv6"),
+					m.EXPECT().Noticef(pp.EmojiUserWarning, "IP%d_PROVIDER was changed to %q because no domains, WAF lists, or SPF records use %s", 6, "none", "IPv6"),
 					m.EXPECT().Noticef(pp.EmojiUserError, "Nothing to update because both IP4_PROVIDER and IP6_PROVIDER are %q", "none"),
 				)
 			},
@@ -282,6 +506,9 @@ func TestNormalize(t *testing.T) {
 				TTL:              10000,
 				ProxiedTemplate:  "true",
 				RecordComment:    "hello",
+				RecordTags:       []string{"owner:ddns"},
+				RecordOwnership:  api.OwnRecordsByComment,
+				UpdateSVCBHints:  true,
 				DetectionTimeout: 5 * time.Second,
 			},
 			ok: true,
@@ -296,6 +523,9 @@ func TestNormalize(t *testing.T) {
 				ProxiedTemplate:  "true",
 				Proxied:          map[domain.Domain]bool{},
 				RecordComment:    "hello",
+				RecordTags:       []string{"owner:ddns"},
+				RecordOwnership:  api.OwnRecordsByComment,
+				UpdateSVCBHints:  true,
 				DetectionTimeout: 5 * time.Second,
 			},
 			prepareMockPP: func(m *mocks.MockPP) {
@@ -306,6 +536,9 @@ func TestNormalize(t *testing.T) {
 					m.EXPECT().Noticef(pp.EmojiUserWarning, "TTL=%v is ignored because no domains will be updated", api.TTL(10000)),
 					m.EXPECT().Noticef(pp.EmojiUserWarning, "PROXIED=%s is ignored because no domains will be updated", "true"),
 					m.EXPECT().Noticef(pp.EmojiUserWarning, "RECORD_COMMENT=%s is ignored because no domains will be updated", "hello"),
+					m.EXPECT().Noticef(pp.EmojiUserWarning, "RECORD_TAGS=%s is ignored because no domains will be updated", "owner:ddns"),
+					m.EXPECT().Noticef(pp.EmojiUserWarning, "UPDATE_SVCB_HINTS=true is ignored because no domains will be updated"),
+					m.EXPECT().Noticef(pp.EmojiUserWarning, "RECORD_OWNERSHIP=%s is ignored because no domains will be updated", api.OwnRecordsByComment),
 				)
 			},
 		},
@@ -350,6 +583,29 @@ func TestNormalize(t *testing.T) {
 				)
 			},
 		},
+		"ownership/empty-comment": {
+			input: &config.Config{ //nolint:exhaustruct
+				UpdateOnStart: true,
+				Provider: map[ipnet.Type]provider.Provider{
+					ipnet.IP6: provider.NewCloudflareTrace(),
+				},
+				Domains: map[ipnet.Type][]domain.Domain{
+					ipnet.IP6: {domain.FQDN("a.b.c")},
+				},
+				ProxiedTemplate: "false",
+				RecordOwnership: api.OwnRecordsByComment,
+			},
+			ok:       false,
+			expected: nil,
+			prepareMockPP: func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().IsShowing(pp.Info).Return(true),
+					m.EXPECT().Infof(pp.EmojiEnvVars, "Checking settings . . ."),
+					m.EXPECT().Indent().Return(m),
+					m.EXPECT().Noticef(pp.EmojiUserError, "RECORD_OWNERSHIP=%s requires a non-empty RECORD_COMMENT to mark the DNS records owned by the updater", api.OwnRecordsByComment),
+				)
+			},
+		},
 		"proxied": {
 			input: &config.Config{ //nolint:exhaustruct
 				UpdateOnStart: true,
@@ -453,6 +709,181 @@ func TestNormalize(t *testing.T) {
 				)
 			},
 		},
+		"domain-providers": {
+			input: &config.Config{ //nolint:exhaustruct
+				UpdateOnStart: true,
+				Provider: map[ipnet.Type]provider.Provider{
+					ipnet.IP4: provider.NewCloudflareTrace(),
+				},
+				DomainProviderTemplates: map[ipnet.Type]string{ipnet.IP4: " is(lan.b.c) = local ; sub(x.y)=local\nis(a.b.c)=cloudflare.trace;"},
+				Domains: map[ipnet.Type][]domain.Domain{
+					ipnet.IP4: {domain.FQDN("a.b.c"), domain.FQDN("lan.b.c")},
+				},
+				ProxiedTemplate:  "false",
+				DetectionTimeout: 5 * time.Second,
+			},
+			ok: true,
+			expected: &config.Config{ //nolint:exhaustruct
+				UpdateOnStart: true,
+				Provider: map[ipnet.Type]provider.Provider{
+					ipnet.IP4: provider.NewCloudflareTrace(),
+				},
+				DomainProviderTemplates: map[ipnet.Type]string{ipnet.IP4: " is(lan.b.c) = local ; sub(x.y)=local\nis(a.b.c)=cloudflare.trace;"},
+				DomainProviders: map[ipnet.Type][]config.DomainProvider{
+					ipnet.IP4: {
+						{Provider: provider.NewLocal(), Domains: []domain.Domain{domain.FQDN("lan.b.c")}},
+						{Provider: provider.NewCloudflareTrace(), Domains: []domain.Domain{domain.FQDN("a.b.c")}},
+					},
+				},
+				Domains: map[ipnet.Type][]domain.Domain{
+					ipnet.IP4: {domain.FQDN("a.b.c"), domain.FQDN("lan.b.c")},
+				},
+				ProxiedTemplate: "false",
+				Proxied: map[domain.Domain]bool{
+					domain.FQDN("a.b.c"):   false,
+					domain.FQDN("lan.b.c"): false,
+				},
+				DetectionTimeout: 5 * time.Second,
+			},
+			prepareMockPP: func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().IsShowing(pp.Info).Return(true),
+					m.EXPECT().Infof(pp.EmojiEnvVars, "Checking settings . . ."),
+					m.EXPECT().Indent().Return(m),
+					m.EXPECT().Noticef(pp.EmojiUserWarning, "%s (%q) contains %q, which does not apply to any remaining domain", "IP4_DOMAIN_PROVIDERS", " is(lan.b.c) = local ; sub(x.y)=local\nis(a.b.c)=cloudflare.trace;", "sub(x.y)=local"),
+				)
+			},
+		},
+		"domain-providers/same-name": {
+			input: &config.Config{ //nolint:exhaustruct
+				UpdateOnStart: true,
+				Provider: map[ipnet.Type]provider.Provider{
+					ipnet.IP4: provider.NewCloudflareTrace(),
+				},
+				DomainProviderTemplates: map[ipnet.Type]string{ipnet.IP4: "is(a.b.c)=url:https://wan1.lan/ip; is(lan.b.c)=url:https://wan2.lan/ip"},
+				Domains: map[ipnet.Type][]domain.Domain{
+					ipnet.IP4: {domain.FQDN("a.b.c"), domain.FQDN("lan.b.c")},
+				},
+				ProxiedTemplate:  "false",
+				DetectionTimeout: 5 * time.Second,
+			},
+			ok: true,
+			expected: &config.Config{ //nolint:exhaustruct
+				UpdateOnStart: true,
+				Provider: map[ipnet.Type]provider.Provider{
+					ipnet.IP4: provider.NewCloudflareTrace(),
+				},
+				DomainProviderTemplates: map[ipnet.Type]string{ipnet.IP4: "is(a.b.c)=url:https://wan1.lan/ip; is(lan.b.c)=url:https://wan2.lan/ip"},
+				DomainProviders: map[ipnet.Type][]config.DomainProvider{
+					ipnet.IP4: {
+						{Provider: provider.MustNewCustomURL("https://wan1.lan/ip"), Domains: []domain.Domain{domain.FQDN("a.b.c")}},
+						{Provider: provider.MustNewCustomURL("https://wan2.lan/ip"), Domains: []domain.Domain{domain.FQDN("lan.b.c")}},
+					},
+				},
+				Domains: map[ipnet.Type][]domain.Domain{
+					ipnet.IP4: {domain.FQDN("a.b.c"), domain.FQDN("lan.b.c")},
+				},
+				ProxiedTemplate: "false",
+				Proxied: map[domain.Domain]bool{
+					domain.FQDN("a.b.c"):   false,
+					domain.FQDN("lan.b.c"): false,
+				},
+				DetectionTimeout: 5 * time.Second,
+			},
+			prepareMockPP: func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().IsShowing(pp.Info).Return(true),
+					m.EXPECT().Infof(pp.EmojiEnvVars, "Checking settings . . ."),
+					m.EXPECT().Indent().Return(m),
+				)
+			},
+		},
+		"domain-providers/ignored": {
+			input: &config.Config{ //nolint:exhaustruct
+				UpdateOnStart: true,
+				Provider: map[ipnet.Type]provider.Provider{
+					ipnet.IP4: provider.NewCloudflareTrace(),
+				},
+				DomainProviderTemplates: map[ipnet.Type]string{ipnet.IP6: "is(a.b.c)=local"},
+				Domains: map[ipnet.Type][]domain.Domain{
+					ipnet.IP4: {domain.FQDN("a.b.c")},
+				},
+				ProxiedTemplate:  "false",
+				DetectionTimeout: 5 * time.Second,
+			},
+			ok: true,
+			expected: &config.Config{ //nolint:exhaustruct
+				UpdateOnStart: true,
+				Provider: map[ipnet.Type]provider.Provider{
+					ipnet.IP4: provider.NewCloudflareTrace(),
+				},
+				DomainProviderTemplates: map[ipnet.Type]string{ipnet.IP6: "is(a.b.c)=local"},
+				Domains: map[ipnet.Type][]domain.Domain{
+					ipnet.IP4: {domain.FQDN("a.b.c")},
+				},
+				ProxiedTemplate: "false",
+				Proxied: map[domain.Domain]bool{
+					domain.FQDN("a.b.c"): false,
+				},
+				DetectionTimeout: 5 * time.Second,
+			},
+			prepareMockPP: func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().IsShowing(pp.Info).Return(true),
+					m.EXPECT().Infof(pp.EmojiEnvVars, "Checking settings . . ."),
+					m.EXPECT().Indent().Return(m),
+					m.EXPECT().Noticef(pp.EmojiUserWarning, "%s is ignored because %s is disabled", "IP6_DOMAIN_PROVIDERS", "IPv6"),
+				)
+			},
+		},
+		"domain-providers/ill-formed": {
+			input: &config.Config{ //nolint:exhaustruct
+				UpdateOnStart: true,
+				Provider: map[ipnet.Type]provider.Provider{
+					ipnet.IP4: provider.NewCloudflareTrace(),
+				},
+				DomainProviderTemplates: map[ipnet.Type]string{ipnet.IP4: "is(a.b.c)=local; lan.b.c"},
+				Domains: map[ipnet.Type][]domain.Domain{
+					ipnet.IP4: {domain.FQDN("a.b.c")},
+				},
+				ProxiedTemplate:  "false",
+				DetectionTimeout: 5 * time.Second,
+			},
+			ok:       false,
+			expected: nil,
+			prepareMockPP: func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().IsShowing(pp.Info).Return(true),
+					m.EXPECT().Infof(pp.EmojiEnvVars, "Checking settings . . ."),
+					m.EXPECT().Indent().Return(m),
+					m.EXPECT().Noticef(pp.EmojiUserError, "%s (%q) contains %q, which is not in the form <domain expression>=<provider>", "IP4_DOMAIN_PROVIDERS", "is(a.b.c)=local; lan.b.c", "lan.b.c"),
+				)
+			},
+		},
+		"domain-providers/none": {
+			input: &config.Config{ //nolint:exhaustruct
+				UpdateOnStart: true,
+				Provider: map[ipnet.Type]provider.Provider{
+					ipnet.IP4: provider.NewCloudflareTrace(),
+				},
+				DomainProviderTemplates: map[ipnet.Type]string{ipnet.IP4: "is(a.b.c)=none"},
+				Domains: map[ipnet.Type][]domain.Domain{
+					ipnet.IP4: {domain.FQDN("a.b.c")},
+				},
+				ProxiedTemplate:  "false",
+				DetectionTimeout: 5 * time.Second,
+			},
+			ok:       false,
+			expected: nil,
+			prepareMockPP: func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().IsShowing(pp.Info).Return(true),
+					m.EXPECT().Infof(pp.EmojiEnvVars, "Checking settings . . ."),
+					m.EXPECT().Indent().Return(m),
+					m.EXPECT().Noticef(pp.EmojiUserError, "%s (%q) cannot use the provider %q; remove the domains from the domain lists instead", "IP4_DOMAIN_PROVIDERS", "is(a.b.c)=none", "none"),
+				)
+			},
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
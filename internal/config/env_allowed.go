@@ -0,0 +1,78 @@
+package config
+
+import (
+	"net/netip"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// ReadAllowedIPs reads a comma-separated list of "any", "public", IP ranges, and IP addresses
+// as the policy deciding which detected addresses of the IP family may be used.
+func ReadAllowedIPs(ppfmt pp.PP, ipNet ipnet.Type, key string, field *ipnet.Policy) bool {
+	vals := GetenvAsList(key, ",")
+	if len(vals) == 0 {
+		*field = ipnet.Policy{}
+		return true
+	}
+
+	policy := ipnet.Policy{Public: false, Prefixes: nil}
+	for _, val := range vals {
+		switch val {
+		case "any":
+			if len(vals) > 1 {
+				ppfmt.Noticef(pp.EmojiUserError, `%s (%q) cannot combine "any" with other items`, key, Getenv(key))
+				return false
+			}
+			*field = ipnet.Policy{}
+			return true
+
+		case "public":
+			policy.Public = true
+
+		default:
+			prefix, err := netip.ParsePrefix(val)
+			if err != nil {
+				ip, errAddr := netip.ParseAddr(val)
+				if errAddr != nil {
+					ppfmt.Noticef(pp.EmojiUserError,
+						`%s (%q) contains %q, which is neither "any", "public", an IP range, nor an IP address`,
+						key, Getenv(key), val)
+					return false
+				}
+				prefix = netip.PrefixFrom(ip, ip.BitLen())
+			}
+			if !ipNet.Matches(prefix.Addr()) || prefix.Addr().Is4In6() {
+				ppfmt.Noticef(pp.EmojiUserError, "%s (%q) contains %q, which is not an %s range",
+					key, Getenv(key), val, ipNet.Describe())
+				return false
+			}
+			policy.Prefixes = append(policy.Prefixes, prefix.Masked())
+		}
+	}
+
+	*field = policy
+	return true
+}
+
+// ReadAllowedIPsMap reads IP4_ALLOWED and IP6_ALLOWED into a map.
+// IP families allowing all addresses are not in the map.
+func ReadAllowedIPsMap(ppfmt pp.PP, field *map[ipnet.Type]ipnet.Policy) bool {
+	var ip4Policy, ip6Policy ipnet.Policy
+
+	if !ReadAllowedIPs(ppfmt, ipnet.IP4, "IP4_ALLOWED", &ip4Policy) ||
+		!ReadAllowedIPs(ppfmt, ipnet.IP6, "IP6_ALLOWED", &ip6Policy) {
+		return false
+	}
+
+	policies := map[ipnet.Type]ipnet.Policy{}
+	if !ip4Policy.AllowsAll() {
+		policies[ipnet.IP4] = ip4Policy
+	}
+	if !ip6Policy.AllowsAll() {
+		policies[ipnet.IP6] = ip6Policy
+	}
+
+	*field = policies
+	return true
+}
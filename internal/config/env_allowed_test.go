@@ -0,0 +1,76 @@
+// vim: nowrap
+package config_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/config"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+//nolint:paralleltest // environment vars are global
+func TestReadAllowedIPsMap(t *testing.T) {
+	for name, tc := range map[string]struct {
+		ip4           string
+		ip6           string
+		expected      map[ipnet.Type]ipnet.Policy
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"empty": {"", "", map[ipnet.Type]ipnet.Policy{}, true, nil},
+		"any":   {" any ", "any", map[ipnet.Type]ipnet.Policy{}, true, nil},
+		"full": {
+			"public, 10.0.0.1", "2001:db8::1/32",
+			map[ipnet.Type]ipnet.Policy{
+				ipnet.IP4: {Public: true, Prefixes: []netip.Prefix{netip.MustParsePrefix("10.0.0.1/32")}},
+				ipnet.IP6: {Public: false, Prefixes: []netip.Prefix{netip.MustParsePrefix("2001:db8::/32")}},
+			},
+			true, nil,
+		},
+		"any-public": {
+			"any,public", "", nil, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s (%q) cannot combine "any" with other items`, "IP4_ALLOWED", "any,public")
+			},
+		},
+		"invalid": {
+			"private", "", nil, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s (%q) contains %q, which is neither "any", "public", an IP range, nor an IP address`, "IP4_ALLOWED", "private", "private")
+			},
+		},
+		"mismatch": {
+			"", "public,10.0.0.0/8", nil, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, "%s (%q) contains %q, which is not an %s range", "IP6_ALLOWED", "public,10.0.0.0/8", "10.0.0.0/8", "IPv6")
+			},
+		},
+		"mapped": {
+			"::ffff:10.0.0.0/104", "", nil, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, "%s (%q) contains %q, which is not an %s range", "IP4_ALLOWED", "::ffff:10.0.0.0/104", "::ffff:10.0.0.0/104", "IPv4")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			store(t, "IP4_ALLOWED", tc.ip4)
+			store(t, "IP6_ALLOWED", tc.ip6)
+
+			var field map[ipnet.Type]ipnet.Policy
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			ok := config.ReadAllowedIPsMap(mockPP, &field)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, field)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -116,6 +117,49 @@ func ReadTTL(ppfmt pp.PP, key string, field *api.TTL) bool {
 	}
 }
 
+// ReadOwnership reads an environment variable as an ownership mode of DNS records ("all" or "comment").
+func ReadOwnership(ppfmt pp.PP, key string, field *api.Ownership) bool {
+	val := Getenv(key)
+	if val == "" {
+		ppfmt.Infof(pp.EmojiBullet, "Use default %s=%s", key, *field)
+		return true
+	}
+
+	switch strings.ToLower(val) {
+	case api.OwnAllRecords.String():
+		*field = api.OwnAllRecords
+	case api.OwnRecordsByComment.String():
+		*field = api.OwnRecordsByComment
+	default:
+		ppfmt.Noticef(pp.EmojiUserError, "%s (%q) should be %q or %q",
+			key, val, api.OwnAllRecords.String(), api.OwnRecordsByComment.String())
+		return false
+	}
+	return true
+}
+
+// ReadTags reads an environment variable as a comma-separated list of tags of DNS records,
+// such as "owner:ddns,env:home". The tags are sorted and deduplicated.
+func ReadTags(ppfmt pp.PP, key string, field *[]string) bool {
+	vals := GetenvAsList(key, ",")
+	if len(vals) == 0 {
+		if len(*field) > 0 {
+			ppfmt.Infof(pp.EmojiBullet, "Use default %s=%s", key, strings.Join(*field, ","))
+		}
+		return true
+	}
+
+	for _, val := range vals {
+		if name, _, found := strings.Cut(val, ":"); !found || name == "" {
+			ppfmt.Noticef(pp.EmojiUserWarning, `Tag %q should be in the format "name:value"`, val)
+		}
+	}
+
+	slices.Sort(vals)
+	*field = slices.Compact(vals)
+	return true
+}
+
 // ReadNonnegDuration reads an environment variable and parses it as a time duration.
 func ReadNonnegDuration(ppfmt pp.PP, key string, field *time.Duration) bool {
 	val := Getenv(key)
@@ -324,6 +324,97 @@ func TestReadTTL(t *testing.T) {
 	}
 }
 
+//nolint:paralleltest // environment vars are global
+func TestReadOwnership(t *testing.T) {
+	key := keyPrefix + "OWNERSHIP"
+	for name, tc := range map[string]struct {
+		set           bool
+		val           string
+		oldField      api.Ownership
+		newField      api.Ownership
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"empty": {
+			true, "", api.OwnAllRecords, api.OwnAllRecords, true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%s", key, api.OwnAllRecords)
+			},
+		},
+		"all":     {true, "  all ", api.OwnRecordsByComment, api.OwnAllRecords, true, nil},
+		"comment": {true, " Comment  ", api.OwnAllRecords, api.OwnRecordsByComment, true, nil},
+		"tag": {
+			true, "tag", api.OwnAllRecords, api.OwnAllRecords, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, "%s (%q) should be %q or %q", key, "tag", "all", "comment")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			set(t, key, tc.set, tc.val)
+			field := tc.oldField
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			ok := config.ReadOwnership(mockPP, key, &field)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.newField, field)
+		})
+	}
+}
+
+//nolint:paralleltest // environment vars are global
+func TestReadTags(t *testing.T) {
+	key := keyPrefix + "TAGS"
+	for name, tc := range map[string]struct {
+		set           bool
+		val           string
+		oldField      []string
+		newField      []string
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"nil":   {false, "", nil, nil, true, nil},
+		"empty": {true, " , ", nil, nil, true, nil},
+		"default": {
+			false, "", []string{"env:home", "owner:ddns"}, []string{"env:home", "owner:ddns"}, true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiBullet, "Use default %s=%s", key, "env:home,owner:ddns")
+			},
+		},
+		"one":      {true, " owner:ddns ", nil, []string{"owner:ddns"}, true, nil},
+		"sorted":   {true, "owner:ddns, env:home,owner:ddns", nil, []string{"env:home", "owner:ddns"}, true, nil},
+		"no-value": {true, "owner:", []string{"env:home"}, []string{"owner:"}, true, nil},
+		"no-colon": {
+			true, "ddns,owner:ddns", nil, []string{"ddns", "owner:ddns"}, true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserWarning, `Tag %q should be in the format "name:value"`, "ddns")
+			},
+		},
+		"no-name": {
+			true, ":ddns", nil, []string{":ddns"}, true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserWarning, `Tag %q should be in the format "name:value"`, ":ddns")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			set(t, key, tc.set, tc.val)
+			field := tc.oldField
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			ok := config.ReadTags(mockPP, key, &field)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.newField, field)
+		})
+	}
+}
+
 //nolint:paralleltest // environment vars are global
 func TestReadNonnegDuration(t *testing.T) {
 	key := keyPrefix + "DURATION"
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+)
+
+// ReadDetectionBinding reads the network interface and the source address
+// to which the detection of the IP family should be bound.
+func ReadDetectionBinding(ppfmt pp.PP, ipNet ipnet.Type, keyInterface, keyAddress string,
+	field *provider.Binding,
+) bool {
+	b := provider.Binding{Interface: Getenv(keyInterface), Address: netip.Addr{}}
+
+	if raw := Getenv(keyAddress); raw != "" {
+		addr, err := netip.ParseAddr(raw)
+		if err != nil {
+			ppfmt.Noticef(pp.EmojiUserError, "%s (%q) is not a valid IP address: %v", keyAddress, raw, err)
+			return false
+		}
+		if !ipNet.Matches(addr) {
+			ppfmt.Noticef(pp.EmojiUserError, "%s (%q) is not an %s address", keyAddress, raw, ipNet.Describe())
+			return false
+		}
+		b.Address = addr
+	}
+
+	*field = b
+	return true
+}
+
+// ReadDetectionBindingMap reads IP4_DETECTION_INTERFACE, IP4_DETECTION_ADDRESS,
+// IP6_DETECTION_INTERFACE, and IP6_DETECTION_ADDRESS into a map. IP families without
+// any restrictions are not in the map.
+func ReadDetectionBindingMap(ppfmt pp.PP, field *map[ipnet.Type]provider.Binding) bool {
+	var ip4Binding, ip6Binding provider.Binding
+
+	if !ReadDetectionBinding(ppfmt, ipnet.IP4, "IP4_DETECTION_INTERFACE", "IP4_DETECTION_ADDRESS", &ip4Binding) ||
+		!ReadDetectionBinding(ppfmt, ipnet.IP6, "IP6_DETECTION_INTERFACE", "IP6_DETECTION_ADDRESS", &ip6Binding) {
+		return false
+	}
+
+	bindings := map[ipnet.Type]provider.Binding{}
+	if !ip4Binding.IsZero() {
+		bindings[ipnet.IP4] = ip4Binding
+	}
+	if !ip6Binding.IsZero() {
+		bindings[ipnet.IP6] = ip6Binding
+	}
+
+	*field = bindings
+	return true
+}
+
+// parseBind parses the part after "bind:", which is a network interface, optionally followed by "@"
+// and a source address, then a slash and the provider to run with the binding
+// (e.g., "wan1/cloudflare.trace" or "@192.168.1.2/cloudflare.trace").
+func parseBind(ppfmt pp.PP, key, val string) (provider.Provider, bool) {
+	rawBinding, rawProvider, found := strings.Cut(val, "/")
+	iface, rawAddress, hasAddress := strings.Cut(rawBinding, "@")
+	iface, rawAddress = strings.TrimSpace(iface), strings.TrimSpace(rawAddress)
+	if !found || strings.TrimSpace(rawProvider) == "" || (iface == "" && rawAddress == "") {
+		ppfmt.Noticef(pp.EmojiUserError,
+			`%s=bind: must be followed by a network interface and/or a source address, a slash, and a provider, `+
+				`such as "bind:wan1/cloudflare.trace" or "bind:@192.168.1.2/cloudflare.trace"`,
+			key)
+		return nil, false
+	}
+
+	b := provider.Binding{Interface: iface, Address: netip.Addr{}}
+	if hasAddress {
+		addr, err := netip.ParseAddr(rawAddress)
+		if err != nil {
+			ppfmt.Noticef(pp.EmojiUserError, "%s=bind: has an invalid source address %q: %v", key, rawAddress, err)
+			return nil, false
+		}
+		b.Address = addr
+	}
+
+	p, ok := parseProvider(ppfmt, key, strings.TrimSpace(rawProvider))
+	if !ok {
+		return nil, false
+	}
+	if p == nil {
+		ppfmt.Noticef(pp.EmojiUserError, `%s=bind: cannot bind the provider %q`, key, provider.Name(nil))
+		return nil, false
+	}
+
+	return provider.NewBound(ppfmt, b, p)
+}
+
+// BindProviderMap makes the providers use the detection bindings of their IP families.
+// Providers with their own bindings (using "bind:") are unchanged.
+func BindProviderMap(ppfmt pp.PP, bindings map[ipnet.Type]provider.Binding,
+	field *map[ipnet.Type]provider.Provider,
+) bool {
+	providers := map[ipnet.Type]provider.Provider{}
+	for ipNet, p := range ipnet.Bindings(*field) {
+		if b, ok := bindings[ipNet]; ok && p != nil && !b.IsZero() {
+			if p, ok = provider.NewBound(ppfmt, b, p); !ok {
+				return false
+			}
+		}
+		providers[ipNet] = p
+	}
+
+	*field = providers
+	return true
+}
@@ -0,0 +1,132 @@
+//go:build linux
+
+// vim: nowrap
+package config_test
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/config"
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+)
+
+//nolint:paralleltest // environment vars are global
+func TestReadProviderBind(t *testing.T) {
+	key := keyPrefix + "PROVIDER"
+
+	for name, tc := range map[string]struct {
+		val           string
+		expected      string
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"iface":   {" bind : wan1 / cloudflare.trace ", "bind:wan1/cloudflare.trace", true, nil},
+		"address": {"bind:@192.168.1.2/url:https://1.1.1.1/cdn-cgi/trace", "bind:@192.168.1.2/url:(redacted)", true, nil},
+		"both":    {"bind:wan1@2001:db8::1/cloudflare.doh", "bind:wan1@2001:db8::1/cloudflare.doh", true, nil},
+		"quorum": {
+			"quorum:bind:wan1/cloudflare.trace,bind:wan1/cloudflare.doh,local",
+			"quorum:2:bind:wan1/cloudflare.trace,bind:wan1/cloudflare.doh,local", true, nil,
+		},
+		"no-slash": {
+			"bind:wan1", "none", false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=bind: must be followed by a network interface and/or a source address, a slash, and a provider, `+`such as "bind:wan1/cloudflare.trace" or "bind:@192.168.1.2/cloudflare.trace"`, key)
+			},
+		},
+		"no-binding": {
+			"bind:/cloudflare.trace", "none", false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=bind: must be followed by a network interface and/or a source address, a slash, and a provider, `+`such as "bind:wan1/cloudflare.trace" or "bind:@192.168.1.2/cloudflare.trace"`, key)
+			},
+		},
+		"bad-address": {
+			"bind:wan1@192.168.1/cloudflare.trace", "none", false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, "%s=bind: has an invalid source address %q: %v", key, "192.168.1", gomock.Any())
+			},
+		},
+		"none": {
+			"bind:wan1/none", "none", false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=bind: cannot bind the provider %q`, key, "none")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			store(t, key, tc.val)
+
+			var field provider.Provider
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			ok := config.ReadProvider(mockPP, key, keyPrefix+"DEPRECATED", &field)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, provider.Name(field))
+		})
+	}
+}
+
+func TestBindProviderMap(t *testing.T) {
+	t.Parallel()
+
+	field := map[ipnet.Type]provider.Provider{
+		ipnet.IP4: provider.NewCloudflareTrace(),
+		ipnet.IP6: provider.MustNewBound(provider.Binding{Interface: "wan3", Address: netip.Addr{}}, provider.NewCloudflareTrace()),
+	}
+	bindings := map[ipnet.Type]provider.Binding{
+		ipnet.IP4: {Interface: "wan1", Address: netip.Addr{}},
+		ipnet.IP6: {Interface: "wan2", Address: netip.Addr{}},
+	}
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	require.True(t, config.BindProviderMap(mockPP, bindings, &field))
+	require.Equal(t, "bind:wan1/cloudflare.trace", provider.Name(field[ipnet.IP4]))
+	require.Equal(t, "bind:wan3/cloudflare.trace", provider.Name(field[ipnet.IP6]))
+}
+
+func TestNormalizeDomainProvidersBinding(t *testing.T) {
+	t.Parallel()
+
+	c := &config.Config{ //nolint:exhaustruct
+		UpdateOnStart: true,
+		Provider: map[ipnet.Type]provider.Provider{
+			ipnet.IP4: provider.MustNewBound(provider.Binding{Interface: "wan1", Address: netip.Addr{}}, provider.NewCloudflareTrace()),
+		},
+		DomainProviderTemplates: map[ipnet.Type]string{ipnet.IP4: "is(wan1.b.c)=cloudflare.trace; is(wan2.b.c)=bind:wan2/cloudflare.trace; is(lan.b.c)=local"},
+		DetectionBinding:        map[ipnet.Type]provider.Binding{ipnet.IP4: {Interface: "wan1", Address: netip.Addr{}}},
+		Domains: map[ipnet.Type][]domain.Domain{
+			ipnet.IP4: {domain.FQDN("wan1.b.c"), domain.FQDN("wan2.b.c"), domain.FQDN("lan.b.c")},
+		},
+		ProxiedTemplate:  "false",
+		DetectionTimeout: 5 * time.Second,
+	}
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().IsShowing(pp.Info).Return(false)
+	require.True(t, c.Normalize(mockPP))
+
+	names := map[string]string{}
+	for _, group := range c.DomainProviders[ipnet.IP4] {
+		for _, dom := range group.Domains {
+			names[dom.Describe()] = provider.Name(group.Provider)
+		}
+	}
+	require.Equal(t, map[string]string{
+		"wan1.b.c": "bind:wan1/cloudflare.trace",
+		"wan2.b.c": "bind:wan2/cloudflare.trace",
+		"lan.b.c":  "bind:wan1/local",
+	}, names)
+}
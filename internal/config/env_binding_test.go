@@ -0,0 +1,73 @@
+// vim: nowrap
+package config_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/config"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+)
+
+//nolint:paralleltest // environment vars are global
+func TestReadDetectionBindingMap(t *testing.T) {
+	for name, tc := range map[string]struct {
+		ip4Interface  string
+		ip4Address    string
+		ip6Interface  string
+		ip6Address    string
+		expected      map[ipnet.Type]provider.Binding
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"empty": {"", "", "", "", map[ipnet.Type]provider.Binding{}, true, nil},
+		"full": {
+			"wan1", " 192.0.2.1 ", "wan2", "2001:db8::1",
+			map[ipnet.Type]provider.Binding{
+				ipnet.IP4: {Interface: "wan1", Address: netip.MustParseAddr("192.0.2.1")},
+				ipnet.IP6: {Interface: "wan2", Address: netip.MustParseAddr("2001:db8::1")},
+			},
+			true, nil,
+		},
+		"interface-only": {
+			"", "", "ppp0", "",
+			map[ipnet.Type]provider.Binding{ipnet.IP6: {Interface: "ppp0", Address: netip.Addr{}}},
+			true, nil,
+		},
+		"invalid": {
+			"", "192.0.2", "", "", nil, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, "%s (%q) is not a valid IP address: %v", "IP4_DETECTION_ADDRESS", "192.0.2", gomock.Any())
+			},
+		},
+		"mismatch": {
+			"", "", "", "192.0.2.1", nil, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, "%s (%q) is not an %s address", "IP6_DETECTION_ADDRESS", "192.0.2.1", "IPv6")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			store(t, "IP4_DETECTION_INTERFACE", tc.ip4Interface)
+			store(t, "IP4_DETECTION_ADDRESS", tc.ip4Address)
+			store(t, "IP6_DETECTION_INTERFACE", tc.ip6Interface)
+			store(t, "IP6_DETECTION_ADDRESS", tc.ip6Address)
+
+			var field map[ipnet.Type]provider.Binding
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			ok := config.ReadDetectionBindingMap(mockPP, &field)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, field)
+		})
+	}
+}
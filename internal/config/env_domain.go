@@ -1,6 +1,7 @@
 package config
 
 import (
+	"net/netip"
 	"slices"
 
 	"github.com/favonia/cloudflare-ddns/internal/domain"
@@ -18,6 +19,32 @@ func ReadDomains(ppfmt pp.PP, key string, field *[]domain.Domain) bool {
 	return false
 }
 
+// ReadDomain reads an environment variable as a single domain that is not a wildcard domain.
+// The field is set to nil if the variable is empty.
+func ReadDomain(ppfmt pp.PP, key string, field *domain.Domain) bool {
+	input := Getenv(key)
+	list, ok := domainexp.ParseList(ppfmt, key, input)
+	if !ok {
+		return false
+	}
+
+	switch len(list) {
+	case 0:
+		*field = nil
+		return true
+	case 1:
+		if _, ok := list[0].(domain.Wildcard); ok {
+			ppfmt.Noticef(pp.EmojiUserError, "%s (%q) should not be a wildcard domain", key, input)
+			return false
+		}
+		*field = list[0]
+		return true
+	default:
+		ppfmt.Noticef(pp.EmojiUserError, "%s (%q) should contain only one domain", key, input)
+		return false
+	}
+}
+
 // deduplicate always sorts and deduplicates the input list,
 // returning true if elements are already distinct.
 func deduplicate(list []domain.Domain) []domain.Domain {
@@ -25,14 +52,65 @@ func deduplicate(list []domain.Domain) []domain.Domain {
 	return slices.Compact(list)
 }
 
+// parseIP6Suffix parses an IPv6 suffix such as "::1:2:3:4/64", where the prefix length is the number
+// of leading bits to keep from the detected IPv6 address.
+func parseIP6Suffix(ppfmt pp.PP, key string, input string, d domain.Domain, raw string) (netip.Prefix, bool) {
+	suffix, err := netip.ParsePrefix(raw)
+	if err != nil || !suffix.Addr().Is6() || suffix.Bits() == 0 || suffix.Bits() == 128 ||
+		suffix.Masked().Addr() != netip.IPv6Unspecified() {
+		ppfmt.Noticef(pp.EmojiUserError,
+			`%s (%q) contains an invalid IPv6 suffix %q for the domain %q; `+
+				`it should look like "::1:2:3:4/64", where 64 is the number of leading bits to keep from the detected address`,
+			key, input, raw, d.Describe())
+		return netip.Prefix{}, false
+	}
+	return suffix, true
+}
+
+// readDomainsWithIP6Suffixes reads an environment variable as a comma-separated list of domains,
+// each of which can be followed by "=" and an IPv6 suffix.
+func readDomainsWithIP6Suffixes(ppfmt pp.PP, key string,
+	field *[]domain.Domain, suffixes map[domain.Domain]netip.Prefix,
+) bool {
+	input := Getenv(key)
+	list, rawSuffixes, ok := domainexp.ParseListWithSuffixes(ppfmt, key, input)
+	if !ok {
+		return false
+	}
+
+	for _, d := range list {
+		raw, found := rawSuffixes[d]
+		if !found {
+			continue
+		}
+		suffix, ok := parseIP6Suffix(ppfmt, key, input, d, raw)
+		if !ok {
+			return false
+		}
+		if old, found := suffixes[d]; found && old != suffix {
+			ppfmt.Noticef(pp.EmojiUserError,
+				"The domain %q is assigned different IPv6 suffixes in DOMAINS and IP6_DOMAINS", d.Describe())
+			return false
+		}
+		suffixes[d] = suffix
+	}
+
+	*field = list
+	return true
+}
+
 // ReadDomainMap reads environment variables DOMAINS, IP4_DOMAINS, and IP6_DOMAINS
-// and consolidate the domains into a map.
-func ReadDomainMap(ppfmt pp.PP, field *map[ipnet.Type][]domain.Domain) bool {
+// and consolidate the domains into a map. Domains in DOMAINS and IP6_DOMAINS can be
+// followed by IPv6 suffixes (such as "nas.example.org=::1:2:3:4/64"), which are collected into suffixMap.
+func ReadDomainMap(ppfmt pp.PP, field *map[ipnet.Type][]domain.Domain,
+	suffixMap *map[domain.Domain]netip.Prefix,
+) bool {
 	var domains, ip4Domains, ip6Domains []domain.Domain
+	suffixes := map[domain.Domain]netip.Prefix{}
 
-	if !ReadDomains(ppfmt, "DOMAINS", &domains) ||
+	if !readDomainsWithIP6Suffixes(ppfmt, "DOMAINS", &domains, suffixes) ||
 		!ReadDomains(ppfmt, "IP4_DOMAINS", &ip4Domains) ||
-		!ReadDomains(ppfmt, "IP6_DOMAINS", &ip6Domains) {
+		!readDomainsWithIP6Suffixes(ppfmt, "IP6_DOMAINS", &ip6Domains, suffixes) {
 		return false
 	}
 
@@ -43,6 +121,7 @@ func ReadDomainMap(ppfmt pp.PP, field *map[ipnet.Type][]domain.Domain) bool {
 		ipnet.IP4: ip4Domains,
 		ipnet.IP6: ip6Domains,
 	}
+	*suffixMap = suffixes
 
 	return true
 }
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/favonia/cloudflare-ddns/internal/domain"
+	"github.com/favonia/cloudflare-ddns/internal/domainexp"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+)
+
+// domainProviderKey gives the name of the setting assigning providers to domains, such as IP4_DOMAIN_PROVIDERS.
+func domainProviderKey(ipNet ipnet.Type) string {
+	return fmt.Sprintf("IP%d_DOMAIN_PROVIDERS", ipNet.Int())
+}
+
+// ReadDomainProviderTemplateMap reads the environment variables IP4_DOMAIN_PROVIDERS and IP6_DOMAIN_PROVIDERS
+// without parsing them. The rules are parsed by [Config.Normalize] once the domains are known.
+func ReadDomainProviderTemplateMap(_ppfmt pp.PP, field *map[ipnet.Type]string) bool {
+	templates := map[ipnet.Type]string{}
+	for ipNet := range ipnet.All {
+		if val := Getenv(domainProviderKey(ipNet)); val != "" {
+			templates[ipNet] = val
+		}
+	}
+
+	*field = templates
+	return true
+}
+
+// splitDomainProviderRules splits the rules separated by semicolons or newlines, skipping empty ones.
+func splitDomainProviderRules(input string) []string {
+	return strings.FieldsFunc(input, func(r rune) bool { return r == ';' || r == '\n' })
+}
+
+// parseDomainProviders parses rules in the form "<domain expression>=<provider>", separated by
+// semicolons or newlines, and assigns the provider of the first matching rule to each domain.
+// Rules with the same provider specification share one provider, and each provider is identified by
+// its position in the result, not by its name. (The updater still runs providers with the same normalized
+// name only once in each round.) Domains assigned no providers are left out of the result.
+// The providers use the detection binding unless they have their own bindings.
+func parseDomainProviders(ppfmt pp.PP, key string, input string,
+	domains []domain.Domain, binding provider.Binding,
+) ([]DomainProvider, bool) {
+	var providers []provider.Provider
+	indexes := map[string]int{} // the indexes of providers by their specifications
+	assigned := map[domain.Domain]int{}
+
+	for _, rule := range splitDomainProviderRules(input) {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+
+		rawExpression, rawProvider, found := strings.Cut(rule, "=")
+		rawProvider = strings.TrimSpace(rawProvider)
+		if !found || rawProvider == "" {
+			ppfmt.Noticef(pp.EmojiUserError,
+				"%s (%q) contains %q, which is not in the form <domain expression>=<provider>",
+				key, input, strings.TrimSpace(rule))
+			return nil, false
+		}
+
+		predicate, ok := domainexp.ParseExpression(ppfmt, key, rawExpression)
+		if !ok {
+			return nil, false
+		}
+
+		i, found := indexes[rawProvider]
+		if !found {
+			p, ok := parseProvider(ppfmt, key, rawProvider)
+			if !ok {
+				return nil, false
+			}
+			if p == nil {
+				ppfmt.Noticef(pp.EmojiUserError,
+					"%s (%q) cannot use the provider %q; remove the domains from the domain lists instead",
+					key, input, provider.Name(nil))
+				return nil, false
+			}
+			if !binding.IsZero() {
+				if p, ok = provider.NewBound(ppfmt, binding, p); !ok {
+					return nil, false
+				}
+			}
+
+			i = len(providers)
+			indexes[rawProvider] = i
+			providers = append(providers, p)
+		}
+
+		matched := false
+		for _, d := range domains {
+			if _, done := assigned[d]; done || !predicate(d) {
+				continue
+			}
+			matched = true
+			assigned[d] = i
+		}
+		if !matched {
+			ppfmt.Noticef(pp.EmojiUserWarning,
+				"%s (%q) contains %q, which does not apply to any remaining domain",
+				key, input, strings.TrimSpace(rule))
+		}
+	}
+
+	groups := make([]DomainProvider, len(providers))
+	for i, p := range providers {
+		groups[i].Provider = p
+	}
+	for _, d := range domains {
+		if i, found := assigned[d]; found {
+			groups[i].Domains = append(groups[i].Domains, d)
+		}
+	}
+
+	return slices.DeleteFunc(groups, func(g DomainProvider) bool { return len(g.Domains) == 0 }), true
+}
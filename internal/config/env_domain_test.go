@@ -2,6 +2,7 @@
 package config_test
 
 import (
+	"net/netip"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -96,6 +97,65 @@ func TestReadDomains(t *testing.T) {
 	}
 }
 
+//nolint:paralleltest // environment vars are global
+func TestReadDomain(t *testing.T) {
+	key := keyPrefix + "DOMAIN"
+	type f = domain.FQDN
+	for name, tc := range map[string]struct {
+		set           bool
+		val           string
+		oldField      domain.Domain
+		newField      domain.Domain
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"nil":   {false, "", f("test.org"), nil, true, nil},
+		"empty": {true, "", f("test.org"), nil, true, nil},
+		"fqdn":  {true, " _ddns.書.org ", nil, f("_ddns.xn--rov.org"), true, nil},
+		"wildcard": {
+			true, "*.a.org",
+			f("test.org"),
+			f("test.org"),
+			false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, "%s (%q) should not be a wildcard domain", key, "*.a.org")
+			},
+		},
+		"multiple": {
+			true, "a.org,b.org",
+			f("test.org"),
+			f("test.org"),
+			false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, "%s (%q) should contain only one domain", key, "a.org,b.org")
+			},
+		},
+		"illformed": {
+			true, ")",
+			f("test.org"),
+			f("test.org"),
+			false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, "%s (%q) has unexpected token %q", key, ")", ")")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			set(t, key, tc.set, tc.val)
+			field := tc.oldField
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			ok := config.ReadDomain(mockPP, key, &field)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.newField, field)
+		})
+	}
+}
+
 //nolint:paralleltest // environment vars are global
 func TestReadDomainMap(t *testing.T) {
 	for name, tc := range map[string]struct {
@@ -103,6 +163,7 @@ func TestReadDomainMap(t *testing.T) {
 		ip4Domains    string
 		ip6Domains    string
 		expected      map[ipnet.Type][]domain.Domain
+		suffixes      map[domain.Domain]netip.Prefix
 		ok            bool
 		prepareMockPP func(*mocks.MockPP)
 	}{
@@ -112,6 +173,7 @@ func TestReadDomainMap(t *testing.T) {
 				ipnet.IP4: {domain.FQDN("a1.com"), domain.FQDN("a2.com"), domain.FQDN("b1.com"), domain.FQDN("b2.com")},
 				ipnet.IP6: {domain.FQDN("a1.com"), domain.FQDN("a2.com"), domain.FQDN("c1.com"), domain.FQDN("c2.com")},
 			},
+			map[domain.Domain]netip.Prefix{},
 			true,
 			nil,
 		},
@@ -121,6 +183,7 @@ func TestReadDomainMap(t *testing.T) {
 				ipnet.IP4: {domain.FQDN("a1.com")},
 				ipnet.IP6: {domain.FQDN("a1.com"), domain.Wildcard("a1.com")},
 			},
+			map[domain.Domain]netip.Prefix{},
 			true,
 			nil,
 		},
@@ -130,15 +193,47 @@ func TestReadDomainMap(t *testing.T) {
 				ipnet.IP4: {},
 				ipnet.IP6: {},
 			},
+			map[domain.Domain]netip.Prefix{},
 			true,
 			nil,
 		},
 		"ill-formed": {
-			" ", "   ", "*.*", nil, false,
+			" ", "   ", "*.*", nil, nil, false,
 			func(m *mocks.MockPP) {
 				m.EXPECT().Noticef(pp.EmojiUserError, "%s (%q) contains an ill-formed domain %q: %v", "IP6_DOMAINS", "*.*", "*.*", gomock.Any())
 			},
 		},
+		"suffixes": {
+			"a1.com=::1:2:3:4/64", "b1.com", "c1.com=::5:0:0:0:1/56,a1.com=::1:2:3:4/64",
+			map[ipnet.Type][]domain.Domain{
+				ipnet.IP4: {domain.FQDN("a1.com"), domain.FQDN("b1.com")},
+				ipnet.IP6: {domain.FQDN("a1.com"), domain.FQDN("c1.com")},
+			},
+			map[domain.Domain]netip.Prefix{
+				domain.FQDN("a1.com"): netip.MustParsePrefix("::1:2:3:4/64"),
+				domain.FQDN("c1.com"): netip.MustParsePrefix("::5:0:0:0:1/56"),
+			},
+			true,
+			nil,
+		},
+		"suffixes/conflict": {
+			"a1.com=::1/64", "", "a1.com=::2/64", nil, nil, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, "The domain %q is assigned different IPv6 suffixes in DOMAINS and IP6_DOMAINS", "a1.com")
+			},
+		},
+		"suffixes/invalid": {
+			"", "", "a1.com=2001:db8::1/64", nil, nil, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s (%q) contains an invalid IPv6 suffix %q for the domain %q; it should look like "::1:2:3:4/64", where 64 is the number of leading bits to keep from the detected address`, "IP6_DOMAINS", "a1.com=2001:db8::1/64", "2001:db8::1/64", "a1.com")
+			},
+		},
+		"suffixes/ip4": {
+			"", "a1.com=::1/64", "", nil, nil, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, "%s (%q) contains an ill-formed domain %q: %v", "IP4_DOMAINS", "a1.com=::1/64", gomock.Any(), gomock.Any())
+			},
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			mockCtrl := gomock.NewController(t)
@@ -148,14 +243,16 @@ func TestReadDomainMap(t *testing.T) {
 			store(t, "IP6_DOMAINS", tc.ip6Domains)
 
 			var field map[ipnet.Type][]domain.Domain
+			var suffixes map[domain.Domain]netip.Prefix
 			mockPP := mocks.NewMockPP(mockCtrl)
 			if tc.prepareMockPP != nil {
 				tc.prepareMockPP(mockPP)
 			}
-			ok := config.ReadDomainMap(mockPP, &field)
+			ok := config.ReadDomainMap(mockPP, &field, &suffixes)
 			require.Equal(t, tc.ok, ok)
 			require.ElementsMatch(t, tc.expected[ipnet.IP4], field[ipnet.IP4])
 			require.ElementsMatch(t, tc.expected[ipnet.IP6], field[ipnet.IP6])
+			require.Equal(t, tc.suffixes, suffixes)
 		})
 	}
 }
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/favonia/cloudflare-ddns/internal/ipnet"
@@ -83,6 +84,16 @@ func ReadProvider(ppfmt pp.PP, key, keyDeprecated string, field *provider.Provid
 		return false
 	}
 
+	p, ok := parseProvider(ppfmt, key, val)
+	if ok {
+		*field = p
+	}
+	return ok
+}
+
+// parseProvider parses the value of an environment variable as a provider,
+// such as "cloudflare.trace" or "url:https://example.org".
+func parseProvider(ppfmt pp.PP, key, val string) (provider.Provider, bool) {
 	parts := strings.SplitN(val, ":", 2) // len(parts) >= 1 because val is not empty
 	for i := range parts {
 		parts[i] = strings.TrimSpace(parts[i])
@@ -95,10 +106,9 @@ func ReadProvider(ppfmt pp.PP, key, keyDeprecated string, field *provider.Provid
 			`%s=cloudflare is invalid; use %s=cloudflare.trace or %s=cloudflare.doh`,
 			key, key, key,
 		)
-		return false
+		return nil, false
 	case len(parts) == 1 && parts[0] == "cloudflare.trace":
-		*field = provider.NewCloudflareTrace()
-		return true
+		return provider.NewCloudflareTrace(), true
 	case len(parts) == 2 && parts[0] == "cloudflare.trace":
 		ppfmt.InfoOncef(pp.MessageUndocumentedCustomCloudflareTraceProvider, pp.EmojiHint,
 			`You are using the undocumented "cloudflare.trace" provider with custom URL`)
@@ -108,46 +118,136 @@ func ReadProvider(ppfmt pp.PP, key, keyDeprecated string, field *provider.Provid
 				`%s=cloudflare.trace: must be followed by a URL`,
 				key,
 			)
-			return false
+			return nil, false
 		}
-		*field = provider.NewCloudflareTraceCustom(parts[1])
-		return true
+		return provider.NewCloudflareTraceCustom(parts[1]), true
 	case len(parts) == 1 && parts[0] == "cloudflare.doh":
-		*field = provider.NewCloudflareDOH()
-		return true
+		return provider.NewCloudflareDOH(), true
+	case len(parts) == 2 && parts[0] == "doh":
+		if parts[1] == "" {
+			ppfmt.Noticef(
+				pp.EmojiUserError,
+				`%s=doh: must be followed by a domain name and a URL`,
+				key,
+			)
+			return nil, false
+		}
+		return provider.NewCustomDOH(ppfmt, parts[1])
+	case len(parts) == 2 && parts[0] == "dns":
+		if parts[1] == "" {
+			ppfmt.Noticef(
+				pp.EmojiUserError,
+				`%s=dns: must be followed by a domain name and a DNS server`,
+				key,
+			)
+			return nil, false
+		}
+		return provider.NewCustomDNS(ppfmt, parts[1])
+	case len(parts) == 2 && parts[0] == "dot":
+		if parts[1] == "" {
+			ppfmt.Noticef(
+				pp.EmojiUserError,
+				`%s=dot: must be followed by a domain name and a DNS server`,
+				key,
+			)
+			return nil, false
+		}
+		return provider.NewCustomDOT(ppfmt, parts[1])
 	case len(parts) == 1 && parts[0] == "ipify":
 		ppfmt.Noticef(
 			pp.EmojiUserWarning,
 			`%s=ipify is deprecated; use %s=cloudflare.trace or %s=cloudflare.doh`,
 			key, key, key,
 		)
-		*field = provider.NewIpify()
-		return true
+		return provider.NewIpify(), true
 	case len(parts) == 1 && parts[0] == "local":
-		*field = provider.NewLocal()
-		return true
+		return provider.NewLocal(), true
+	case len(parts) == 1 && parts[0] == "gateway":
+		return provider.NewGateway(), true
 	case len(parts) == 2 && parts[0] == "local.iface":
-		if parts[1] == "" {
+		if iface, _, _ := strings.Cut(parts[1], "@"); strings.TrimSpace(iface) == "" {
 			ppfmt.Noticef(
 				pp.EmojiUserError,
 				`%s=local.iface: must be followed by a network interface name`,
 				key,
 			)
-			return false
+			return nil, false
 		}
 		ppfmt.InfoOncef(pp.MessageExperimentalLocalWithInterface, pp.EmojiHint,
 			`You are using the experimental "local.iface" provider added in version 1.15.0`)
-		*field = provider.NewLocalWithInterface(parts[1])
-		return true
-	case len(parts) == 2 && parts[0] == "url":
-		p, ok := provider.NewCustomURL(ppfmt, parts[1])
-		if ok {
-			*field = p
+		return provider.NewCustomLocalWithInterface(ppfmt, parts[1])
+	case len(parts) == 2 && parts[0] == "local.iface.all":
+		if iface, _, _ := strings.Cut(parts[1], "@"); strings.TrimSpace(iface) == "" {
+			ppfmt.Noticef(
+				pp.EmojiUserError,
+				`%s=local.iface.all: must be followed by a network interface name`,
+				key,
+			)
+			return nil, false
+		}
+		ppfmt.InfoOncef(pp.MessageExperimentalLocalWithInterface, pp.EmojiHint,
+			`You are using the experimental "local.iface.all" provider`)
+		return provider.NewCustomLocalWithInterfaceAll(ppfmt, parts[1])
+	case len(parts) == 2 && parts[0] == "quorum":
+		return parseQuorum(ppfmt, key, parts[1])
+	case len(parts) == 2 && parts[0] == "fallback":
+		return parseFallback(ppfmt, key, parts[1])
+	case len(parts) == 2 && parts[0] == "bind":
+		return parseBind(ppfmt, key, parts[1])
+	case len(parts) == 2 && parts[0] == "stun":
+		if parts[1] == "" {
+			ppfmt.Noticef(
+				pp.EmojiUserError,
+				`%s=stun: must be followed by a host and a port`,
+				key,
+			)
+			return nil, false
+		}
+		return provider.NewSTUN(ppfmt, parts[1])
+	case len(parts) == 2 && parts[0] == "file":
+		if parts[1] == "" {
+			ppfmt.Noticef(
+				pp.EmojiUserError,
+				`%s=file: must be followed by the absolute path of a file`,
+				key,
+			)
+			return nil, false
+		}
+		return provider.NewCustomFile(ppfmt, parts[1])
+	case len(parts) == 2 && parts[0] == "exec":
+		if parts[1] == "" {
+			ppfmt.Noticef(
+				pp.EmojiUserError,
+				`%s=exec: must be followed by the absolute path of a command`,
+				key,
+			)
+			return nil, false
 		}
-		return ok
+		return provider.NewExec(ppfmt, parts[1])
+	case len(parts) == 2 && parts[0] == "url.json":
+		if parts[1] == "" {
+			ppfmt.Noticef(
+				pp.EmojiUserError,
+				`%s=url.json: must be followed by a selector and a URL`,
+				key,
+			)
+			return nil, false
+		}
+		return provider.NewCustomURLJSON(ppfmt, parts[1])
+	case len(parts) == 2 && parts[0] == "url.regexp":
+		if parts[1] == "" {
+			ppfmt.Noticef(
+				pp.EmojiUserError,
+				`%s=url.regexp: must be followed by a name and a URL`,
+				key,
+			)
+			return nil, false
+		}
+		return parseRegexpProvider(ppfmt, key, parts[1])
+	case len(parts) == 2 && parts[0] == "url":
+		return provider.NewCustomURL(ppfmt, parts[1])
 	case len(parts) == 1 && parts[0] == "none":
-		*field = nil
-		return true
+		return nil, true
 	case len(parts) == 2 && parts[0] == "debug.const":
 		ppfmt.InfoOncef(pp.MessageUndocumentedDebugConstProvider, pp.EmojiHint,
 			`You are using the undocumented "debug.const" provider`)
@@ -157,19 +257,134 @@ func ReadProvider(ppfmt pp.PP, key, keyDeprecated string, field *provider.Provid
 				`%s=debug.const: must be followed by an IP address`,
 				key,
 			)
-			return false
-		}
-		p, ok := provider.NewDebugConst(ppfmt, parts[1])
-		if ok {
-			*field = p
+			return nil, false
 		}
-		return ok
+		return provider.NewDebugConst(ppfmt, parts[1])
 	default:
 		ppfmt.Noticef(pp.EmojiUserError, "%s (%q) is not a valid provider", key, val)
+		return nil, false
+	}
+}
+
+// isCompositeProvider checks whether the value names a provider made of other providers.
+func isCompositeProvider(val string) bool {
+	name, _, _ := strings.Cut(val, ":")
+	switch strings.TrimSpace(name) {
+	case "quorum", "fallback":
+		return true
+	default:
+		return false
+	}
+}
+
+// isProviderName checks whether the value is the name of a provider (the part before the first colon).
+func isProviderName(name string) bool {
+	switch name {
+	case "bind", "cloudflare", "cloudflare.doh", "cloudflare.trace", "debug.const",
+		"dns", "doh", "dot", "exec", "fallback", "file", "gateway", "ipify",
+		"local", "local.iface", "local.iface.all", "none", "quorum", "stun",
+		"url", "url.json", "url.regexp":
+		return true
+	default:
 		return false
 	}
 }
 
+// splitProviderList splits a comma-separated list of providers. A comma after a provider with
+// an argument only separates two providers when it is followed by a provider name (or nothing),
+// so that commas within the argument (e.g., the query "?a=1,2" in "url:https://host/path?a=1,2") are kept.
+func splitProviderList(val string) []string {
+	var rawProviders []string
+	start := 0
+	for i := range len(val) {
+		if val[i] != ',' {
+			continue
+		}
+		name, _, _ := strings.Cut(val[i+1:], ":")
+		name, _, _ = strings.Cut(name, ",")
+		name = strings.TrimSpace(name)
+		if !strings.Contains(val[start:i], ":") || name == "" || isProviderName(name) {
+			rawProviders = append(rawProviders, val[start:i])
+			start = i + 1
+		}
+	}
+	return append(rawProviders, val[start:])
+}
+
+// parseProviderList parses a comma-separated list of providers for the provider compositeName.
+// Composite providers cannot be nested, and "none" is not allowed.
+// See [splitProviderList] for how commas within a provider are handled.
+func parseProviderList(ppfmt pp.PP, key, compositeName, val string) ([]provider.Provider, bool) {
+	rawProviders := splitProviderList(val)
+	providers := make([]provider.Provider, 0, len(rawProviders))
+	for _, raw := range rawProviders {
+		raw = strings.TrimSpace(raw)
+		switch {
+		case raw == "":
+			ppfmt.Noticef(pp.EmojiUserError, `%s=%s: contains an empty provider`, key, compositeName)
+			return nil, false
+		case isCompositeProvider(raw):
+			ppfmt.Noticef(pp.EmojiUserError, `%s=%s: cannot contain another composite provider (%q)`,
+				key, compositeName, raw)
+			return nil, false
+		}
+
+		p, ok := parseProvider(ppfmt, key, raw)
+		if !ok {
+			return nil, false
+		}
+		if p == nil {
+			ppfmt.Noticef(pp.EmojiUserError, `%s=%s: cannot contain the provider %q`,
+				key, compositeName, provider.Name(nil))
+			return nil, false
+		}
+		providers = append(providers, p)
+	}
+	return providers, true
+}
+
+// parseQuorum parses the part after "quorum:", which is a comma-separated list of providers
+// optionally preceded by the quorum and a colon (e.g., "2:cloudflare.trace,cloudflare.doh,local").
+func parseQuorum(ppfmt pp.PP, key, val string) (provider.Provider, bool) {
+	threshold := 0 // strict majority
+	if rawThreshold, rest, found := strings.Cut(val, ":"); found {
+		if t, err := strconv.Atoi(strings.TrimSpace(rawThreshold)); err == nil {
+			if t <= 0 {
+				ppfmt.Noticef(pp.EmojiUserError, `%s=quorum: has a non-positive quorum (%d)`, key, t)
+				return nil, false
+			}
+			threshold, val = t, rest
+		}
+	}
+
+	if strings.TrimSpace(val) == "" {
+		ppfmt.Noticef(pp.EmojiUserError, `%s=quorum: must be followed by a comma-separated list of providers`, key)
+		return nil, false
+	}
+
+	providers, ok := parseProviderList(ppfmt, key, "quorum", val)
+	if !ok {
+		return nil, false
+	}
+
+	return provider.NewQuorum(ppfmt, threshold, providers)
+}
+
+// parseFallback parses the part after "fallback:", which is a comma-separated list of providers.
+func parseFallback(ppfmt pp.PP, key, val string) (provider.Provider, bool) {
+	if strings.TrimSpace(val) == "" {
+		ppfmt.Noticef(pp.EmojiUserError, `%s=fallback: must be followed by a comma-separated list of providers`, key)
+		return nil, false
+	}
+
+	providers, ok := parseProviderList(ppfmt, key, "fallback", val)
+	if !ok {
+		return nil, false
+	}
+
+	return provider.NewFallback(ppfmt, providers)
+}
+
 // ReadProviderMap reads the environment variables IP4_PROVIDER and IP6_PROVIDER,
 // with support of deprecated environment variables IP4_POLICY and IP6_POLICY.
 func ReadProviderMap(ppfmt pp.PP, field *map[ipnet.Type]provider.Provider) bool {
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/favonia/cloudflare-ddns/internal/file"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+)
+
+// parseHeaders parses lines in the form "<name>: <value>" into HTTP headers.
+// Empty lines are ignored. The values are never printed because they may contain secrets.
+func parseHeaders(ppfmt pp.PP, key, text string, headers map[string]string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			ppfmt.Noticef(pp.EmojiUserError, `%s has a line not in the form "<name>: <value>"`, key)
+			return false
+		}
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+
+		if !httpguts.ValidHeaderFieldName(name) {
+			ppfmt.Noticef(pp.EmojiUserError, `%s has an invalid HTTP header name %q`, key, name)
+			return false
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			ppfmt.Noticef(pp.EmojiUserError, `%s has an invalid value for the HTTP header %q`, key, name)
+			return false
+		}
+
+		headers[name] = value
+	}
+	return true
+}
+
+// readHeaders reads the HTTP headers in the environment variable key and the file specified by key_FILE.
+// The headers in the file take precedence.
+func readHeaders(ppfmt pp.PP, key string) (map[string]string, bool) {
+	headers := map[string]string{}
+
+	if !parseHeaders(ppfmt, key, Getenv(key), headers) {
+		return nil, false
+	}
+
+	if path := Getenv(key + "_FILE"); path != "" {
+		text, ok := file.ReadString(ppfmt, path)
+		if !ok || !parseHeaders(ppfmt, key+"_FILE", text, headers) {
+			return nil, false
+		}
+	}
+
+	if len(headers) == 0 {
+		return nil, true
+	}
+	return headers, true
+}
+
+// urlRegexpNameRegex matches valid names of the options of url.regexp providers.
+var urlRegexpNameRegex = regexp.MustCompile(`^[a-z0-9_]+$`) //nolint:gochecknoglobals
+
+// urlRegexpKey gives the name of an option of the url.regexp providers with the given name,
+// such as URL_REGEXP_MODEM_REGEXP for the name "modem" and the option "REGEXP".
+func urlRegexpKey(name, option string) string {
+	return "URL_REGEXP_" + strings.ToUpper(name) + "_" + option
+}
+
+// parseRegexpProvider parses the part after "url.regexp:", which is in the form "<name>@<URL>".
+// The regular expression and the other parts of the HTTP request are read from the environment variables
+// URL_REGEXP_<NAME>_REGEXP, URL_REGEXP_<NAME>_METHOD, URL_REGEXP_<NAME>_HEADERS,
+// URL_REGEXP_<NAME>_HEADERS_FILE, and URL_REGEXP_<NAME>_BODY, so that providers with different names
+// have their own options no matter where they are used.
+func parseRegexpProvider(ppfmt pp.PP, key, spec string) (provider.Provider, bool) {
+	name, rawURL, found := strings.Cut(spec, "@")
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !found || !urlRegexpNameRegex.MatchString(name) {
+		ppfmt.Noticef(pp.EmojiUserError,
+			`%s=url.regexp: must be followed by a name (letters, digits, and underscores) and a URL, `+
+				`such as "url.regexp:modem@https://modem.lan/status"`,
+			key)
+		return nil, false
+	}
+
+	options := provider.URLRegexpOptions{
+		Name:    name,
+		Regexp:  Getenv(urlRegexpKey(name, "REGEXP")),
+		Method:  Getenv(urlRegexpKey(name, "METHOD")),
+		Headers: nil,
+		Body:    Getenv(urlRegexpKey(name, "BODY")),
+	}
+
+	if options.Regexp == "" {
+		ppfmt.Noticef(pp.EmojiUserError, `%s=url.regexp:%s@<URL> requires %s`, key, name, urlRegexpKey(name, "REGEXP"))
+		return nil, false
+	}
+
+	headers, ok := readHeaders(ppfmt, urlRegexpKey(name, "HEADERS"))
+	if !ok {
+		return nil, false
+	}
+	options.Headers = headers
+
+	return provider.NewCustomURLRegexp(ppfmt, strings.TrimSpace(rawURL), options)
+}
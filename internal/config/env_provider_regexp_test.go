@@ -0,0 +1,162 @@
+// vim: nowrap
+package config_test
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/config"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+)
+
+//nolint:paralleltest // environment vars and file system are global
+func TestReadProviderRegexp(t *testing.T) {
+	key := keyPrefix + "PROVIDER"
+	const url = "https://modem.lan/status"
+
+	var none provider.Provider
+
+	for name, tc := range map[string]struct {
+		val           string
+		regexp        string
+		method        string
+		headers       string
+		headersFile   string
+		mapFS         map[string]string
+		body          string
+		expected      provider.Provider
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"minimal": {
+			"url.regexp:modem@" + url, `WAN: ([0-9.]+)`, "", "", "", nil, "",
+			provider.MustNewCustomURLRegexp(url, provider.URLRegexpOptions{Name: "modem", Regexp: `WAN: ([0-9.]+)`, Method: "", Headers: nil, Body: ""}),
+			true, nil,
+		},
+		"full": {
+			" url.regexp : Modem @ " + url, `"wan":"([^"]*)"`, "post", "Content-Type: application/json\n\nX-Trace: 1 ", "/run/secrets/headers",
+			map[string]string{"run/secrets/headers": "Authorization: Bearer secret\nX-Trace: 2"},
+			`{"action":"status"}`,
+			provider.MustNewCustomURLRegexp(url, provider.URLRegexpOptions{
+				Name:    "modem",
+				Regexp:  `"wan":"([^"]*)"`,
+				Method:  "POST",
+				Headers: map[string]string{"Content-Type": "application/json", "Authorization": "Bearer secret", "X-Trace": "2"},
+				Body:    `{"action":"status"}`,
+			}),
+			true, nil,
+		},
+		"other-name": {
+			"url.regexp:router@" + url, `WAN: ([0-9.]+)`, "", "", "", nil, "", none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=url.regexp:%s@<URL> requires %s`, key, "router", "URL_REGEXP_ROUTER_REGEXP")
+			},
+		},
+		"empty": {
+			"url.regexp:", `(.*)`, "", "", "", nil, "", none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=url.regexp: must be followed by a name and a URL`, key)
+			},
+		},
+		"no-name": {
+			"url.regexp:" + url, `(.*)`, "", "", "", nil, "", none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=url.regexp: must be followed by a name (letters, digits, and underscores) and a URL, such as "url.regexp:modem@https://modem.lan/status"`, key)
+			},
+		},
+		"no-regexp": {
+			"url.regexp:modem@" + url, "", "", "", "", nil, "", none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=url.regexp:%s@<URL> requires %s`, key, "modem", "URL_REGEXP_MODEM_REGEXP")
+			},
+		},
+		"no-group": {
+			"url.regexp:modem@" + url, `[0-9.]+`, "", "", "", nil, "", none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The regular expression of the provider %s must have a capturing group for the IP address`, "url.regexp:modem@(redacted)")
+			},
+		},
+		"header/no-colon": {
+			"url.regexp:modem@" + url, `(.*)`, "", "Bearer secret", "", nil, "", none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s has a line not in the form "<name>: <value>"`, "URL_REGEXP_MODEM_HEADERS")
+			},
+		},
+		"header/bad-name": {
+			"url.regexp:modem@" + url, `(.*)`, "", "X Trace: 1", "", nil, "", none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s has an invalid HTTP header name %q`, "URL_REGEXP_MODEM_HEADERS", "X Trace")
+			},
+		},
+		"header-file/bad-value": {
+			"url.regexp:modem@" + url, `(.*)`, "", "", "/headers", map[string]string{"headers": "Authorization: a\x01b"}, "", none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s has an invalid value for the HTTP header %q`, "URL_REGEXP_MODEM_HEADERS_FILE", "Authorization")
+			},
+		},
+		"header-file/missing": {
+			"url.regexp:modem@" + url, `(.*)`, "", "", "/headers", nil, "", none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, "Failed to read %q: %v", "headers", gomock.Any())
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			store(t, key, tc.val)
+			store(t, "URL_REGEXP_MODEM_REGEXP", tc.regexp)
+			store(t, "URL_REGEXP_MODEM_METHOD", tc.method)
+			store(t, "URL_REGEXP_MODEM_HEADERS", tc.headers)
+			store(t, "URL_REGEXP_MODEM_HEADERS_FILE", tc.headersFile)
+			store(t, "URL_REGEXP_MODEM_BODY", tc.body)
+			unset(t, "URL_REGEXP_ROUTER_REGEXP")
+
+			mapFS := fstest.MapFS{}
+			for path, content := range tc.mapFS {
+				mapFS[path] = &fstest.MapFile{
+					Data:    []byte(content),
+					Mode:    0o644,
+					ModTime: time.Unix(1234, 5678),
+					Sys:     nil,
+				}
+			}
+			useMemFS(mapFS)
+
+			var field provider.Provider
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			ok := config.ReadProvider(mockPP, key, keyPrefix+"DEPRECATED", &field)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, field)
+		})
+	}
+}
+
+//nolint:paralleltest // environment vars are global
+func TestReadProviderRegexpInQuorum(t *testing.T) {
+	key := keyPrefix + "PROVIDER"
+
+	store(t, key, "quorum:url.regexp:modem@https://modem.lan/status,url.regexp:router@https://router.lan/")
+	store(t, "URL_REGEXP_MODEM_REGEXP", `WAN: ([0-9.]+)`)
+	store(t, "URL_REGEXP_ROUTER_REGEXP", `"wan":"([^"]*)"`)
+	unset(t, "URL_REGEXP_MODEM_METHOD", "URL_REGEXP_MODEM_HEADERS", "URL_REGEXP_MODEM_HEADERS_FILE", "URL_REGEXP_MODEM_BODY",
+		"URL_REGEXP_ROUTER_METHOD", "URL_REGEXP_ROUTER_HEADERS", "URL_REGEXP_ROUTER_HEADERS_FILE", "URL_REGEXP_ROUTER_BODY")
+
+	var field provider.Provider
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	ok := config.ReadProvider(mockPP, key, keyPrefix+"DEPRECATED", &field)
+	require.True(t, ok)
+	require.Equal(t, provider.MustNewQuorum(0, []provider.Provider{
+		provider.MustNewCustomURLRegexp("https://modem.lan/status", provider.URLRegexpOptions{Name: "modem", Regexp: `WAN: ([0-9.]+)`, Method: "", Headers: nil, Body: ""}),
+		provider.MustNewCustomURLRegexp("https://router.lan/", provider.URLRegexpOptions{Name: "router", Regexp: `"wan":"([^"]*)"`, Method: "", Headers: nil, Body: ""}),
+	}), field)
+}
@@ -3,6 +3,7 @@ package config_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
@@ -29,6 +30,18 @@ func TestReadProvider(t *testing.T) {
 		ipify         = provider.NewIpify()
 		custom        = provider.MustNewCustomURL("https://url.io")
 		debugConst    = provider.MustNewDebugConst("1.1.1.1")
+		quorum        = provider.MustNewQuorum(0, []provider.Provider{trace, doh, custom})
+		quorum1       = provider.MustNewQuorum(1, []provider.Provider{trace, doh, custom})
+		customQuery   = provider.MustNewCustomURL("https://url.io/ip?a=1,2")
+		quorumQuery   = provider.MustNewQuorum(0, []provider.Provider{customQuery, trace, doh})
+		gateway       = provider.NewGateway()
+		customDOH     = provider.MustNewCustomDOH("myip.opendns.com@https://doh.opendns.com/dns-query")
+		stun          = provider.MustNewSTUN("stun.example.org:3478")
+		customDNS     = provider.MustNewCustomDNS("myip.opendns.com@resolver1.opendns.com")
+		customJSON    = provider.MustNewCustomURLJSON("$.wan.ipv4@https://router.lan/api")
+		exec          = provider.MustNewExec("/usr/local/bin/wan-ip")
+		customDOT     = provider.MustNewCustomDOT("whoami.cloudflare/TXT/CH@1.1.1.1#one.one.one.one")
+		fallback      = provider.MustNewFallback([]provider.Provider{localLoopback, trace})
 	)
 
 	for name, tc := range map[string]struct {
@@ -157,6 +170,159 @@ func TestReadProvider(t *testing.T) {
 				m.EXPECT().Noticef(pp.EmojiUserError, "%s (%q) is not a valid provider", key, "something-else")
 			},
 		},
+		"quorum": {
+			true, "  quorum : cloudflare.trace , cloudflare.doh,url:https://url.io ", false, "", none, quorum, true, nil,
+		},
+		"quorum/threshold": {
+			true, "quorum:1:cloudflare.trace,cloudflare.doh,url:https://url.io", false, "", none, quorum1, true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserWarning, `The quorum (%d) of the provider "quorum" is not a strict majority of the %d providers`, 1, 3)
+			},
+		},
+		"quorum/comma": {
+			true, "quorum:url:https://url.io/ip?a=1,2,cloudflare.trace, cloudflare.doh", false, "", none, quorumQuery, true, nil,
+		},
+		"quorum/zero": {
+			true, "quorum:0:cloudflare.trace,cloudflare.doh", false, "", none, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=quorum: has a non-positive quorum (%d)`, key, 0)
+			},
+		},
+		"quorum/empty": {
+			true, "quorum:", false, "", none, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=quorum: must be followed by a comma-separated list of providers`, key)
+			},
+		},
+		"quorum/empty-item": {
+			true, "quorum:cloudflare.trace,,cloudflare.doh", false, "", none, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=%s: contains an empty provider`, key, "quorum")
+			},
+		},
+		"quorum/none": {
+			true, "quorum:cloudflare.trace,none", false, "", none, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=%s: cannot contain the provider %q`, key, "quorum", "none")
+			},
+		},
+		"quorum/nested": {
+			true, "quorum:cloudflare.trace,quorum:local", false, "", none, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=%s: cannot contain another composite provider (%q)`, key, "quorum", "quorum:local")
+			},
+		},
+		"quorum/invalid": {
+			true, "quorum:cloudflare.trace,something-else", false, "", none, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, "%s (%q) is not a valid provider", key, "something-else")
+			},
+		},
+		"quorum/one": {
+			true, "quorum:cloudflare.trace", false, "", none, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider "quorum" needs at least 2 providers, but only %d was given`, 1)
+			},
+		},
+		"fallback": {
+			true, " fallback : local.iface:lo , cloudflare.trace ", false, "", none, fallback, true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().InfoOncef(pp.MessageExperimentalLocalWithInterface, pp.EmojiHint, `You are using the experimental "local.iface" provider added in version 1.15.0`)
+			},
+		},
+		"fallback/empty": {
+			true, "fallback:", false, "", none, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=fallback: must be followed by a comma-separated list of providers`, key)
+			},
+		},
+		"fallback/nested": {
+			true, "fallback:cloudflare.trace,quorum:local,cloudflare.doh", false, "", none, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=%s: cannot contain another composite provider (%q)`, key, "fallback", "quorum:local")
+			},
+		},
+		"fallback/one": {
+			true, "fallback:cloudflare.trace", false, "", none, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider "fallback" needs at least 2 providers, but only %d was given`, 1)
+			},
+		},
+		"doh": {
+			true, " doh : myip.opendns.com@https://doh.opendns.com/dns-query ", false, "", none, customDOH, true, nil,
+		},
+		"doh/empty": {
+			true, "doh:", false, "", none, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=doh: must be followed by a domain name and a URL`, key)
+			},
+		},
+		"dns": {
+			true, " dns : myip.opendns.com@resolver1.opendns.com ", false, "", none, customDNS, true,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Noticef(pp.EmojiUserWarning, `The provider %s uses unencrypted DNS, which can be spoofed by forged packets; consider using "dot:" or "doh:" instead`, "dns:myip.opendns.com@resolver1.opendns.com"),
+					m.EXPECT().InfoOncef(pp.MessagePlainDNSProvider, pp.EmojiHint, "Read more about DNS spoofing and the threat model of the updater at %s", pp.ThreatModelURL),
+				)
+			},
+		},
+		"dns/empty": {
+			true, "dns:", false, "", none, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=dns: must be followed by a domain name and a DNS server`, key)
+			},
+		},
+		"dot": {
+			true, " dot : whoami.cloudflare/TXT/CH@1.1.1.1#one.one.one.one ", false, "", none, customDOT, true, nil,
+		},
+		"dot/empty": {
+			true, "dot:", false, "", none, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=dot: must be followed by a domain name and a DNS server`, key)
+			},
+		},
+		"url.json": {
+			true, " url.json : $.wan.ipv4@https://router.lan/api ", false, "", none, customJSON, true, nil,
+		},
+		"url.json/empty": {
+			true, "url.json:", false, "", none, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=url.json: must be followed by a selector and a URL`, key)
+			},
+		},
+		"exec": {
+			true, " exec : /usr/local/bin/wan-ip ", false, "", none, exec, true, nil,
+		},
+		"exec/empty": {
+			true, "exec:", false, "", none, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=exec: must be followed by the absolute path of a command`, key)
+			},
+		},
+		"exec/relative": {
+			true, "exec:wan-ip", false, "", none, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider "exec:%s" does not contain an absolute path`, "wan-ip")
+			},
+		},
+		"gateway": {
+			true, " gateway ", false, "", none, gateway, true, nil,
+		},
+		"stun": {
+			true, " stun : stun.example.org:3478 ", false, "", none, stun, true, nil,
+		},
+		"stun/empty": {
+			true, "stun:", false, "", none, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=stun: must be followed by a host and a port`, key)
+			},
+		},
+		"stun/no-port": {
+			true, "stun:stun.example.org", false, "", none, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider "stun:%s" does not contain a valid host and port`, "stun.example.org")
+			},
+		},
 		"debug.const:1.1.1.1": {
 			true, "   debug.const   :  1.1.1.1 ", false, "", trace, debugConst, true,
 			func(m *mocks.MockPP) {
@@ -189,6 +355,155 @@ func TestReadProvider(t *testing.T) {
 	}
 }
 
+//nolint:paralleltest // environment vars are global
+func TestReadProviderFile(t *testing.T) {
+	key := keyPrefix + "PROVIDER"
+
+	var none provider.Provider
+
+	for name, tc := range map[string]struct {
+		val           string
+		expected      provider.Provider
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"default": {" file : /run/wan-ip ", provider.MustNewFile("/run/wan-ip", provider.DefaultFileMaxAge), true, nil},
+		"max-age": {"file:/run/wan-ip@1h", provider.MustNewFile("/run/wan-ip", time.Hour), true, nil},
+		"empty": {
+			"file:", none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=file: must be followed by the absolute path of a file`, key)
+			},
+		},
+		"relative": {
+			"file:wan-ip", none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider "file:%s" does not contain an absolute path`, "wan-ip")
+			},
+		},
+		"illformed-max-age": {
+			"file:/run/wan-ip@-1h", none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider "file:%s" has an invalid maximum age %q (it should be a non-negative duration such as "10m")`, "/run/wan-ip", "-1h")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			store(t, key, tc.val)
+
+			var field provider.Provider
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			ok := config.ReadProvider(mockPP, key, keyPrefix+"DEPRECATED", &field)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, field)
+		})
+	}
+}
+
+//nolint:paralleltest // environment vars are global
+func TestReadProviderLocalWithInterface(t *testing.T) {
+	key := keyPrefix + "PROVIDER"
+
+	var none provider.Provider
+
+	for name, tc := range map[string]struct {
+		val           string
+		experimental  bool
+		expected      provider.Provider
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"default": {"local.iface:eth0", true, provider.NewLocalWithInterface("eth0"), true, nil},
+		"policy": {
+			" local.iface : eth0 @ prefix=2001:db8::/32 + stable + iid=::1:2:3:4 ", true,
+			provider.MustNewCustomLocalWithInterface("eth0@prefix=2001:db8::/32+stable+iid=::1:2:3:4"), true, nil,
+		},
+		"bad-prefer": {
+			"local.iface:eth0@newest", true, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s has an unknown option %q (expecting prefix=<prefix>, stable, eui64, iid=<interface identifier>, or longest-lived)`, "local.iface:eth0", "newest")
+			},
+		},
+		"no-iface": {
+			"local.iface:@stable", false, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=local.iface: must be followed by a network interface name`, key)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			store(t, key, tc.val)
+
+			var field provider.Provider
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.experimental {
+				mockPP.EXPECT().InfoOncef(pp.MessageExperimentalLocalWithInterface, pp.EmojiHint, `You are using the experimental "local.iface" provider added in version 1.15.0`)
+			}
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			ok := config.ReadProvider(mockPP, key, keyPrefix+"DEPRECATED", &field)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, field)
+		})
+	}
+}
+
+//nolint:paralleltest // environment vars are global
+func TestReadProviderLocalWithInterfaceAll(t *testing.T) {
+	key := keyPrefix + "PROVIDER"
+
+	var none provider.Provider
+
+	for name, tc := range map[string]struct {
+		val           string
+		experimental  bool
+		expected      provider.Provider
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"default": {" local.iface.all : eth0 ", true, provider.MustNewCustomLocalWithInterfaceAll("eth0"), true, nil},
+		"prefix": {
+			"local.iface.all:eth0@prefix=2001:db8::/32", true,
+			provider.MustNewCustomLocalWithInterfaceAll("eth0@prefix=2001:db8::/32"), true, nil,
+		},
+		"prefer": {
+			"local.iface.all:eth0@stable", true, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s cannot take the preference %q because it uses all the addresses`, "local.iface.all:eth0", "stable")
+			},
+		},
+		"empty": {
+			"local.iface.all:", false, none, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s=local.iface.all: must be followed by a network interface name`, key)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			store(t, key, tc.val)
+
+			var field provider.Provider
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.experimental {
+				mockPP.EXPECT().InfoOncef(pp.MessageExperimentalLocalWithInterface, pp.EmojiHint, `You are using the experimental "local.iface.all" provider`)
+			}
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			ok := config.ReadProvider(mockPP, key, keyPrefix+"DEPRECATED", &field)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, field)
+		})
+	}
+}
+
 //nolint:paralleltest // environment vars are global
 func TestReadProviderMap(t *testing.T) {
 	var (
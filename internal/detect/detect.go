@@ -0,0 +1,125 @@
+// Package detect runs many providers side by side to diagnose the detection of IP addresses.
+// It never touches DNS records or WAF lists.
+package detect
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+)
+
+// Candidate is a provider to run for an IP network.
+type Candidate struct {
+	IPNet    ipnet.Type
+	Provider provider.Provider
+}
+
+// Candidates lists the providers to compare for each IP network: the configured provider (if any),
+// "cloudflare.trace", "cloudflare.doh", "local", and "local.iface:<iface>" for each of the network interfaces.
+// A provider is skipped if another one with the same name is already in the list.
+func Candidates(configured map[ipnet.Type]provider.Provider, ifaces []string) []Candidate {
+	var candidates []Candidate
+	for ipNet := range ipnet.All {
+		providers := []provider.Provider{}
+		if p := configured[ipNet]; p != nil {
+			providers = append(providers, p)
+		}
+		providers = append(providers, provider.NewCloudflareTrace(), provider.NewCloudflareDOH(), provider.NewLocal())
+		for _, iface := range ifaces {
+			providers = append(providers, provider.NewLocalWithInterface(iface))
+		}
+
+		seen := map[string]bool{}
+		for _, p := range providers {
+			if seen[p.Name()] {
+				continue
+			}
+			seen[p.Name()] = true
+			candidates = append(candidates, Candidate{IPNet: ipNet, Provider: p})
+		}
+	}
+	return candidates
+}
+
+// Interfaces lists the names of the network interfaces that are up, except loopback interfaces.
+func Interfaces(ppfmt pp.PP) []string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		ppfmt.Noticef(pp.EmojiError, "Failed to list network interfaces: %v", err)
+		return nil
+	}
+
+	names := make([]string, 0, len(ifaces))
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		names = append(names, iface.Name)
+	}
+	return names
+}
+
+// Result is the outcome of running a provider for an IP network.
+type Result struct {
+	IPNet    ipnet.Type
+	Provider string
+	OK       bool
+	IPs      []netip.Addr
+	Notes    []string          // the notes about the detection, such as disagreements among the sources
+	Metadata provider.Metadata // the metadata about the detection, such as the Cloudflare data center
+	Messages []string          // the errors and warnings reported by the provider
+	Latency  time.Duration     // how long the detection took
+}
+
+// run runs one provider, capturing the errors and warnings it reports.
+// The addresses not allowed by the policy are removed (see [provider.WithPolicy]).
+func run(ctx context.Context, c Candidate, policy ipnet.Policy, timeout time.Duration) Result {
+	var messages strings.Builder
+	ppfmt := pp.New(&messages, false, pp.Quiet)
+
+	ctx, cancel := context.WithTimeout(provider.WithPolicy(ctx, policy), timeout)
+	defer cancel()
+
+	start := time.Now()
+	detection, ok := provider.Detect(ctx, ppfmt, c.Provider, c.IPNet)
+	latency := time.Since(start)
+
+	return Result{
+		IPNet:    c.IPNet,
+		Provider: c.Provider.Name(),
+		OK:       ok,
+		IPs:      detection.IPs,
+		Notes:    detection.Notes,
+		Metadata: detection.Metadata,
+		Messages: strings.FieldsFunc(messages.String(), func(r rune) bool { return r == '\n' }),
+		Latency:  latency,
+	}
+}
+
+// Run runs all the candidates at the same time, each with its own timeout and the policy
+// of its IP network, and returns the results in the same order as the candidates.
+func Run(ctx context.Context, candidates []Candidate, policies map[ipnet.Type]ipnet.Policy,
+	timeout time.Duration,
+) []Result {
+	results := make([]Result, len(candidates))
+
+	var wg sync.WaitGroup
+	for i, c := range candidates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, c, policies[c.IPNet], timeout)
+		}()
+	}
+	wg.Wait()
+
+	provider.CloseIdleConnections()
+	return results
+}
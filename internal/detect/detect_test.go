@@ -0,0 +1,172 @@
+// vim: nowrap
+package detect_test
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/detect"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+)
+
+func TestCandidates(t *testing.T) {
+	t.Parallel()
+
+	type candidate struct {
+		ipNet    ipnet.Type
+		provider string
+	}
+
+	for name, tc := range map[string]struct {
+		configured map[ipnet.Type]provider.Provider
+		ifaces     []string
+		expected   []candidate
+	}{
+		"none": {
+			map[ipnet.Type]provider.Provider{ipnet.IP4: nil, ipnet.IP6: nil},
+			nil,
+			[]candidate{
+				{ipnet.IP4, "cloudflare.trace"}, {ipnet.IP4, "cloudflare.doh"}, {ipnet.IP4, "local"},
+				{ipnet.IP6, "cloudflare.trace"}, {ipnet.IP6, "cloudflare.doh"}, {ipnet.IP6, "local"},
+			},
+		},
+		"default": {
+			map[ipnet.Type]provider.Provider{ipnet.IP4: provider.NewCloudflareTrace(), ipnet.IP6: provider.NewCloudflareTrace()},
+			[]string{"eth0"},
+			[]candidate{
+				{ipnet.IP4, "cloudflare.trace"}, {ipnet.IP4, "cloudflare.doh"}, {ipnet.IP4, "local"}, {ipnet.IP4, "local.iface:eth0"},
+				{ipnet.IP6, "cloudflare.trace"}, {ipnet.IP6, "cloudflare.doh"}, {ipnet.IP6, "local"}, {ipnet.IP6, "local.iface:eth0"},
+			},
+		},
+		"custom": {
+			map[ipnet.Type]provider.Provider{ipnet.IP4: provider.MustNewCustomURL("https://api4.ipify.org"), ipnet.IP6: provider.NewLocalWithInterface("eth1")},
+			[]string{"eth0", "eth1"},
+			[]candidate{
+				{ipnet.IP4, "url:(redacted)"}, {ipnet.IP4, "cloudflare.trace"}, {ipnet.IP4, "cloudflare.doh"}, {ipnet.IP4, "local"}, {ipnet.IP4, "local.iface:eth0"}, {ipnet.IP4, "local.iface:eth1"},
+				{ipnet.IP6, "local.iface:eth1"}, {ipnet.IP6, "cloudflare.trace"}, {ipnet.IP6, "cloudflare.doh"}, {ipnet.IP6, "local"}, {ipnet.IP6, "local.iface:eth0"},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var actual []candidate
+			for _, c := range detect.Candidates(tc.configured, tc.ifaces) {
+				actual = append(actual, candidate{c.IPNet, c.Provider.Name()})
+			}
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	ip4 := netip.MustParseAddr("1.2.3.4")
+	ip6 := netip.MustParseAddr("::1")
+
+	mockCtrl := gomock.NewController(t)
+	good := mocks.NewMockProvider(mockCtrl)
+	good.EXPECT().Name().Return("good").AnyTimes()
+	good.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(ip4, true)
+	good.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP6).Return(ip6, true)
+	bad := mocks.NewMockProvider(mockCtrl)
+	bad.EXPECT().Name().Return("bad").AnyTimes()
+	bad.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).DoAndReturn(
+		func(ctx context.Context, ppfmt pp.PP, _ ipnet.Type) (netip.Addr, bool) {
+			<-ctx.Done()
+			ppfmt.Noticef(pp.EmojiError, "Failed to detect: %v", ctx.Err())
+			ppfmt.Infof(pp.EmojiBullet, "This is not captured")
+			return netip.Addr{}, false
+		})
+	private := mocks.NewMockProvider(mockCtrl)
+	private.EXPECT().Name().Return("private").AnyTimes()
+	private.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(netip.MustParseAddr("10.0.0.1"), true)
+
+	results := detect.Run(context.Background(), []detect.Candidate{
+		{IPNet: ipnet.IP4, Provider: good},
+		{IPNet: ipnet.IP4, Provider: bad},
+		{IPNet: ipnet.IP4, Provider: private},
+		{IPNet: ipnet.IP6, Provider: good},
+	}, map[ipnet.Type]ipnet.Policy{ipnet.IP4: {Public: true, Prefixes: nil}}, time.Millisecond)
+
+	require.Len(t, results, 4)
+	for i := range results {
+		require.GreaterOrEqual(t, results[i].Latency, time.Duration(0))
+		results[i].Latency = 0
+	}
+	require.Equal(t, []detect.Result{
+		{IPNet: ipnet.IP4, Provider: "good", OK: true, IPs: []netip.Addr{ip4}, Notes: nil, Metadata: nil, Messages: []string{}, Latency: 0},
+		{IPNet: ipnet.IP4, Provider: "bad", OK: false, IPs: nil, Notes: nil, Metadata: nil, Messages: []string{"Failed to detect: context deadline exceeded"}, Latency: 0},
+		{IPNet: ipnet.IP4, Provider: "private", OK: false, IPs: nil, Notes: nil, Metadata: nil, Messages: []string{"Detected IPv4 address 10.0.0.1 is not allowed by IP4_ALLOWED=public"}, Latency: 0},
+		{IPNet: ipnet.IP6, Provider: "good", OK: true, IPs: []netip.Addr{ip6}, Notes: nil, Metadata: nil, Messages: []string{}, Latency: 0},
+	}, results)
+}
+
+//nolint:gochecknoglobals
+var testResults = []detect.Result{
+	{IPNet: ipnet.IP4, Provider: "cloudflare.trace", OK: true, IPs: []netip.Addr{netip.MustParseAddr("1.2.3.4")}, Notes: nil, Metadata: provider.Metadata{"colo": "SJC", "loc": "US", "warp": "off", "tls": "TLSv1.3"}, Messages: nil, Latency: 123456789},
+	{IPNet: ipnet.IP4, Provider: "local", OK: true, IPs: []netip.Addr{netip.MustParseAddr("10.0.0.1")}, Notes: nil, Metadata: nil, Messages: nil, Latency: 1000000},
+	{IPNet: ipnet.IP4, Provider: "cloudflare.doh", OK: true, IPs: []netip.Addr{netip.MustParseAddr("1.2.3.4")}, Notes: []string{"note"}, Metadata: nil, Messages: []string{"warning"}, Latency: 2000000},
+	{IPNet: ipnet.IP6, Provider: "local.iface:eth0", OK: true, IPs: []netip.Addr{netip.MustParseAddr("::1"), netip.MustParseAddr("::2")}, Notes: nil, Metadata: nil, Messages: nil, Latency: 0},
+}
+
+func TestPrintTable(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		results  []detect.Result
+		expected string
+	}{
+		"empty": {
+			nil,
+			`FAMILY  PROVIDER  RESULT  LATENCY  MESSAGES
+
+IPv4: no provider succeeded
+IPv6: no provider succeeded
+`,
+		},
+		"mixed": {
+			append(testResults, detect.Result{IPNet: ipnet.IP6, Provider: "cloudflare.trace", OK: false, IPs: nil, Notes: nil, Metadata: nil, Messages: []string{"error 1", "error 2"}, Latency: 5000000000}),
+			`FAMILY  PROVIDER          RESULT                           LATENCY  MESSAGES
+IPv4    cloudflare.trace  1.2.3.4 (via SJC, US, warp=off)  123ms    -
+IPv4    local             10.0.0.1                         1ms      -
+IPv4    cloudflare.doh    1.2.3.4                          2ms      note; warning
+IPv6    local.iface:eth0  ::1, ::2                         0s       -
+IPv6    cloudflare.trace  (failed)                         5s       error 1; error 2
+
+IPv4: the providers disagree: 1.2.3.4 (cloudflare.trace, cloudflare.doh); 10.0.0.1 (local)
+IPv6: all successful providers agree on ::1, ::2 (local.iface:eth0)
+`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf strings.Builder
+			require.NoError(t, detect.PrintTable(&buf, tc.results))
+			require.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestPrintJSON(t *testing.T) {
+	t.Parallel()
+
+	var buf strings.Builder
+	require.NoError(t, detect.PrintJSON(&buf, []detect.Result{testResults[0], testResults[2], testResults[3]}))
+	require.JSONEq(t, `[
+  {"family": "IPv4", "provider": "cloudflare.trace", "ok": true, "ips": ["1.2.3.4"], "notes": [], "metadata": {"colo": "SJC", "loc": "US", "warp": "off", "tls": "TLSv1.3"}, "messages": [], "latency_ms": 123.456},
+  {"family": "IPv4", "provider": "cloudflare.doh", "ok": true, "ips": ["1.2.3.4"], "notes": ["note"], "metadata": {}, "messages": ["warning"], "latency_ms": 2},
+  {"family": "IPv6", "provider": "local.iface:eth0", "ok": true, "ips": ["::1", "::2"], "notes": [], "metadata": {}, "messages": [], "latency_ms": 0}
+]`, buf.String())
+}
@@ -0,0 +1,143 @@
+package detect
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/netip"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+)
+
+// describeIPs describes the detected IP addresses, such as "1.1.1.1, 1.0.0.1".
+func describeIPs(ips []netip.Addr) string {
+	return pp.JoinMap(netip.Addr.String, ips)
+}
+
+// describeResult describes the detected IP addresses and how the request was routed,
+// or "(failed)" if the detection failed.
+func describeResult(r Result) string {
+	if !r.OK {
+		return "(failed)"
+	}
+	if route := provider.DescribeMetadata(r.Metadata); route != "" {
+		return fmt.Sprintf("%s (%s)", describeIPs(r.IPs), route)
+	}
+	return describeIPs(r.IPs)
+}
+
+// describeMessages joins the notes and the messages in one line, or gives "-" if there are none.
+func describeMessages(r Result) string {
+	messages := append(append([]string{}, r.Notes...), r.Messages...)
+	if len(messages) == 0 {
+		return "-"
+	}
+	return strings.Join(messages, "; ")
+}
+
+// agreement is a group of providers that detected the same IP addresses.
+type agreement struct {
+	ips       string
+	providers []string
+}
+
+// groupByIPs groups the successful providers for the IP network by the IP addresses they detected,
+// in the order of their first appearance.
+func groupByIPs(results []Result, ipNet ipnet.Type) []agreement {
+	var groups []agreement
+	index := map[string]int{}
+	for _, r := range results {
+		if r.IPNet != ipNet || !r.OK {
+			continue
+		}
+		key := describeIPs(r.IPs)
+		i, found := index[key]
+		if !found {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, agreement{ips: key, providers: nil})
+		}
+		groups[i].providers = append(groups[i].providers, r.Provider)
+	}
+	return groups
+}
+
+// describeAgreements describes the groups, such as "1.1.1.1 (cloudflare.trace, cloudflare.doh); 10.0.0.1 (local)".
+func describeAgreements(groups []agreement) string {
+	descriptions := make([]string, 0, len(groups))
+	for _, g := range groups {
+		descriptions = append(descriptions, fmt.Sprintf("%s (%s)", g.ips, strings.Join(g.providers, ", ")))
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+// PrintTable prints the results as a table, followed by a summary for each IP network.
+func PrintTable(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:mnd
+	fmt.Fprintln(tw, "FAMILY\tPROVIDER\tRESULT\tLATENCY\tMESSAGES")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%v\t%s\n",
+			r.IPNet.Describe(), r.Provider, describeResult(r), r.Latency.Round(time.Millisecond), describeMessages(r))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	for ipNet := range ipnet.All {
+		switch groups := groupByIPs(results, ipNet); len(groups) {
+		case 0:
+			fmt.Fprintf(w, "%s: no provider succeeded\n", ipNet.Describe())
+		case 1:
+			fmt.Fprintf(w, "%s: all successful providers agree on %s\n", ipNet.Describe(), describeAgreements(groups))
+		default:
+			fmt.Fprintf(w, "%s: the providers disagree: %s\n", ipNet.Describe(), describeAgreements(groups))
+		}
+	}
+	return nil
+}
+
+// jsonResult is the JSON representation of [Result].
+type jsonResult struct {
+	Family    string            `json:"family"`
+	Provider  string            `json:"provider"`
+	OK        bool              `json:"ok"`
+	IPs       []string          `json:"ips"`
+	Notes     []string          `json:"notes"`
+	Metadata  map[string]string `json:"metadata"`
+	Messages  []string          `json:"messages"`
+	LatencyMS float64           `json:"latency_ms"`
+}
+
+// PrintJSON prints the results as a JSON array.
+func PrintJSON(w io.Writer, results []Result) error {
+	rs := make([]jsonResult, 0, len(results))
+	for _, r := range results {
+		ips := make([]string, 0, len(r.IPs))
+		for _, ip := range r.IPs {
+			ips = append(ips, ip.String())
+		}
+		metadata := map[string]string{}
+		maps.Copy(metadata, r.Metadata)
+		rs = append(rs, jsonResult{
+			Family:    r.IPNet.Describe(),
+			Provider:  r.Provider,
+			OK:        r.OK,
+			IPs:       ips,
+			Notes:     append([]string{}, r.Notes...),
+			Metadata:  metadata,
+			Messages:  append([]string{}, r.Messages...),
+			LatencyMS: float64(r.Latency.Microseconds()) / float64(time.Millisecond/time.Microsecond),
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rs)
+}
@@ -51,6 +51,18 @@ func StringToASCII(domain string) string {
 	return normalized
 }
 
+// toASCII is profile.ToASCII except that underscores are allowed. Underscores are not valid in host names,
+// but they are common in names of other DNS records, such as "_dmarc.example.org" for TXT records.
+func toASCII(profile *idna.Profile, domain string) (string, error) {
+	normalized, err := profile.ToASCII(domain)
+	if err != nil && strings.Contains(domain, "_") {
+		if _, err := profile.ToASCII(strings.ReplaceAll(domain, "_", "a")); err == nil {
+			return normalized, nil
+		}
+	}
+	return normalized, err
+}
+
 // ErrNotFQDN means a domain name is not fully qualified.
 var ErrNotFQDN error = errors.New("not fully qualified")
 
@@ -59,7 +71,7 @@ var ErrNotFQDN error = errors.New("not fully qualified")
 // gives back the same ASCII form without errors. Otherwise,
 // the ASCII form (possibly using Punycode) is stored to avoid ambiguity.
 func New(domain string) (Domain, error) {
-	normalized, err := profileDroppingLeadingDots.ToASCII(domain)
+	normalized, err := toASCII(profileDroppingLeadingDots, domain)
 
 	// Remove the final dot for consistency
 	normalized = strings.TrimRight(normalized, ".")
@@ -76,7 +88,7 @@ func New(domain string) (Domain, error) {
 	// Special case: "*.something"
 	if normalized, ok := strings.CutPrefix(normalized, "*."); ok {
 		// redo the normalization after removing the offending "*" to get the true error (if any)
-		normalized, err := profileKeepingLeadingDots.ToASCII(normalized)
+		normalized, err := toASCII(profileKeepingLeadingDots, normalized)
 		return Wildcard(normalized), err
 	}
 
@@ -52,11 +52,16 @@ func TestNew(t *testing.T) {
 		{"عربي.de", f("xn--ngbrx4e.de"), true, ""},
 		{"نامهای.de", f("xn--mgba3gch31f.de"), true, ""},
 		{"نامه\u200Cای.de", f("xn--mgba3gch31f060k.de"), true, ""},
+		// underscores
+		{"_DDNS.fass.de", f("_ddns.fass.de"), true, ""},
+		{"_ddns.faß.de", f("_ddns.xn--fa-hia.de"), true, ""},
+		{"_ddns.\u0080.com", f("_ddns.xn--a.com"), false, "idna: disallowed rune U+005F"},
 		// wildcards
 		{"*.fass.de", w("fass.de"), true, ""},
 		{"*.faß.de", w("xn--fa-hia.de"), true, ""},
 		{"*.fäß.de", w("xn--f-qfao.de"), true, ""},
 		{"*.xn--fa-hia.de", w("xn--fa-hia.de"), true, ""},
+		{"*._ddns.fass.de", w("_ddns.fass.de"), true, ""},
 		{"*.₹.com", w("xn--yzg.com"), true, ""},
 		{"*.𑀓.com", w("xn--n00d.com"), true, ""},
 		{"*.\u0080.com", w("xn--a.com"), false, `idna: invalid label "\u0080"`},
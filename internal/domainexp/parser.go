@@ -43,27 +43,59 @@ func scanASCIIDomainList(ppfmt pp.PP, key string, input string, tokens []string)
 	return domains, tokens
 }
 
+func newDomain(ppfmt pp.PP, key string, input string, raw string) (domain.Domain, bool) {
+	d, err := domain.New(raw)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFQDN) {
+			ppfmt.Noticef(pp.EmojiUserError,
+				`%s (%q) contains a domain %q that is probably not fully qualified; a fully qualified domain name (FQDN) would look like "*.example.org" or "sub.example.org"`, //nolint:lll
+				key, input, d.Describe())
+			return nil, false
+		} else {
+			ppfmt.Noticef(pp.EmojiUserError,
+				"%s (%q) contains an ill-formed domain %q: %v",
+				key, input, d.Describe(), err)
+			return nil, false
+		}
+	}
+	return d, true
+}
+
 func scanDomainList(ppfmt pp.PP, key string, input string, tokens []string) ([]domain.Domain, []string) {
 	list, tokens := scanList(ppfmt, key, input, tokens)
 	domains := make([]domain.Domain, 0, len(list))
 	for _, raw := range list {
-		d, err := domain.New(raw)
-		if err != nil {
-			if errors.Is(err, domain.ErrNotFQDN) {
-				ppfmt.Noticef(pp.EmojiUserError,
-					`%s (%q) contains a domain %q that is probably not fully qualified; a fully qualified domain name (FQDN) would look like "*.example.org" or "sub.example.org"`, //nolint:lll
-					key, input, d.Describe())
-				return nil, nil
-			} else {
+		d, ok := newDomain(ppfmt, key, input, raw)
+		if !ok {
+			return nil, nil
+		}
+		domains = append(domains, d)
+	}
+	return domains, tokens
+}
+
+func scanDomainListWithSuffixes(ppfmt pp.PP, key string, input string, tokens []string,
+) ([]domain.Domain, map[domain.Domain]string, []string) {
+	list, tokens := scanList(ppfmt, key, input, tokens)
+	domains := make([]domain.Domain, 0, len(list))
+	suffixes := map[domain.Domain]string{}
+	for _, raw := range list {
+		raw, suffix, hasSuffix := strings.Cut(raw, "=")
+		d, ok := newDomain(ppfmt, key, input, raw)
+		if !ok {
+			return nil, nil, nil
+		}
+		if hasSuffix {
+			if old, found := suffixes[d]; found && old != suffix {
 				ppfmt.Noticef(pp.EmojiUserError,
-					"%s (%q) contains an ill-formed domain %q: %v",
-					key, input, d.Describe(), err)
-				return nil, nil
+					"%s (%q) assigns different suffixes to the domain %q", key, input, d.Describe())
+				return nil, nil, nil
 			}
+			suffixes[d] = suffix
 		}
 		domains = append(domains, d)
 	}
-	return domains, tokens
+	return domains, suffixes, tokens
 }
 
 func scanConstants(_ppfmt pp.PP, _key string, _input string, tokens []string, expected []string) (string, []string) {
@@ -249,6 +281,25 @@ func ParseList(ppfmt pp.PP, key string, input string) ([]domain.Domain, bool) {
 	return list, true
 }
 
+// ParseListWithSuffixes is similar to [ParseList] except that each domain may be followed by "=" and a suffix,
+// such as "nas.example.org=::1:2:3:4/64". The suffixes are returned as they are without further parsing.
+func ParseListWithSuffixes(ppfmt pp.PP, key string, input string) ([]domain.Domain, map[domain.Domain]string, bool) {
+	tokens, ok := tokenize(ppfmt, key, input)
+	if !ok {
+		return nil, nil, false
+	}
+
+	list, suffixes, tokens := scanDomainListWithSuffixes(ppfmt, key, input, tokens)
+	if tokens == nil {
+		return nil, nil, false
+	} else if len(tokens) > 0 {
+		ppfmt.Noticef(pp.EmojiUserError, `%s (%q) has unexpected token %q`, key, input, tokens[0])
+		return nil, nil, false
+	}
+
+	return list, suffixes, true
+}
+
 // ParseExpression parses a boolean expression containing domains. Internationalized domain names are fully supported.
 // A boolean expression must have one of the following forms:
 //
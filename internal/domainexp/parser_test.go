@@ -64,6 +64,52 @@ func TestParseList(t *testing.T) {
 	}
 }
 
+func TestParseListWithSuffixes(t *testing.T) {
+	t.Parallel()
+	key := "key"
+	type f = domain.FQDN
+	type ds = []domain.Domain
+	type ss = map[domain.Domain]string
+	for name, tc := range map[string]struct {
+		input            string
+		ok               bool
+		expected         ds
+		expectedSuffixes ss
+		prepareMockPP    func(m *mocks.MockPP)
+	}{
+		"none":     {" a.a ,  a.b ", true, ds{f("a.a"), f("a.b")}, ss{}, nil},
+		"suffix":   {" a.a=::1/64 ,  a.b ", true, ds{f("a.a"), f("a.b")}, ss{f("a.a"): "::1/64"}, nil},
+		"repeated": {"a.a=::1/64,a.a=::1/64", true, ds{f("a.a"), f("a.a")}, ss{f("a.a"): "::1/64"}, nil},
+		"conflict": {
+			"a.a=::1/64,a.a=::2/64", false, nil, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, "%s (%q) assigns different suffixes to the domain %q", key, "a.a=::1/64,a.a=::2/64", "a.a")
+			},
+		},
+		"illformed": {
+			"a.a=::1/64)", false, nil, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `%s (%q) has unexpected token %q`, key, "a.a=::1/64)", ")")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			list, suffixes, ok := domainexp.ParseListWithSuffixes(mockPP, key, tc.input)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, list)
+			require.Equal(t, tc.expectedSuffixes, suffixes)
+		})
+	}
+}
+
 type ErrorMatcher struct {
 	Error error
 }
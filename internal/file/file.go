@@ -3,9 +3,11 @@ package file
 
 import (
 	"bytes"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/favonia/cloudflare-ddns/internal/pp"
 )
@@ -17,9 +19,8 @@ const LinuxRoot string = "/"
 // and can be modified to a virtual file system for testing.
 var FS = os.DirFS(LinuxRoot) //nolint:gochecknoglobals
 
-// ReadString reads the content of the file at path. It treats an absolute path as
-// a path relative to the root of [FS].
-func ReadString(ppfmt pp.PP, path string) (string, bool) {
+// toFSPath turns an absolute path into a path relative to the root of [FS].
+func toFSPath(ppfmt pp.PP, path string) (string, bool) {
 	// os.DirFS(...).Open() does not accept absolute paths
 	if filepath.IsAbs(path) {
 		newpath, err := filepath.Rel(LinuxRoot, path)
@@ -29,6 +30,16 @@ func ReadString(ppfmt pp.PP, path string) (string, bool) {
 		}
 		path = newpath
 	}
+	return path, true
+}
+
+// ReadString reads the content of the file at path. It treats an absolute path as
+// a path relative to the root of [FS].
+func ReadString(ppfmt pp.PP, path string) (string, bool) {
+	path, ok := toFSPath(ppfmt, path)
+	if !ok {
+		return "", false
+	}
 
 	body, err := fs.ReadFile(FS, path)
 	if err != nil {
@@ -38,3 +49,33 @@ func ReadString(ppfmt pp.PP, path string) (string, bool) {
 
 	return string(bytes.TrimSpace(body)), true
 }
+
+// ReadStringWithModTime is the same as [ReadString] except that it also returns
+// the modification time of the file.
+func ReadStringWithModTime(ppfmt pp.PP, path string) (string, time.Time, bool) {
+	path, ok := toFSPath(ppfmt, path)
+	if !ok {
+		return "", time.Time{}, false
+	}
+
+	f, err := FS.Open(path)
+	if err != nil {
+		ppfmt.Noticef(pp.EmojiUserError, "Failed to read %q: %v", path, err)
+		return "", time.Time{}, false
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		ppfmt.Noticef(pp.EmojiUserError, "Failed to read %q: %v", path, err)
+		return "", time.Time{}, false
+	}
+
+	body, err := io.ReadAll(f)
+	if err != nil {
+		ppfmt.Noticef(pp.EmojiUserError, "Failed to read %q: %v", path, err)
+		return "", time.Time{}, false
+	}
+
+	return string(bytes.TrimSpace(body)), info.ModTime(), true
+}
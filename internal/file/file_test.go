@@ -98,3 +98,65 @@ func TestReadStringAbsolutePath(t *testing.T) {
 	require.True(t, ok)
 	require.Equal(t, expected, content)
 }
+
+//nolint:paralleltest // changing global var file.FS
+func TestReadStringWithModTime(t *testing.T) {
+	path := "test/file.txt"
+	written := " hello world   " // space is intentionally added to test trimming
+	expected := strings.TrimSpace(written)
+	modTime := time.Unix(1234, 5678)
+
+	useMemFS(t, fstest.MapFS{
+		path: &fstest.MapFile{
+			Data:    []byte(written),
+			Mode:    0o644,
+			ModTime: modTime,
+			Sys:     nil,
+		},
+		"dir/file.txt": &fstest.MapFile{
+			Data:    []byte("hello"),
+			Mode:    0,
+			ModTime: modTime,
+			Sys:     nil,
+		},
+	})
+
+	for name, tc := range map[string]struct {
+		path          string
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"relative": {path, true, nil},
+		"absolute": {"/" + path, true, nil},
+		"wrong-path": {
+			"wrong/path.txt", false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, "Failed to read %q: %v", "wrong/path.txt", gomock.Any())
+			},
+		},
+		"dir": {
+			"dir", false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, "Failed to read %q: %v", "dir", gomock.Any())
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			content, mtime, ok := file.ReadStringWithModTime(mockPP, tc.path)
+			require.Equal(t, tc.ok, ok)
+			if tc.ok {
+				require.Equal(t, expected, content)
+				require.True(t, modTime.Equal(mtime))
+			} else {
+				require.Empty(t, content)
+				require.Zero(t, mtime)
+			}
+		})
+	}
+}
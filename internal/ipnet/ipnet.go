@@ -52,6 +52,32 @@ func (t Type) RecordType() string {
 	}
 }
 
+// SVCBHintKey prints out the key of the SvcParam in HTTPS and SVCB records (RFC 9460) that gives hints
+// of the addresses in the IP network. For IPv4, it is ipv4hint; for IPv6, it is ipv6hint.
+func (t Type) SVCBHintKey() string {
+	switch t {
+	case IP4:
+		return "ipv4hint"
+	case IP6:
+		return "ipv6hint"
+	default:
+		return ""
+	}
+}
+
+// SPFMechanism prints out the name of the SPF mechanism (RFC 7208) matching addresses in the IP network.
+// For IPv4, it is ip4; for IPv6, it is ip6.
+func (t Type) SPFMechanism() string {
+	switch t {
+	case IP4:
+		return "ip4"
+	case IP6:
+		return "ip6"
+	default:
+		return ""
+	}
+}
+
 // UDPNetwork gives the network name for net.Dial.
 func (t Type) UDPNetwork() string {
 	switch t {
@@ -68,6 +68,40 @@ func TestRecordType(t *testing.T) {
 	}
 }
 
+func TestSVCBHintKey(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		input    ipnet.Type
+		expected string
+	}{
+		"4":   {ipnet.IP4, "ipv4hint"},
+		"6":   {ipnet.IP6, "ipv6hint"},
+		"100": {ipnet.Type(100), ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, tc.input.SVCBHintKey())
+		})
+	}
+}
+
+func TestSPFMechanism(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		input    ipnet.Type
+		expected string
+	}{
+		"4":   {ipnet.IP4, "ip4"},
+		"6":   {ipnet.IP6, "ip6"},
+		"100": {ipnet.Type(100), ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, tc.input.SPFMechanism())
+		})
+	}
+}
+
 func TestUDPNetwork(t *testing.T) {
 	t.Parallel()
 	for name, tc := range map[string]struct {
@@ -233,3 +267,26 @@ func TestBindings(t *testing.T) {
 	}
 	require.Equal(t, 1, count)
 }
+
+func TestApplySuffix(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		ip       string
+		suffix   string
+		expected string
+	}{
+		"64":   {"2001:db8:1:2:a:b:c:d", "::1:2:3:4/64", "2001:db8:1:2:1:2:3:4"},
+		"56":   {"2001:db8:1:2:a:b:c:d", "::5:1:2:3:4/56", "2001:db8:1:5:1:2:3:4"},
+		"60":   {"2001:db8:1:2ff:a:b:c:d", "::1:0:0:0:1/60", "2001:db8:1:2f1::1"},
+		"0":    {"2001:db8::1", "::2/0", "::2"},
+		"128":  {"2001:db8::1", "::2/128", "2001:db8::1"},
+		"ipv4": {"1.2.3.4", "::2/64", "1.2.3.4"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, netip.MustParseAddr(tc.expected),
+				ipnet.ApplySuffix(netip.MustParseAddr(tc.ip), netip.MustParsePrefix(tc.suffix)))
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package ipnet
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// specialUseRange is a range of IP addresses not meant to be reachable from the public internet.
+type specialUseRange struct {
+	prefix      netip.Prefix
+	description string
+}
+
+// specialUseRanges lists the special-use ranges (RFC 6890 and its updates).
+//
+//nolint:gochecknoglobals
+var specialUseRanges = []specialUseRange{
+	// IPv4
+	{netip.MustParsePrefix("0.0.0.0/8"), "a reserved address"},
+	{netip.MustParsePrefix("10.0.0.0/8"), "a private address"},
+	{netip.MustParsePrefix("100.64.0.0/10"), "a shared address for carrier-grade NAT"},
+	{netip.MustParsePrefix("127.0.0.0/8"), "a loopback address"},
+	{netip.MustParsePrefix("169.254.0.0/16"), "a link-local address"},
+	{netip.MustParsePrefix("172.16.0.0/12"), "a private address"},
+	{netip.MustParsePrefix("192.0.0.0/24"), "a reserved address"},
+	{netip.MustParsePrefix("192.0.2.0/24"), "a documentation address"},
+	{netip.MustParsePrefix("192.88.99.0/24"), "a reserved address"},
+	{netip.MustParsePrefix("192.168.0.0/16"), "a private address"},
+	{netip.MustParsePrefix("198.18.0.0/15"), "a benchmarking address"},
+	{netip.MustParsePrefix("198.51.100.0/24"), "a documentation address"},
+	{netip.MustParsePrefix("203.0.113.0/24"), "a documentation address"},
+	{netip.MustParsePrefix("224.0.0.0/4"), "a multicast address"},
+	{netip.MustParsePrefix("240.0.0.0/4"), "a reserved address"},
+
+	// IPv6
+	{netip.MustParsePrefix("::/8"), "a reserved address"},
+	{netip.MustParsePrefix("64:ff9b:1::/48"), "a private address"},
+	{netip.MustParsePrefix("100::/64"), "a reserved address"},
+	{netip.MustParsePrefix("2001:2::/48"), "a benchmarking address"},
+	{netip.MustParsePrefix("2001::/23"), "a reserved address"}, // IETF protocol assignments; after its sub-ranges
+	{netip.MustParsePrefix("2001:db8::/32"), "a documentation address"},
+	{netip.MustParsePrefix("3fff::/20"), "a documentation address"},
+	{netip.MustParsePrefix("fc00::/7"), "a unique local address"},
+	{netip.MustParsePrefix("fe80::/10"), "a link-local address"},
+	{netip.MustParsePrefix("fec0::/10"), "a reserved address"},
+	{netip.MustParsePrefix("ff00::/8"), "a multicast address"},
+}
+
+// DescribeSpecialUse describes why the IP address is not a public address,
+// such as "a private address". It returns the empty string for public addresses.
+func DescribeSpecialUse(ip netip.Addr) string {
+	ip = ip.Unmap()
+	for _, r := range specialUseRanges {
+		if r.prefix.Contains(ip) {
+			return r.description
+		}
+	}
+	return ""
+}
+
+// IsPublic checks whether the IP address is a public address,
+// that is, not a private, shared, reserved, documentation, or other special-use address.
+func IsPublic(ip netip.Addr) bool {
+	return ip.IsValid() && DescribeSpecialUse(ip) == ""
+}
+
+// Policy decides which detected IP addresses may be used.
+// An address is allowed if it is a public address and Public is true,
+// or if it is in one of the Prefixes. The zero value allows all addresses.
+type Policy struct {
+	Public   bool           // whether public addresses are allowed
+	Prefixes []netip.Prefix // additional ranges of allowed addresses
+}
+
+// AllowsAll checks whether the policy allows all addresses.
+func (p Policy) AllowsAll() bool {
+	return !p.Public && len(p.Prefixes) == 0
+}
+
+// Allows checks whether the IP address is allowed by the policy.
+func (p Policy) Allows(ip netip.Addr) bool {
+	if p.AllowsAll() || (p.Public && IsPublic(ip)) {
+		return true
+	}
+	for _, prefix := range p.Prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Describe gives a description of the policy in the same syntax as its configuration,
+// such as "public,203.0.113.0/24".
+func (p Policy) Describe() string {
+	if p.AllowsAll() {
+		return "any"
+	}
+
+	descriptions := make([]string, 0, 1+len(p.Prefixes))
+	if p.Public {
+		descriptions = append(descriptions, "public")
+	}
+	for _, prefix := range p.Prefixes {
+		descriptions = append(descriptions, DescribePrefixOrIP(prefix))
+	}
+	return strings.Join(descriptions, ",")
+}
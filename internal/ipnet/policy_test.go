@@ -0,0 +1,66 @@
+// vim: nowrap
+package ipnet_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+)
+
+func TestDescribeSpecialUse(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		input    netip.Addr
+		expected string
+	}{
+		"public4":      {mustIP("1.1.1.1"), ""},
+		"private4":     {mustIP("192.168.1.10"), "a private address"},
+		"cgnat":        {mustIP("100.64.1.1"), "a shared address for carrier-grade NAT"},
+		"doc4":         {mustIP("203.0.113.5"), "a documentation address"},
+		"mapped":       {mustIP("::ffff:10.0.0.1"), "a private address"},
+		"public6":      {mustIP("2606:4700:4700::1111"), ""},
+		"ula":          {mustIP("fd00::1"), "a unique local address"},
+		"doc6":         {mustIP("2001:db8::1"), "a documentation address"},
+		"ietf6":        {mustIP("2001::1"), "a reserved address"},
+		"bench6":       {mustIP("2001:2::1"), "a benchmarking address"},
+		"multicast6":   {mustIP("ff02::1"), "a multicast address"},
+		"unspecified6": {mustIP("::"), "a reserved address"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, ipnet.DescribeSpecialUse(tc.input))
+			require.Equal(t, tc.expected == "", ipnet.IsPublic(tc.input))
+		})
+	}
+}
+
+func TestPolicyAllows(t *testing.T) {
+	t.Parallel()
+
+	isp := netip.MustParsePrefix("2001:db8::/32")
+
+	for name, tc := range map[string]struct {
+		policy      ipnet.Policy
+		input       netip.Addr
+		expected    bool
+		description string
+	}{
+		"any/private":          {ipnet.Policy{}, mustIP("192.168.1.10"), true, "any"},
+		"public/public":        {ipnet.Policy{Public: true, Prefixes: nil}, mustIP("1.1.1.1"), true, "public"},
+		"public/private":       {ipnet.Policy{Public: true, Prefixes: nil}, mustIP("192.168.1.10"), false, "public"},
+		"public/cgnat":         {ipnet.Policy{Public: true, Prefixes: nil}, mustIP("100.64.1.1"), false, "public"},
+		"prefix/in":            {ipnet.Policy{Public: false, Prefixes: []netip.Prefix{isp}}, mustIP("2001:db8::1"), true, "2001:db8::/32"},
+		"prefix/out":           {ipnet.Policy{Public: false, Prefixes: []netip.Prefix{isp}}, mustIP("2606:4700::1"), false, "2001:db8::/32"},
+		"public+single/single": {ipnet.Policy{Public: true, Prefixes: []netip.Prefix{netip.MustParsePrefix("10.0.0.1/32")}}, mustIP("10.0.0.1"), true, "public,10.0.0.1"},
+		"public+single/other":  {ipnet.Policy{Public: true, Prefixes: []netip.Prefix{netip.MustParsePrefix("10.0.0.1/32")}}, mustIP("10.0.0.2"), false, "public,10.0.0.1"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, tc.policy.Allows(tc.input))
+			require.Equal(t, tc.description, tc.policy.Describe())
+		})
+	}
+}
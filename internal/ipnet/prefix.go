@@ -32,3 +32,21 @@ func DescribePrefixOrIP(p netip.Prefix) string {
 		return p.Masked().String()
 	}
 }
+
+// ApplySuffix keeps the first suffix.Bits() bits of ip and takes the remaining bits from suffix.Addr().
+// For example, applying the suffix ::1:2:3:4/64 to 2001:db8::abcd gives 2001:db8::1:2:3:4.
+// Both ip and suffix.Addr() should be IPv6 addresses; otherwise, ip is returned unchanged.
+func ApplySuffix(ip netip.Addr, suffix netip.Prefix) netip.Addr {
+	if !ip.Is6() || !suffix.Addr().Is6() {
+		return ip
+	}
+
+	bytes, suffixBytes := ip.As16(), suffix.Addr().As16()
+	for i := range bytes {
+		// the number of bits in this byte that should come from ip
+		kept := min(max(suffix.Bits()-i*8, 0), 8) //nolint:mnd
+		mask := byte(0xff << (8 - kept))          //nolint:mnd
+		bytes[i] = bytes[i]&mask | suffixBytes[i]&^mask
+	}
+	return netip.AddrFrom16(bytes)
+}
@@ -82,6 +82,45 @@ func (c *HandleCreateRecordCall) DoAndReturn(f func(context.Context, pp.PP, ipne
 	return c
 }
 
+// CreateTXTRecord mocks base method.
+func (m *MockHandle) CreateTXTRecord(arg0 context.Context, arg1 pp.PP, arg2 domain.Domain, arg3 string, arg4 api.RecordParams) (api.ID, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTXTRecord", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(api.ID)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// CreateTXTRecord indicates an expected call of CreateTXTRecord.
+func (mr *MockHandleMockRecorder) CreateTXTRecord(arg0, arg1, arg2, arg3, arg4 any) *HandleCreateTXTRecordCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTXTRecord", reflect.TypeOf((*MockHandle)(nil).CreateTXTRecord), arg0, arg1, arg2, arg3, arg4)
+	return &HandleCreateTXTRecordCall{Call: call}
+}
+
+// HandleCreateTXTRecordCall wrap *gomock.Call
+type HandleCreateTXTRecordCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *HandleCreateTXTRecordCall) Return(arg0 api.ID, arg1 bool) *HandleCreateTXTRecordCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *HandleCreateTXTRecordCall) Do(f func(context.Context, pp.PP, domain.Domain, string, api.RecordParams) (api.ID, bool)) *HandleCreateTXTRecordCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *HandleCreateTXTRecordCall) DoAndReturn(f func(context.Context, pp.PP, domain.Domain, string, api.RecordParams) (api.ID, bool)) *HandleCreateTXTRecordCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // CreateWAFListItems mocks base method.
 func (m *MockHandle) CreateWAFListItems(arg0 context.Context, arg1 pp.PP, arg2 api.WAFList, arg3 string, arg4 []netip.Prefix, arg5 string) bool {
 	m.ctrl.T.Helper()
@@ -236,9 +275,9 @@ func (c *HandleFinalClearWAFListAsyncCall) DoAndReturn(f func(context.Context, p
 }
 
 // ListRecords mocks base method.
-func (m *MockHandle) ListRecords(arg0 context.Context, arg1 pp.PP, arg2 ipnet.Type, arg3 domain.Domain, arg4 api.RecordParams) ([]api.Record, bool, bool) {
+func (m *MockHandle) ListRecords(arg0 context.Context, arg1 pp.PP, arg2 ipnet.Type, arg3 domain.Domain, arg4 api.RecordParams, arg5 api.Ownership) ([]api.Record, bool, bool) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListRecords", arg0, arg1, arg2, arg3, arg4)
+	ret := m.ctrl.Call(m, "ListRecords", arg0, arg1, arg2, arg3, arg4, arg5)
 	ret0, _ := ret[0].([]api.Record)
 	ret1, _ := ret[1].(bool)
 	ret2, _ := ret[2].(bool)
@@ -246,9 +285,9 @@ func (m *MockHandle) ListRecords(arg0 context.Context, arg1 pp.PP, arg2 ipnet.Ty
 }
 
 // ListRecords indicates an expected call of ListRecords.
-func (mr *MockHandleMockRecorder) ListRecords(arg0, arg1, arg2, arg3, arg4 any) *HandleListRecordsCall {
+func (mr *MockHandleMockRecorder) ListRecords(arg0, arg1, arg2, arg3, arg4, arg5 any) *HandleListRecordsCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRecords", reflect.TypeOf((*MockHandle)(nil).ListRecords), arg0, arg1, arg2, arg3, arg4)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRecords", reflect.TypeOf((*MockHandle)(nil).ListRecords), arg0, arg1, arg2, arg3, arg4, arg5)
 	return &HandleListRecordsCall{Call: call}
 }
 
@@ -264,13 +303,93 @@ func (c *HandleListRecordsCall) Return(arg0 []api.Record, arg1, arg2 bool) *Hand
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *HandleListRecordsCall) Do(f func(context.Context, pp.PP, ipnet.Type, domain.Domain, api.RecordParams) ([]api.Record, bool, bool)) *HandleListRecordsCall {
+func (c *HandleListRecordsCall) Do(f func(context.Context, pp.PP, ipnet.Type, domain.Domain, api.RecordParams, api.Ownership) ([]api.Record, bool, bool)) *HandleListRecordsCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *HandleListRecordsCall) DoAndReturn(f func(context.Context, pp.PP, ipnet.Type, domain.Domain, api.RecordParams) ([]api.Record, bool, bool)) *HandleListRecordsCall {
+func (c *HandleListRecordsCall) DoAndReturn(f func(context.Context, pp.PP, ipnet.Type, domain.Domain, api.RecordParams, api.Ownership) ([]api.Record, bool, bool)) *HandleListRecordsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ListSVCBRecords mocks base method.
+func (m *MockHandle) ListSVCBRecords(arg0 context.Context, arg1 pp.PP, arg2 domain.Domain) ([]api.SVCBRecord, bool, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSVCBRecords", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]api.SVCBRecord)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(bool)
+	return ret0, ret1, ret2
+}
+
+// ListSVCBRecords indicates an expected call of ListSVCBRecords.
+func (mr *MockHandleMockRecorder) ListSVCBRecords(arg0, arg1, arg2 any) *HandleListSVCBRecordsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSVCBRecords", reflect.TypeOf((*MockHandle)(nil).ListSVCBRecords), arg0, arg1, arg2)
+	return &HandleListSVCBRecordsCall{Call: call}
+}
+
+// HandleListSVCBRecordsCall wrap *gomock.Call
+type HandleListSVCBRecordsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *HandleListSVCBRecordsCall) Return(arg0 []api.SVCBRecord, arg1, arg2 bool) *HandleListSVCBRecordsCall {
+	c.Call = c.Call.Return(arg0, arg1, arg2)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *HandleListSVCBRecordsCall) Do(f func(context.Context, pp.PP, domain.Domain) ([]api.SVCBRecord, bool, bool)) *HandleListSVCBRecordsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *HandleListSVCBRecordsCall) DoAndReturn(f func(context.Context, pp.PP, domain.Domain) ([]api.SVCBRecord, bool, bool)) *HandleListSVCBRecordsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ListTXTRecords mocks base method.
+func (m *MockHandle) ListTXTRecords(arg0 context.Context, arg1 pp.PP, arg2 domain.Domain) ([]api.TXTRecord, bool, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTXTRecords", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]api.TXTRecord)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(bool)
+	return ret0, ret1, ret2
+}
+
+// ListTXTRecords indicates an expected call of ListTXTRecords.
+func (mr *MockHandleMockRecorder) ListTXTRecords(arg0, arg1, arg2 any) *HandleListTXTRecordsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTXTRecords", reflect.TypeOf((*MockHandle)(nil).ListTXTRecords), arg0, arg1, arg2)
+	return &HandleListTXTRecordsCall{Call: call}
+}
+
+// HandleListTXTRecordsCall wrap *gomock.Call
+type HandleListTXTRecordsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *HandleListTXTRecordsCall) Return(arg0 []api.TXTRecord, arg1, arg2 bool) *HandleListTXTRecordsCall {
+	c.Call = c.Call.Return(arg0, arg1, arg2)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *HandleListTXTRecordsCall) Do(f func(context.Context, pp.PP, domain.Domain) ([]api.TXTRecord, bool, bool)) *HandleListTXTRecordsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *HandleListTXTRecordsCall) DoAndReturn(f func(context.Context, pp.PP, domain.Domain) ([]api.TXTRecord, bool, bool)) *HandleListTXTRecordsCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -353,3 +472,79 @@ func (c *HandleUpdateRecordCall) DoAndReturn(f func(context.Context, pp.PP, ipne
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
+
+// UpdateSVCBRecord mocks base method.
+func (m *MockHandle) UpdateSVCBRecord(arg0 context.Context, arg1 pp.PP, arg2 domain.Domain, arg3 api.SVCBRecord) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSVCBRecord", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// UpdateSVCBRecord indicates an expected call of UpdateSVCBRecord.
+func (mr *MockHandleMockRecorder) UpdateSVCBRecord(arg0, arg1, arg2, arg3 any) *HandleUpdateSVCBRecordCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSVCBRecord", reflect.TypeOf((*MockHandle)(nil).UpdateSVCBRecord), arg0, arg1, arg2, arg3)
+	return &HandleUpdateSVCBRecordCall{Call: call}
+}
+
+// HandleUpdateSVCBRecordCall wrap *gomock.Call
+type HandleUpdateSVCBRecordCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *HandleUpdateSVCBRecordCall) Return(arg0 bool) *HandleUpdateSVCBRecordCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *HandleUpdateSVCBRecordCall) Do(f func(context.Context, pp.PP, domain.Domain, api.SVCBRecord) bool) *HandleUpdateSVCBRecordCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *HandleUpdateSVCBRecordCall) DoAndReturn(f func(context.Context, pp.PP, domain.Domain, api.SVCBRecord) bool) *HandleUpdateSVCBRecordCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// UpdateTXTRecord mocks base method.
+func (m *MockHandle) UpdateTXTRecord(arg0 context.Context, arg1 pp.PP, arg2 domain.Domain, arg3 api.TXTRecord) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTXTRecord", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// UpdateTXTRecord indicates an expected call of UpdateTXTRecord.
+func (mr *MockHandleMockRecorder) UpdateTXTRecord(arg0, arg1, arg2, arg3 any) *HandleUpdateTXTRecordCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTXTRecord", reflect.TypeOf((*MockHandle)(nil).UpdateTXTRecord), arg0, arg1, arg2, arg3)
+	return &HandleUpdateTXTRecordCall{Call: call}
+}
+
+// HandleUpdateTXTRecordCall wrap *gomock.Call
+type HandleUpdateTXTRecordCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *HandleUpdateTXTRecordCall) Return(arg0 bool) *HandleUpdateTXTRecordCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *HandleUpdateTXTRecordCall) Do(f func(context.Context, pp.PP, domain.Domain, api.TXTRecord) bool) *HandleUpdateTXTRecordCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *HandleUpdateTXTRecordCall) DoAndReturn(f func(context.Context, pp.PP, domain.Domain, api.TXTRecord) bool) *HandleUpdateTXTRecordCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
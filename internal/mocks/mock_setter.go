@@ -120,8 +120,86 @@ func (c *SetterFinalDeleteCall) DoAndReturn(f func(context.Context, pp.PP, ipnet
 	return c
 }
 
+// ListIPs mocks base method.
+func (m *MockSetter) ListIPs(arg0 context.Context, arg1 pp.PP, arg2 ipnet.Type, arg3 domain.Domain, arg4 api.RecordParams) ([]netip.Addr, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListIPs", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]netip.Addr)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// ListIPs indicates an expected call of ListIPs.
+func (mr *MockSetterMockRecorder) ListIPs(arg0, arg1, arg2, arg3, arg4 any) *SetterListIPsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIPs", reflect.TypeOf((*MockSetter)(nil).ListIPs), arg0, arg1, arg2, arg3, arg4)
+	return &SetterListIPsCall{Call: call}
+}
+
+// SetterListIPsCall wrap *gomock.Call
+type SetterListIPsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *SetterListIPsCall) Return(arg0 []netip.Addr, arg1 bool) *SetterListIPsCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *SetterListIPsCall) Do(f func(context.Context, pp.PP, ipnet.Type, domain.Domain, api.RecordParams) ([]netip.Addr, bool)) *SetterListIPsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *SetterListIPsCall) DoAndReturn(f func(context.Context, pp.PP, ipnet.Type, domain.Domain, api.RecordParams) ([]netip.Addr, bool)) *SetterListIPsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ListWAFList mocks base method.
+func (m *MockSetter) ListWAFList(arg0 context.Context, arg1 pp.PP, arg2 api.WAFList, arg3 string) ([]netip.Prefix, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWAFList", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]netip.Prefix)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// ListWAFList indicates an expected call of ListWAFList.
+func (mr *MockSetterMockRecorder) ListWAFList(arg0, arg1, arg2, arg3 any) *SetterListWAFListCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWAFList", reflect.TypeOf((*MockSetter)(nil).ListWAFList), arg0, arg1, arg2, arg3)
+	return &SetterListWAFListCall{Call: call}
+}
+
+// SetterListWAFListCall wrap *gomock.Call
+type SetterListWAFListCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *SetterListWAFListCall) Return(arg0 []netip.Prefix, arg1 bool) *SetterListWAFListCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *SetterListWAFListCall) Do(f func(context.Context, pp.PP, api.WAFList, string) ([]netip.Prefix, bool)) *SetterListWAFListCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *SetterListWAFListCall) DoAndReturn(f func(context.Context, pp.PP, api.WAFList, string) ([]netip.Prefix, bool)) *SetterListWAFListCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Set mocks base method.
-func (m *MockSetter) Set(arg0 context.Context, arg1 pp.PP, arg2 ipnet.Type, arg3 domain.Domain, arg4 netip.Addr, arg5 api.RecordParams) setter.ResponseCode {
+func (m *MockSetter) Set(arg0 context.Context, arg1 pp.PP, arg2 ipnet.Type, arg3 domain.Domain, arg4 []netip.Addr, arg5 api.RecordParams) setter.ResponseCode {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Set", arg0, arg1, arg2, arg3, arg4, arg5)
 	ret0, _ := ret[0].(setter.ResponseCode)
@@ -147,19 +225,133 @@ func (c *SetterSetCall) Return(arg0 setter.ResponseCode) *SetterSetCall {
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *SetterSetCall) Do(f func(context.Context, pp.PP, ipnet.Type, domain.Domain, netip.Addr, api.RecordParams) setter.ResponseCode) *SetterSetCall {
+func (c *SetterSetCall) Do(f func(context.Context, pp.PP, ipnet.Type, domain.Domain, []netip.Addr, api.RecordParams) setter.ResponseCode) *SetterSetCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *SetterSetCall) DoAndReturn(f func(context.Context, pp.PP, ipnet.Type, domain.Domain, []netip.Addr, api.RecordParams) setter.ResponseCode) *SetterSetCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// SetHeartbeat mocks base method.
+func (m *MockSetter) SetHeartbeat(arg0 context.Context, arg1 pp.PP, arg2 domain.Domain, arg3 string, arg4 api.RecordParams) setter.ResponseCode {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHeartbeat", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(setter.ResponseCode)
+	return ret0
+}
+
+// SetHeartbeat indicates an expected call of SetHeartbeat.
+func (mr *MockSetterMockRecorder) SetHeartbeat(arg0, arg1, arg2, arg3, arg4 any) *SetterSetHeartbeatCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHeartbeat", reflect.TypeOf((*MockSetter)(nil).SetHeartbeat), arg0, arg1, arg2, arg3, arg4)
+	return &SetterSetHeartbeatCall{Call: call}
+}
+
+// SetterSetHeartbeatCall wrap *gomock.Call
+type SetterSetHeartbeatCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *SetterSetHeartbeatCall) Return(arg0 setter.ResponseCode) *SetterSetHeartbeatCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *SetterSetHeartbeatCall) Do(f func(context.Context, pp.PP, domain.Domain, string, api.RecordParams) setter.ResponseCode) *SetterSetHeartbeatCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *SetterSetHeartbeatCall) DoAndReturn(f func(context.Context, pp.PP, domain.Domain, string, api.RecordParams) setter.ResponseCode) *SetterSetHeartbeatCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// SetSPF mocks base method.
+func (m *MockSetter) SetSPF(arg0 context.Context, arg1 pp.PP, arg2 ipnet.Type, arg3 domain.Domain, arg4 []netip.Addr) setter.ResponseCode {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSPF", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(setter.ResponseCode)
+	return ret0
+}
+
+// SetSPF indicates an expected call of SetSPF.
+func (mr *MockSetterMockRecorder) SetSPF(arg0, arg1, arg2, arg3, arg4 any) *SetterSetSPFCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSPF", reflect.TypeOf((*MockSetter)(nil).SetSPF), arg0, arg1, arg2, arg3, arg4)
+	return &SetterSetSPFCall{Call: call}
+}
+
+// SetterSetSPFCall wrap *gomock.Call
+type SetterSetSPFCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *SetterSetSPFCall) Return(arg0 setter.ResponseCode) *SetterSetSPFCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *SetterSetSPFCall) Do(f func(context.Context, pp.PP, ipnet.Type, domain.Domain, []netip.Addr) setter.ResponseCode) *SetterSetSPFCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *SetterSetSPFCall) DoAndReturn(f func(context.Context, pp.PP, ipnet.Type, domain.Domain, []netip.Addr) setter.ResponseCode) *SetterSetSPFCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// SetSVCBHints mocks base method.
+func (m *MockSetter) SetSVCBHints(arg0 context.Context, arg1 pp.PP, arg2 ipnet.Type, arg3 domain.Domain, arg4 []netip.Addr) setter.ResponseCode {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSVCBHints", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(setter.ResponseCode)
+	return ret0
+}
+
+// SetSVCBHints indicates an expected call of SetSVCBHints.
+func (mr *MockSetterMockRecorder) SetSVCBHints(arg0, arg1, arg2, arg3, arg4 any) *SetterSetSVCBHintsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSVCBHints", reflect.TypeOf((*MockSetter)(nil).SetSVCBHints), arg0, arg1, arg2, arg3, arg4)
+	return &SetterSetSVCBHintsCall{Call: call}
+}
+
+// SetterSetSVCBHintsCall wrap *gomock.Call
+type SetterSetSVCBHintsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *SetterSetSVCBHintsCall) Return(arg0 setter.ResponseCode) *SetterSetSVCBHintsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *SetterSetSVCBHintsCall) Do(f func(context.Context, pp.PP, ipnet.Type, domain.Domain, []netip.Addr) setter.ResponseCode) *SetterSetSVCBHintsCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *SetterSetCall) DoAndReturn(f func(context.Context, pp.PP, ipnet.Type, domain.Domain, netip.Addr, api.RecordParams) setter.ResponseCode) *SetterSetCall {
+func (c *SetterSetSVCBHintsCall) DoAndReturn(f func(context.Context, pp.PP, ipnet.Type, domain.Domain, []netip.Addr) setter.ResponseCode) *SetterSetSVCBHintsCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
 // SetWAFList mocks base method.
-func (m *MockSetter) SetWAFList(arg0 context.Context, arg1 pp.PP, arg2 api.WAFList, arg3 string, arg4 map[ipnet.Type]netip.Addr, arg5 string) setter.ResponseCode {
+func (m *MockSetter) SetWAFList(arg0 context.Context, arg1 pp.PP, arg2 api.WAFList, arg3 string, arg4 map[ipnet.Type][]netip.Addr, arg5 string) setter.ResponseCode {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "SetWAFList", arg0, arg1, arg2, arg3, arg4, arg5)
 	ret0, _ := ret[0].(setter.ResponseCode)
@@ -185,13 +377,13 @@ func (c *SetterSetWAFListCall) Return(arg0 setter.ResponseCode) *SetterSetWAFLis
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *SetterSetWAFListCall) Do(f func(context.Context, pp.PP, api.WAFList, string, map[ipnet.Type]netip.Addr, string) setter.ResponseCode) *SetterSetWAFListCall {
+func (c *SetterSetWAFListCall) Do(f func(context.Context, pp.PP, api.WAFList, string, map[ipnet.Type][]netip.Addr, string) setter.ResponseCode) *SetterSetWAFListCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *SetterSetWAFListCall) DoAndReturn(f func(context.Context, pp.PP, api.WAFList, string, map[ipnet.Type]netip.Addr, string) setter.ResponseCode) *SetterSetWAFListCall {
+func (c *SetterSetWAFListCall) DoAndReturn(f func(context.Context, pp.PP, api.WAFList, string, map[ipnet.Type][]netip.Addr, string) setter.ResponseCode) *SetterSetWAFListCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -0,0 +1,58 @@
+// Package netevent watches changes of network addresses so that the updater can react to them immediately.
+package netevent
+
+import (
+	"context"
+	"time"
+)
+
+// Watcher sends a value to C when network addresses have changed.
+type Watcher struct {
+	C      <-chan struct{}
+	cancel context.CancelFunc
+	close  func()
+}
+
+// Close stops watching network addresses.
+func (w *Watcher) Close() {
+	w.cancel()
+	w.close()
+}
+
+// Throttle turns raw events from in into debounced and rate-limited events.
+// An event is sent to the returned channel only after no raw events have arrived for the debounce period,
+// and two events are at least minInterval apart. Raw events arriving in the meanwhile are merged.
+// The returned channel has a buffer of size one so that a pending event is not lost while the receiver is busy.
+func Throttle(ctx context.Context, in <-chan struct{}, debounce, minInterval time.Duration) <-chan struct{} {
+	out := make(chan struct{}, 1)
+
+	go func() {
+		timer := time.NewTimer(0)
+		timer.Stop()
+		defer timer.Stop()
+
+		var last time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-in:
+				fire := time.Now().Add(debounce)
+				if earliest := last.Add(minInterval); !last.IsZero() && fire.Before(earliest) {
+					fire = earliest
+				}
+				timer.Reset(time.Until(fire))
+
+			case <-timer.C:
+				last = time.Now()
+				select {
+				case out <- struct{}{}:
+				default: // an event is already pending
+				}
+			}
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,111 @@
+package netevent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// Multicast groups of rtnetlink (see rtnetlink.h); the package syscall does not define them.
+const (
+	rtmgrpIPv4IfAddr = 0x10
+	rtmgrpIPv6IfAddr = 0x100
+)
+
+// IsAddressChange checks whether a batch of rtnetlink messages contains an addition or a removal
+// of an address with the global scope. Changes of link-local or host-local addresses are ignored.
+func IsAddressChange(b []byte) bool {
+	msgs, err := syscall.ParseNetlinkMessage(b)
+	if err != nil {
+		return false
+	}
+
+	for _, m := range msgs {
+		if m.Header.Type != syscall.RTM_NEWADDR && m.Header.Type != syscall.RTM_DELADDR {
+			continue
+		}
+		if len(m.Data) < syscall.SizeofIfAddrmsg {
+			continue
+		}
+		// struct ifaddrmsg { family, prefixlen, flags, scope, index }
+		if m.Data[3] == syscall.RT_SCOPE_UNIVERSE {
+			return true
+		}
+	}
+	return false
+}
+
+// subscribe opens a netlink socket subscribing to the changes of IPv4 and IPv6 addresses.
+func subscribe() (*os.File, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{ //nolint:exhaustruct
+		Family: syscall.AF_NETLINK,
+		Groups: rtmgrpIPv4IfAddr | rtmgrpIPv6IfAddr,
+	}); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	// A non-blocking file is managed by the runtime poller, which makes Close interrupt pending reads.
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	return os.NewFile(uintptr(fd), "netlink"), nil
+}
+
+// listen reads netlink messages from the socket and sends a raw event to the channel for each address change.
+func listen(ppfmt pp.PP, sock *os.File, raw chan<- struct{}) {
+	notify := func() {
+		select {
+		case raw <- struct{}{}:
+		default:
+		}
+	}
+
+	buf := make([]byte, os.Getpagesize())
+	for {
+		n, err := sock.Read(buf)
+		switch {
+		case errors.Is(err, os.ErrClosed):
+			return
+		case errors.Is(err, syscall.ENOBUFS):
+			// Some messages were dropped; assume that addresses have changed.
+			notify()
+		case err != nil:
+			ppfmt.Noticef(pp.EmojiError, "Failed to receive network address changes: %v", err)
+			return
+		case IsAddressChange(buf[:n]):
+			notify()
+		}
+	}
+}
+
+// New starts watching the changes of network addresses. See [Throttle] for
+// the meanings of debounce and minInterval.
+func New(ppfmt pp.PP, debounce, minInterval time.Duration) (*Watcher, bool) {
+	sock, err := subscribe()
+	if err != nil {
+		ppfmt.Noticef(pp.EmojiError, "Failed to watch network address changes: %v", err)
+		return nil, false
+	}
+
+	raw := make(chan struct{}, 1)
+	go listen(ppfmt, sock, raw)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Watcher{
+		C:      Throttle(ctx, raw, debounce, minInterval),
+		cancel: cancel,
+		close:  func() { sock.Close() },
+	}, true
+}
@@ -0,0 +1,62 @@
+//go:build linux
+
+package netevent_test
+
+import (
+	"encoding/binary"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/netevent"
+)
+
+// netlinkMessage builds a netlink message with an ifaddrmsg of the scope as the payload.
+func netlinkMessage(msgType uint16, scope uint8) []byte {
+	b := make([]byte, syscall.NLMSG_HDRLEN+syscall.SizeofIfAddrmsg)
+	binary.NativeEndian.PutUint32(b[0:4], uint32(len(b)))
+	binary.NativeEndian.PutUint16(b[4:6], msgType)
+	b[syscall.NLMSG_HDRLEN] = syscall.AF_INET6
+	b[syscall.NLMSG_HDRLEN+3] = scope
+	return b
+}
+
+func TestIsAddressChange(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		input    []byte
+		expected bool
+	}{
+		"new":        {netlinkMessage(syscall.RTM_NEWADDR, syscall.RT_SCOPE_UNIVERSE), true},
+		"del":        {netlinkMessage(syscall.RTM_DELADDR, syscall.RT_SCOPE_UNIVERSE), true},
+		"link-local": {netlinkMessage(syscall.RTM_NEWADDR, syscall.RT_SCOPE_LINK), false},
+		"route":      {netlinkMessage(syscall.RTM_NEWROUTE, syscall.RT_SCOPE_UNIVERSE), false},
+		"batch": {
+			append(netlinkMessage(syscall.RTM_NEWADDR, syscall.RT_SCOPE_HOST), netlinkMessage(syscall.RTM_DELADDR, syscall.RT_SCOPE_UNIVERSE)...),
+			true,
+		},
+		"truncated": {netlinkMessage(syscall.RTM_NEWADDR, syscall.RT_SCOPE_UNIVERSE)[:10], false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, netevent.IsAddressChange(tc.input))
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+
+	w, ok := netevent.New(mockPP, 0, 0)
+	if !ok {
+		t.Skip("netlink sockets are not available")
+	}
+	w.Close()
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package netevent
+
+import (
+	"time"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// New reports that watching network addresses is only supported on Linux.
+func New(ppfmt pp.PP, _debounce, _minInterval time.Duration) (*Watcher, bool) {
+	ppfmt.Noticef(pp.EmojiUserError, "Watching network address changes is only supported on Linux")
+	return nil, false
+}
@@ -0,0 +1,84 @@
+package netevent_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/netevent"
+)
+
+// count counts the events received within the duration.
+func count(c <-chan struct{}, d time.Duration) int {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	n := 0
+	for {
+		select {
+		case <-c:
+			n++
+		case <-timer.C:
+			return n
+		}
+	}
+}
+
+func TestThrottleDebounce(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	in := make(chan struct{})
+	out := netevent.Throttle(ctx, in, 50*time.Millisecond, 0)
+
+	start := time.Now()
+	for range 5 {
+		in <- struct{}{}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	<-out
+	require.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	require.Equal(t, 0, count(out, 100*time.Millisecond))
+}
+
+func TestThrottleMinInterval(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	in := make(chan struct{})
+	out := netevent.Throttle(ctx, in, time.Millisecond, 200*time.Millisecond)
+
+	in <- struct{}{}
+	<-out
+	start := time.Now()
+
+	// A flapping interface
+	for range 5 {
+		in <- struct{}{}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	<-out
+	require.GreaterOrEqual(t, time.Since(start), 180*time.Millisecond)
+	require.Equal(t, 0, count(out, 50*time.Millisecond))
+}
+
+func TestThrottleCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	in := make(chan struct{}, 1)
+	out := netevent.Throttle(ctx, in, 50*time.Millisecond, 0)
+
+	in <- struct{}{}
+	cancel()
+	require.Equal(t, 0, count(out, 100*time.Millisecond))
+}
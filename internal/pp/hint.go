@@ -19,4 +19,8 @@ const (
 	MessageExperimentalLocalWithInterface                      // New feature introduced in 1.15.0
 	MessageUndocumentedDebugConstProvider                      // Undocumented feature
 	MessageUndocumentedCustomCloudflareTraceProvider           // Undocumented feature
+	MessageCarrierGradeNAT                                     // The address is behind carrier-grade NAT
+	MessagePlainDNSProvider                                    // Plain DNS can be spoofed
+	MessageIP4BlockedByPolicy                                  // How to adjust IP4_ALLOWED
+	MessageIP6BlockedByPolicy                                  // How to adjust IP6_ALLOWED
 )
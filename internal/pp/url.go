@@ -4,4 +4,5 @@ package pp
 const (
 	IssueReportingURL string = "https://github.com/favonia/cloudflare-ddns/issues/new"
 	ManualURL         string = "https://github.com/favonia/cloudflare-ddns/blob/main/README.markdown"
+	ThreatModelURL    string = "https://github.com/favonia/cloudflare-ddns/blob/main/docs/DESIGN.markdown#network-security-threat-model"
 )
@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"context"
+	"net/netip"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+type policyKey struct{}
+
+// WithPolicy returns a copy of ctx under which [Detect] only keeps the IP addresses allowed by the policy.
+// The policy is applied to the addresses detected by each provider, including the providers inside
+// "quorum" and "fallback", so that blocked addresses never take part in voting or falling back.
+func WithPolicy(ctx context.Context, policy ipnet.Policy) context.Context {
+	return context.WithValue(ctx, policyKey{}, policy)
+}
+
+// filterAllowed removes the IP addresses not allowed by the policy in ctx (see [WithPolicy])
+// and moves them to the field Blocked. The detection fails if no addresses are left.
+func filterAllowed(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type, d Detection) (Detection, bool) {
+	policy, _ := ctx.Value(policyKey{}).(ipnet.Policy)
+	if policy.AllowsAll() {
+		return d, true
+	}
+
+	var allowed []netip.Addr
+	for _, ip := range d.IPs {
+		if policy.Allows(ip) {
+			allowed = append(allowed, ip)
+			continue
+		}
+
+		ppfmt.Noticef(pp.EmojiError, "Detected %s address %s is not allowed by IP%d_ALLOWED=%s",
+			ipNet.Describe(), ip.String(), ipNet.Int(), policy.Describe())
+		d.Blocked = append(d.Blocked, ip)
+	}
+
+	d.IPs = allowed
+	return d, len(allowed) > 0
+}
@@ -0,0 +1,103 @@
+// vim: nowrap
+package provider_test
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+)
+
+func TestDetectWithPolicy(t *testing.T) {
+	t.Parallel()
+
+	public := netip.MustParseAddr("1.1.1.1")
+	private := netip.MustParseAddr("10.0.0.1")
+	policy := ipnet.Policy{Public: true, Prefixes: nil}
+
+	for name, tc := range map[string]struct {
+		provider      provider.Provider
+		ok            bool
+		expected      provider.Detection
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"allowed": {
+			provider.MustNewDebugConst("1.1.1.1"),
+			true,
+			provider.Detection{IPs: []netip.Addr{public}, Notes: nil, Metadata: nil, Dissenters: nil, Blocked: nil},
+			nil,
+		},
+		"blocked": {
+			provider.MustNewDebugConst("10.0.0.1"),
+			false,
+			provider.Detection{IPs: nil, Notes: nil, Metadata: nil, Dissenters: nil, Blocked: []netip.Addr{private}},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Detected %s address %s is not allowed by IP%d_ALLOWED=%s", "IPv4", "10.0.0.1", 4, "public")
+			},
+		},
+		"multi": {
+			multiProvider{[]netip.Addr{public, private}},
+			true,
+			provider.Detection{IPs: []netip.Addr{public}, Notes: nil, Metadata: nil, Dissenters: nil, Blocked: []netip.Addr{private}},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Detected %s address %s is not allowed by IP%d_ALLOWED=%s", "IPv4", "10.0.0.1", 4, "public")
+			},
+		},
+		"quorum": {
+			provider.MustNewQuorum(2, []provider.Provider{
+				provider.MustNewDebugConst("10.0.0.1"),
+				provider.MustNewDebugConst("10.0.0.1"),
+				provider.MustNewDebugConst("1.1.1.1"),
+			}),
+			false,
+			provider.Detection{
+				IPs:        nil,
+				Notes:      []string{"debug.const:10.0.0.1 failed", "debug.const:10.0.0.1 failed", "debug.const:1.1.1.1 detected 1.1.1.1"},
+				Metadata:   nil,
+				Dissenters: []string{"debug.const:10.0.0.1", "debug.const:10.0.0.1", "debug.const:1.1.1.1"},
+				Blocked:    []netip.Addr{private, private},
+			},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Detected %s address %s is not allowed by IP%d_ALLOWED=%s", "IPv4", "10.0.0.1", 4, "public").Times(2)
+				m.EXPECT().Noticef(pp.EmojiError, "Failed to reach a quorum of %d out of %d providers on the %s address", 2, 3, "IPv4")
+			},
+		},
+		"fallback": {
+			provider.MustNewFallback([]provider.Provider{
+				provider.MustNewDebugConst("10.0.0.1"),
+				provider.MustNewDebugConst("1.1.1.1"),
+			}),
+			true,
+			provider.Detection{IPs: []netip.Addr{public}, Notes: nil, Metadata: nil, Dissenters: nil, Blocked: []netip.Addr{private}},
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Noticef(pp.EmojiError, "Detected %s address %s is not allowed by IP%d_ALLOWED=%s", "IPv4", "10.0.0.1", 4, "public"),
+					m.EXPECT().Infof(pp.EmojiSwitch, "Falling back to the provider %s", "debug.const:1.1.1.1"),
+					m.EXPECT().Infof(pp.EmojiInternet, "The %s address was detected by the provider %s", "IPv4", "debug.const:1.1.1.1"),
+				)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			ctx := provider.WithPolicy(context.Background(), policy)
+			detection, ok := provider.Detect(ctx, mockPP, tc.provider, ipnet.IP4)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, detection)
+		})
+	}
+}
@@ -4,6 +4,7 @@ package provider
 import (
 	"context"
 	"net/netip"
+	"strings"
 
 	"github.com/favonia/cloudflare-ddns/internal/ipnet"
 	"github.com/favonia/cloudflare-ddns/internal/pp"
@@ -21,6 +22,127 @@ type Provider interface {
 	// GetIP gets the IP.
 }
 
+// An Annotator is a [Provider] that can also explain how the IP was detected,
+// for example, by reporting disagreements among several sources.
+type Annotator interface {
+	Provider
+
+	GetIPWithNotes(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) (netip.Addr, []string, bool)
+	// GetIPWithNotes gets the IP and human-readable notes about the detection.
+}
+
+// A MultiProvider is a [Provider] that can detect several IP addresses at once,
+// for example, all the addresses assigned to a network interface.
+type MultiProvider interface {
+	Provider
+
+	GetIPs(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) ([]netip.Addr, bool)
+	// GetIPs gets the IPs, sorted and without duplicates.
+}
+
+// Metadata is optional information about a detection, such as the Cloudflare data center that answered the request.
+// The keys and the values are specific to the provider.
+type Metadata = protocol.Metadata
+
+// A MetadataProvider is a [Provider] that can also report metadata about the detection,
+// for example, whether the request went through Cloudflare WARP.
+type MetadataProvider interface {
+	Provider
+
+	GetIPWithMetadata(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) (netip.Addr, Metadata, bool)
+	// GetIPWithMetadata gets the IP and the metadata about the detection.
+}
+
+// A Detector is a [Provider] that combines other providers and thus has to forward
+// their optional capabilities, for example, the provider "fallback".
+type Detector interface {
+	Provider
+
+	Detect(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) (Detection, bool)
+	// Detect detects the IP addresses using the optional capabilities of the underlying providers.
+}
+
+// Detection is the outcome of [Detect].
+type Detection struct {
+	IPs        []netip.Addr // sorted and without duplicates
+	Notes      []string     // human-readable notes from an [Annotator]
+	Metadata   Metadata     // metadata from a [MetadataProvider]
+	Dissenters []string     // the names of the providers that failed or disagreed, such as in a quorum
+	Blocked    []netip.Addr // the detected addresses not allowed by the policy (see [WithPolicy])
+}
+
+// Detect detects the IP addresses using the optional capabilities of p. It calls [Detector.Detect]
+// if p is a [Detector], [MultiProvider.GetIPs] if p is a [MultiProvider],
+// [MetadataProvider.GetIPWithMetadata] if p is a [MetadataProvider],
+// and otherwise calls [GetIPWithNotes] to get one IP. The addresses not allowed by
+// the policy in ctx (see [WithPolicy]) are removed.
+func Detect(ctx context.Context, ppfmt pp.PP, p Provider, ipNet ipnet.Type) (Detection, bool) {
+	if p, ok := p.(Detector); ok {
+		// The policy was already applied to the underlying providers.
+		return p.Detect(ctx, ppfmt, ipNet)
+	}
+
+	detection, ok := detectOne(ctx, ppfmt, p, ipNet)
+	if !ok {
+		return detection, false
+	}
+	return filterAllowed(ctx, ppfmt, ipNet, detection)
+}
+
+// detectOne is [Detect] for a provider that is not a [Detector], without the policy.
+func detectOne(ctx context.Context, ppfmt pp.PP, p Provider, ipNet ipnet.Type) (Detection, bool) {
+	switch p := p.(type) {
+	case MultiProvider:
+		ips, ok := p.GetIPs(ctx, ppfmt, ipNet)
+		return Detection{IPs: ips, Notes: nil, Metadata: nil, Dissenters: nil, Blocked: nil}, ok
+
+	case MetadataProvider:
+		ip, metadata, ok := p.GetIPWithMetadata(ctx, ppfmt, ipNet)
+		if !ok {
+			return Detection{IPs: nil, Notes: nil, Metadata: nil, Dissenters: nil, Blocked: nil}, false
+		}
+		return Detection{IPs: []netip.Addr{ip}, Notes: nil, Metadata: metadata, Dissenters: nil, Blocked: nil}, true
+
+	default:
+		ip, notes, ok := GetIPWithNotes(ctx, ppfmt, p, ipNet)
+		if !ok {
+			return Detection{IPs: nil, Notes: notes, Metadata: nil, Dissenters: nil, Blocked: nil}, false
+		}
+		return Detection{IPs: []netip.Addr{ip}, Notes: notes, Metadata: nil, Dissenters: nil, Blocked: nil}, true
+	}
+}
+
+// DescribeMetadata describes how the request of the detection was routed, such as "via SJC, US, warp=off",
+// using the metadata reported by cloudflare.trace. Cloudflare Gateway is only mentioned when it is in use,
+// and other metadata (such as the TLS version) is left out. It returns "" if there is nothing to describe.
+func DescribeMetadata(m Metadata) string {
+	var parts []string
+	if colo := m["colo"]; colo != "" {
+		parts = append(parts, "via "+colo)
+	}
+	if loc := m["loc"]; loc != "" {
+		parts = append(parts, loc)
+	}
+	if warp, found := m["warp"]; found {
+		parts = append(parts, "warp="+warp)
+	}
+	if gateway, found := m["gateway"]; found && gateway != "off" {
+		parts = append(parts, "gateway="+gateway)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// GetIPWithNotes calls [Annotator.GetIPWithNotes] if p is an [Annotator],
+// and otherwise calls [Provider.GetIP] without any notes.
+func GetIPWithNotes(ctx context.Context, ppfmt pp.PP, p Provider, ipNet ipnet.Type) (netip.Addr, []string, bool) {
+	if a, ok := p.(Annotator); ok {
+		return a.GetIPWithNotes(ctx, ppfmt, ipNet)
+	}
+
+	ip, ok := p.GetIP(ctx, ppfmt, ipNet)
+	return ip, nil, ok
+}
+
 // Name gets the protocol name. It returns "none" for nil.
 func Name(p Provider) string {
 	if p == nil {
@@ -35,3 +157,6 @@ func Name(p Provider) string {
 func CloseIdleConnections() {
 	protocol.CloseIdleConnections()
 }
+
+// Binding restricts the sockets for detecting IP addresses to a network interface and/or a source address.
+type Binding = protocol.Binding
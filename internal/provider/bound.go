@@ -0,0 +1,84 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"strings"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+type bound struct {
+	binding  Binding
+	network  *protocol.BoundNetwork
+	provider Provider
+}
+
+// NewBound creates a provider that runs another provider with its own sockets bound to
+// a network interface and/or a source address. This is useful for machines with multiple uplinks.
+// If p is already bound, p is returned as it is, for its own binding takes precedence.
+func NewBound(ppfmt pp.PP, b Binding, p Provider) (Provider, bool) {
+	if _, ok := p.(bound); ok {
+		return p, true
+	}
+
+	network, err := protocol.NewBoundNetwork(b)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			ppfmt.Noticef(pp.EmojiUserError,
+				"Binding the detection to a network interface or a source address is only supported on Linux")
+		} else {
+			ppfmt.Noticef(pp.EmojiImpossible, "Failed to bind the detection to %s: %v", b.String(), err)
+		}
+		return nil, false
+	}
+
+	return bound{binding: b, network: network, provider: p}, true
+}
+
+// MustNewBound creates a bound provider and panics if it fails.
+func MustNewBound(b Binding, p Provider) Provider {
+	var buf strings.Builder
+	bp, ok := NewBound(pp.NewDefault(&buf), b, p)
+	if !ok {
+		panic(buf.String())
+	}
+	return bp
+}
+
+// Name of the detection protocol.
+func (p bound) Name() string {
+	return "bind:" + p.binding.String() + "/" + Name(p.provider)
+}
+
+// GetIP detects the IP address with the bound sockets.
+func (p bound) GetIP(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) (netip.Addr, bool) {
+	ip, _, ok := p.GetIPWithNotes(ctx, ppfmt, ipNet)
+	return ip, ok
+}
+
+// GetIPWithNotes detects the IP address with the bound sockets.
+// Only the first IP address is returned if the provider detected several addresses.
+func (p bound) GetIPWithNotes(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) (netip.Addr, []string, bool) {
+	detection, ok := p.Detect(ctx, ppfmt, ipNet)
+	if !ok || len(detection.IPs) == 0 {
+		return netip.Addr{}, nil, false
+	}
+	return detection.IPs[0], detection.Notes, true
+}
+
+// Detect detects the IP addresses with the bound sockets. The optional capabilities
+// of the provider, such as detecting several addresses or reporting metadata, are kept.
+func (p bound) Detect(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) (Detection, bool) {
+	if p.binding.Address.IsValid() && !ipNet.Matches(p.binding.Address) {
+		ppfmt.Noticef(pp.EmojiUserError, "The provider %s cannot detect %s addresses because %s is not an %s address",
+			p.Name(), ipNet.Describe(), p.binding.Address, ipNet.Describe())
+		return Detection{IPs: nil, Notes: nil, Metadata: nil, Dissenters: nil, Blocked: nil}, false
+	}
+
+	defer p.network.CloseIdleConnections()
+	return Detect(protocol.WithBoundNetwork(ctx, p.network), ppfmt, p.provider, ipNet)
+}
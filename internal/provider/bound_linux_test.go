@@ -0,0 +1,99 @@
+//go:build linux
+
+// vim: nowrap
+package provider_test
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+)
+
+func TestBoundName(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		binding  provider.Binding
+		expected string
+	}{
+		"iface":   {provider.Binding{Interface: "wan1", Address: netip.Addr{}}, "bind:wan1/cloudflare.trace"},
+		"address": {provider.Binding{Interface: "", Address: netip.MustParseAddr("192.168.1.2")}, "bind:@192.168.1.2/cloudflare.trace"},
+		"both":    {provider.Binding{Interface: "wan1", Address: netip.MustParseAddr("2001:db8::1")}, "bind:wan1@2001:db8::1/cloudflare.trace"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			p := provider.MustNewBound(tc.binding, provider.NewCloudflareTrace())
+			require.Equal(t, tc.expected, provider.Name(p))
+		})
+	}
+}
+
+func TestNewBoundNested(t *testing.T) {
+	t.Parallel()
+
+	inner := provider.MustNewBound(provider.Binding{Interface: "wan2", Address: netip.Addr{}}, provider.NewCloudflareTrace())
+	outer := provider.MustNewBound(provider.Binding{Interface: "wan1", Address: netip.Addr{}}, inner)
+	require.Equal(t, "bind:wan2/cloudflare.trace", provider.Name(outer))
+}
+
+func TestBoundDetect(t *testing.T) {
+	t.Parallel()
+
+	ip1 := netip.MustParseAddr("1.1.1.1")
+	ip2 := netip.MustParseAddr("2.2.2.2")
+
+	for name, tc := range map[string]struct {
+		address       netip.Addr
+		ok            bool
+		expected      provider.Detection
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"multi": {
+			netip.Addr{}, true,
+			provider.Detection{IPs: []netip.Addr{ip1, ip2}, Notes: nil, Metadata: nil, Dissenters: nil, Blocked: nil},
+			nil,
+		},
+		"address": {
+			netip.MustParseAddr("127.0.0.1"), true,
+			provider.Detection{IPs: []netip.Addr{ip1, ip2}, Notes: nil, Metadata: nil, Dissenters: nil, Blocked: nil},
+			nil,
+		},
+		"wrong-family": {
+			netip.MustParseAddr("::1"), false,
+			provider.Detection{IPs: nil, Notes: nil, Metadata: nil, Dissenters: nil, Blocked: nil},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, "The provider %s cannot detect %s addresses because %s is not an %s address", "bind:lo@::1/multi", "IPv4", netip.MustParseAddr("::1"), "IPv4")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			p := provider.MustNewBound(provider.Binding{Interface: "lo", Address: tc.address}, multiProvider{[]netip.Addr{ip1, ip2}})
+			detection, ok := provider.Detect(context.Background(), mockPP, p, ipnet.IP4)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, detection)
+
+			if tc.ok {
+				ip, ok := p.GetIP(context.Background(), mockPP, ipnet.IP4)
+				require.True(t, ok)
+				require.Equal(t, ip1, ip)
+			}
+		})
+	}
+}
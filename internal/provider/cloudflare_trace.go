@@ -1,13 +1,15 @@
 package provider
 
 import (
-	"regexp"
-
-	"github.com/favonia/cloudflare-ddns/internal/ipnet"
 	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
 )
 
-var fieldIP = regexp.MustCompile(`(?m:^ip=(.*)$)`)
+// traceMetadataKeys are the fields of https://api.cloudflare.com/cdn-cgi/trace kept as metadata:
+// the data center that handled the request ("colo"), the country ("loc"), whether Cloudflare WARP
+// ("warp") or Cloudflare Gateway ("gateway") was in use, and the HTTP and TLS versions ("http" and "tls").
+//
+//nolint:gochecknoglobals
+var traceMetadataKeys = []string{"colo", "loc", "warp", "gateway", "http", "tls"}
 
 // NewCloudflareTrace creates a specialized CloudflareTrace provider that parses https://1.1.1.1/cdn-cgi/trace.
 // If use1001 is true, 1.0.0.1 is used instead of 1.1.1.1.
@@ -18,11 +20,10 @@ func NewCloudflareTrace() Provider {
 // NewCloudflareTraceCustom creates a specialized CloudflareTrace provider
 // with a specific URL.
 func NewCloudflareTraceCustom(url string) Provider {
-	return protocol.Regexp{
+	return protocol.KeyValue{
 		ProviderName: "cloudflare.trace",
-		Param: map[ipnet.Type]protocol.RegexpParam{
-			ipnet.IP4: {url, fieldIP},
-			ipnet.IP6: {url, fieldIP},
-		},
+		URL:          url,
+		IPKey:        "ip",
+		MetadataKeys: traceMetadataKeys,
 	}
 }
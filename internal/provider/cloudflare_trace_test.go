@@ -13,3 +13,27 @@ func TestCloudflareTraceName(t *testing.T) {
 
 	require.Equal(t, "cloudflare.trace", provider.Name(provider.NewCloudflareTrace()))
 }
+
+func TestDescribeMetadata(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		metadata provider.Metadata
+		expected string
+	}{
+		"nil":   {nil, ""},
+		"empty": {provider.Metadata{}, ""},
+		"trace": {
+			provider.Metadata{"colo": "SJC", "loc": "US", "warp": "off", "gateway": "off", "http": "http/2", "tls": "TLSv1.3"},
+			"via SJC, US, warp=off",
+		},
+		"gateway": {provider.Metadata{"colo": "SJC", "warp": "on", "gateway": "on"}, "via SJC, warp=on, gateway=on"},
+		"loc":     {provider.Metadata{"loc": "US"}, "US"},
+		"other":   {provider.Metadata{"tls": "TLSv1.3"}, ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, provider.DescribeMetadata(tc.metadata))
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+// parseDNSServer parses "<host>[:<port>]" and returns the host and the address with the port.
+func parseDNSServer(ppfmt pp.PP, providerName, server, defaultPort string) (string, string, bool) {
+	server = strings.TrimSpace(server)
+
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		// Try again with the default port
+		host, port, err = net.SplitHostPort(net.JoinHostPort(strings.Trim(server, "[]"), defaultPort))
+	}
+	if err != nil || host == "" || port == "" || (strings.Contains(host, ":") && !isIPAddress(host)) {
+		ppfmt.Noticef(pp.EmojiUserError, `The provider %s does not contain a valid DNS server`, providerName)
+		return "", "", false
+	}
+
+	return host, net.JoinHostPort(host, port), true
+}
+
+func isIPAddress(host string) bool {
+	_, err := netip.ParseAddr(host)
+	return err == nil
+}
+
+func newCustomDNS(ppfmt pp.PP, scheme, spec string, useTLS bool) (Provider, bool) {
+	spec = strings.TrimSpace(spec)
+	providerName := scheme + ":" + spec
+
+	question, server, found := strings.Cut(spec, "@")
+	if !found {
+		ppfmt.Noticef(pp.EmojiUserError,
+			`The provider %s is not in the form %s:<name>[/<type>[/<class>]]@<server>`, providerName, scheme)
+		return nil, false
+	}
+
+	name, qtype, class, ok := parseDNSQuestion(ppfmt, providerName, question)
+	if !ok {
+		return nil, false
+	}
+
+	tlsServerName := ""
+	defaultPort := "53"
+	if useTLS {
+		defaultPort = "853"
+		server, tlsServerName, _ = strings.Cut(server, "#")
+		tlsServerName = strings.TrimSpace(tlsServerName)
+	}
+
+	host, server, ok := parseDNSServer(ppfmt, providerName, server, defaultPort)
+	if !ok {
+		return nil, false
+	}
+	if useTLS && tlsServerName == "" {
+		tlsServerName = host
+	}
+
+	return protocol.DNS{
+		ProviderName: providerName,
+		Param: map[ipnet.Type]protocol.DNSParam{
+			ipnet.IP4: {server, useTLS, tlsServerName, name, class, qtype[ipnet.IP4]},
+			ipnet.IP6: {server, useTLS, tlsServerName, name, class, qtype[ipnet.IP6]},
+		},
+		RootCAs: nil,
+	}, true
+}
+
+// NewCustomDNS creates a plain DNS provider from "<name>[/<type>[/<class>]]@<host>[:<port>]".
+func NewCustomDNS(ppfmt pp.PP, spec string) (Provider, bool) {
+	p, ok := newCustomDNS(ppfmt, "dns", spec, false)
+	if !ok {
+		return nil, false
+	}
+
+	ppfmt.Noticef(pp.EmojiUserWarning,
+		`The provider %s uses unencrypted DNS, which can be spoofed by forged packets; `+
+			`consider using "dot:" or "doh:" instead`, Name(p))
+	ppfmt.InfoOncef(pp.MessagePlainDNSProvider, pp.EmojiHint,
+		"Read more about DNS spoofing and the threat model of the updater at %s", pp.ThreatModelURL)
+	return p, true
+}
+
+// MustNewCustomDNS creates a plain DNS provider and panics if it fails.
+func MustNewCustomDNS(spec string) Provider {
+	var buf strings.Builder
+	p, ok := NewCustomDNS(pp.NewDefault(&buf), spec)
+	if !ok {
+		panic(buf.String())
+	}
+	return p
+}
+
+// NewCustomDOT creates a DNS-over-TLS provider from
+// "<name>[/<type>[/<class>]]@<host>[:<port>][#<TLS server name>]".
+// If the TLS server name is omitted, the host is used.
+func NewCustomDOT(ppfmt pp.PP, spec string) (Provider, bool) {
+	return newCustomDNS(ppfmt, "dot", spec, true)
+}
+
+// MustNewCustomDOT creates a DNS-over-TLS provider and panics if it fails.
+func MustNewCustomDOT(spec string) Provider {
+	var buf strings.Builder
+	p, ok := NewCustomDOT(pp.NewDefault(&buf), spec)
+	if !ok {
+		panic(buf.String())
+	}
+	return p
+}
@@ -0,0 +1,182 @@
+// vim: nowrap
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+func TestCustomDNSName(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "dns:myip.opendns.com@resolver1.opendns.com", provider.Name(provider.MustNewCustomDNS("myip.opendns.com@resolver1.opendns.com")))
+	require.Equal(t, "dot:whoami.cloudflare/TXT/CH@1.1.1.1", provider.Name(provider.MustNewCustomDOT("whoami.cloudflare/TXT/CH@1.1.1.1")))
+}
+
+func TestNewCustomDNS(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		input         string
+		expected      map[ipnet.Type]protocol.DNSParam
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"default-port": {
+			"myip.opendns.com@resolver1.opendns.com",
+			map[ipnet.Type]protocol.DNSParam{
+				ipnet.IP4: {"resolver1.opendns.com:53", false, "", "myip.opendns.com.", dnsmessage.ClassINET, dnsmessage.TypeA},
+				ipnet.IP6: {"resolver1.opendns.com:53", false, "", "myip.opendns.com.", dnsmessage.ClassINET, dnsmessage.TypeAAAA},
+			},
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Noticef(pp.EmojiUserWarning, `The provider %s uses unencrypted DNS, which can be spoofed by forged packets; consider using "dot:" or "doh:" instead`, "dns:myip.opendns.com@resolver1.opendns.com"),
+					m.EXPECT().InfoOncef(pp.MessagePlainDNSProvider, pp.EmojiHint, "Read more about DNS spoofing and the threat model of the updater at %s", pp.ThreatModelURL),
+				)
+			},
+		},
+		"ipv6-port": {
+			"whoami.cloudflare/TXT/CH@[2606:4700:4700::1111]:5353",
+			map[ipnet.Type]protocol.DNSParam{
+				ipnet.IP4: {"[2606:4700:4700::1111]:5353", false, "", "whoami.cloudflare.", dnsmessage.ClassCHAOS, dnsmessage.TypeTXT},
+				ipnet.IP6: {"[2606:4700:4700::1111]:5353", false, "", "whoami.cloudflare.", dnsmessage.ClassCHAOS, dnsmessage.TypeTXT},
+			},
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Noticef(pp.EmojiUserWarning, `The provider %s uses unencrypted DNS, which can be spoofed by forged packets; consider using "dot:" or "doh:" instead`, "dns:whoami.cloudflare/TXT/CH@[2606:4700:4700::1111]:5353"),
+					m.EXPECT().InfoOncef(pp.MessagePlainDNSProvider, pp.EmojiHint, "Read more about DNS spoofing and the threat model of the updater at %s", pp.ThreatModelURL),
+				)
+			},
+		},
+		"ipv6-default-port": {
+			"a@2606:4700:4700::1111",
+			map[ipnet.Type]protocol.DNSParam{
+				ipnet.IP4: {"[2606:4700:4700::1111]:53", false, "", "a.", dnsmessage.ClassINET, dnsmessage.TypeA},
+				ipnet.IP6: {"[2606:4700:4700::1111]:53", false, "", "a.", dnsmessage.ClassINET, dnsmessage.TypeAAAA},
+			},
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Noticef(pp.EmojiUserWarning, `The provider %s uses unencrypted DNS, which can be spoofed by forged packets; consider using "dot:" or "doh:" instead`, "dns:a@2606:4700:4700::1111"),
+					m.EXPECT().InfoOncef(pp.MessagePlainDNSProvider, pp.EmojiHint, "Read more about DNS spoofing and the threat model of the updater at %s", pp.ThreatModelURL),
+				)
+			},
+		},
+		"no-at": {
+			"resolver1.opendns.com", nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s is not in the form %s:<name>[/<type>[/<class>]]@<server>`, "dns:resolver1.opendns.com", "dns")
+			},
+		},
+		"bad-type": {
+			"a/MX@resolver1.opendns.com", nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s has an unsupported record type %q (only TXT, A, and AAAA are supported)`, "dns:a/MX@resolver1.opendns.com", "MX")
+			},
+		},
+		"empty-server": {
+			"a@", nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s does not contain a valid DNS server`, "dns:a@")
+			},
+		},
+		"bad-server": {
+			"a@1.1.1.1:53:53", nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s does not contain a valid DNS server`, "dns:a@1.1.1.1:53:53")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			p, ok := provider.NewCustomDNS(mockPP, tc.input)
+			if tc.expected == nil {
+				require.False(t, ok)
+				require.Nil(t, p)
+				require.Panics(t, func() { provider.MustNewCustomDNS(tc.input) })
+			} else {
+				require.True(t, ok)
+				require.Equal(t, protocol.DNS{ProviderName: "dns:" + tc.input, Param: tc.expected, RootCAs: nil}, p)
+			}
+		})
+	}
+}
+
+func TestNewCustomDOT(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		input         string
+		expected      map[ipnet.Type]protocol.DNSParam
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"default-port": {
+			"whoami.cloudflare/TXT/CH@1.1.1.1",
+			map[ipnet.Type]protocol.DNSParam{
+				ipnet.IP4: {"1.1.1.1:853", true, "1.1.1.1", "whoami.cloudflare.", dnsmessage.ClassCHAOS, dnsmessage.TypeTXT},
+				ipnet.IP6: {"1.1.1.1:853", true, "1.1.1.1", "whoami.cloudflare.", dnsmessage.ClassCHAOS, dnsmessage.TypeTXT},
+			},
+			nil,
+		},
+		"server-name": {
+			"whoami.cloudflare/TXT/CH@1.1.1.1:8853#one.one.one.one",
+			map[ipnet.Type]protocol.DNSParam{
+				ipnet.IP4: {"1.1.1.1:8853", true, "one.one.one.one", "whoami.cloudflare.", dnsmessage.ClassCHAOS, dnsmessage.TypeTXT},
+				ipnet.IP6: {"1.1.1.1:8853", true, "one.one.one.one", "whoami.cloudflare.", dnsmessage.ClassCHAOS, dnsmessage.TypeTXT},
+			},
+			nil,
+		},
+		"host-name": {
+			"myip.example.org@dns.example",
+			map[ipnet.Type]protocol.DNSParam{
+				ipnet.IP4: {"dns.example:853", true, "dns.example", "myip.example.org.", dnsmessage.ClassINET, dnsmessage.TypeA},
+				ipnet.IP6: {"dns.example:853", true, "dns.example", "myip.example.org.", dnsmessage.ClassINET, dnsmessage.TypeAAAA},
+			},
+			nil,
+		},
+		"no-at": {
+			"1.1.1.1", nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s is not in the form %s:<name>[/<type>[/<class>]]@<server>`, "dot:1.1.1.1", "dot")
+			},
+		},
+		"empty-server": {
+			"a@#one.one.one.one", nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s does not contain a valid DNS server`, "dot:a@#one.one.one.one")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			p, ok := provider.NewCustomDOT(mockPP, tc.input)
+			if tc.expected == nil {
+				require.False(t, ok)
+				require.Nil(t, p)
+				require.Panics(t, func() { provider.MustNewCustomDOT(tc.input) })
+			} else {
+				require.True(t, ok)
+				require.Equal(t, protocol.DNS{ProviderName: "dot:" + tc.input, Param: tc.expected, RootCAs: nil}, p)
+			}
+		})
+	}
+}
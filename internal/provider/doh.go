@@ -0,0 +1,113 @@
+package provider
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+// parseDNSQuestion parses "<name>[/<type>[/<class>]]" for the providers based on DNS queries.
+// If the type is omitted, A is used for IPv4 and AAAA is used for IPv6.
+func parseDNSQuestion(ppfmt pp.PP, providerName, question string,
+) (string, map[ipnet.Type]dnsmessage.Type, dnsmessage.Class, bool) {
+	parts := strings.Split(question, "/")
+	if len(parts) > 3 {
+		ppfmt.Noticef(pp.EmojiUserError,
+			`The provider %s has too many parts in the DNS question %q`, providerName, question)
+		return "", nil, 0, false
+	}
+
+	name := strings.TrimSpace(parts[0])
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	if _, err := dnsmessage.NewName(name); err != nil || name == "." || strings.Contains(name, "..") {
+		ppfmt.Noticef(pp.EmojiUserError, `The provider %s has an invalid domain name %q`, providerName, parts[0])
+		return "", nil, 0, false
+	}
+
+	qtype := map[ipnet.Type]dnsmessage.Type{ipnet.IP4: dnsmessage.TypeA, ipnet.IP6: dnsmessage.TypeAAAA}
+	if len(parts) >= 2 {
+		switch strings.ToUpper(strings.TrimSpace(parts[1])) {
+		case "TXT":
+			qtype = map[ipnet.Type]dnsmessage.Type{ipnet.IP4: dnsmessage.TypeTXT, ipnet.IP6: dnsmessage.TypeTXT}
+		case "A":
+			qtype = map[ipnet.Type]dnsmessage.Type{ipnet.IP4: dnsmessage.TypeA, ipnet.IP6: dnsmessage.TypeA}
+		case "AAAA":
+			qtype = map[ipnet.Type]dnsmessage.Type{ipnet.IP4: dnsmessage.TypeAAAA, ipnet.IP6: dnsmessage.TypeAAAA}
+		default:
+			ppfmt.Noticef(pp.EmojiUserError,
+				`The provider %s has an unsupported record type %q (only TXT, A, and AAAA are supported)`,
+				providerName, parts[1])
+			return "", nil, 0, false
+		}
+	}
+
+	class := dnsmessage.ClassINET
+	if len(parts) >= 3 {
+		switch strings.ToUpper(strings.TrimSpace(parts[2])) {
+		case "IN":
+			class = dnsmessage.ClassINET
+		case "CH", "CHAOS":
+			class = dnsmessage.ClassCHAOS
+		default:
+			ppfmt.Noticef(pp.EmojiUserError,
+				`The provider %s has an unsupported class %q (only IN and CH are supported)`,
+				providerName, parts[2])
+			return "", nil, 0, false
+		}
+	}
+
+	return name, qtype, class, true
+}
+
+// NewCustomDOH creates a DNS-over-HTTPS provider from "<name>[/<type>[/<class>]]@<URL>".
+func NewCustomDOH(ppfmt pp.PP, spec string) (Provider, bool) {
+	const providerName = "doh:(redacted)"
+
+	question, rawURL, found := strings.Cut(spec, "@")
+	if !found {
+		ppfmt.Noticef(pp.EmojiUserError,
+			`The provider %s is not in the form doh:<name>[/<type>[/<class>]]@<URL>`, providerName)
+		return nil, false
+	}
+
+	name, qtype, class, ok := parseDNSQuestion(ppfmt, providerName, question)
+	if !ok {
+		return nil, false
+	}
+
+	rawURL = strings.TrimSpace(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil || !u.IsAbs() || u.Opaque != "" || u.Host == "" {
+		ppfmt.Noticef(pp.EmojiUserError, `The provider %s does not contain a valid URL`, providerName)
+		return nil, false
+	}
+	if u.Scheme != "https" {
+		ppfmt.Noticef(pp.EmojiUserError, `The provider %s only supports HTTPS`, providerName)
+		return nil, false
+	}
+
+	return protocol.DNSOverHTTPS{
+		ProviderName: providerName,
+		Param: map[ipnet.Type]protocol.DNSOverHTTPSParam{
+			ipnet.IP4: {rawURL, name, class, qtype[ipnet.IP4]},
+			ipnet.IP6: {rawURL, name, class, qtype[ipnet.IP6]},
+		},
+	}, true
+}
+
+// MustNewCustomDOH creates a DNS-over-HTTPS provider and panics if it fails.
+func MustNewCustomDOH(spec string) Provider {
+	var buf strings.Builder
+	p, ok := NewCustomDOH(pp.NewDefault(&buf), spec)
+	if !ok {
+		panic(buf.String())
+	}
+	return p
+}
@@ -0,0 +1,134 @@
+// vim: nowrap
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+func TestCustomDOHName(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "doh:(redacted)", provider.Name(provider.MustNewCustomDOH("myip.opendns.com@https://doh.opendns.com/dns-query")))
+}
+
+func TestNewCustomDOH(t *testing.T) {
+	t.Parallel()
+
+	const url = "https://dns.example/dns-query"
+
+	for name, tc := range map[string]struct {
+		input         string
+		expected      map[ipnet.Type]protocol.DNSOverHTTPSParam
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"default-type": {
+			"myip.example.org@" + url,
+			map[ipnet.Type]protocol.DNSOverHTTPSParam{
+				ipnet.IP4: {url, "myip.example.org.", dnsmessage.ClassINET, dnsmessage.TypeA},
+				ipnet.IP6: {url, "myip.example.org.", dnsmessage.ClassINET, dnsmessage.TypeAAAA},
+			},
+			nil,
+		},
+		"txt": {
+			" o-o.myaddr.example.org. / txt @ " + url + " ",
+			map[ipnet.Type]protocol.DNSOverHTTPSParam{
+				ipnet.IP4: {url, "o-o.myaddr.example.org.", dnsmessage.ClassINET, dnsmessage.TypeTXT},
+				ipnet.IP6: {url, "o-o.myaddr.example.org.", dnsmessage.ClassINET, dnsmessage.TypeTXT},
+			},
+			nil,
+		},
+		"chaos": {
+			"whoami.cloudflare/TXT/CH@" + url,
+			map[ipnet.Type]protocol.DNSOverHTTPSParam{
+				ipnet.IP4: {url, "whoami.cloudflare.", dnsmessage.ClassCHAOS, dnsmessage.TypeTXT},
+				ipnet.IP6: {url, "whoami.cloudflare.", dnsmessage.ClassCHAOS, dnsmessage.TypeTXT},
+			},
+			nil,
+		},
+		"aaaa": {
+			"myip.example.org/AAAA/IN@" + url,
+			map[ipnet.Type]protocol.DNSOverHTTPSParam{
+				ipnet.IP4: {url, "myip.example.org.", dnsmessage.ClassINET, dnsmessage.TypeAAAA},
+				ipnet.IP6: {url, "myip.example.org.", dnsmessage.ClassINET, dnsmessage.TypeAAAA},
+			},
+			nil,
+		},
+		"no-at": {
+			url, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s is not in the form doh:<name>[/<type>[/<class>]]@<URL>`, "doh:(redacted)")
+			},
+		},
+		"too-many-parts": {
+			"a/TXT/IN/x@" + url, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s has too many parts in the DNS question %q`, "doh:(redacted)", "a/TXT/IN/x")
+			},
+		},
+		"empty-name": {
+			"@" + url, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s has an invalid domain name %q`, "doh:(redacted)", "")
+			},
+		},
+		"bad-name": {
+			"a..b@" + url, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s has an invalid domain name %q`, "doh:(redacted)", "a..b")
+			},
+		},
+		"bad-type": {
+			"a/MX@" + url, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s has an unsupported record type %q (only TXT, A, and AAAA are supported)`, "doh:(redacted)", "MX")
+			},
+		},
+		"bad-class": {
+			"a/TXT/HS@" + url, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s has an unsupported class %q (only IN and CH are supported)`, "doh:(redacted)", "HS")
+			},
+		},
+		"bad-url": {
+			"a@dns.example", nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s does not contain a valid URL`, "doh:(redacted)")
+			},
+		},
+		"http": {
+			"a@http://dns.example/dns-query", nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s only supports HTTPS`, "doh:(redacted)")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			p, ok := provider.NewCustomDOH(mockPP, tc.input)
+			if tc.expected == nil {
+				require.False(t, ok)
+				require.Nil(t, p)
+				require.Panics(t, func() { provider.MustNewCustomDOH(tc.input) })
+			} else {
+				require.True(t, ok)
+				require.Equal(t, protocol.DNSOverHTTPS{ProviderName: "doh:(redacted)", Param: tc.expected}, p)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package provider
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+// NewExec creates a provider that runs the command at path.
+func NewExec(ppfmt pp.PP, path string) (Provider, bool) {
+	if !filepath.IsAbs(path) {
+		ppfmt.Noticef(pp.EmojiUserError, `The provider "exec:%s" does not contain an absolute path`, path)
+		return nil, false
+	}
+
+	return protocol.Exec{
+		ProviderName: "exec:" + path,
+		Path:         path,
+	}, true
+}
+
+// MustNewExec creates a provider that runs the command at path and panics if it fails.
+func MustNewExec(path string) Provider {
+	var buf strings.Builder
+	p, ok := NewExec(pp.NewDefault(&buf), path)
+	if !ok {
+		panic(buf.String())
+	}
+	return p
+}
@@ -0,0 +1,56 @@
+// vim: nowrap
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+func TestExecName(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "exec:/usr/local/bin/wan-ip", provider.Name(provider.MustNewExec("/usr/local/bin/wan-ip")))
+}
+
+func TestNewExec(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		input         string
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"absolute": {"/usr/local/bin/wan-ip", true, nil},
+		"relative": {
+			"wan-ip", false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider "exec:%s" does not contain an absolute path`, "wan-ip")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			p, ok := provider.NewExec(mockPP, tc.input)
+			require.Equal(t, tc.ok, ok)
+			if tc.ok {
+				require.Equal(t, protocol.Exec{ProviderName: "exec:" + tc.input, Path: tc.input}, p)
+			} else {
+				require.Nil(t, p)
+				require.Panics(t, func() { provider.MustNewExec(tc.input) })
+			}
+		})
+	}
+}
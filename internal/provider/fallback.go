@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+type fallback struct {
+	providers []Provider
+}
+
+// NewFallback creates a provider that tries the given providers one by one
+// until one of them succeeds.
+func NewFallback(ppfmt pp.PP, providers []Provider) (Provider, bool) {
+	if len(providers) < 2 {
+		ppfmt.Noticef(pp.EmojiUserError,
+			`The provider "fallback" needs at least 2 providers, but only %d was given`, len(providers))
+		return nil, false
+	}
+
+	return fallback{providers: providers}, true
+}
+
+// MustNewFallback creates a fallback provider and panics if it fails.
+func MustNewFallback(providers []Provider) Provider {
+	var buf strings.Builder
+	p, ok := NewFallback(pp.NewDefault(&buf), providers)
+	if !ok {
+		panic(buf.String())
+	}
+	return p
+}
+
+// Name of the detection protocol.
+func (p fallback) Name() string {
+	names := make([]string, 0, len(p.providers))
+	for _, sub := range p.providers {
+		names = append(names, Name(sub))
+	}
+	return "fallback:" + strings.Join(names, ",")
+}
+
+// GetIP detects the IP address by trying the providers in order.
+func (p fallback) GetIP(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) (netip.Addr, bool) {
+	ip, _, ok := p.GetIPWithNotes(ctx, ppfmt, ipNet)
+	return ip, ok
+}
+
+// GetIPWithNotes detects the IP address by trying the providers in order.
+// Only the first IP address is returned if the successful provider detected several addresses.
+func (p fallback) GetIPWithNotes(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) (netip.Addr, []string, bool) {
+	detection, ok := p.Detect(ctx, ppfmt, ipNet)
+	if !ok || len(detection.IPs) == 0 {
+		return netip.Addr{}, nil, false
+	}
+	return detection.IPs[0], detection.Notes, true
+}
+
+// Detect detects the IP addresses by trying the providers in order. The optional capabilities
+// of the providers, such as detecting several addresses or reporting metadata, are kept.
+// If the context has a deadline, each provider gets an equal share of the remaining time,
+// and the time not used by a provider is passed on to the next ones. A provider whose addresses
+// are all blocked by the policy (see [WithPolicy]) fails, and the blocked addresses are kept.
+func (p fallback) Detect(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) (Detection, bool) {
+	var blocked []netip.Addr
+	for i, sub := range p.providers {
+		if i > 0 {
+			ppfmt.Infof(pp.EmojiSwitch, "Falling back to the provider %s", Name(sub))
+		}
+
+		subCtx, cancel := ctx, func() {}
+		if deadline, ok := ctx.Deadline(); ok {
+			share := time.Until(deadline) / time.Duration(len(p.providers)-i)
+			subCtx, cancel = context.WithTimeout(ctx, share)
+		}
+		detection, ok := Detect(subCtx, ppfmt, sub, ipNet)
+		cancel()
+
+		blocked = append(blocked, detection.Blocked...)
+		if ok {
+			detection.Blocked = blocked
+			ppfmt.Infof(pp.EmojiInternet, "The %s address was detected by the provider %s", ipNet.Describe(), Name(sub))
+			return detection, true
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return Detection{IPs: nil, Notes: nil, Metadata: nil, Dissenters: nil, Blocked: blocked}, false
+}
@@ -0,0 +1,216 @@
+// vim: nowrap
+package provider_test
+
+import (
+	"context"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+)
+
+func TestFallbackName(t *testing.T) {
+	t.Parallel()
+
+	p := provider.MustNewFallback([]provider.Provider{
+		provider.NewLocalWithInterface("eth0"),
+		provider.NewCloudflareTrace(),
+		provider.NewCloudflareDOH(),
+	})
+	require.Equal(t, "fallback:local.iface:eth0,cloudflare.trace,cloudflare.doh", provider.Name(p))
+}
+
+func TestNewFallback(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Noticef(pp.EmojiUserError, `The provider "fallback" needs at least 2 providers, but only %d was given`, 1)
+	p, ok := provider.NewFallback(mockPP, []provider.Provider{provider.NewCloudflareTrace()})
+	require.False(t, ok)
+	require.Nil(t, p)
+
+	require.Panics(t, func() { provider.MustNewFallback(nil) })
+}
+
+func TestFallbackGetIP(t *testing.T) {
+	t.Parallel()
+
+	ip1 := netip.MustParseAddr("1.1.1.1")
+	ip2 := netip.MustParseAddr("2.2.2.2")
+	invalidIP := netip.Addr{}
+
+	type result struct {
+		ip netip.Addr
+		ok bool
+	}
+
+	for name, tc := range map[string]struct {
+		results       []result
+		expected      netip.Addr
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"first": {
+			[]result{{ip1, true}, {ip2, true}},
+			ip1, true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiInternet, "The %s address was detected by the provider %s", "IPv4", "p0")
+			},
+		},
+		"second": {
+			[]result{{invalidIP, false}, {ip2, true}},
+			ip2, true,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Infof(pp.EmojiSwitch, "Falling back to the provider %s", "p1"),
+					m.EXPECT().Infof(pp.EmojiInternet, "The %s address was detected by the provider %s", "IPv4", "p1"),
+				)
+			},
+		},
+		"none": {
+			[]result{{invalidIP, false}, {invalidIP, false}},
+			invalidIP, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiSwitch, "Falling back to the provider %s", "p1")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			providers := make([]provider.Provider, 0, len(tc.results))
+			for i, r := range tc.results {
+				m := mocks.NewMockProvider(mockCtrl)
+				m.EXPECT().Name().Return("p" + strconv.Itoa(i)).AnyTimes()
+				m.EXPECT().GetIP(gomock.Any(), mockPP, ipnet.IP4).Return(r.ip, r.ok).MaxTimes(1)
+				providers = append(providers, m)
+			}
+
+			ip, ok := provider.MustNewFallback(providers).GetIP(context.Background(), mockPP, ipnet.IP4)
+			require.Equal(t, tc.expected, ip)
+			require.Equal(t, tc.ok, ok)
+		})
+	}
+}
+
+func TestFallbackGetIPTimeoutShare(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+
+	ip := netip.MustParseAddr("1.1.1.1")
+	ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+
+	slow := mocks.NewMockProvider(mockCtrl)
+	slow.EXPECT().Name().Return("slow").AnyTimes()
+	slow.EXPECT().GetIP(gomock.Any(), mockPP, ipnet.IP4).DoAndReturn(
+		func(ctx context.Context, _ pp.PP, _ ipnet.Type) (netip.Addr, bool) {
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok)
+			require.LessOrEqual(t, time.Until(deadline), 200*time.Millisecond)
+			<-ctx.Done()
+			return netip.Addr{}, false
+		})
+	fast := mocks.NewMockProvider(mockCtrl)
+	fast.EXPECT().Name().Return("fast").AnyTimes()
+	fast.EXPECT().GetIP(gomock.Any(), mockPP, ipnet.IP4).DoAndReturn(
+		func(ctx context.Context, _ pp.PP, _ ipnet.Type) (netip.Addr, bool) {
+			require.NoError(t, ctx.Err())
+			return ip, true
+		})
+
+	gomock.InOrder(
+		mockPP.EXPECT().Infof(pp.EmojiSwitch, "Falling back to the provider %s", "fast"),
+		mockPP.EXPECT().Infof(pp.EmojiInternet, "The %s address was detected by the provider %s", "IPv4", "fast"),
+	)
+
+	detected, ok := provider.MustNewFallback([]provider.Provider{slow, fast}).GetIP(ctx, mockPP, ipnet.IP4)
+	require.True(t, ok)
+	require.Equal(t, ip, detected)
+}
+
+type multiProvider struct{ ips []netip.Addr }
+
+func (multiProvider) Name() string { return "multi" }
+
+func (p multiProvider) GetIP(context.Context, pp.PP, ipnet.Type) (netip.Addr, bool) {
+	return p.ips[0], true
+}
+
+func (p multiProvider) GetIPs(context.Context, pp.PP, ipnet.Type) ([]netip.Addr, bool) {
+	return p.ips, true
+}
+
+type metadataProvider struct {
+	ip       netip.Addr
+	metadata provider.Metadata
+}
+
+func (metadataProvider) Name() string { return "metadata" }
+
+func (p metadataProvider) GetIP(context.Context, pp.PP, ipnet.Type) (netip.Addr, bool) {
+	return p.ip, true
+}
+
+func (p metadataProvider) GetIPWithMetadata(context.Context, pp.PP, ipnet.Type) (netip.Addr, provider.Metadata, bool) {
+	return p.ip, p.metadata, true
+}
+
+func TestFallbackDetect(t *testing.T) {
+	t.Parallel()
+
+	ip1 := netip.MustParseAddr("1.1.1.1")
+	ip2 := netip.MustParseAddr("2.2.2.2")
+	metadata := provider.Metadata{"colo": "SJC"}
+
+	for name, tc := range map[string]struct {
+		sub      provider.Provider
+		expected provider.Detection
+	}{
+		"multi": {
+			multiProvider{[]netip.Addr{ip1, ip2}},
+			provider.Detection{IPs: []netip.Addr{ip1, ip2}, Notes: nil, Metadata: nil, Dissenters: nil, Blocked: nil},
+		},
+		"metadata": {
+			metadataProvider{ip1, metadata},
+			provider.Detection{IPs: []netip.Addr{ip1}, Notes: nil, Metadata: metadata, Dissenters: nil, Blocked: nil},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			gomock.InOrder(
+				mockPP.EXPECT().Infof(pp.EmojiSwitch, "Falling back to the provider %s", tc.sub.Name()),
+				mockPP.EXPECT().Infof(pp.EmojiInternet, "The %s address was detected by the provider %s", "IPv4", tc.sub.Name()),
+			)
+
+			failing := mocks.NewMockProvider(mockCtrl)
+			failing.EXPECT().Name().Return("failing").AnyTimes()
+			failing.EXPECT().GetIP(gomock.Any(), mockPP, ipnet.IP4).Return(netip.Addr{}, false)
+
+			p := provider.MustNewFallback([]provider.Provider{failing, tc.sub})
+			detection, ok := provider.Detect(context.Background(), mockPP, p, ipnet.IP4)
+			require.True(t, ok)
+			require.Equal(t, tc.expected, detection)
+		})
+	}
+}
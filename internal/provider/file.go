@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+// DefaultFileMaxAge is the maximum age of the file read by the provider "file" when it is not specified.
+const DefaultFileMaxAge = 24 * time.Hour
+
+// NewFile creates a provider that reads the IP address from the file at path.
+// The file is considered stale if it was not modified within maxAge; zero means no limit.
+func NewFile(ppfmt pp.PP, path string, maxAge time.Duration) (Provider, bool) {
+	if !filepath.IsAbs(path) {
+		ppfmt.Noticef(pp.EmojiUserError, `The provider "file:%s" does not contain an absolute path`, path)
+		return nil, false
+	}
+
+	return protocol.File{
+		ProviderName: "file:" + path + "@" + maxAge.String(),
+		Path:         path,
+		MaxAge:       maxAge,
+	}, true
+}
+
+// MustNewFile creates a provider that reads the IP address from a file and panics if it fails.
+func MustNewFile(path string, maxAge time.Duration) Provider {
+	var buf strings.Builder
+	p, ok := NewFile(pp.NewDefault(&buf), path, maxAge)
+	if !ok {
+		panic(buf.String())
+	}
+	return p
+}
+
+// NewCustomFile creates a provider from "<path>[@<max-age>]", where the maximum age is
+// a duration such as "10m" and defaults to [DefaultFileMaxAge]. The maximum age "0" means no limit.
+// The last "@" is treated as the start of the maximum age only if it is not followed by "/",
+// so that paths such as "/run/user@1000/wan-ip" still work.
+func NewCustomFile(ppfmt pp.PP, spec string) (Provider, bool) {
+	path, maxAge := spec, DefaultFileMaxAge
+	if i := strings.LastIndex(spec, "@"); i >= 0 && !strings.Contains(spec[i+1:], "/") {
+		path = strings.TrimSpace(spec[:i])
+		rawMaxAge := strings.TrimSpace(spec[i+1:])
+
+		var err error
+		maxAge, err = time.ParseDuration(rawMaxAge)
+		if err != nil || maxAge < 0 {
+			ppfmt.Noticef(pp.EmojiUserError,
+				`The provider "file:%s" has an invalid maximum age %q (it should be a non-negative duration such as "10m")`,
+				path, rawMaxAge)
+			return nil, false
+		}
+	}
+
+	return NewFile(ppfmt, path, maxAge)
+}
@@ -0,0 +1,113 @@
+// vim: nowrap
+package provider_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+func TestFileName(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "file:/run/wan-ip@0s", provider.Name(provider.MustNewFile("/run/wan-ip", 0)))
+	require.Equal(t, "file:/run/wan-ip@10m0s", provider.Name(provider.MustNewFile("/run/wan-ip", 10*time.Minute)))
+}
+
+func TestNewFile(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		input         string
+		maxAge        time.Duration
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"absolute":        {"/run/wan-ip", 0, true, nil},
+		"absolute/maxage": {"/run/wan-ip", time.Hour, true, nil},
+		"relative": {
+			"wan-ip", 0, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider "file:%s" does not contain an absolute path`, "wan-ip")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			p, ok := provider.NewFile(mockPP, tc.input, tc.maxAge)
+			require.Equal(t, tc.ok, ok)
+			if tc.ok {
+				require.Equal(t, protocol.File{ProviderName: "file:" + tc.input + "@" + tc.maxAge.String(), Path: tc.input, MaxAge: tc.maxAge}, p)
+			} else {
+				require.Nil(t, p)
+				require.Panics(t, func() { provider.MustNewFile(tc.input, tc.maxAge) })
+			}
+		})
+	}
+}
+
+func TestNewCustomFile(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		input         string
+		path          string
+		maxAge        time.Duration
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"default":         {"/run/wan-ip", "/run/wan-ip", provider.DefaultFileMaxAge, true, nil},
+		"max-age":         {"/run/wan-ip @ 10m", "/run/wan-ip", 10 * time.Minute, true, nil},
+		"no-limit":        {"/run/wan-ip@0", "/run/wan-ip", 0, true, nil},
+		"at-in-directory": {"/run/user@1000/wan-ip", "/run/user@1000/wan-ip", provider.DefaultFileMaxAge, true, nil},
+		"at-in-both":      {"/run/user@1000/wan-ip@1h", "/run/user@1000/wan-ip", time.Hour, true, nil},
+		"invalid-max-age": {
+			"/run/wan-ip@soon", "", 0, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider "file:%s" has an invalid maximum age %q (it should be a non-negative duration such as "10m")`, "/run/wan-ip", "soon")
+			},
+		},
+		"negative-max-age": {
+			"/run/wan-ip@-1h", "", 0, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider "file:%s" has an invalid maximum age %q (it should be a non-negative duration such as "10m")`, "/run/wan-ip", "-1h")
+			},
+		},
+		"relative": {
+			"wan-ip@1h", "", 0, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider "file:%s" does not contain an absolute path`, "wan-ip")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			p, ok := provider.NewCustomFile(mockPP, tc.input)
+			require.Equal(t, tc.ok, ok)
+			if tc.ok {
+				require.Equal(t, provider.MustNewFile(tc.path, tc.maxAge), p)
+			} else {
+				require.Nil(t, p)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package provider
+
+import (
+	"net/netip"
+
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+// NewGateway creates a provider that asks the default gateway for its external IPv4 address.
+func NewGateway() Provider {
+	return protocol.Gateway{
+		ProviderName:    "gateway",
+		Gateway:         netip.Addr{}, // use the default gateway
+		SSDPAddr:        "239.255.255.250:1900",
+		PortMappingPort: 5351,
+	}
+}
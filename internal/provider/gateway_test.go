@@ -0,0 +1,15 @@
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+)
+
+func TestGatewayName(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "gateway", provider.Name(provider.NewGateway()))
+}
@@ -1,11 +1,161 @@
 package provider
 
-import "github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
 
 // NewLocalWithInterface creates a protocol.LocalWithInterface provider.
 func NewLocalWithInterface(iface string) Provider {
 	return protocol.LocalWithInterface{
 		ProviderName:  "local.iface:" + iface,
 		InterfaceName: iface,
+		Policy:        protocol.InterfacePolicy{}, //nolint:exhaustruct
+		All:           false,
+	}
+}
+
+// parseInterfaceSpec parses "<iface>[@<option>+<option>...]" where each option is
+// "prefix=<prefix>" or a preference ("stable", "eui64", "iid=<interface identifier>",
+// or "longest-lived"). Preferences are in the order of importance and are only allowed
+// when allowPreferences is true. The returned name contains the normalized options.
+func parseInterfaceSpec(ppfmt pp.PP, method string, spec string, allowPreferences bool,
+) (string, protocol.InterfacePolicy, string, bool) {
+	iface, rawOptions, hasOptions := strings.Cut(spec, "@")
+	iface = strings.TrimSpace(iface)
+	providerName := method + ":" + iface
+
+	var policy protocol.InterfacePolicy
+	if !hasOptions {
+		return iface, policy, providerName, true
+	}
+
+	options := strings.Split(rawOptions, "+")
+	normalized := make([]string, 0, len(options))
+	hasPrefix := false
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+		key, value, hasValue := strings.Cut(option, "=")
+		key = strings.ToLower(key)
+
+		if key == "prefix" {
+			p, err := netip.ParsePrefix(value)
+			if err != nil {
+				ppfmt.Noticef(pp.EmojiUserError, `The provider %s has an invalid prefix %q: %v`, providerName, value, err)
+				return "", policy, "", false
+			}
+			if hasPrefix {
+				ppfmt.Noticef(pp.EmojiUserError, `The provider %s has more than one prefix`, providerName)
+				return "", policy, "", false
+			}
+			hasPrefix = true
+			policy.Prefix = p.Masked()
+			normalized = append(normalized, "prefix="+policy.Prefix.String())
+			continue
+		}
+
+		if hasValue && (key == "stable" || key == "eui64" || key == "longest-lived") {
+			ppfmt.Noticef(pp.EmojiUserError, `The provider %s has the option %q, but %s takes no value`,
+				providerName, option, key)
+			return "", policy, "", false
+		}
+
+		var preference protocol.InterfacePreference
+		switch key {
+		case "stable":
+			preference = protocol.PreferStable
+		case "eui64":
+			preference = protocol.PreferEUI64
+		case "longest-lived":
+			preference = protocol.PreferLongestLived
+		case "iid":
+			iid, err := netip.ParseAddr(value)
+			if err != nil || !iid.Is6() || iid.Zone() != "" {
+				ppfmt.Noticef(pp.EmojiUserError,
+					`The provider %s has an invalid interface identifier %q (it should look like "::1:2:3:4")`,
+					providerName, value)
+				return "", policy, "", false
+			}
+			policy.InterfaceID = iid
+			preference = protocol.PreferInterfaceID
+			key = "iid=" + iid.String()
+		default:
+			ppfmt.Noticef(pp.EmojiUserError,
+				`The provider %s has an unknown option %q (expecting prefix=<prefix>, stable, eui64, iid=<interface identifier>, or longest-lived)`,
+				providerName, option)
+			return "", policy, "", false
+		}
+
+		if !allowPreferences {
+			ppfmt.Noticef(pp.EmojiUserError,
+				`The provider %s cannot take the preference %q because it uses all the addresses`,
+				providerName, option)
+			return "", policy, "", false
+		}
+		policy.Preferences = append(policy.Preferences, preference)
+		normalized = append(normalized, key)
+	}
+
+	return iface, policy, providerName + "@" + strings.Join(normalized, "+"), true
+}
+
+// NewCustomLocalWithInterface creates a protocol.LocalWithInterface provider from
+// "<iface>[@<option>+<option>...]". If the option "prefix=<prefix>" is given, only addresses
+// within the prefix are considered. The other options are preferences, each of which is one of
+// "stable", "eui64", "iid=<interface identifier>", and "longest-lived", in the order of importance.
+func NewCustomLocalWithInterface(ppfmt pp.PP, spec string) (Provider, bool) {
+	iface, policy, providerName, ok := parseInterfaceSpec(ppfmt, "local.iface", spec, true)
+	if !ok {
+		return nil, false
+	}
+
+	return protocol.LocalWithInterface{
+		ProviderName:  providerName,
+		InterfaceName: iface,
+		Policy:        policy,
+		All:           false,
+	}, true
+}
+
+// MustNewCustomLocalWithInterface creates a protocol.LocalWithInterface provider from
+// a specification and panics if it fails.
+func MustNewCustomLocalWithInterface(spec string) Provider {
+	var buf strings.Builder
+	p, ok := NewCustomLocalWithInterface(pp.NewDefault(&buf), spec)
+	if !ok {
+		panic(buf.String())
+	}
+	return p
+}
+
+// NewCustomLocalWithInterfaceAll creates a protocol.LocalWithInterface provider that detects
+// all the stable global unicast addresses assigned to a network interface. The specification
+// has the form "<iface>[@prefix=<prefix>]". If the prefix is given, only addresses within
+// the prefix are considered.
+func NewCustomLocalWithInterfaceAll(ppfmt pp.PP, spec string) (Provider, bool) {
+	iface, policy, providerName, ok := parseInterfaceSpec(ppfmt, "local.iface.all", spec, false)
+	if !ok {
+		return nil, false
+	}
+
+	return protocol.LocalWithInterface{
+		ProviderName:  providerName,
+		InterfaceName: iface,
+		Policy:        policy,
+		All:           true,
+	}, true
+}
+
+// MustNewCustomLocalWithInterfaceAll creates a protocol.LocalWithInterface provider detecting
+// all the addresses from a specification and panics if it fails.
+func MustNewCustomLocalWithInterfaceAll(spec string) Provider {
+	var buf strings.Builder
+	p, ok := NewCustomLocalWithInterfaceAll(pp.NewDefault(&buf), spec)
+	if !ok {
+		panic(buf.String())
 	}
+	return p
 }
@@ -0,0 +1,153 @@
+// vim: nowrap
+package provider_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+func TestNewCustomLocalWithInterface(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		spec          string
+		ok            bool
+		name          string
+		expected      protocol.InterfacePolicy
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"default": {"eth0", true, "local.iface:eth0", protocol.InterfacePolicy{}, nil},
+		"all": {
+			" eth0 @ prefix=2001:db8::1/48 + Stable+eui64+iid=0::1:2:3:4+longest-lived", true,
+			"local.iface:eth0@prefix=2001:db8::/48+stable+eui64+iid=::1:2:3:4+longest-lived",
+			protocol.InterfacePolicy{
+				Prefix:      netip.MustParsePrefix("2001:db8::/48"),
+				InterfaceID: netip.MustParseAddr("::1:2:3:4"),
+				Preferences: []protocol.InterfacePreference{protocol.PreferStable, protocol.PreferEUI64, protocol.PreferInterfaceID, protocol.PreferLongestLived},
+			},
+			nil,
+		},
+		"bad-prefix": {
+			"eth0@prefix=2001:db8::", false, "", protocol.InterfacePolicy{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s has an invalid prefix %q: %v`, "local.iface:eth0", "2001:db8::", gomock.Any())
+			},
+		},
+		"two-prefixes": {
+			"eth0@prefix=2001:db8::/32+prefix=2001:db9::/32", false, "", protocol.InterfacePolicy{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s has more than one prefix`, "local.iface:eth0")
+			},
+		},
+		"bad-iid": {
+			"eth0@iid=1.2.3.4", false, "", protocol.InterfacePolicy{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s has an invalid interface identifier %q (it should look like "::1:2:3:4")`, "local.iface:eth0", "1.2.3.4")
+			},
+		},
+		"stable-with-value": {
+			"eth0@stable=1", false, "", protocol.InterfacePolicy{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s has the option %q, but %s takes no value`, "local.iface:eth0", "stable=1", "stable")
+			},
+		},
+		"eui64-with-value": {
+			"eth0@EUI64=", false, "", protocol.InterfacePolicy{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s has the option %q, but %s takes no value`, "local.iface:eth0", "EUI64=", "eui64")
+			},
+		},
+		"longest-lived-with-value": {
+			"eth0@prefix=2001:db8::/32+longest-lived=yes", false, "", protocol.InterfacePolicy{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s has the option %q, but %s takes no value`, "local.iface:eth0", "longest-lived=yes", "longest-lived")
+			},
+		},
+		"unknown": {
+			"eth0@stable+newest", false, "", protocol.InterfacePolicy{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s has an unknown option %q (expecting prefix=<prefix>, stable, eui64, iid=<interface identifier>, or longest-lived)`, "local.iface:eth0", "newest")
+			},
+		},
+		"empty-option": {
+			"eth0@", false, "", protocol.InterfacePolicy{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s has an unknown option %q (expecting prefix=<prefix>, stable, eui64, iid=<interface identifier>, or longest-lived)`, "local.iface:eth0", "")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			p, ok := provider.NewCustomLocalWithInterface(mockPP, tc.spec)
+			require.Equal(t, tc.ok, ok)
+			if tc.ok {
+				require.Equal(t, protocol.LocalWithInterface{ProviderName: tc.name, InterfaceName: "eth0", Policy: tc.expected, All: false}, p)
+			} else {
+				require.Nil(t, p)
+			}
+		})
+	}
+}
+
+func TestNewCustomLocalWithInterfaceAll(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		spec          string
+		ok            bool
+		name          string
+		expected      protocol.InterfacePolicy
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"default": {"eth0", true, "local.iface.all:eth0", protocol.InterfacePolicy{}, nil},
+		"prefix": {
+			"eth0@prefix=2001:db8::1/48", true, "local.iface.all:eth0@prefix=2001:db8::/48",
+			protocol.InterfacePolicy{Prefix: netip.MustParsePrefix("2001:db8::/48")}, nil,
+		},
+		"bad-prefix": {
+			"eth0@prefix=2001:db8::", false, "", protocol.InterfacePolicy{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s has an invalid prefix %q: %v`, "local.iface.all:eth0", "2001:db8::", gomock.Any())
+			},
+		},
+		"preference": {
+			"eth0@prefix=2001:db8::/48+stable", false, "", protocol.InterfacePolicy{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s cannot take the preference %q because it uses all the addresses`, "local.iface.all:eth0", "stable")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			p, ok := provider.NewCustomLocalWithInterfaceAll(mockPP, tc.spec)
+			require.Equal(t, tc.ok, ok)
+			if tc.ok {
+				require.Equal(t, protocol.LocalWithInterface{ProviderName: tc.name, InterfaceName: "eth0", Policy: tc.expected, All: true}, p)
+			} else {
+				require.Nil(t, p)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package protocol
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/netip"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+const (
+	dnsInitialRTO       = time.Second
+	dnsMaxTransmissions = 3
+)
+
+// isDNSResponseTo checks whether msg looks like a response with the message ID.
+func isDNSResponseTo(msg []byte, id uint16) bool {
+	return len(msg) >= 12 && binary.BigEndian.Uint16(msg[0:2]) == id && msg[2]&0x80 != 0
+}
+
+// isDNSTruncated checks the TC bit of a DNS message.
+func isDNSTruncated(msg []byte) bool {
+	return len(msg) >= 12 && msg[2]&0x02 != 0
+}
+
+// exchangeDNSOverStream sends the query and reads the response over TCP or TLS,
+// where each message is prefixed with its length (RFC 1035 Section 4.2.2).
+func exchangeDNSOverStream(ctx context.Context, conn net.Conn, q []byte) ([]byte, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	msg := make([]byte, 2, 2+len(q))
+	binary.BigEndian.PutUint16(msg, uint16(len(q))) //nolint:gosec // DNS queries are short
+	if _, err := conn.Write(append(msg, q...)); err != nil {
+		return nil, err
+	}
+
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	r := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, r); err != nil {
+		return nil, err
+	}
+
+	if ctx.Err() != nil {
+		return nil, context.Cause(ctx)
+	}
+	return r, nil
+}
+
+func exchangeDNSOverUDP(ctx context.Context, ipNet ipnet.Type, server string, q []byte, id uint16) ([]byte, error) {
+	conn, err := splitDialer(ctx, ipNet).DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return exchangeUDP(ctx, conn, q, dnsInitialRTO, dnsMaxTransmissions,
+		func(msg []byte) bool { return isDNSResponseTo(msg, id) })
+}
+
+func exchangeDNSOverTCP(ctx context.Context, ipNet ipnet.Type, server string, q []byte) ([]byte, error) {
+	conn, err := splitDialer(ctx, ipNet).DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return exchangeDNSOverStream(ctx, conn, q)
+}
+
+func exchangeDNSOverTLS(ctx context.Context, ipNet ipnet.Type, server, serverName string, rootCAs *x509.CertPool,
+	q []byte,
+) ([]byte, error) {
+	conn, err := splitDialer(ctx, ipNet).DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	tlsConn := tls.Client(conn, &tls.Config{ //nolint:exhaustruct
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    rootCAs,
+	})
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil, err
+	}
+
+	return exchangeDNSOverStream(ctx, tlsConn, q)
+}
+
+// DNSParam is the parameter of a detection protocol using plain DNS or DNS over TLS.
+type DNSParam = struct {
+	Server        string           // the DNS server in the form "host:port"
+	TLS           bool             // whether to use DNS over TLS (RFC 7858)
+	TLSServerName string           // the name to verify in the certificate of the DNS-over-TLS server
+	Name          string           // domain name to query
+	Class         dnsmessage.Class // DNS class to query
+	Type          dnsmessage.Type  // DNS record type to query (TXT, A, or AAAA)
+}
+
+// DNS represents a generic detection protocol using plain DNS or DNS over TLS.
+type DNS struct {
+	ProviderName string // name of the protocol
+	Param        map[ipnet.Type]DNSParam
+	RootCAs      *x509.CertPool // root certificates for DNS over TLS; nil means the system roots
+}
+
+// Name of the detection protocol.
+func (p DNS) Name() string {
+	return p.ProviderName
+}
+
+func (p DNS) exchange(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type, param DNSParam, q []byte, id uint16,
+) ([]byte, bool) {
+	if param.TLS {
+		r, err := exchangeDNSOverTLS(ctx, ipNet, param.Server, param.TLSServerName, p.RootCAs, q)
+		if err != nil {
+			ppfmt.Noticef(pp.EmojiError, "Failed to send the DNS-over-TLS query to %q: %v", param.Server, err)
+			return nil, false
+		}
+		return r, true
+	}
+
+	r, err := exchangeDNSOverUDP(ctx, ipNet, param.Server, q, id)
+	if err != nil {
+		ppfmt.Noticef(pp.EmojiError, "Failed to send the DNS query to %q: %v", param.Server, err)
+		return nil, false
+	}
+	if !isDNSTruncated(r) {
+		return r, true
+	}
+
+	// The response was truncated; retry with TCP.
+	r, err = exchangeDNSOverTCP(ctx, ipNet, param.Server, q)
+	if err != nil {
+		ppfmt.Noticef(pp.EmojiError, "Failed to send the DNS query to %q over TCP: %v", param.Server, err)
+		return nil, false
+	}
+	return r, true
+}
+
+// GetIP detects the IP address by plain DNS or DNS over TLS.
+func (p DNS) GetIP(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) (netip.Addr, bool) {
+	param, found := p.Param[ipNet]
+	if !found {
+		ppfmt.Noticef(pp.EmojiImpossible, "Unhandled IP network: %s", ipNet.Describe())
+		return netip.Addr{}, false
+	}
+
+	id := randUint16(ppfmt)
+
+	q, ok := newDNSQuery(ppfmt, id, param.Name, param.Class, param.Type)
+	if !ok {
+		return netip.Addr{}, false
+	}
+
+	r, ok := p.exchange(ctx, ppfmt, ipNet, param, q, id)
+	if !ok {
+		return netip.Addr{}, false
+	}
+
+	ip, ok := parseDNSResponse(ppfmt, r, id, param.Name, param.Class, param.Type)
+	if !ok {
+		return netip.Addr{}, false
+	}
+
+	return ipNet.NormalizeDetectedIP(ppfmt, ip)
+}
@@ -0,0 +1,315 @@
+// vim: nowrap
+package protocol_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+func TestDNSName(t *testing.T) {
+	t.Parallel()
+
+	p := protocol.DNS{
+		ProviderName: "very secret name",
+		Param:        nil,
+		RootCAs:      nil,
+	}
+
+	require.Equal(t, "very secret name", p.Name())
+}
+
+// dnsAnswer answers a DNS query with the IP address, setting TC if truncated is true.
+func dnsAnswer(t *testing.T, query []byte, ip netip.Addr, truncated bool) []byte {
+	t.Helper()
+
+	var msg dnsmessage.Message
+	if err := msg.Unpack(query); err != nil || len(msg.Questions) != 1 {
+		return nil
+	}
+	q := msg.Questions[0]
+
+	var answers []dnsmessage.Resource
+	header := dnsmessage.ResourceHeader{Name: q.Name, Class: q.Class} //nolint:exhaustruct
+	switch {
+	case truncated:
+	case q.Type == dnsmessage.TypeA && ip.Is4():
+		answers = append(answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.AResource{A: ip.As4()}})
+	case q.Type == dnsmessage.TypeAAAA && ip.Is6():
+		answers = append(answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.AAAAResource{AAAA: ip.As16()}})
+	case q.Type == dnsmessage.TypeTXT:
+		answers = append(answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.TXTResource{TXT: []string{ip.String()}}})
+	}
+
+	r, err := (&dnsmessage.Message{
+		Header:      dnsmessage.Header{ID: msg.ID, Response: true, Truncated: truncated}, //nolint:exhaustruct
+		Questions:   msg.Questions,
+		Answers:     answers,
+		Authorities: []dnsmessage.Resource{},
+		Additionals: []dnsmessage.Resource{},
+	}).Pack()
+	require.NoError(t, err)
+	return r
+}
+
+func localhost(ipNet ipnet.Type) string {
+	if ipNet == ipnet.IP6 {
+		return "::1"
+	}
+	return "127.0.0.1"
+}
+
+// newUDPDNSServer starts a DNS server over UDP.
+func newUDPDNSServer(t *testing.T, ipNet ipnet.Type, ip netip.Addr, truncated bool) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket(ipNet.UDPNetwork(), net.JoinHostPort(localhost(ipNet), "0"))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			if err != nil {
+				continue
+			}
+			if r := dnsAnswer(t, buf[:n], ip, truncated); r != nil {
+				_, _ = conn.WriteTo(r, addr)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+// serveStreamDNS serves DNS over a stream listener (TCP or TLS).
+func serveStreamDNS(t *testing.T, l net.Listener, ip netip.Addr) {
+	t.Helper()
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				var length [2]byte
+				if _, err := io.ReadFull(conn, length[:]); err != nil {
+					return
+				}
+				q := make([]byte, binary.BigEndian.Uint16(length[:]))
+				if _, err := io.ReadFull(conn, q); err != nil {
+					return
+				}
+				r := dnsAnswer(t, q, ip, false)
+				binary.BigEndian.PutUint16(length[:], uint16(len(r)))
+				_, _ = conn.Write(append(length[:], r...))
+			}()
+		}
+	}()
+}
+
+// newTLSDNSServer starts a DNS-over-TLS server and returns its address and the root certificates to trust it.
+// The certificate is valid for 127.0.0.1, ::1, and example.com.
+func newTLSDNSServer(t *testing.T, ip netip.Addr) (string, *x509.CertPool) {
+	t.Helper()
+
+	// Borrow the certificate of an HTTPS test server.
+	https := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(https.Close)
+
+	l, err := tls.Listen("tcp4", "127.0.0.1:0", &tls.Config{ //nolint:exhaustruct
+		Certificates: https.TLS.Certificates,
+		MinVersion:   tls.VersionTLS12,
+	})
+	require.NoError(t, err)
+	serveStreamDNS(t, l, ip)
+
+	return l.Addr().String(), https.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs //nolint:forcetypeassert
+}
+
+func TestDNSGetIP(t *testing.T) {
+	t.Parallel()
+
+	ip4 := netip.MustParseAddr("1.2.3.4")
+	ip6 := netip.MustParseAddr("2606:4700:4700::1234")
+	invalidIP := netip.Addr{}
+
+	for name, tc := range map[string]struct {
+		ipNet         ipnet.Type
+		qtype         dnsmessage.Type
+		answer        netip.Addr
+		server        func(*testing.T, netip.Addr) string
+		expected      netip.Addr
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"a": {
+			ipnet.IP4, dnsmessage.TypeA, ip4,
+			func(t *testing.T, ip netip.Addr) string { return newUDPDNSServer(t, ipnet.IP4, ip, false) },
+			ip4, nil,
+		},
+		"aaaa": {
+			ipnet.IP6, dnsmessage.TypeAAAA, ip6,
+			func(t *testing.T, ip netip.Addr) string { return newUDPDNSServer(t, ipnet.IP6, ip, false) },
+			ip6, nil,
+		},
+		"txt": {
+			ipnet.IP4, dnsmessage.TypeTXT, ip4,
+			func(t *testing.T, ip netip.Addr) string { return newUDPDNSServer(t, ipnet.IP4, ip, false) },
+			ip4, nil,
+		},
+		"6to4": {
+			ipnet.IP4, dnsmessage.TypeA, ip4,
+			func(t *testing.T, ip netip.Addr) string { return newUDPDNSServer(t, ipnet.IP6, ip, false) },
+			invalidIP,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Failed to send the DNS query to %q: %v", gomock.Any(), gomock.Any())
+			},
+		},
+		"truncated": {
+			ipnet.IP4, dnsmessage.TypeA, ip4,
+			func(t *testing.T, ip netip.Addr) string {
+				server := newUDPDNSServer(t, ipnet.IP4, ip, true)
+				l, err := net.Listen("tcp4", server)
+				require.NoError(t, err)
+				serveStreamDNS(t, l, ip)
+				return server
+			},
+			ip4, nil,
+		},
+		"truncated/no-tcp": {
+			ipnet.IP4, dnsmessage.TypeA, ip4,
+			func(t *testing.T, ip netip.Addr) string { return newUDPDNSServer(t, ipnet.IP4, ip, true) },
+			invalidIP,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Failed to send the DNS query to %q over TCP: %v", gomock.Any(), gomock.Any())
+			},
+		},
+		"no-answer": {
+			ipnet.IP4, dnsmessage.TypeAAAA, ip4,
+			func(t *testing.T, ip netip.Addr) string { return newUDPDNSServer(t, ipnet.IP4, ip, false) },
+			invalidIP,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiImpossible, "Invalid DNS response: no %v records", dnsmessage.TypeAAAA)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			p := protocol.DNS{
+				ProviderName: "",
+				Param: map[ipnet.Type]protocol.DNSParam{
+					tc.ipNet: {tc.server(t, tc.answer), false, "", "test.", dnsmessage.ClassINET, tc.qtype},
+				},
+				RootCAs: nil,
+			}
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			ip, ok := p.GetIP(context.Background(), mockPP, tc.ipNet)
+			require.Equal(t, tc.expected, ip)
+			require.Equal(t, tc.expected.IsValid(), ok)
+		})
+	}
+}
+
+func TestDNSOverTLSGetIP(t *testing.T) {
+	t.Parallel()
+
+	ip := netip.MustParseAddr("1.2.3.4")
+	invalidIP := netip.Addr{}
+
+	for name, tc := range map[string]struct {
+		serverName    string
+		trusted       bool
+		expected      netip.Addr
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"ip":   {"127.0.0.1", true, ip, nil},
+		"name": {"example.com", true, ip, nil},
+		"wrong-name": {
+			"example.org", true, invalidIP,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Failed to send the DNS-over-TLS query to %q: %v", gomock.Any(), gomock.Any())
+			},
+		},
+		"untrusted": {
+			"127.0.0.1", false, invalidIP,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Failed to send the DNS-over-TLS query to %q: %v", gomock.Any(), gomock.Any())
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server, rootCAs := newTLSDNSServer(t, ip)
+			if !tc.trusted {
+				rootCAs = x509.NewCertPool()
+			}
+			p := protocol.DNS{
+				ProviderName: "",
+				Param: map[ipnet.Type]protocol.DNSParam{
+					ipnet.IP4: {server, true, tc.serverName, "test.", dnsmessage.ClassINET, dnsmessage.TypeA},
+				},
+				RootCAs: rootCAs,
+			}
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			detected, ok := p.GetIP(context.Background(), mockPP, ipnet.IP4)
+			require.Equal(t, tc.expected, detected)
+			require.Equal(t, tc.expected.IsValid(), ok)
+		})
+	}
+}
+
+func TestDNSGetIPUnhandled(t *testing.T) {
+	t.Parallel()
+
+	p := protocol.DNS{
+		ProviderName: "",
+		Param:        map[ipnet.Type]protocol.DNSParam{},
+		RootCAs:      nil,
+	}
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Noticef(pp.EmojiImpossible, "Unhandled IP network: %s", "IPv4")
+
+	ip, ok := p.GetIP(context.Background(), mockPP, ipnet.IP4)
+	require.False(t, ok)
+	require.Zero(t, ip)
+}
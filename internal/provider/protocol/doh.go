@@ -30,7 +30,8 @@ func randUint16(ppfmt pp.PP) uint16 {
 	return binary.BigEndian.Uint16(buf)
 }
 
-func newDNSQuery(ppfmt pp.PP, id uint16, name string, class dnsmessage.Class) ([]byte, bool) {
+func newDNSQuery(ppfmt pp.PP, id uint16, name string, class dnsmessage.Class, qtype dnsmessage.Type,
+) ([]byte, bool) {
 	msg, err := (&dnsmessage.Message{
 		Header: dnsmessage.Header{ //nolint:exhaustruct
 			ID:               id,
@@ -46,7 +47,7 @@ func newDNSQuery(ppfmt pp.PP, id uint16, name string, class dnsmessage.Class) ([
 		Questions: []dnsmessage.Question{
 			{
 				Name:  dnsmessage.MustNewName(name),
-				Type:  dnsmessage.TypeTXT,
+				Type:  qtype,
 				Class: class,
 			},
 		},
@@ -63,13 +64,34 @@ func newDNSQuery(ppfmt pp.PP, id uint16, name string, class dnsmessage.Class) ([
 	return msg, true
 }
 
-func parseDNSAnswers(ppfmt pp.PP, answers []dnsmessage.Resource, name string, class dnsmessage.Class,
+// matchDNSNames collects name and its aliases according to the CNAME records in the answers.
+func matchDNSNames(answers []dnsmessage.Resource, name string, class dnsmessage.Class) map[string]bool {
+	names := map[string]bool{strings.ToLower(name): true}
+	for changed := true; changed; {
+		changed = false
+		for _, ans := range answers {
+			if ans.Header.Type != dnsmessage.TypeCNAME || ans.Header.Class != class ||
+				!names[strings.ToLower(ans.Header.Name.String())] {
+				continue
+			}
+			target := strings.ToLower(ans.Body.(*dnsmessage.CNAMEResource).CNAME.String()) //nolint:forcetypeassert
+			if !names[target] {
+				names[target] = true
+				changed = true
+			}
+		}
+	}
+	return names
+}
+
+func parseDNSTXTAnswers(ppfmt pp.PP, answers []dnsmessage.Resource, names map[string]bool, class dnsmessage.Class,
 ) (netip.Addr, bool) {
 	var invalidIP netip.Addr
 	var ipString string
 
 	for _, ans := range answers {
-		if ans.Header.Name.String() != name || ans.Header.Type != dnsmessage.TypeTXT || ans.Header.Class != class {
+		if !names[strings.ToLower(ans.Header.Name.String())] ||
+			ans.Header.Type != dnsmessage.TypeTXT || ans.Header.Class != class {
 			continue
 		}
 
@@ -106,7 +128,61 @@ func parseDNSAnswers(ppfmt pp.PP, answers []dnsmessage.Resource, name string, cl
 	return ip, true
 }
 
-func parseDNSResponse(ppfmt pp.PP, r []byte, id uint16, name string, class dnsmessage.Class) (netip.Addr, bool) {
+func parseDNSAddressAnswers(ppfmt pp.PP, answers []dnsmessage.Resource, names map[string]bool,
+	class dnsmessage.Class, qtype dnsmessage.Type,
+) (netip.Addr, bool) {
+	var invalidIP netip.Addr
+	var ip netip.Addr
+
+	for _, ans := range answers {
+		if !names[strings.ToLower(ans.Header.Name.String())] ||
+			ans.Header.Type != qtype || ans.Header.Class != class {
+			continue
+		}
+
+		var found netip.Addr
+		switch body := ans.Body.(type) {
+		case *dnsmessage.AResource:
+			found = netip.AddrFrom4(body.A)
+		case *dnsmessage.AAAAResource:
+			found = netip.AddrFrom16(body.AAAA)
+		default:
+			continue
+		}
+
+		if ip.IsValid() && ip != found {
+			ppfmt.Noticef(pp.EmojiImpossible, "Invalid DNS response: more than one %v record", qtype)
+			return invalidIP, false
+		}
+		ip = found
+	}
+
+	if !ip.IsValid() {
+		ppfmt.Noticef(pp.EmojiImpossible, "Invalid DNS response: no %v records", qtype)
+		return invalidIP, false
+	}
+
+	return ip, true
+}
+
+func parseDNSAnswers(ppfmt pp.PP, answers []dnsmessage.Resource, name string,
+	class dnsmessage.Class, qtype dnsmessage.Type,
+) (netip.Addr, bool) {
+	names := matchDNSNames(answers, name, class)
+
+	switch qtype {
+	case dnsmessage.TypeTXT:
+		return parseDNSTXTAnswers(ppfmt, answers, names, class)
+	case dnsmessage.TypeA, dnsmessage.TypeAAAA:
+		return parseDNSAddressAnswers(ppfmt, answers, names, class, qtype)
+	default:
+		ppfmt.Noticef(pp.EmojiImpossible, "Unhandled DNS record type: %v", qtype)
+		return netip.Addr{}, false
+	}
+}
+
+func parseDNSResponse(ppfmt pp.PP, r []byte, id uint16, name string, class dnsmessage.Class, qtype dnsmessage.Type,
+) (netip.Addr, bool) {
 	var invalidIP netip.Addr
 
 	var msg dnsmessage.Message
@@ -133,17 +209,18 @@ func parseDNSResponse(ppfmt pp.PP, r []byte, id uint16, name string, class dnsme
 		return invalidIP, false
 	}
 
-	return parseDNSAnswers(ppfmt, msg.Answers, name, class)
+	return parseDNSAnswers(ppfmt, msg.Answers, name, class, qtype)
 }
 
-func getIPFromDNS(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type, url string, name string, class dnsmessage.Class,
+func getIPFromDNS(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type,
+	url string, name string, class dnsmessage.Class, qtype dnsmessage.Type,
 ) (netip.Addr, bool) {
 	var invalidIP netip.Addr
 
 	// message ID for the DNS payloads
 	id := randUint16(ppfmt)
 
-	q, ok := newDNSQuery(ppfmt, id, name, class)
+	q, ok := newDNSQuery(ppfmt, id, name, class, qtype)
 	if !ok {
 		return invalidIP, false
 	}
@@ -158,7 +235,7 @@ func getIPFromDNS(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type, url string
 		},
 		requestBody: bytes.NewReader(q),
 		extract: func(ppfmt pp.PP, body []byte) (netip.Addr, bool) {
-			return parseDNSResponse(ppfmt, body, id, name, class)
+			return parseDNSResponse(ppfmt, body, id, name, class, qtype)
 		},
 	}
 
@@ -170,6 +247,7 @@ type DNSOverHTTPSParam = struct {
 	URL   string           // the DoH server
 	Name  string           // domain name to query
 	Class dnsmessage.Class // DNS class to query
+	Type  dnsmessage.Type  // DNS record type to query (TXT, A, or AAAA)
 }
 
 // DNSOverHTTPS represents a generic detection protocol using DNS over HTTPS.
@@ -191,7 +269,7 @@ func (p DNSOverHTTPS) GetIP(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type)
 		return netip.Addr{}, false
 	}
 
-	ip, ok := getIPFromDNS(ctx, ppfmt, ipNet, param.URL, param.Name, param.Class)
+	ip, ok := getIPFromDNS(ctx, ppfmt, ipNet, param.URL, param.Name, param.Class, param.Type)
 	if !ok {
 		return netip.Addr{}, false
 	}
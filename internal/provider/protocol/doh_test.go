@@ -31,12 +31,20 @@ func TestDNSOverHTTPSName(t *testing.T) {
 	require.Equal(t, "very secret name", p.Name())
 }
 
-func setupServer(t *testing.T, name string, class dnsmessage.Class,
+func setupServer(t *testing.T, name string, class dnsmessage.Class, qtype dnsmessage.Type,
 	response bool, header *dnsmessage.Header, idShift uint16, answers []dnsmessage.Resource,
 ) *httptest.Server {
 	t.Helper()
 
-	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return httptest.NewServer(dnsHandler(t, name, class, qtype, response, header, idShift, answers))
+}
+
+func dnsHandler(t *testing.T, name string, class dnsmessage.Class, qtype dnsmessage.Type,
+	response bool, header *dnsmessage.Header, idShift uint16, answers []dnsmessage.Resource,
+) http.HandlerFunc {
+	t.Helper()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if !assert.Equal(t, http.MethodPost, r.Method) ||
 			!assert.Equal(t, "application/dns-message", r.Header.Get("Content-Type")) ||
 			!assert.Equal(t, "application/dns-message", r.Header.Get("Accept")) {
@@ -57,7 +65,7 @@ func setupServer(t *testing.T, name string, class dnsmessage.Class,
 			[]dnsmessage.Question{
 				{
 					Name:  dnsmessage.MustNewName(name),
-					Type:  dnsmessage.TypeTXT,
+					Type:  qtype,
 					Class: class,
 				},
 			},
@@ -91,7 +99,7 @@ func setupServer(t *testing.T, name string, class dnsmessage.Class,
 		if _, err = w.Write(response); !assert.NoError(t, err) {
 			panic(http.ErrAbortHandler)
 		}
-	}))
+	})
 }
 
 func TestDNSOverHTTPSGetIP(t *testing.T) {
@@ -539,12 +547,96 @@ func TestDNSOverHTTPSGetIP(t *testing.T) {
 			t.Parallel()
 			mockCtrl := gomock.NewController(t)
 
-			server := setupServer(t, tc.name, tc.class, tc.response, tc.header, tc.idShift, tc.answers)
+			server := setupServer(t, tc.name, tc.class, dnsmessage.TypeTXT, tc.response, tc.header, tc.idShift, tc.answers)
+
+			provider := &protocol.DNSOverHTTPS{
+				ProviderName: "",
+				Param: map[ipnet.Type]protocol.DNSOverHTTPSParam{
+					tc.urlKey: {server.URL, tc.name, tc.class, dnsmessage.TypeTXT},
+				},
+			}
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			ip, ok := provider.GetIP(context.Background(), mockPP, tc.ipNet)
+			require.Equal(t, tc.expected, ip)
+			require.Equal(t, tc.expected.IsValid(), ok)
+		})
+	}
+}
+
+func TestDNSOverHTTPSGetIPAddressRecords(t *testing.T) {
+	t.Parallel()
+
+	ip4 := netip.MustParseAddr("1.2.3.4")
+	ip6 := netip.MustParseAddr("2606:4700:4700::1234")
+	invalidIP := netip.Addr{}
+
+	a := func(name string, ip netip.Addr) dnsmessage.Resource {
+		return dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Class: dnsmessage.ClassINET}, //nolint:exhaustruct
+			Body:   &dnsmessage.AResource{A: ip.As4()},
+		}
+	}
+	aaaa := func(name string, ip netip.Addr) dnsmessage.Resource {
+		return dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Class: dnsmessage.ClassINET}, //nolint:exhaustruct
+			Body:   &dnsmessage.AAAAResource{AAAA: ip.As16()},
+		}
+	}
+	cname := func(name, target string) dnsmessage.Resource {
+		return dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Class: dnsmessage.ClassINET}, //nolint:exhaustruct
+			Body:   &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName(target)},
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		ipNet         ipnet.Type
+		qtype         dnsmessage.Type
+		answers       []dnsmessage.Resource
+		expected      netip.Addr
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"a":          {ipnet.IP4, dnsmessage.TypeA, []dnsmessage.Resource{a("test.", ip4)}, ip4, nil},
+		"aaaa":       {ipnet.IP6, dnsmessage.TypeAAAA, []dnsmessage.Resource{aaaa("test.", ip6)}, ip6, nil},
+		"case":       {ipnet.IP4, dnsmessage.TypeA, []dnsmessage.Resource{a("TeSt.", ip4)}, ip4, nil},
+		"duplicate":  {ipnet.IP4, dnsmessage.TypeA, []dnsmessage.Resource{a("test.", ip4), a("test.", ip4)}, ip4, nil},
+		"cname":      {ipnet.IP4, dnsmessage.TypeA, []dnsmessage.Resource{a("target.", ip4), cname("alias.", "target."), cname("test.", "alias.")}, ip4, nil},
+		"irrelevant": {ipnet.IP4, dnsmessage.TypeA, []dnsmessage.Resource{a("other.", netip.MustParseAddr("5.6.7.8")), aaaa("test.", ip6), a("test.", ip4)}, ip4, nil},
+		"a-for-ipv6": {
+			ipnet.IP6, dnsmessage.TypeA, []dnsmessage.Resource{a("test.", ip4)}, invalidIP,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Detected IP address %s is not a valid IPv6 address", ip4.String())
+			},
+		},
+		"none": {
+			ipnet.IP6, dnsmessage.TypeAAAA, []dnsmessage.Resource{a("test.", ip4)}, invalidIP,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiImpossible, "Invalid DNS response: no %v records", dnsmessage.TypeAAAA)
+			},
+		},
+		"multiple": {
+			ipnet.IP4, dnsmessage.TypeA, []dnsmessage.Resource{a("test.", ip4), a("test.", netip.MustParseAddr("5.6.7.8"))}, invalidIP,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiImpossible, "Invalid DNS response: more than one %v record", dnsmessage.TypeA)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			server := newSplitServer(tc.ipNet, dnsHandler(t, "test.", dnsmessage.ClassINET, tc.qtype, true,
+				&dnsmessage.Header{Response: true}, 0, tc.answers)) //nolint:exhaustruct
+			t.Cleanup(server.Close)
 
 			provider := &protocol.DNSOverHTTPS{
 				ProviderName: "",
 				Param: map[ipnet.Type]protocol.DNSOverHTTPSParam{
-					tc.urlKey: {server.URL, tc.name, tc.class},
+					tc.ipNet: {server.URL, "test.", dnsmessage.ClassINET, tc.qtype},
 				},
 			}
 
@@ -0,0 +1,98 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/netip"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// ExecIPVersionEnv is the environment variable holding the IP version (4 or 6) for the command.
+const ExecIPVersionEnv = "DDNS_IP_VERSION"
+
+// execWaitDelay is how long to wait for the output after the command is killed.
+const execWaitDelay = time.Second
+
+// cappedBuffer keeps only the first [maxReadLength] bytes written to it.
+type cappedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := int(maxReadLength) - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(room, len(p))])
+	}
+	return len(p), nil
+}
+
+// firstIP finds the first valid IP address of the IP family among the words of the output.
+// If there are none, the first IP address of the other family is returned so that the mismatch can be reported.
+func firstIP(output string, ipNet ipnet.Type) (netip.Addr, bool) {
+	var other netip.Addr
+	for _, word := range strings.Fields(output) {
+		ip, err := netip.ParseAddr(word)
+		switch {
+		case err != nil:
+			continue
+		case ipNet.Matches(ip):
+			return ip, true
+		case !other.IsValid():
+			other = ip
+		}
+	}
+	return other, other.IsValid()
+}
+
+// Exec represents a generic detection protocol to run a local command.
+type Exec struct {
+	ProviderName string // name of the protocol
+	Path         string // path of the command
+}
+
+// Name of the detection protocol.
+func (p Exec) Name() string {
+	return p.ProviderName
+}
+
+// GetIP detects the IP address by running the command and taking the first IP address of the IP family in its output.
+// The IP version (4 or 6) is passed to the command in the environment variable [ExecIPVersionEnv].
+func (p Exec) GetIP(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) (netip.Addr, bool) {
+	var stdout, stderr cappedBuffer
+
+	cmd := exec.CommandContext(ctx, p.Path)
+	cmd.Env = append(os.Environ(), ExecIPVersionEnv+"="+strconv.Itoa(ipNet.Int()))
+	cmd.Stdin = nil
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = execWaitDelay
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = context.Cause(ctx)
+		}
+
+		var exitErr *exec.ExitError
+		if msg := strings.TrimSpace(stderr.String()); errors.As(err, &exitErr) && msg != "" {
+			ppfmt.Noticef(pp.EmojiError, "Failed to run %q: %v (%q)", p.Path, err, msg)
+		} else {
+			ppfmt.Noticef(pp.EmojiError, "Failed to run %q: %v", p.Path, err)
+		}
+		return netip.Addr{}, false
+	}
+
+	ip, found := firstIP(stdout.String(), ipNet)
+	if !found {
+		ppfmt.Noticef(pp.EmojiError, "Failed to find an IP address in the output of %q", p.Path)
+		return netip.Addr{}, false
+	}
+
+	return ipNet.NormalizeDetectedIP(ppfmt, ip)
+}
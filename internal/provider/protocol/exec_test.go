@@ -0,0 +1,146 @@
+// vim: nowrap
+package protocol_test
+
+import (
+	"context"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+func TestExecName(t *testing.T) {
+	t.Parallel()
+
+	p := protocol.Exec{
+		ProviderName: "very secret name",
+		Path:         "",
+	}
+
+	require.Equal(t, "very secret name", p.Name())
+}
+
+// writeScript writes a shell script and returns its path.
+func writeScript(t *testing.T, script string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "script")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o700)) //nolint:gosec
+	return path
+}
+
+func TestExecGetIP(t *testing.T) {
+	t.Parallel()
+
+	const versioned = `if [ "$DDNS_IP_VERSION" = 4 ]; then echo 1.2.3.4; else echo 2001:db8::4; fi`
+
+	for name, tc := range map[string]struct {
+		script        string
+		ipNet         ipnet.Type
+		timeout       time.Duration
+		expected      netip.Addr
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"ip4": {versioned, ipnet.IP4, time.Second, netip.MustParseAddr("1.2.3.4"), nil},
+		"ip6": {versioned, ipnet.IP6, time.Second, netip.MustParseAddr("2001:db8::4"), nil},
+		"first": {
+			`echo "WAN address: up"; echo "  4.3.2.1 1.2.3.4"`,
+			ipnet.IP4, time.Second, netip.MustParseAddr("4.3.2.1"), nil,
+		},
+		"both/ip4": {
+			`echo 1.2.3.4 2001:db8::4`,
+			ipnet.IP4, time.Second, netip.MustParseAddr("1.2.3.4"), nil,
+		},
+		"both/ip6": {
+			`echo 1.2.3.4 2001:db8::4`,
+			ipnet.IP6, time.Second, netip.MustParseAddr("2001:db8::4"), nil,
+		},
+		"mapped": {
+			`echo ::ffff:1.2.3.4`,
+			ipnet.IP4, time.Second, netip.MustParseAddr("1.2.3.4"), nil,
+		},
+		"no-ip": {
+			`echo "WAN is down"`,
+			ipnet.IP4, time.Second, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Failed to find an IP address in the output of %q", gomock.Any())
+			},
+		},
+		"wrong-family": {
+			`echo 1.2.3.4`,
+			ipnet.IP6, time.Second, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Detected IP address %s is not a valid IPv6 address", "1.2.3.4")
+			},
+		},
+		"exit": {
+			`echo 1.2.3.4; echo "not logged in" >&2; exit 3`,
+			ipnet.IP4, time.Second, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Failed to run %q: %v (%q)", gomock.Any(), gomock.Any(), "not logged in")
+			},
+		},
+		"exit/silent": {
+			`exit 3`,
+			ipnet.IP4, time.Second, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Failed to run %q: %v", gomock.Any(), gomock.Any())
+			},
+		},
+		"timeout": {
+			`exec sleep 10`,
+			ipnet.IP4, 100 * time.Millisecond, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Failed to run %q: %v", gomock.Any(), context.DeadlineExceeded)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithTimeout(context.Background(), tc.timeout)
+			defer cancel()
+
+			p := protocol.Exec{
+				ProviderName: "",
+				Path:         writeScript(t, tc.script),
+			}
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			ip, ok := p.GetIP(ctx, mockPP, tc.ipNet)
+			require.Equal(t, tc.expected, ip)
+			require.Equal(t, tc.expected.IsValid(), ok)
+		})
+	}
+}
+
+func TestExecGetIPNotFound(t *testing.T) {
+	t.Parallel()
+
+	p := protocol.Exec{
+		ProviderName: "",
+		Path:         filepath.Join(t.TempDir(), "missing"),
+	}
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Noticef(pp.EmojiError, "Failed to run %q: %v", p.Path, gomock.Any())
+
+	ip, ok := p.GetIP(context.Background(), mockPP, ipnet.IP4)
+	require.False(t, ok)
+	require.Zero(t, ip)
+}
@@ -0,0 +1,80 @@
+package protocol
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/favonia/cloudflare-ddns/internal/file"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// parseIPFile parses the IP addresses in the content of a file, separated by spaces or newlines.
+// Everything after "#" on a line is ignored.
+func parseIPFile(ppfmt pp.PP, path string, content string) ([]netip.Addr, bool) {
+	var ips []netip.Addr
+	for _, line := range strings.Split(content, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		for _, word := range strings.Fields(line) {
+			ip, err := netip.ParseAddr(word)
+			if err != nil {
+				ppfmt.Noticef(pp.EmojiError, "Failed to parse %q in %q as an IP address", word, path)
+				return nil, false
+			}
+			ips = append(ips, ip)
+		}
+	}
+	return ips, true
+}
+
+// File represents a generic detection protocol to read the IP address from a file.
+type File struct {
+	ProviderName string        // name of the protocol
+	Path         string        // path of the file
+	MaxAge       time.Duration // maximum age of the file; zero means no limit
+}
+
+// Name of the detection protocol.
+func (p File) Name() string {
+	return p.ProviderName
+}
+
+// GetIP detects the IP address by reading the file. If the file contains exactly one
+// IP address, that address is used; otherwise, the first address of the IP network is used.
+func (p File) GetIP(_ context.Context, ppfmt pp.PP, ipNet ipnet.Type) (netip.Addr, bool) {
+	content, modTime, ok := file.ReadStringWithModTime(ppfmt, p.Path)
+	if !ok {
+		return netip.Addr{}, false
+	}
+
+	if age := time.Since(modTime); p.MaxAge > 0 && age > p.MaxAge {
+		ppfmt.Noticef(pp.EmojiError,
+			"The file %q was last modified %v ago, longer than the maximum age %v",
+			p.Path, age.Round(time.Second), p.MaxAge)
+		return netip.Addr{}, false
+	}
+
+	ips, ok := parseIPFile(ppfmt, p.Path, content)
+	if !ok {
+		return netip.Addr{}, false
+	}
+
+	switch len(ips) {
+	case 0:
+		ppfmt.Noticef(pp.EmojiError, "The file %q does not contain any IP address", p.Path)
+		return netip.Addr{}, false
+	case 1:
+		return ipNet.NormalizeDetectedIP(ppfmt, ips[0])
+	}
+
+	for _, ip := range ips {
+		if ipNet.Matches(ip) {
+			return ipNet.NormalizeDetectedIP(ppfmt, ip)
+		}
+	}
+
+	ppfmt.Noticef(pp.EmojiError, "The file %q does not contain any %s address", p.Path, ipNet.Describe())
+	return netip.Addr{}, false
+}
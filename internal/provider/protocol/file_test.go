@@ -0,0 +1,133 @@
+// vim: nowrap
+package protocol_test
+
+import (
+	"context"
+	"net/netip"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/file"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+func TestFileName(t *testing.T) {
+	t.Parallel()
+
+	p := protocol.File{
+		ProviderName: "very secret name",
+		Path:         "",
+		MaxAge:       0,
+	}
+
+	require.Equal(t, "very secret name", p.Name())
+}
+
+func useMemFS(t *testing.T, memfs fstest.MapFS) {
+	t.Helper()
+	file.FS = memfs
+	t.Cleanup(func() { file.FS = os.DirFS("/") })
+}
+
+//nolint:paralleltest // changing global var file.FS
+func TestFileGetIP(t *testing.T) {
+	const path = "/run/wan-ip"
+
+	for name, tc := range map[string]struct {
+		content       string
+		age           time.Duration
+		maxAge        time.Duration
+		ipNet         ipnet.Type
+		expected      netip.Addr
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"one":    {"1.2.3.4\n", time.Hour, 0, ipnet.IP4, netip.MustParseAddr("1.2.3.4"), nil},
+		"both/4": {"# written by ip-up\n1.2.3.4\n2001:db8::1 # PD\n", 0, time.Minute, ipnet.IP4, netip.MustParseAddr("1.2.3.4"), nil},
+		"both/6": {"1.2.3.4 2001:db8::1", 0, time.Minute, ipnet.IP6, netip.MustParseAddr("2001:db8::1"), nil},
+		"mapped": {"::ffff:1.2.3.4", 0, 0, ipnet.IP4, netip.MustParseAddr("1.2.3.4"), nil},
+		"one/wrong-family": {
+			"1.2.3.4", 0, 0, ipnet.IP6, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Detected IP address %s is not a valid IPv6 address", "1.2.3.4")
+			},
+		},
+		"both/missing": {
+			"1.2.3.4 5.6.7.8", 0, 0, ipnet.IP6, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "The file %q does not contain any %s address", path, "IPv6")
+			},
+		},
+		"empty": {
+			"# nothing yet\n", 0, 0, ipnet.IP4, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "The file %q does not contain any IP address", path)
+			},
+		},
+		"invalid": {
+			"1.2.3.4 down", 0, 0, ipnet.IP4, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Failed to parse %q in %q as an IP address", "down", path)
+			},
+		},
+		"stale": {
+			"1.2.3.4", 2 * time.Hour, time.Hour, ipnet.IP4, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "The file %q was last modified %v ago, longer than the maximum age %v", path, 2*time.Hour, time.Hour)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			useMemFS(t, fstest.MapFS{
+				"run/wan-ip": &fstest.MapFile{
+					Data:    []byte(tc.content),
+					Mode:    0o644,
+					ModTime: time.Now().Add(-tc.age),
+					Sys:     nil,
+				},
+			})
+
+			p := protocol.File{
+				ProviderName: "",
+				Path:         path,
+				MaxAge:       tc.maxAge,
+			}
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			ip, ok := p.GetIP(context.Background(), mockPP, tc.ipNet)
+			require.Equal(t, tc.expected, ip)
+			require.Equal(t, tc.expected.IsValid(), ok)
+		})
+	}
+}
+
+//nolint:paralleltest // changing global var file.FS
+func TestFileGetIPMissing(t *testing.T) {
+	useMemFS(t, fstest.MapFS{})
+
+	p := protocol.File{
+		ProviderName: "",
+		Path:         "/run/wan-ip",
+		MaxAge:       0,
+	}
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Noticef(pp.EmojiUserError, "Failed to read %q: %v", "run/wan-ip", gomock.Any())
+
+	ip, ok := p.GetIP(context.Background(), mockPP, ipnet.IP4)
+	require.False(t, ok)
+	require.Zero(t, ip)
+}
@@ -0,0 +1,151 @@
+package protocol
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// Gateway detects the IPv4 address by asking the default gateway (usually the home router)
+// for its external address. It tries UPnP IGD, NAT-PMP, and PCP, in that order.
+type Gateway struct {
+	// Name of the detection protocol.
+	ProviderName string
+
+	// The gateway to ask. If it is not valid, the default IPv4 gateway in the routing table is used.
+	Gateway netip.Addr
+
+	// The UDP address to send SSDP M-SEARCH requests to (usually the SSDP multicast address).
+	SSDPAddr string
+
+	// The UDP port of the NAT-PMP and PCP server on the gateway (usually 5351).
+	PortMappingPort uint16
+}
+
+// Name of the detection protocol.
+func (p Gateway) Name() string {
+	return p.ProviderName
+}
+
+// routeTablePath is the Linux routing table.
+const routeTablePath = "/proc/net/route"
+
+var errNoDefaultGateway = errors.New("no default gateway")
+
+// ParseDefaultGateway4 finds the default IPv4 gateway in the Linux routing table (/proc/net/route).
+func ParseDefaultGateway4(table string) (netip.Addr, error) {
+	const rtfGateway = 0x2 // RTF_GATEWAY
+
+	scanner := bufio.NewScanner(strings.NewReader(table))
+	scanner.Scan() // skip the header
+	for scanner.Scan() {
+		// Iface Destination Gateway Flags RefCnt Use Metric Mask MTU Window IRTT
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 || fields[1] != "00000000" || fields[7] != "00000000" {
+			continue
+		}
+
+		flags, err := strconv.ParseUint(fields[3], 16, 16)
+		if err != nil || flags&rtfGateway == 0 {
+			continue
+		}
+
+		raw, err := hex.DecodeString(fields[2])
+		if err != nil || len(raw) != 4 {
+			return netip.Addr{}, fmt.Errorf("invalid gateway %q", fields[2])
+		}
+
+		// The kernel prints the address as a number in the native byte order.
+		var addr [4]byte
+		binary.NativeEndian.PutUint32(addr[:], binary.BigEndian.Uint32(raw))
+		return netip.AddrFrom4(addr), nil
+	}
+
+	return netip.Addr{}, errNoDefaultGateway
+}
+
+func (p Gateway) getGateway(ppfmt pp.PP) (netip.Addr, bool) {
+	if p.Gateway.IsValid() {
+		return p.Gateway, true
+	}
+
+	table, err := os.ReadFile(routeTablePath)
+	if err != nil {
+		ppfmt.Noticef(pp.EmojiError, "Failed to read the routing table: %v", err)
+		return netip.Addr{}, false
+	}
+
+	gateway, err := ParseDefaultGateway4(string(table))
+	if err != nil {
+		ppfmt.Noticef(pp.EmojiError, "Failed to find the default IPv4 gateway: %v", err)
+		return netip.Addr{}, false
+	}
+
+	return gateway, true
+}
+
+// cgnatPrefix is the shared address space for carrier-grade NAT (RFC 6598).
+//
+//nolint:gochecknoglobals
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// GetIP detects the IP address by asking the gateway.
+func (p Gateway) GetIP(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) (netip.Addr, bool) {
+	if ipNet != ipnet.IP4 {
+		ppfmt.Noticef(pp.EmojiError, "The provider %s can only detect IPv4 addresses", p.ProviderName)
+		return netip.Addr{}, false
+	}
+
+	gateway, ok := p.getGateway(ppfmt)
+	if !ok {
+		return netip.Addr{}, false
+	}
+
+	methods := []struct {
+		name  string
+		getIP func(context.Context, netip.Addr) (netip.Addr, error)
+	}{
+		{"UPnP IGD", p.getIPFromUPnP},
+		{"NAT-PMP", p.getIPFromNATPMP},
+		{"PCP", p.getIPFromPCP},
+	}
+
+	for _, m := range methods {
+		ip, err := m.getIP(ctx, gateway)
+		if err != nil {
+			ppfmt.Infof(pp.EmojiWarning, "Failed to get the external address from the gateway %s via %s: %v",
+				gateway.String(), m.name, err)
+			if ctx.Err() != nil {
+				return netip.Addr{}, false
+			}
+			continue
+		}
+
+		if ip.Is4() && cgnatPrefix.Contains(ip) {
+			ppfmt.Noticef(pp.EmojiError,
+				"The gateway %s reported the external address %s (via %s), which is in the range %s for carrier-grade NAT",
+				gateway.String(), ip.String(), m.name, cgnatPrefix.String())
+			ppfmt.InfoOncef(pp.MessageCarrierGradeNAT, pp.EmojiHint,
+				"Your internet service provider seems to put your router behind carrier-grade NAT, "+
+					"so the address known to your router is not your public IP address. "+
+					`Please use a provider such as "cloudflare.trace" that asks a server on the internet`)
+			return netip.Addr{}, false
+		}
+
+		return ipNet.NormalizeDetectedIP(ppfmt, ip)
+	}
+
+	ppfmt.Noticef(pp.EmojiError,
+		"Failed to get the external address from the gateway %s via any of UPnP IGD, NAT-PMP, and PCP", gateway.String())
+	return netip.Addr{}, false
+}
@@ -0,0 +1,130 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+)
+
+// This file implements the minimum of NAT-PMP (RFC 6886) and PCP (RFC 6887)
+// to learn the external address of the gateway.
+
+const (
+	// Both RFCs suggest longer retransmission schedules, but we would rather
+	// give up early and leave the time to other providers.
+	portMappingInitialRTO       = 250 * time.Millisecond
+	portMappingMaxTransmissions = 3
+
+	natpmpVersion                    = 0
+	natpmpOpExternalAddress          = 0
+	natpmpResponseBit                = 0x80
+	natpmpExternalAddressSize        = 12
+	pcpVersion                       = 2
+	pcpOpMap                         = 1
+	pcpResponseBit                   = 0x80
+	pcpMapSize                       = 60
+	pcpMapLifetime            uint32 = 60 // seconds; the mapping will be deleted right away
+	pcpProtocolUDP                   = 17
+)
+
+var errShortResponse = errors.New("response too short")
+
+func (p Gateway) dialPortMapping(ctx context.Context, gateway netip.Addr) (net.Conn, error) {
+	return splitDialer(ctx, ipnet.IP4).DialContext(ctx, "udp",
+		netip.AddrPortFrom(gateway, p.PortMappingPort).String())
+}
+
+func (p Gateway) getIPFromNATPMP(ctx context.Context, gateway netip.Addr) (netip.Addr, error) {
+	conn, err := p.dialPortMapping(ctx, gateway)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	defer conn.Close()
+
+	response, err := exchangeUDP(ctx, conn, []byte{natpmpVersion, natpmpOpExternalAddress},
+		portMappingInitialRTO, portMappingMaxTransmissions,
+		func(msg []byte) bool {
+			return len(msg) >= 2 && msg[0] == natpmpVersion && msg[1] == natpmpResponseBit|natpmpOpExternalAddress
+		})
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	if len(response) < 4 {
+		return netip.Addr{}, errShortResponse
+	}
+	if result := binary.BigEndian.Uint16(response[2:4]); result != 0 {
+		return netip.Addr{}, fmt.Errorf("result code %d", result)
+	}
+	if len(response) < natpmpExternalAddressSize {
+		return netip.Addr{}, errShortResponse
+	}
+
+	return netip.AddrFrom4([4]byte(response[8:12])), nil
+}
+
+// newPCPMapRequest creates a MAP request for the local UDP port.
+func newPCPMapRequest(local netip.AddrPort, nonce [12]byte, lifetime uint32) []byte {
+	msg := make([]byte, pcpMapSize)
+	msg[0] = pcpVersion
+	msg[1] = pcpOpMap
+	binary.BigEndian.PutUint32(msg[4:8], lifetime)
+	clientIP := local.Addr().As16() // IPv4 addresses are IPv4-mapped
+	copy(msg[8:24], clientIP[:])
+	copy(msg[24:36], nonce[:])
+	msg[36] = pcpProtocolUDP
+	binary.BigEndian.PutUint16(msg[40:42], local.Port())
+	// suggested external port: 0 (no preference)
+	anyIP4 := netip.IPv4Unspecified().As16()
+	copy(msg[44:60], anyIP4[:])
+	return msg
+}
+
+func (p Gateway) getIPFromPCP(ctx context.Context, gateway netip.Addr) (netip.Addr, error) {
+	conn, err := p.dialPortMapping(ctx, gateway)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	defer conn.Close()
+
+	local, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return netip.Addr{}, fmt.Errorf("unexpected local address %q", conn.LocalAddr().String())
+	}
+	localAddrPort := netip.AddrPortFrom(local.AddrPort().Addr().Unmap(), local.AddrPort().Port())
+
+	var nonce [12]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return netip.Addr{}, err
+	}
+
+	response, err := exchangeUDP(ctx, conn, newPCPMapRequest(localAddrPort, nonce, pcpMapLifetime),
+		portMappingInitialRTO, portMappingMaxTransmissions,
+		func(msg []byte) bool {
+			return len(msg) >= 4 && msg[0] == pcpVersion && msg[1] == pcpResponseBit|pcpOpMap &&
+				(len(msg) < pcpMapSize || bytes.Equal(msg[24:36], nonce[:]))
+		})
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	if result := response[3]; result != 0 {
+		return netip.Addr{}, fmt.Errorf("result code %d", result)
+	}
+	if len(response) < pcpMapSize {
+		return netip.Addr{}, errShortResponse
+	}
+
+	// Delete the mapping right away; it is fine if this fails.
+	_, _ = conn.Write(newPCPMapRequest(localAddrPort, nonce, 0))
+
+	return netip.AddrFrom16([16]byte(response[44:60])).Unmap(), nil
+}
@@ -0,0 +1,448 @@
+// vim: nowrap
+package protocol_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+func TestGatewayName(t *testing.T) {
+	t.Parallel()
+
+	p := protocol.Gateway{
+		ProviderName:    "very secret name",
+		Gateway:         netip.Addr{},
+		SSDPAddr:        "",
+		PortMappingPort: 0,
+	}
+
+	require.Equal(t, "very secret name", p.Name())
+}
+
+func TestParseDefaultGateway4(t *testing.T) {
+	t.Parallel()
+
+	const header = "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\t\tMTU\tWindow\tIRTT\n"
+
+	for name, tc := range map[string]struct {
+		table    string
+		expected netip.Addr
+		ok       bool
+	}{
+		"default": {
+			header +
+				"eth0\t0000A8C0\t00000000\t0001\t0\t0\t0\t00FFFFFF\t0\t0\t0\n" +
+				"eth0\t00000000\t0100A8C0\t0003\t0\t0\t100\t00000000\t0\t0\t0\n",
+			netip.MustParseAddr("192.168.0.1"), true,
+		},
+		"no-gateway-flag": {
+			header + "eth0\t00000000\t0100A8C0\t0001\t0\t0\t100\t00000000\t0\t0\t0\n",
+			netip.Addr{}, false,
+		},
+		"no-default": {
+			header + "eth0\t0000A8C0\t00000000\t0001\t0\t0\t0\t00FFFFFF\t0\t0\t0\n",
+			netip.Addr{}, false,
+		},
+		"invalid": {
+			header + "eth0\t00000000\t0100A8\t0003\t0\t0\t100\t00000000\t0\t0\t0\n",
+			netip.Addr{}, false,
+		},
+		"empty": {"", netip.Addr{}, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ip, err := protocol.ParseDefaultGateway4(tc.table)
+			if tc.ok {
+				require.NoError(t, err)
+				// The routing table is in the native byte order, which is little-endian on all supported platforms.
+				require.Equal(t, tc.expected, ip)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+const upnpDescription = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <specVersion><major>1</major><minor>0</minor></specVersion>
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:1</deviceType>
+    <serviceList>
+      <service>
+        <serviceType>urn:schemas-upnp-org:service:Layer3Forwarding:1</serviceType>
+        <controlURL>/ctl/L3F</controlURL>
+      </service>
+    </serviceList>
+    <deviceList>
+      <device>
+        <deviceType>urn:schemas-upnp-org:device:WANDevice:1</deviceType>
+        <deviceList>
+          <device>
+            <deviceType>urn:schemas-upnp-org:device:WANConnectionDevice:1</deviceType>
+            <serviceList>
+              <service>
+                <serviceType>urn:schemas-upnp-org:service:WANIPConnection:1</serviceType>
+                <controlURL>%s</controlURL>
+              </service>
+            </serviceList>
+          </device>
+        </deviceList>
+      </device>
+    </deviceList>
+  </device>
+</root>`
+
+const upnpResponse = `<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+  <s:Body>
+    <u:GetExternalIPAddressResponse xmlns:u="urn:schemas-upnp-org:service:WANIPConnection:1">
+      <NewExternalIPAddress>%s</NewExternalIPAddress>
+    </u:GetExternalIPAddressResponse>
+  </s:Body>
+</s:Envelope>`
+
+// newFakeUPnPGateway starts a fake SSDP responder and a fake HTTP server for UPnP IGD.
+// It returns the SSDP address.
+func newFakeUPnPGateway(t *testing.T, externalIP string, controlURL func(server string) string) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/desc.xml":
+			fmt.Fprintf(w, upnpDescription, controlURL("http://"+r.Host))
+		case r.Method == http.MethodPost && r.URL.Path == "/ctl/IPConn":
+			if !assert.Equal(t, `"urn:schemas-upnp-org:service:WANIPConnection:1#GetExternalIPAddress"`, r.Header.Get("SOAPAction")) {
+				panic(http.ErrAbortHandler)
+			}
+			fmt.Fprintf(w, upnpResponse, externalIP)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			if err != nil || !strings.HasPrefix(string(buf[:n]), "M-SEARCH * HTTP/1.1\r\n") ||
+				!strings.Contains(string(buf[:n]), "\r\nST: urn:schemas-upnp-org:device:InternetGatewayDevice:1\r\n") {
+				continue
+			}
+			_, _ = conn.WriteTo([]byte("HTTP/1.1 200 OK\r\n"+
+				"CACHE-CONTROL: max-age=120\r\n"+
+				"ST: urn:schemas-upnp-org:device:InternetGatewayDevice:1\r\n"+
+				"USN: uuid:fake::urn:schemas-upnp-org:device:InternetGatewayDevice:1\r\n"+
+				"LOCATION: "+server.URL+"/desc.xml\r\n"+
+				"\r\n"), addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+// newSilentUDPAddr returns the address of a UDP socket that never responds.
+func newSilentUDPAddr(t *testing.T) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn.LocalAddr().String()
+}
+
+// newClosedUDPPort returns a UDP port on which nothing is listening.
+func newClosedUDPPort(t *testing.T) uint16 {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := conn.LocalAddr().(*net.UDPAddr).AddrPort().Port() //nolint:forcetypeassert
+	conn.Close()
+	return port
+}
+
+// newFakePortMappingServer starts a fake NAT-PMP/PCP server and returns its port.
+func newFakePortMappingServer(t *testing.T, handler func(req []byte) []byte) uint16 {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			if err != nil {
+				continue
+			}
+			if resp := handler(append([]byte{}, buf[:n]...)); resp != nil {
+				_, _ = conn.WriteTo(resp, addr)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().(*net.UDPAddr).AddrPort().Port() //nolint:forcetypeassert
+}
+
+func natpmpResponse(result uint16, ip netip.Addr) []byte {
+	resp := make([]byte, 12)
+	resp[1] = 128
+	binary.BigEndian.PutUint16(resp[2:4], result)
+	ip4 := ip.As4()
+	copy(resp[8:12], ip4[:])
+	return resp
+}
+
+// pcpHandler responds to NAT-PMP requests with "unsupported version"
+// and to PCP MAP requests with the IP address. Deletion requests are sent to deleted.
+func pcpHandler(ip netip.Addr, result byte, deleted chan<- []byte) func([]byte) []byte {
+	return func(req []byte) []byte {
+		switch {
+		case len(req) >= 2 && req[0] == 0:
+			return natpmpResponse(1, netip.IPv4Unspecified())
+		case len(req) == 60 && req[0] == 2 && req[1] == 1:
+			if binary.BigEndian.Uint32(req[4:8]) == 0 {
+				deleted <- req
+				return nil
+			}
+			resp := make([]byte, 60)
+			resp[0] = 2
+			resp[1] = 0x81
+			resp[3] = result
+			copy(resp[4:8], req[4:8])
+			copy(resp[24:44], req[24:44])
+			ip16 := ip.As16()
+			copy(resp[44:60], ip16[:])
+			return resp
+		default:
+			return nil
+		}
+	}
+}
+
+func TestGatewayGetIP(t *testing.T) {
+	t.Parallel()
+
+	gateway := netip.MustParseAddr("127.0.0.1")
+	ip := netip.MustParseAddr("203.0.113.1")
+	cgnat := netip.MustParseAddr("100.64.1.2")
+	invalidIP := netip.Addr{}
+	relative := func(string) string { return "/ctl/IPConn" }
+
+	for name, tc := range map[string]struct {
+		ssdpAddr      func(*testing.T) string
+		port          func(*testing.T) uint16
+		expected      netip.Addr
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"upnp": {
+			func(t *testing.T) string { return newFakeUPnPGateway(t, ip.String(), relative) },
+			newClosedUDPPort,
+			ip, nil,
+		},
+		"upnp/absolute-control-url": {
+			func(t *testing.T) string {
+				return newFakeUPnPGateway(t, ip.String(), func(server string) string { return server + "/ctl/IPConn" })
+			},
+			newClosedUDPPort,
+			ip, nil,
+		},
+		"upnp/control-url-elsewhere": {
+			func(t *testing.T) string {
+				return newFakeUPnPGateway(t, ip.String(), func(string) string { return "http://192.0.2.1/ctl/IPConn" })
+			},
+			func(t *testing.T) uint16 {
+				return newFakePortMappingServer(t, func([]byte) []byte { return natpmpResponse(0, ip) })
+			},
+			ip,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiWarning, "Failed to get the external address from the gateway %s via %s: %v", "127.0.0.1", "UPnP IGD", errors.New(`"http://192.0.2.1/ctl/IPConn" does not point to the gateway`))
+			},
+		},
+		"upnp/cgnat": {
+			func(t *testing.T) string { return newFakeUPnPGateway(t, cgnat.String(), relative) },
+			newClosedUDPPort,
+			invalidIP,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Noticef(pp.EmojiError, "The gateway %s reported the external address %s (via %s), which is in the range %s for carrier-grade NAT", "127.0.0.1", "100.64.1.2", "UPnP IGD", "100.64.0.0/10"),
+					m.EXPECT().InfoOncef(pp.MessageCarrierGradeNAT, pp.EmojiHint, "Your internet service provider seems to put your router behind carrier-grade NAT, so the address known to your router is not your public IP address. "+`Please use a provider such as "cloudflare.trace" that asks a server on the internet`),
+				)
+			},
+		},
+		"upnp/invalid": {
+			func(t *testing.T) string { return newFakeUPnPGateway(t, "not an address", relative) },
+			newClosedUDPPort,
+			invalidIP,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Infof(pp.EmojiWarning, "Failed to get the external address from the gateway %s via %s: %v", "127.0.0.1", "UPnP IGD", errors.New(`invalid external address "not an address"`)),
+					m.EXPECT().Infof(pp.EmojiWarning, "Failed to get the external address from the gateway %s via %s: %v", "127.0.0.1", "NAT-PMP", gomock.Any()),
+					m.EXPECT().Infof(pp.EmojiWarning, "Failed to get the external address from the gateway %s via %s: %v", "127.0.0.1", "PCP", gomock.Any()),
+					m.EXPECT().Noticef(pp.EmojiError, "Failed to get the external address from the gateway %s via any of UPnP IGD, NAT-PMP, and PCP", "127.0.0.1"),
+				)
+			},
+		},
+		"natpmp": {
+			newSilentUDPAddr,
+			func(t *testing.T) uint16 {
+				return newFakePortMappingServer(t, func([]byte) []byte { return natpmpResponse(0, ip) })
+			},
+			ip,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiWarning, "Failed to get the external address from the gateway %s via %s: %v", "127.0.0.1", "UPnP IGD", errors.New("no UPnP IGD responded"))
+			},
+		},
+		"natpmp/cgnat": {
+			newSilentUDPAddr,
+			func(t *testing.T) uint16 {
+				return newFakePortMappingServer(t, func([]byte) []byte { return natpmpResponse(0, cgnat) })
+			},
+			invalidIP,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Infof(pp.EmojiWarning, "Failed to get the external address from the gateway %s via %s: %v", "127.0.0.1", "UPnP IGD", gomock.Any()),
+					m.EXPECT().Noticef(pp.EmojiError, "The gateway %s reported the external address %s (via %s), which is in the range %s for carrier-grade NAT", "127.0.0.1", "100.64.1.2", "NAT-PMP", "100.64.0.0/10"),
+					m.EXPECT().InfoOncef(pp.MessageCarrierGradeNAT, pp.EmojiHint, gomock.Any()),
+				)
+			},
+		},
+		"natpmp/no-address": {
+			newSilentUDPAddr,
+			func(t *testing.T) uint16 {
+				return newFakePortMappingServer(t, func([]byte) []byte { return natpmpResponse(0, netip.IPv4Unspecified()) })
+			},
+			invalidIP,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Infof(pp.EmojiWarning, "Failed to get the external address from the gateway %s via %s: %v", "127.0.0.1", "UPnP IGD", gomock.Any()),
+					m.EXPECT().Noticef(pp.EmojiError, "Detected %s address %s is an unspecified address", "IPv4", "0.0.0.0"),
+				)
+			},
+		},
+		"pcp": {
+			newSilentUDPAddr,
+			func(t *testing.T) uint16 {
+				return newFakePortMappingServer(t, pcpHandler(ip, 0, make(chan []byte, 1)))
+			},
+			ip,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Infof(pp.EmojiWarning, "Failed to get the external address from the gateway %s via %s: %v", "127.0.0.1", "UPnP IGD", gomock.Any()),
+					m.EXPECT().Infof(pp.EmojiWarning, "Failed to get the external address from the gateway %s via %s: %v", "127.0.0.1", "NAT-PMP", errors.New("result code 1")),
+				)
+			},
+		},
+		"pcp/error": {
+			newSilentUDPAddr,
+			func(t *testing.T) uint16 {
+				return newFakePortMappingServer(t, pcpHandler(ip, 2, make(chan []byte, 1)))
+			},
+			invalidIP,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Infof(pp.EmojiWarning, "Failed to get the external address from the gateway %s via %s: %v", "127.0.0.1", "UPnP IGD", gomock.Any()),
+					m.EXPECT().Infof(pp.EmojiWarning, "Failed to get the external address from the gateway %s via %s: %v", "127.0.0.1", "NAT-PMP", errors.New("result code 1")),
+					m.EXPECT().Infof(pp.EmojiWarning, "Failed to get the external address from the gateway %s via %s: %v", "127.0.0.1", "PCP", errors.New("result code 2")),
+					m.EXPECT().Noticef(pp.EmojiError, "Failed to get the external address from the gateway %s via any of UPnP IGD, NAT-PMP, and PCP", "127.0.0.1"),
+				)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			p := protocol.Gateway{
+				ProviderName:    "gateway",
+				Gateway:         gateway,
+				SSDPAddr:        tc.ssdpAddr(t),
+				PortMappingPort: tc.port(t),
+			}
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			detected, ok := p.GetIP(context.Background(), mockPP, ipnet.IP4)
+			require.Equal(t, tc.expected, detected)
+			require.Equal(t, tc.expected.IsValid(), ok)
+		})
+	}
+}
+
+func TestGatewayGetIPPCPDeletesMapping(t *testing.T) {
+	t.Parallel()
+
+	ip := netip.MustParseAddr("203.0.113.1")
+	deleted := make(chan []byte, 1)
+	p := protocol.Gateway{
+		ProviderName:    "gateway",
+		Gateway:         netip.MustParseAddr("127.0.0.1"),
+		SSDPAddr:        newSilentUDPAddr(t),
+		PortMappingPort: newFakePortMappingServer(t, pcpHandler(ip, 0, deleted)),
+	}
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Infof(pp.EmojiWarning, "Failed to get the external address from the gateway %s via %s: %v", "127.0.0.1", gomock.Any(), gomock.Any()).Times(2)
+
+	detected, ok := p.GetIP(context.Background(), mockPP, ipnet.IP4)
+	require.True(t, ok)
+	require.Equal(t, ip, detected)
+
+	req := <-deleted
+	require.Equal(t, byte(17), req[36]) // UDP
+	require.NotZero(t, binary.BigEndian.Uint16(req[40:42]))
+}
+
+func TestGatewayGetIP6(t *testing.T) {
+	t.Parallel()
+
+	p := protocol.Gateway{
+		ProviderName:    "gateway",
+		Gateway:         netip.MustParseAddr("127.0.0.1"),
+		SSDPAddr:        "",
+		PortMappingPort: 0,
+	}
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Noticef(pp.EmojiError, "The provider %s can only detect IPv4 addresses", "gateway")
+
+	detected, ok := p.GetIP(context.Background(), mockPP, ipnet.IP6)
+	require.False(t, ok)
+	require.Zero(t, detected)
+}
@@ -0,0 +1,265 @@
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+)
+
+// This file implements the minimum of UPnP IGD to call GetExternalIPAddress.
+
+const (
+	ssdpSearchTarget = "urn:schemas-upnp-org:device:InternetGatewayDevice:1"
+	ssdpWait         = time.Second // also used as the MX value of M-SEARCH
+)
+
+//nolint:gochecknoglobals
+var upnpWANServicePrefixes = []string{
+	"urn:schemas-upnp-org:service:WANIPConnection:",
+	"urn:schemas-upnp-org:service:WANPPPConnection:",
+}
+
+// gatewayClient is the HTTP client to talk to the gateway. It does not use any proxy
+// and it does not follow redirections, so that only the gateway is contacted.
+//
+//nolint:gochecknoglobals
+var gatewayClient = &http.Client{ //nolint:exhaustruct
+	Transport: &http.Transport{ //nolint:exhaustruct
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return splitDialer(ctx, ipnet.IP4).DialContext(ctx, network, address)
+		},
+		DisableKeepAlives: true,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+var (
+	errNoUPnPGateway    = errors.New("no UPnP IGD responded")
+	errNoUPnPWANService = errors.New("no WANIPConnection or WANPPPConnection service")
+)
+
+// checkGatewayURL parses rawURL relative to base and checks that it points to the gateway.
+func checkGatewayURL(base *url.URL, rawURL string, gateway netip.Addr) (*url.URL, error) {
+	u, err := base.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("%q is not an HTTP URL", u.Redacted())
+	}
+
+	if host, err := netip.ParseAddr(u.Hostname()); err != nil || host.Unmap() != gateway {
+		return nil, fmt.Errorf("%q does not point to the gateway", u.Redacted())
+	}
+
+	return u, nil
+}
+
+// discoverUPnP sends an SSDP M-SEARCH request and returns the location of
+// the device description of the first Internet Gateway Device at the gateway.
+func (p Gateway) discoverUPnP(ctx context.Context, gateway netip.Addr) (*url.URL, error) {
+	dst, err := net.ResolveUDPAddr("udp4", p.SSDPAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(ssdpWait)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	request := "M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: " + p.SSDPAddr + "\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		fmt.Sprintf("MX: %d\r\n", int(ssdpWait/time.Second)) +
+		"ST: " + ssdpSearchTarget + "\r\n" +
+		"\r\n"
+	if _, err := conn.WriteTo([]byte(request), dst); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, maxUDPMessageLength)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return nil, errNoUPnPGateway
+			}
+			return nil, err
+		}
+
+		// Only the gateway is trusted.
+		if from, ok := from.(*net.UDPAddr); !ok || from.AddrPort().Addr().Unmap() != gateway {
+			continue
+		}
+
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:n])), nil)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("ST") != ssdpSearchTarget {
+			continue
+		}
+
+		return checkGatewayURL(&url.URL{}, resp.Header.Get("LOCATION"), gateway) //nolint:exhaustruct
+	}
+}
+
+type upnpService struct {
+	ServiceType string `xml:"serviceType"`
+	ControlURL  string `xml:"controlURL"`
+}
+
+type upnpDevice struct {
+	Services []upnpService `xml:"serviceList>service"`
+	Devices  []upnpDevice  `xml:"deviceList>device"`
+}
+
+// findWANService finds the first WANIPConnection or WANPPPConnection service in the device tree.
+func (d upnpDevice) findWANService() (upnpService, bool) {
+	for _, s := range d.Services {
+		for _, prefix := range upnpWANServicePrefixes {
+			if strings.HasPrefix(s.ServiceType, prefix) {
+				return s, true
+			}
+		}
+	}
+	for _, sub := range d.Devices {
+		if s, ok := sub.findWANService(); ok {
+			return s, true
+		}
+	}
+	return upnpService{}, false //nolint:exhaustruct
+}
+
+func doGatewayRequest(req *http.Request) ([]byte, error) {
+	resp, err := gatewayClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", req.URL.Redacted(), resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxReadLength))
+}
+
+// getUPnPWANService reads the device description and returns the WAN service and its control URL.
+func getUPnPWANService(ctx context.Context, location *url.URL, gateway netip.Addr) (upnpService, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location.String(), nil)
+	if err != nil {
+		return upnpService{}, nil, err //nolint:exhaustruct
+	}
+
+	body, err := doGatewayRequest(req)
+	if err != nil {
+		return upnpService{}, nil, err //nolint:exhaustruct
+	}
+
+	var root struct {
+		URLBase string     `xml:"URLBase"`
+		Device  upnpDevice `xml:"device"`
+	}
+	if err := xml.Unmarshal(body, &root); err != nil {
+		return upnpService{}, nil, fmt.Errorf("invalid device description: %w", err) //nolint:exhaustruct
+	}
+
+	service, ok := root.Device.findWANService()
+	if !ok {
+		return upnpService{}, nil, errNoUPnPWANService //nolint:exhaustruct
+	}
+
+	base := location
+	if root.URLBase != "" {
+		if base, err = checkGatewayURL(location, root.URLBase, gateway); err != nil {
+			return upnpService{}, nil, err //nolint:exhaustruct
+		}
+	}
+
+	controlURL, err := checkGatewayURL(base, service.ControlURL, gateway)
+	if err != nil {
+		return upnpService{}, nil, err //nolint:exhaustruct
+	}
+
+	return service, controlURL, nil
+}
+
+// callUPnPGetExternalIPAddress calls the action GetExternalIPAddress of the WAN service.
+func callUPnPGetExternalIPAddress(ctx context.Context, service upnpService, controlURL *url.URL) (netip.Addr, error) {
+	var body bytes.Buffer
+	body.WriteString(`<?xml version="1.0"?>` +
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" ` +
+		`s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">` +
+		`<s:Body><u:GetExternalIPAddress xmlns:u="`)
+	if err := xml.EscapeText(&body, []byte(service.ServiceType)); err != nil {
+		return netip.Addr{}, err
+	}
+	body.WriteString(`"/></s:Body></s:Envelope>`)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, controlURL.String(), &body)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", fmt.Sprintf(`"%s#GetExternalIPAddress"`, service.ServiceType))
+
+	respBody, err := doGatewayRequest(req)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	var envelope struct {
+		IP string `xml:"Body>GetExternalIPAddressResponse>NewExternalIPAddress"`
+	}
+	if err := xml.Unmarshal(respBody, &envelope); err != nil {
+		return netip.Addr{}, fmt.Errorf("invalid response: %w", err)
+	}
+
+	ip, err := netip.ParseAddr(strings.TrimSpace(envelope.IP))
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("invalid external address %q", envelope.IP)
+	}
+
+	return ip, nil
+}
+
+func (p Gateway) getIPFromUPnP(ctx context.Context, gateway netip.Addr) (netip.Addr, error) {
+	location, err := p.discoverUPnP(ctx, gateway)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	service, controlURL, err := getUPnPWANService(ctx, location, gateway)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	return callUPnPGetExternalIPAddress(ctx, service, controlURL)
+}
@@ -36,7 +36,7 @@ func (h httpCore) getIP(ctx context.Context, ppfmt pp.PP) (netip.Addr, bool) {
 		req.Header.Set(header, value)
 	}
 
-	c := SharedRetryableSplitClient(h.ipNet)
+	c := retryableSplitClient(ctx, h.ipNet)
 
 	resp, err := c.Do(req)
 	if err != nil {
@@ -0,0 +1,145 @@
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// JSONPath is a JSONPath-like selector, such as "$.wan.ipv4" or "$.interfaces[0].address".
+// Each step is either an object key or an array index, depending on the JSON value it is applied to.
+type JSONPath []string
+
+// String prints the selector in the form of "$.key[0]".
+func (path JSONPath) String() string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, step := range path {
+		switch {
+		case isJSONPathIndex(step):
+			b.WriteString("[" + step + "]")
+		case isJSONPathName(step):
+			b.WriteString("." + step)
+		default:
+			b.WriteString("[" + strconv.Quote(step) + "]")
+		}
+	}
+	return b.String()
+}
+
+// isJSONPathIndex checks whether the step is a valid array index.
+func isJSONPathIndex(step string) bool {
+	_, err := strconv.ParseUint(step, 10, 31)
+	return err == nil && (step == "0" || step[0] != '0')
+}
+
+// isJSONPathName checks whether the step can be written after a dot.
+func isJSONPathName(step string) bool {
+	return step != "" && strings.IndexFunc(step, func(r rune) bool {
+		return !(r == '_' || r == '-' || '0' <= r && r <= '9' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z')
+	}) < 0
+}
+
+// Select finds the value at the selector.
+func (path JSONPath) Select(value any) (any, bool) {
+	for _, step := range path {
+		switch v := value.(type) {
+		case map[string]any:
+			child, found := v[step]
+			if !found {
+				return nil, false
+			}
+			value = child
+		case []any:
+			if !isJSONPathIndex(step) {
+				return nil, false
+			}
+			i, _ := strconv.Atoi(step)
+			if i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func getIPFromJSON(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type, url string, path JSONPath,
+) (netip.Addr, bool) {
+	c := httpCore{
+		ipNet:             ipNet,
+		url:               url,
+		method:            http.MethodGet,
+		additionalHeaders: map[string]string{"Accept": "application/json"},
+		requestBody:       nil,
+		extract: func(ppfmt pp.PP, body []byte) (netip.Addr, bool) {
+			var invalidIP netip.Addr
+
+			var doc any
+			if err := json.Unmarshal(body, &doc); err != nil {
+				ppfmt.Noticef(pp.EmojiError, `Failed to parse the response of %q as JSON: %v`, url, err)
+				return invalidIP, false
+			}
+
+			value, found := path.Select(doc)
+			if !found {
+				ppfmt.Noticef(pp.EmojiError, `Failed to find %s in the response of %q`, path.String(), url)
+				return invalidIP, false
+			}
+
+			ipString, ok := value.(string)
+			if !ok {
+				ppfmt.Noticef(pp.EmojiError, `The value of %s in the response of %q is not a string`, path.String(), url)
+				return invalidIP, false
+			}
+
+			ip, err := netip.ParseAddr(strings.TrimSpace(ipString))
+			if err != nil {
+				ppfmt.Noticef(pp.EmojiError, `Failed to parse the IP address in the response of %q (%q)`, url, ipString)
+				return invalidIP, false
+			}
+			return ip, true
+		},
+	}
+
+	return c.getIP(ctx, ppfmt)
+}
+
+// JSONParam is the type of parameters for the JSON provider for a specific IP network.
+type JSONParam = struct {
+	URL  string   // URL of the JSON document
+	Path JSONPath // selector of the IP address
+}
+
+// JSON represents a generic detection protocol to extract the IP address from a JSON document.
+type JSON struct {
+	ProviderName string // name of the detection protocol
+	Param        map[ipnet.Type]JSONParam
+}
+
+// Name of the detection protocol.
+func (p JSON) Name() string { return p.ProviderName }
+
+// GetIP detects the IP address by extracting it from the JSON document.
+func (p JSON) GetIP(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) (netip.Addr, bool) {
+	param, found := p.Param[ipNet]
+	if !found {
+		ppfmt.Noticef(pp.EmojiImpossible, "Unhandled IP network: %s", ipNet.Describe())
+		return netip.Addr{}, false
+	}
+
+	ip, ok := getIPFromJSON(ctx, ppfmt, ipNet, param.URL, param.Path)
+	if !ok {
+		return netip.Addr{}, false
+	}
+
+	return ipNet.NormalizeDetectedIP(ppfmt, ip)
+}
@@ -0,0 +1,171 @@
+// vim: nowrap
+package protocol_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+func TestJSONName(t *testing.T) {
+	t.Parallel()
+
+	p := protocol.JSON{
+		ProviderName: "very secret name",
+		Param:        nil,
+	}
+
+	require.Equal(t, "very secret name", p.Name())
+}
+
+func TestJSONPathString(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		path     protocol.JSONPath
+		expected string
+	}{
+		"root":   {protocol.JSONPath{}, "$"},
+		"names":  {protocol.JSONPath{"wan", "ipv4"}, "$.wan.ipv4"},
+		"index":  {protocol.JSONPath{"wan", "0", "ip-address"}, "$.wan[0].ip-address"},
+		"quoted": {protocol.JSONPath{"ip address", "", "01"}, `$["ip address"][""].01`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, tc.path.String())
+		})
+	}
+}
+
+func TestJSONGetIP(t *testing.T) {
+	t.Parallel()
+
+	const doc = `{"wan": {"ipv4": "1.2.3.4", "ipv6": "2001:db8::4", "up": true, "bad": "hello"}, "list": [{"address": " 5.6.7.8 "}]}`
+
+	server4 := newSplitServer(ipnet.IP4, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, doc)
+	}))
+	t.Cleanup(server4.Close)
+	server6 := newSplitServer(ipnet.IP6, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, doc)
+	}))
+	t.Cleanup(server6.Close)
+	illformed := newSplitServer(ipnet.IP4, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "1.2.3.4")
+	}))
+	t.Cleanup(illformed.Close)
+
+	for name, tc := range map[string]struct {
+		url           string
+		path          protocol.JSONPath
+		ipNet         ipnet.Type
+		expected      netip.Addr
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"4":     {server4.URL, protocol.JSONPath{"wan", "ipv4"}, ipnet.IP4, netip.MustParseAddr("1.2.3.4"), nil},
+		"6":     {server6.URL, protocol.JSONPath{"wan", "ipv6"}, ipnet.IP6, netip.MustParseAddr("2001:db8::4"), nil},
+		"array": {server4.URL, protocol.JSONPath{"list", "0", "address"}, ipnet.IP4, netip.MustParseAddr("5.6.7.8"), nil},
+		"6to4": {
+			server4.URL, protocol.JSONPath{"wan", "ipv6"}, ipnet.IP4, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Detected IP address %s is not a valid IPv4 address", "2001:db8::4")
+			},
+		},
+		"missing": {
+			server4.URL, protocol.JSONPath{"lan", "ipv4"}, ipnet.IP4, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, `Failed to find %s in the response of %q`, "$.lan.ipv4", server4.URL)
+			},
+		},
+		"out-of-range": {
+			server4.URL, protocol.JSONPath{"list", "1", "address"}, ipnet.IP4, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, `Failed to find %s in the response of %q`, "$.list[1].address", server4.URL)
+			},
+		},
+		"not-index": {
+			server4.URL, protocol.JSONPath{"list", "first"}, ipnet.IP4, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, `Failed to find %s in the response of %q`, "$.list.first", server4.URL)
+			},
+		},
+		"too-deep": {
+			server4.URL, protocol.JSONPath{"wan", "ipv4", "value"}, ipnet.IP4, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, `Failed to find %s in the response of %q`, "$.wan.ipv4.value", server4.URL)
+			},
+		},
+		"not-string": {
+			server4.URL, protocol.JSONPath{"wan", "up"}, ipnet.IP4, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, `The value of %s in the response of %q is not a string`, "$.wan.up", server4.URL)
+			},
+		},
+		"not-ip": {
+			server4.URL, protocol.JSONPath{"wan", "bad"}, ipnet.IP4, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, `Failed to parse the IP address in the response of %q (%q)`, server4.URL, "hello")
+			},
+		},
+		"not-json": {
+			illformed.URL, protocol.JSONPath{"wan", "ipv4"}, ipnet.IP4, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, `Failed to parse the response of %q as JSON: %v`, illformed.URL, gomock.Any())
+			},
+		},
+		"request-fail": {
+			"", protocol.JSONPath{"wan", "ipv4"}, ipnet.IP4, netip.Addr{},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Failed to send HTTP(S) request to %q: %v", "", gomock.Any())
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			p := protocol.JSON{
+				ProviderName: "",
+				Param: map[ipnet.Type]protocol.JSONParam{
+					tc.ipNet: {tc.url, tc.path},
+				},
+			}
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			ip, ok := p.GetIP(context.Background(), mockPP, tc.ipNet)
+			require.Equal(t, tc.expected, ip)
+			require.Equal(t, tc.expected.IsValid(), ok)
+		})
+	}
+}
+
+func TestJSONGetIPUnhandled(t *testing.T) {
+	t.Parallel()
+
+	p := protocol.JSON{
+		ProviderName: "",
+		Param:        map[ipnet.Type]protocol.JSONParam{},
+	}
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Noticef(pp.EmojiImpossible, "Unhandled IP network: %s", "IPv6")
+
+	ip, ok := p.GetIP(context.Background(), mockPP, ipnet.IP6)
+	require.False(t, ok)
+	require.Zero(t, ip)
+}
@@ -0,0 +1,98 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/netip"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// Metadata is optional information about a detection, such as the data center that answered the request.
+// The keys and the values are specific to the protocol.
+type Metadata = map[string]string
+
+// parseKeyValues parses lines in the form key=value. Lines without "=" are skipped,
+// and the first value is used for a repeated key.
+func parseKeyValues(body []byte) map[string]string {
+	fields := map[string]string{}
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		key, value, found := bytes.Cut(bytes.TrimSpace(line), []byte("="))
+		if !found {
+			continue
+		}
+		if _, seen := fields[string(key)]; !seen {
+			fields[string(key)] = string(value)
+		}
+	}
+	return fields
+}
+
+// KeyValue represents a generic detection protocol to parse an HTTP response
+// consisting of lines in the form key=value, such as https://www.cloudflare.com/cdn-cgi/trace.
+type KeyValue struct {
+	ProviderName string   // name of the detection protocol
+	URL          string   // URL of the detection page
+	IPKey        string   // the key of the IP address
+	MetadataKeys []string // the keys to keep as metadata
+}
+
+// Name of the detection protocol.
+func (p KeyValue) Name() string { return p.ProviderName }
+
+// GetIP detects the IP address by parsing the HTTP response.
+func (p KeyValue) GetIP(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) (netip.Addr, bool) {
+	ip, _, ok := p.GetIPWithMetadata(ctx, ppfmt, ipNet)
+	return ip, ok
+}
+
+// GetIPWithMetadata detects the IP address by parsing the HTTP response,
+// and also returns the values of [KeyValue.MetadataKeys] found in the response.
+func (p KeyValue) GetIPWithMetadata(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type,
+) (netip.Addr, Metadata, bool) {
+	var fields map[string]string
+
+	c := httpCore{
+		ipNet:             ipNet,
+		url:               p.URL,
+		method:            http.MethodGet,
+		additionalHeaders: nil,
+		requestBody:       nil,
+		extract: func(ppfmt pp.PP, body []byte) (netip.Addr, bool) {
+			var invalidIP netip.Addr
+
+			fields = parseKeyValues(body)
+			ipString, found := fields[p.IPKey]
+			if !found {
+				ppfmt.Noticef(pp.EmojiError, `Failed to find the IP address in the response of %q (%q)`, p.URL, body)
+				return invalidIP, false
+			}
+			ip, err := netip.ParseAddr(ipString)
+			if err != nil {
+				ppfmt.Noticef(pp.EmojiError, `Failed to parse the IP address in the response of %q (%q)`, p.URL, ipString)
+				return invalidIP, false
+			}
+			return ip, true
+		},
+	}
+
+	ip, ok := c.getIP(ctx, ppfmt)
+	if !ok {
+		return netip.Addr{}, nil, false
+	}
+
+	ip, ok = ipNet.NormalizeDetectedIP(ppfmt, ip)
+	if !ok {
+		return netip.Addr{}, nil, false
+	}
+
+	metadata := Metadata{}
+	for _, key := range p.MetadataKeys {
+		if value, found := fields[key]; found {
+			metadata[key] = value
+		}
+	}
+	return ip, metadata, true
+}
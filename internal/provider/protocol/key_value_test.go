@@ -0,0 +1,123 @@
+// vim: nowrap
+package protocol_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+func TestKeyValueName(t *testing.T) {
+	t.Parallel()
+
+	p := protocol.KeyValue{
+		ProviderName: "very secret name",
+		URL:          "",
+		IPKey:        "",
+		MetadataKeys: nil,
+	}
+
+	require.Equal(t, "very secret name", p.Name())
+}
+
+func TestKeyValueGetIPWithMetadata(t *testing.T) {
+	t.Parallel()
+
+	ip4 := netip.MustParseAddr("1.2.3.4")
+	ip6 := netip.MustParseAddr("::1:2:3:4:5:6")
+	invalidIP := netip.Addr{}
+
+	traceWriter := func(ip string) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprintf(w, "fl=1f1\nh=api.cloudflare.com\nip=%s\nts=1.2\ncolo=SJC\nloc=US\ntls=TLSv1.3\nwarp=off\ncolo=LAX\n", ip)
+		}
+	}
+
+	server4 := newSplitServer(ipnet.IP4, traceWriter(ip4.String()))
+	t.Cleanup(server4.Close)
+	server6 := newSplitServer(ipnet.IP6, traceWriter(ip6.String()))
+	t.Cleanup(server6.Close)
+	server6via4 := newSplitServer(ipnet.IP4, traceWriter(ip6.String()))
+	t.Cleanup(server6via4.Close)
+	illformed := newSplitServer(ipnet.IP4, traceWriter("hello"))
+	t.Cleanup(illformed.Close)
+	missing := newSplitServer(ipnet.IP4, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "colo=SJC\n")
+	}))
+	t.Cleanup(missing.Close)
+
+	metadata := protocol.Metadata{"colo": "SJC", "loc": "US", "warp": "off", "tls": "TLSv1.3"}
+
+	for name, tc := range map[string]struct {
+		url              string
+		ipNet            ipnet.Type
+		expected         netip.Addr
+		expectedMetadata protocol.Metadata
+		prepareMockPP    func(*mocks.MockPP)
+	}{
+		"4": {server4.URL, ipnet.IP4, ip4, metadata, nil},
+		"6": {server6.URL, ipnet.IP6, ip6, metadata, nil},
+		"6to4": {
+			server6via4.URL, ipnet.IP4, invalidIP, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Detected IP address %s is not a valid IPv4 address", ip6.String())
+			},
+		},
+		"illformed": {
+			illformed.URL, ipnet.IP4, invalidIP, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, `Failed to parse the IP address in the response of %q (%q)`, illformed.URL, "hello")
+			},
+		},
+		"missing": {
+			missing.URL, ipnet.IP4, invalidIP, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, `Failed to find the IP address in the response of %q (%q)`, missing.URL, []byte("colo=SJC\n"))
+			},
+		},
+		"request-fail": {
+			"", ipnet.IP4, invalidIP, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Failed to send HTTP(S) request to %q: %v", "", gomock.Any())
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			p := protocol.KeyValue{
+				ProviderName: "",
+				URL:          tc.url,
+				IPKey:        "ip",
+				MetadataKeys: []string{"colo", "loc", "warp", "gateway", "tls"},
+			}
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			ip, metadata, ok := p.GetIPWithMetadata(context.Background(), mockPP, tc.ipNet)
+			require.Equal(t, tc.expected, ip)
+			require.Equal(t, tc.expectedMetadata, metadata)
+			require.Equal(t, tc.expected.IsValid(), ok)
+
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			ip, ok = p.GetIP(context.Background(), mockPP, tc.ipNet)
+			require.Equal(t, tc.expected, ip)
+			require.Equal(t, tc.expected.IsValid(), ok)
+		})
+	}
+}
@@ -1,23 +1,118 @@
 package protocol
 
 import (
+	"cmp"
 	"context"
+	"math"
 	"net"
 	"net/netip"
 	"slices"
+	"time"
 
 	"github.com/favonia/cloudflare-ddns/internal/ipnet"
 	"github.com/favonia/cloudflare-ddns/internal/pp"
 )
 
+// InfiniteLifetime is the lifetime of an address that never expires.
+const InfiniteLifetime = time.Duration(math.MaxInt64)
+
+// InterfaceAddr is an address assigned to a network interface, along with its attributes.
+type InterfaceAddr struct {
+	Addr              netip.Addr
+	Temporary         bool          // an RFC 4941 temporary address (IFA_F_TEMPORARY)
+	Deprecated        bool          // a deprecated address (IFA_F_DEPRECATED)
+	PreferredLifetime time.Duration // remaining preferred lifetime
+	ValidLifetime     time.Duration // remaining valid lifetime
+}
+
+// InterfacePreference is a preference among addresses assigned to a network interface.
+type InterfacePreference int
+
+const (
+	// PreferStable prefers addresses that are neither temporary nor deprecated.
+	PreferStable InterfacePreference = iota + 1
+	// PreferEUI64 prefers IPv6 addresses whose interface identifiers are derived from MAC addresses.
+	PreferEUI64
+	// PreferInterfaceID prefers IPv6 addresses with the interface identifier in [InterfacePolicy].
+	PreferInterfaceID
+	// PreferLongestLived prefers addresses with the longest preferred lifetime.
+	PreferLongestLived
+)
+
+// InterfacePolicy is the policy to select an address assigned to a network interface.
+// The zero value selects the first suitable address.
+type InterfacePolicy struct {
+	Prefix      netip.Prefix          // if valid, only addresses within the prefix are considered
+	InterfaceID netip.Addr            // the interface identifier (the lower 64 bits) for [PreferInterfaceID]
+	Preferences []InterfacePreference // preferences in the order of importance
+}
+
+// isEUI64 checks whether the interface identifier of an IPv6 address is a modified EUI-64 identifier.
+func isEUI64(ip netip.Addr) bool {
+	if !ip.Is6() || ip.Is4In6() {
+		return false
+	}
+	b := ip.As16()
+	return b[11] == 0xff && b[12] == 0xfe
+}
+
+// hasInterfaceID checks whether the lower 64 bits of two IPv6 addresses are the same.
+func hasInterfaceID(ip, iid netip.Addr) bool {
+	if !ip.Is6() || ip.Is4In6() || !iid.Is6() {
+		return false
+	}
+	a, b := ip.As16(), iid.As16()
+	return [8]byte(a[8:]) == [8]byte(b[8:])
+}
+
+// preferTrue orders true before false.
+func preferTrue(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// compare orders the addresses so that the more preferred ones come first.
+func (policy InterfacePolicy) compare(a, b InterfaceAddr) int {
+	for _, preference := range policy.Preferences {
+		var c int
+		switch preference {
+		case PreferStable:
+			c = preferTrue(!a.Temporary && !a.Deprecated, !b.Temporary && !b.Deprecated)
+		case PreferEUI64:
+			c = preferTrue(isEUI64(a.Addr), isEUI64(b.Addr))
+		case PreferInterfaceID:
+			c = preferTrue(hasInterfaceID(a.Addr, policy.InterfaceID), hasInterfaceID(b.Addr, policy.InterfaceID))
+		case PreferLongestLived:
+			c = cmp.Or(cmp.Compare(b.PreferredLifetime, a.PreferredLifetime), cmp.Compare(b.ValidLifetime, a.ValidLifetime))
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
 // LocalWithInterface detects the IP address by choosing the first "good" IP
-// address assigned to a network interface.
+// address assigned to a network interface. If All is true, [LocalWithInterface.GetIPs]
+// detects all the stable global unicast addresses instead.
 type LocalWithInterface struct {
 	// Name of the detection protocol.
 	ProviderName string
 
 	// The name of the network interface
 	InterfaceName string
+
+	// The policy to select among multiple addresses
+	Policy InterfacePolicy
+
+	// Whether to detect all suitable addresses
+	All bool
 }
 
 // Name of the detection protocol.
@@ -53,17 +148,53 @@ func ExtractInterfaceAddr(ppfmt pp.PP, iface string, addr net.Addr) (netip.Addr,
 
 // SelectInterfaceIP takes a list of [net.Addr] and choose the first reasonable IP (if any).
 func SelectInterfaceIP(ppfmt pp.PP, iface string, ipNet ipnet.Type, addrs []net.Addr) (netip.Addr, bool) {
-	ips := make([]netip.Addr, 0, len(addrs))
+	ifaceAddrs := make([]InterfaceAddr, 0, len(addrs))
 	for _, addr := range addrs {
 		ip, ok := ExtractInterfaceAddr(ppfmt, iface, addr)
 		if !ok {
 			return ip, false
 		}
-		ips = append(ips, ip)
+		ifaceAddrs = append(ifaceAddrs, InterfaceAddr{
+			Addr:              ip,
+			Temporary:         false,
+			Deprecated:        false,
+			PreferredLifetime: InfiniteLifetime,
+			ValidLifetime:     InfiniteLifetime,
+		})
+	}
+
+	return SelectInterfaceAddr(ppfmt, iface, ipNet, InterfacePolicy{}, ifaceAddrs) //nolint:exhaustruct
+}
+
+// SelectInterfaceAddr chooses the most preferred reasonable IP (if any) according to the policy.
+func SelectInterfaceAddr(ppfmt pp.PP, iface string, ipNet ipnet.Type, policy InterfacePolicy,
+	addrs []InterfaceAddr,
+) (netip.Addr, bool) {
+	addrs = slices.DeleteFunc(slices.Clone(addrs), func(addr InterfaceAddr) bool {
+		return !ipNet.Matches(addr.Addr)
+	})
+
+	if policy.Prefix.IsValid() {
+		addrs = slices.DeleteFunc(addrs, func(addr InterfaceAddr) bool {
+			return !policy.Prefix.Contains(addr.Addr.WithZone("").Unmap())
+		})
+		if len(addrs) == 0 {
+			ppfmt.Noticef(pp.EmojiError,
+				"Failed to find any %s address in %s assigned to interface %s",
+				ipNet.Describe(), policy.Prefix.Masked().String(), iface)
+			return netip.Addr{}, false
+		}
+	}
+
+	slices.SortStableFunc(addrs, policy.compare)
+
+	ips := make([]netip.Addr, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.Addr)
 	}
 
 	i := slices.IndexFunc(ips, func(ip netip.Addr) bool {
-		return ipNet.Matches(ip) && ip.IsGlobalUnicast()
+		return ip.IsGlobalUnicast()
 	})
 	if i >= 0 {
 		return ips[i], true
@@ -71,8 +202,7 @@ func SelectInterfaceIP(ppfmt pp.PP, iface string, ipNet ipnet.Type, addrs []net.
 
 	// Choose an IP that is above the link-local scope
 	i = slices.IndexFunc(ips, func(ip netip.Addr) bool {
-		return ipNet.Matches(ip) &&
-			!ip.IsUnspecified() &&
+		return !ip.IsUnspecified() &&
 			!ip.IsLoopback() &&
 			!ip.IsInterfaceLocalMulticast() &&
 			!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast()
@@ -91,20 +221,76 @@ func SelectInterfaceIP(ppfmt pp.PP, iface string, ipNet ipnet.Type, addrs []net.
 	return netip.Addr{}, false
 }
 
-// GetIP detects the IP address by pretending to send an UDP packet.
-// (No actual UDP packets will be sent out.)
-func (p LocalWithInterface) GetIP(_ context.Context, ppfmt pp.PP, ipNet ipnet.Type) (netip.Addr, bool) {
+// SelectAllInterfaceAddrs chooses all the stable global unicast IPs within the prefix of the policy (if any).
+// Temporary and deprecated addresses are skipped. The IPs are sorted and without duplicates.
+func SelectAllInterfaceAddrs(ppfmt pp.PP, iface string, ipNet ipnet.Type, policy InterfacePolicy,
+	addrs []InterfaceAddr,
+) ([]netip.Addr, bool) {
+	ips := make([]netip.Addr, 0, len(addrs))
+	for _, addr := range addrs {
+		ip := addr.Addr.WithZone("").Unmap()
+		if !ipNet.Matches(ip) || (policy.Prefix.IsValid() && !policy.Prefix.Contains(ip)) {
+			continue
+		}
+		if addr.Temporary || addr.Deprecated || !ip.IsGlobalUnicast() {
+			continue
+		}
+		ips = append(ips, ip)
+	}
+
+	if len(ips) == 0 {
+		if policy.Prefix.IsValid() {
+			ppfmt.Noticef(pp.EmojiError,
+				"Failed to find any stable global unicast %s address in %s assigned to interface %s",
+				ipNet.Describe(), policy.Prefix.Masked().String(), iface)
+		} else {
+			ppfmt.Noticef(pp.EmojiError,
+				"Failed to find any stable global unicast %s address assigned to interface %s",
+				ipNet.Describe(), iface)
+		}
+		return nil, false
+	}
+
+	slices.SortFunc(ips, netip.Addr.Compare)
+	return slices.Compact(ips), true
+}
+
+func (p LocalWithInterface) listAddrs(ppfmt pp.PP) ([]InterfaceAddr, bool) {
 	iface, err := net.InterfaceByName(p.InterfaceName)
 	if err != nil {
 		ppfmt.Noticef(pp.EmojiUserError, "Failed to find an interface named %q: %v", p.InterfaceName, err)
-		return netip.Addr{}, false
+		return nil, false
 	}
 
-	addrs, err := iface.Addrs()
-	if err != nil {
-		ppfmt.Noticef(pp.EmojiImpossible, "Failed to list addresses of interface %s: %v", p.InterfaceName, err)
+	return listInterfaceAddrs(ppfmt, iface)
+}
+
+// GetIP detects the IP address by pretending to send an UDP packet.
+// (No actual UDP packets will be sent out.)
+func (p LocalWithInterface) GetIP(_ context.Context, ppfmt pp.PP, ipNet ipnet.Type) (netip.Addr, bool) {
+	addrs, ok := p.listAddrs(ppfmt)
+	if !ok {
 		return netip.Addr{}, false
 	}
 
-	return SelectInterfaceIP(ppfmt, p.InterfaceName, ipNet, addrs)
+	return SelectInterfaceAddr(ppfmt, p.InterfaceName, ipNet, p.Policy, addrs)
+}
+
+// GetIPs detects all the stable global unicast addresses if All is true,
+// and otherwise detects one address as [LocalWithInterface.GetIP] does.
+func (p LocalWithInterface) GetIPs(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) ([]netip.Addr, bool) {
+	if !p.All {
+		ip, ok := p.GetIP(ctx, ppfmt, ipNet)
+		if !ok {
+			return nil, false
+		}
+		return []netip.Addr{ip}, true
+	}
+
+	addrs, ok := p.listAddrs(ppfmt)
+	if !ok {
+		return nil, false
+	}
+
+	return SelectAllInterfaceAddrs(ppfmt, p.InterfaceName, ipNet, p.Policy, addrs)
 }
@@ -0,0 +1,108 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// ifaFlags is IFA_FLAGS, the 32-bit address flags missing from package syscall.
+const ifaFlags = 0x8
+
+// netlinkLifetime converts a lifetime in seconds from netlink, where 0xFFFFFFFF means forever.
+func netlinkLifetime(seconds uint32) time.Duration {
+	if seconds == 0xFFFFFFFF {
+		return InfiniteLifetime
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// ParseInterfaceAddrs parses the netlink messages of RTM_GETADDR and returns
+// the addresses assigned to the interface with the index.
+func ParseInterfaceAddrs(ppfmt pp.PP, iface string, index int, rib []byte) ([]InterfaceAddr, bool) {
+	msgs, err := syscall.ParseNetlinkMessage(rib)
+	if err != nil {
+		ppfmt.Noticef(pp.EmojiImpossible, "Failed to list addresses of interface %s: %v", iface, err)
+		return nil, false
+	}
+
+	var addrs []InterfaceAddr
+	for _, m := range msgs {
+		if m.Header.Type == syscall.NLMSG_DONE {
+			break
+		}
+		if m.Header.Type != syscall.RTM_NEWADDR || len(m.Data) < syscall.SizeofIfAddrmsg {
+			continue
+		}
+
+		// struct ifaddrmsg: family, prefix length, flags, scope, and index
+		family, flags := m.Data[0], uint32(m.Data[2])
+		if binary.NativeEndian.Uint32(m.Data[4:8]) != uint32(index) { //nolint:gosec
+			continue
+		}
+
+		attrs, err := syscall.ParseNetlinkRouteAttr(&m)
+		if err != nil {
+			ppfmt.Noticef(pp.EmojiImpossible, "Failed to list addresses of interface %s: %v", iface, err)
+			return nil, false
+		}
+
+		var address, local []byte
+		preferred, valid := InfiniteLifetime, InfiniteLifetime
+		for _, attr := range attrs {
+			switch attr.Attr.Type {
+			case syscall.IFA_ADDRESS:
+				address = attr.Value
+			case syscall.IFA_LOCAL:
+				local = attr.Value
+			case ifaFlags:
+				if len(attr.Value) >= 4 {
+					flags = binary.NativeEndian.Uint32(attr.Value)
+				}
+			case syscall.IFA_CACHEINFO:
+				// struct ifa_cacheinfo: preferred lifetime, valid lifetime, and timestamps
+				if len(attr.Value) >= 8 {
+					preferred = netlinkLifetime(binary.NativeEndian.Uint32(attr.Value[0:4]))
+					valid = netlinkLifetime(binary.NativeEndian.Uint32(attr.Value[4:8]))
+				}
+			}
+		}
+
+		// For IPv4 point-to-point links, IFA_ADDRESS is the address of the other end.
+		raw := address
+		if family == syscall.AF_INET && local != nil {
+			raw = local
+		}
+		ip, ok := netip.AddrFromSlice(raw)
+		if !ok {
+			ppfmt.Noticef(pp.EmojiImpossible,
+				"Failed to parse address %q assigned to interface %s", net.IP(raw).String(), iface)
+			return nil, false
+		}
+
+		addrs = append(addrs, InterfaceAddr{
+			Addr:              ip.Unmap(),
+			Temporary:         flags&syscall.IFA_F_TEMPORARY != 0,
+			Deprecated:        flags&syscall.IFA_F_DEPRECATED != 0,
+			PreferredLifetime: preferred,
+			ValidLifetime:     valid,
+		})
+	}
+
+	return addrs, true
+}
+
+// listInterfaceAddrs lists the addresses assigned to the interface with their attributes from netlink.
+func listInterfaceAddrs(ppfmt pp.PP, iface *net.Interface) ([]InterfaceAddr, bool) {
+	rib, err := syscall.NetlinkRIB(syscall.RTM_GETADDR, syscall.AF_UNSPEC)
+	if err != nil {
+		ppfmt.Noticef(pp.EmojiImpossible, "Failed to list addresses of interface %s: %v", iface.Name, err)
+		return nil, false
+	}
+
+	return ParseInterfaceAddrs(ppfmt, iface.Name, iface.Index, rib)
+}
@@ -0,0 +1,35 @@
+//go:build !linux
+
+package protocol
+
+import (
+	"net"
+
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// listInterfaceAddrs lists the addresses assigned to the interface. The attributes of
+// the addresses are not available on this platform, and thus all addresses are considered stable.
+func listInterfaceAddrs(ppfmt pp.PP, iface *net.Interface) ([]InterfaceAddr, bool) {
+	addrs, err := iface.Addrs()
+	if err != nil {
+		ppfmt.Noticef(pp.EmojiImpossible, "Failed to list addresses of interface %s: %v", iface.Name, err)
+		return nil, false
+	}
+
+	ifaceAddrs := make([]InterfaceAddr, 0, len(addrs))
+	for _, addr := range addrs {
+		ip, ok := ExtractInterfaceAddr(ppfmt, iface.Name, addr)
+		if !ok {
+			return nil, false
+		}
+		ifaceAddrs = append(ifaceAddrs, InterfaceAddr{
+			Addr:              ip,
+			Temporary:         false,
+			Deprecated:        false,
+			PreferredLifetime: InfiniteLifetime,
+			ValidLifetime:     InfiniteLifetime,
+		})
+	}
+	return ifaceAddrs, true
+}
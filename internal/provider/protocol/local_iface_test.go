@@ -5,9 +5,13 @@ package protocol_test
 
 import (
 	"context"
+	"encoding/binary"
 	"net"
 	"net/netip"
+	"slices"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
@@ -170,6 +174,234 @@ func TestSelectInterfaceIP(t *testing.T) {
 	}
 }
 
+func TestSelectInterfaceAddr(t *testing.T) {
+	t.Parallel()
+
+	addr := func(s string, temporary, deprecated bool, preferred time.Duration) protocol.InterfaceAddr {
+		return protocol.InterfaceAddr{
+			Addr:              netip.MustParseAddr(s),
+			Temporary:         temporary,
+			Deprecated:        deprecated,
+			PreferredLifetime: preferred,
+			ValidLifetime:     preferred + time.Hour,
+		}
+	}
+
+	addrs := []protocol.InterfaceAddr{
+		addr("1.2.3.4", false, false, protocol.InfiniteLifetime),
+		addr("2001:db8::1234:5678:9abc:def0", true, false, time.Hour),
+		addr("2001:db8::211:22ff:fe33:4455", false, true, 0),
+		addr("2001:db8:1::1:2:3:4", false, false, 2*time.Hour),
+		addr("2001:db8:1::aaaa:bbbb:cccc:dddd", true, false, 3*time.Hour),
+	}
+
+	for name, tc := range map[string]struct {
+		ipNet         ipnet.Type
+		policy        protocol.InterfacePolicy
+		output        netip.Addr
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"4/default": {
+			ipnet.IP4, protocol.InterfacePolicy{}, netip.MustParseAddr("1.2.3.4"), nil,
+		},
+		"6/default": {
+			ipnet.IP6, protocol.InterfacePolicy{}, netip.MustParseAddr("2001:db8::1234:5678:9abc:def0"), nil,
+		},
+		"6/stable": {
+			ipnet.IP6, protocol.InterfacePolicy{Preferences: []protocol.InterfacePreference{protocol.PreferStable}},
+			netip.MustParseAddr("2001:db8:1::1:2:3:4"), nil,
+		},
+		"6/eui64": {
+			ipnet.IP6, protocol.InterfacePolicy{Preferences: []protocol.InterfacePreference{protocol.PreferEUI64}},
+			netip.MustParseAddr("2001:db8::211:22ff:fe33:4455"), nil,
+		},
+		"6/iid": {
+			ipnet.IP6, protocol.InterfacePolicy{InterfaceID: netip.MustParseAddr("::aaaa:bbbb:cccc:dddd"), Preferences: []protocol.InterfacePreference{protocol.PreferInterfaceID}},
+			netip.MustParseAddr("2001:db8:1::aaaa:bbbb:cccc:dddd"), nil,
+		},
+		"6/longest-lived": {
+			ipnet.IP6, protocol.InterfacePolicy{Preferences: []protocol.InterfacePreference{protocol.PreferLongestLived}},
+			netip.MustParseAddr("2001:db8:1::aaaa:bbbb:cccc:dddd"), nil,
+		},
+		"6/stable+longest-lived": {
+			ipnet.IP6, protocol.InterfacePolicy{Preferences: []protocol.InterfacePreference{protocol.PreferStable, protocol.PreferLongestLived}},
+			netip.MustParseAddr("2001:db8:1::1:2:3:4"), nil,
+		},
+		"6/prefix": {
+			ipnet.IP6, protocol.InterfacePolicy{Prefix: netip.MustParsePrefix("2001:db8::/64")},
+			netip.MustParseAddr("2001:db8::1234:5678:9abc:def0"), nil,
+		},
+		"6/prefix+stable": {
+			ipnet.IP6, protocol.InterfacePolicy{Prefix: netip.MustParsePrefix("2001:db8::/64"), Preferences: []protocol.InterfacePreference{protocol.PreferStable}},
+			netip.MustParseAddr("2001:db8::1234:5678:9abc:def0"), nil,
+		},
+		"6/prefix/none": {
+			ipnet.IP6, protocol.InterfacePolicy{Prefix: netip.MustParsePrefix("2001:db8:2::1/48")},
+			netip.Addr{},
+			func(ppfmt *mocks.MockPP) {
+				ppfmt.EXPECT().Noticef(pp.EmojiError, "Failed to find any %s address in %s assigned to interface %s", "IPv6", "2001:db8:2::/48", "iface")
+			},
+		},
+		"4/prefix/none": {
+			ipnet.IP4, protocol.InterfacePolicy{Prefix: netip.MustParsePrefix("4.3.2.0/24")},
+			netip.Addr{},
+			func(ppfmt *mocks.MockPP) {
+				ppfmt.EXPECT().Noticef(pp.EmojiError, "Failed to find any %s address in %s assigned to interface %s", "IPv4", "4.3.2.0/24", "iface")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			output, ok := protocol.SelectInterfaceAddr(mockPP, "iface", tc.ipNet, tc.policy, addrs)
+			require.Equal(t, tc.output.IsValid(), ok)
+			require.Equal(t, tc.output, output)
+		})
+	}
+}
+
+func TestSelectAllInterfaceAddrs(t *testing.T) {
+	t.Parallel()
+
+	addr := func(s string, temporary, deprecated bool) protocol.InterfaceAddr {
+		return protocol.InterfaceAddr{
+			Addr:              netip.MustParseAddr(s),
+			Temporary:         temporary,
+			Deprecated:        deprecated,
+			PreferredLifetime: protocol.InfiniteLifetime,
+			ValidLifetime:     protocol.InfiniteLifetime,
+		}
+	}
+
+	addrs := []protocol.InterfaceAddr{
+		addr("1.2.3.4", false, false),
+		addr("2001:db8:1::1:2:3:4", false, false),
+		addr("2001:db8::1234:5678:9abc:def0", true, false),
+		addr("2001:db8::211:22ff:fe33:4455", false, true),
+		addr("fe80::1%iface", false, false),
+		addr("2001:db8::1", false, false),
+		addr("2001:db8::1", false, false),
+	}
+
+	for name, tc := range map[string]struct {
+		ipNet         ipnet.Type
+		policy        protocol.InterfacePolicy
+		output        []netip.Addr
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"4": {ipnet.IP4, protocol.InterfacePolicy{}, []netip.Addr{netip.MustParseAddr("1.2.3.4")}, nil},
+		"6": {
+			ipnet.IP6, protocol.InterfacePolicy{},
+			[]netip.Addr{netip.MustParseAddr("2001:db8::1"), netip.MustParseAddr("2001:db8:1::1:2:3:4")}, nil,
+		},
+		"6/prefix": {
+			ipnet.IP6, protocol.InterfacePolicy{Prefix: netip.MustParsePrefix("2001:db8:1::/48")},
+			[]netip.Addr{netip.MustParseAddr("2001:db8:1::1:2:3:4")}, nil,
+		},
+		"6/prefix/none": {
+			ipnet.IP6, protocol.InterfacePolicy{Prefix: netip.MustParsePrefix("2001:db8:2::1/48")},
+			nil,
+			func(ppfmt *mocks.MockPP) {
+				ppfmt.EXPECT().Noticef(pp.EmojiError, "Failed to find any stable global unicast %s address in %s assigned to interface %s", "IPv6", "2001:db8:2::/48", "iface")
+			},
+		},
+		"4/prefix/none": {
+			ipnet.IP4, protocol.InterfacePolicy{Prefix: netip.MustParsePrefix("4.3.2.0/24")}, nil,
+			func(ppfmt *mocks.MockPP) {
+				ppfmt.EXPECT().Noticef(pp.EmojiError, "Failed to find any stable global unicast %s address in %s assigned to interface %s", "IPv4", "4.3.2.0/24", "iface")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			output, ok := protocol.SelectAllInterfaceAddrs(mockPP, "iface", tc.ipNet, tc.policy, addrs)
+			require.Equal(t, tc.output != nil, ok)
+			require.Equal(t, tc.output, output)
+		})
+	}
+}
+
+// netlinkAddrMessage builds an RTM_NEWADDR message.
+func netlinkAddrMessage(family byte, flags byte, index uint32, attrs map[uint16][]byte) []byte {
+	body := []byte{family, 64, flags, 0}
+	body = binary.NativeEndian.AppendUint32(body, index)
+	for _, typ := range []uint16{syscall.IFA_ADDRESS, syscall.IFA_LOCAL, syscall.IFA_CACHEINFO, 8} {
+		value, found := attrs[typ]
+		if !found {
+			continue
+		}
+		body = binary.NativeEndian.AppendUint16(body, uint16(4+len(value)))
+		body = binary.NativeEndian.AppendUint16(body, typ)
+		body = append(body, value...)
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+	}
+
+	msg := binary.NativeEndian.AppendUint32(nil, uint32(syscall.NLMSG_HDRLEN+len(body)))
+	msg = binary.NativeEndian.AppendUint16(msg, syscall.RTM_NEWADDR)
+	msg = binary.NativeEndian.AppendUint16(msg, 0)
+	msg = binary.NativeEndian.AppendUint32(msg, 0)
+	msg = binary.NativeEndian.AppendUint32(msg, 0)
+	return append(msg, body...)
+}
+
+func cacheInfo(preferred, valid uint32) []byte {
+	return binary.NativeEndian.AppendUint32(binary.NativeEndian.AppendUint32(nil, preferred), valid)
+}
+
+func TestParseInterfaceAddrs(t *testing.T) {
+	t.Parallel()
+
+	ip6 := netip.MustParseAddr("2001:db8::1").AsSlice()
+	ip4 := netip.MustParseAddr("1.2.3.4").AsSlice()
+	peer4 := netip.MustParseAddr("4.3.2.1").AsSlice()
+
+	rib := slices.Concat(
+		netlinkAddrMessage(syscall.AF_INET, 0, 2, map[uint16][]byte{syscall.IFA_ADDRESS: peer4, syscall.IFA_LOCAL: ip4}),
+		netlinkAddrMessage(syscall.AF_INET6, syscall.IFA_F_TEMPORARY, 2, map[uint16][]byte{syscall.IFA_ADDRESS: ip6, syscall.IFA_CACHEINFO: cacheInfo(3600, 7200)}),
+		netlinkAddrMessage(syscall.AF_INET6, 0, 2, map[uint16][]byte{syscall.IFA_ADDRESS: ip6, 8: binary.NativeEndian.AppendUint32(nil, syscall.IFA_F_DEPRECATED), syscall.IFA_CACHEINFO: cacheInfo(0, 0xFFFFFFFF)}),
+		netlinkAddrMessage(syscall.AF_INET6, 0, 3, map[uint16][]byte{syscall.IFA_ADDRESS: ip6}),
+	)
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+
+	addrs, ok := protocol.ParseInterfaceAddrs(mockPP, "iface", 2, rib)
+	require.True(t, ok)
+	require.Equal(t, []protocol.InterfaceAddr{
+		{Addr: netip.MustParseAddr("1.2.3.4"), Temporary: false, Deprecated: false, PreferredLifetime: protocol.InfiniteLifetime, ValidLifetime: protocol.InfiniteLifetime},
+		{Addr: netip.MustParseAddr("2001:db8::1"), Temporary: true, Deprecated: false, PreferredLifetime: time.Hour, ValidLifetime: 2 * time.Hour},
+		{Addr: netip.MustParseAddr("2001:db8::1"), Temporary: false, Deprecated: true, PreferredLifetime: 0, ValidLifetime: protocol.InfiniteLifetime},
+	}, addrs)
+}
+
+func TestParseInterfaceAddrsInvalid(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Noticef(pp.EmojiImpossible, "Failed to parse address %q assigned to interface %s", "?0102", "iface")
+
+	rib := netlinkAddrMessage(syscall.AF_INET6, 0, 2, map[uint16][]byte{syscall.IFA_ADDRESS: {1, 2}})
+	addrs, ok := protocol.ParseInterfaceAddrs(mockPP, "iface", 2, rib)
+	require.False(t, ok)
+	require.Nil(t, addrs)
+}
+
 func TestLocalWithInterfaceGetIP(t *testing.T) {
 	t.Parallel()
 
@@ -221,3 +453,51 @@ func TestLocalWithInterfaceGetIP(t *testing.T) {
 		})
 	}
 }
+
+func TestLocalWithInterfaceGetIPs(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		interfaceName string
+		all           bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"lo": {
+			"lo", false,
+			func(ppfmt *mocks.MockPP) {
+				ppfmt.EXPECT().Noticef(pp.EmojiError, "Failed to find any global unicast %s address assigned to interface %s", "IPv4", "lo")
+			},
+		},
+		"lo/all": {
+			"lo", true,
+			func(ppfmt *mocks.MockPP) {
+				ppfmt.EXPECT().Noticef(pp.EmojiError, "Failed to find any stable global unicast %s address assigned to interface %s", "IPv4", "lo")
+			},
+		},
+		"non-existent/all": {
+			"non-existent-iface", true,
+			func(ppfmt *mocks.MockPP) {
+				ppfmt.EXPECT().Noticef(pp.EmojiUserError, "Failed to find an interface named %q: %v", "non-existent-iface", gomock.Any())
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			provider := &protocol.LocalWithInterface{
+				ProviderName:  "",
+				InterfaceName: tc.interfaceName,
+				All:           tc.all,
+			}
+			ips, ok := provider.GetIPs(context.Background(), mockPP, ipnet.IP4)
+			require.False(t, ok)
+			require.Nil(t, ips)
+		})
+	}
+}
@@ -2,22 +2,35 @@ package protocol
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/netip"
 	"regexp"
+	"strings"
 
 	"github.com/favonia/cloudflare-ddns/internal/ipnet"
 	"github.com/favonia/cloudflare-ddns/internal/pp"
 )
 
-func getIPFromRegexp(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type, url string, re *regexp.Regexp,
-) (netip.Addr, bool) {
+func getIPFromRegexp(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type, param RegexpParam) (netip.Addr, bool) {
+	url, re := param.URL, param.Regexp
+
+	method := param.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	if param.Body != "" {
+		body = strings.NewReader(param.Body)
+	}
+
 	c := httpCore{
 		ipNet:             ipNet,
 		url:               url,
-		method:            http.MethodGet,
-		additionalHeaders: nil,
-		requestBody:       nil,
+		method:            method,
+		additionalHeaders: param.Headers,
+		requestBody:       body,
 		extract: func(ppfmt pp.PP, body []byte) (netip.Addr, bool) {
 			var invalidIP netip.Addr
 
@@ -41,8 +54,11 @@ func getIPFromRegexp(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type, url str
 
 // RegexpParam is the type of parameters for the Regexp provider for a specific IP network.
 type RegexpParam = struct {
-	URL    string         // URL of the detection page
-	Regexp *regexp.Regexp // regular expression to match the IP address
+	URL     string            // URL of the detection page
+	Regexp  *regexp.Regexp    // regular expression to match the IP address
+	Method  string            // HTTP method; empty means GET
+	Headers map[string]string // additional HTTP headers
+	Body    string            // HTTP request body; empty means no body
 }
 
 // Regexp represents a generic detection protocol to parse an HTTP response.
@@ -62,7 +78,7 @@ func (p Regexp) GetIP(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) (netip
 		return netip.Addr{}, false
 	}
 
-	ip, ok := getIPFromRegexp(ctx, ppfmt, ipNet, param.URL, param.Regexp)
+	ip, ok := getIPFromRegexp(ctx, ppfmt, ipNet, param)
 	if !ok {
 		return netip.Addr{}, false
 	}
@@ -4,6 +4,7 @@ package protocol_test
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/netip"
 	"regexp"
@@ -159,3 +160,40 @@ func TestRegexpGetIP(t *testing.T) {
 		})
 	}
 }
+
+func TestRegexpGetIPRequest(t *testing.T) {
+	t.Parallel()
+
+	server := newSplitServer(ipnet.IP4, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer secret" || string(body) != "status" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, "<<1.2.3.4>>")
+	}))
+	t.Cleanup(server.Close)
+
+	p := protocol.Regexp{
+		ProviderName: "secret name",
+		Param: map[ipnet.Type]protocol.RegexpParam{
+			ipnet.IP4: {
+				URL:     server.URL,
+				Regexp:  regexp.MustCompile(`<<(.*)>>`),
+				Method:  http.MethodPost,
+				Headers: map[string]string{"Authorization": "Bearer secret"},
+				Body:    "status",
+			},
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+
+	// The request body must be available again in the second round.
+	for range 2 {
+		ip, ok := p.GetIP(context.Background(), mockPP, ipnet.IP4)
+		require.True(t, ok)
+		require.Equal(t, netip.MustParseAddr("1.2.3.4"), ip)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net"
 	"net/http"
+	"net/netip"
 	"syscall"
 	"time"
 
@@ -13,7 +14,8 @@ import (
 	"github.com/favonia/cloudflare-ddns/internal/ipnet"
 )
 
-// This file contains mechanisms to limit connections to only IPv4 or IPv6.
+// This file contains mechanisms to limit connections to only IPv4 or IPv6,
+// and optionally to a network interface or a source address.
 
 var errBlockedNetwork = errors.New("blocked network")
 
@@ -35,6 +37,47 @@ func filterIP4Only(_ context.Context, network, _ string, _ syscall.RawConn) erro
 	}
 }
 
+// Binding restricts the sockets to a network interface and/or a source address.
+// The zero value means no restrictions.
+type Binding struct {
+	Interface string     // the network interface name; empty means any interface
+	Address   netip.Addr // the source address; the zero value means any address
+}
+
+// IsZero checks whether the binding imposes no restrictions.
+func (b Binding) IsZero() bool {
+	return b.Interface == "" && !b.Address.IsValid()
+}
+
+// String gives the binding in the form "<interface>[@<address>]".
+func (b Binding) String() string {
+	if b.Address.IsValid() {
+		return b.Interface + "@" + b.Address.String()
+	}
+	return b.Interface
+}
+
+// withBinding extends a control function so that sockets are also bound according to the binding.
+func withBinding(
+	filter func(context.Context, string, string, syscall.RawConn) error, b Binding,
+) func(context.Context, string, string, syscall.RawConn) error {
+	if b.IsZero() {
+		return filter
+	}
+
+	return func(ctx context.Context, network, address string, c syscall.RawConn) error {
+		if err := filter(ctx, network, address, c); err != nil {
+			return err
+		}
+
+		var errBind error
+		if err := c.Control(func(fd uintptr) { errBind = bindSocket(fd, b) }); err != nil {
+			return err
+		}
+		return errBind
+	}
+}
+
 func newControlledDialer(control func(context.Context, string, string, syscall.RawConn) error) *net.Dialer {
 	return &net.Dialer{ //nolint:exhaustruct
 		Timeout:        30 * time.Second,
@@ -43,10 +86,10 @@ func newControlledDialer(control func(context.Context, string, string, syscall.R
 	}
 }
 
-func newControlledTransport(control func(context.Context, string, string, syscall.RawConn) error) http.RoundTripper {
+func newControlledTransport(dialer *net.Dialer) http.RoundTripper {
 	return &http.Transport{ //nolint:exhaustruct
 		Proxy:                 http.ProxyFromEnvironment,
-		DialContext:           newControlledDialer(control).DialContext,
+		DialContext:           dialer.DialContext,
 		ForceAttemptHTTP2:     true,
 		MaxIdleConns:          100,
 		IdleConnTimeout:       90 * time.Second,
@@ -55,33 +98,90 @@ func newControlledTransport(control func(context.Context, string, string, syscal
 	}
 }
 
-func newControlledClient(control func(context.Context, string, string, syscall.RawConn) error) *http.Client {
-	return &http.Client{Transport: newControlledTransport(control)} //nolint:exhaustruct
+func newControlledClient(dialer *net.Dialer) *http.Client {
+	return &http.Client{Transport: newControlledTransport(dialer)} //nolint:exhaustruct
 }
 
 //nolint:gochecknoglobals
-var sharedSplitClient = map[ipnet.Type]*http.Client{
-	ipnet.IP4: newControlledClient(filterIP4Only),
-	ipnet.IP6: newControlledClient(filterIP6Only),
+var splitFilter = map[ipnet.Type]func(context.Context, string, string, syscall.RawConn) error{
+	ipnet.IP4: filterIP4Only,
+	ipnet.IP6: filterIP6Only,
+}
+
+// BoundNetwork holds its own dialers and HTTP clients for each IP family,
+// with all the sockets bound according to a binding.
+type BoundNetwork struct {
+	dialer map[ipnet.Type]*net.Dialer
+	client map[ipnet.Type]*http.Client
+}
+
+func newBoundNetwork(b Binding) *BoundNetwork {
+	n := &BoundNetwork{dialer: map[ipnet.Type]*net.Dialer{}, client: map[ipnet.Type]*http.Client{}}
+	for ipNet, filter := range ipnet.Bindings(splitFilter) {
+		n.dialer[ipNet] = newControlledDialer(withBinding(filter, b))
+		n.client[ipNet] = newControlledClient(n.dialer[ipNet])
+	}
+	return n
+}
+
+// NewBoundNetwork creates dialers and HTTP clients whose sockets are bound according to the binding.
+func NewBoundNetwork(b Binding) (*BoundNetwork, error) {
+	if !b.IsZero() && !bindingSupported {
+		return nil, errors.ErrUnsupported
+	}
+	return newBoundNetwork(b), nil
+}
+
+type boundNetworkKey struct{}
+
+// WithBoundNetwork returns a context with which the detection will use the dialers and HTTP clients of n.
+func WithBoundNetwork(ctx context.Context, n *BoundNetwork) context.Context {
+	return context.WithValue(ctx, boundNetworkKey{}, n)
+}
+
+// Client returns the [http.Client] that allows only the traffic of specified IP family.
+func (n *BoundNetwork) Client(ipNet ipnet.Type) *http.Client {
+	return n.client[ipNet]
+}
+
+// CloseIdleConnections closes all idle connections of the HTTP clients.
+func (n *BoundNetwork) CloseIdleConnections() {
+	for _, client := range ipnet.Bindings(n.client) {
+		client.CloseIdleConnections()
+	}
+}
+
+//nolint:gochecknoglobals
+var sharedBoundNetwork = newBoundNetwork(Binding{})
+
+// boundNetwork returns the network set by [WithBoundNetwork], or the shared one without bindings.
+func boundNetwork(ctx context.Context) *BoundNetwork {
+	if n, ok := ctx.Value(boundNetworkKey{}).(*BoundNetwork); ok {
+		return n
+	}
+	return sharedBoundNetwork
+}
+
+// splitDialer returns the [net.Dialer] that allows only the traffic of specified IP family.
+func splitDialer(ctx context.Context, ipNet ipnet.Type) *net.Dialer {
+	return boundNetwork(ctx).dialer[ipNet]
 }
 
 // SharedSplitClient returns the shared [http.Client] that allows only the traffic of specified IP family.
 func SharedSplitClient(ipNet ipnet.Type) *http.Client {
-	return sharedSplitClient[ipNet]
+	return sharedBoundNetwork.Client(ipNet)
 }
 
-// SharedRetryableSplitClient returns a [retryablehttp.Client] with the shared underlying [http.Client]
+// retryableSplitClient returns a [retryablehttp.Client] with the underlying [http.Client]
 // that allows only the traffic of specified IP family.
-func SharedRetryableSplitClient(ipNet ipnet.Type) *retryablehttp.Client {
+func retryableSplitClient(ctx context.Context, ipNet ipnet.Type) *retryablehttp.Client {
 	c := retryablehttp.NewClient()
-	c.HTTPClient = SharedSplitClient(ipNet)
+	c.HTTPClient = boundNetwork(ctx).Client(ipNet)
 	c.Logger = nil
 	return c
 }
 
 // CloseIdleConnections closes all idle connections after making detecting the IP addresses.
 func CloseIdleConnections() {
-	for _, client := range ipnet.Bindings(sharedSplitClient) {
-		client.CloseIdleConnections()
-	}
+	sharedBoundNetwork.CloseIdleConnections()
 }
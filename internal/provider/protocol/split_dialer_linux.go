@@ -0,0 +1,39 @@
+package protocol
+
+import (
+	"net"
+	"syscall"
+)
+
+const bindingSupported = true
+
+// bindSocket binds the socket to the network interface (SO_BINDTODEVICE) and/or the source address.
+func bindSocket(fd uintptr, b Binding) error {
+	if b.Interface != "" {
+		if err := syscall.BindToDevice(int(fd), b.Interface); err != nil {
+			return err
+		}
+	}
+
+	if b.Address.IsValid() {
+		var sa syscall.Sockaddr
+		if b.Address.Is4() {
+			sa = &syscall.SockaddrInet4{Port: 0, Addr: b.Address.As4()}
+		} else {
+			var zoneID uint32
+			if zone := b.Address.Zone(); zone != "" {
+				iface, err := net.InterfaceByName(zone)
+				if err != nil {
+					return err
+				}
+				zoneID = uint32(iface.Index) //nolint:gosec // interface indices are small
+			}
+			sa = &syscall.SockaddrInet6{Port: 0, ZoneId: zoneID, Addr: b.Address.As16()}
+		}
+		if err := syscall.Bind(int(fd), sa); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+//go:build linux
+
+package protocol_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+func TestBoundNetwork(t *testing.T) {
+	t.Parallel()
+
+	server := newSplitServer(ipnet.IP4, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		fmt.Fprint(w, host)
+	}))
+	t.Cleanup(server.Close)
+
+	get := func(client *http.Client) (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	// Linux accepts any address in 127.0.0.0/8 as a loopback address.
+	n2, err := protocol.NewBoundNetwork(protocol.Binding{Interface: "", Address: netip.MustParseAddr("127.0.0.2")})
+	require.NoError(t, err)
+	n3, err := protocol.NewBoundNetwork(protocol.Binding{Interface: "", Address: netip.MustParseAddr("127.0.0.3")})
+	require.NoError(t, err)
+	bad, err := protocol.NewBoundNetwork(protocol.Binding{Interface: "nonexistent0", Address: netip.Addr{}})
+	require.NoError(t, err)
+
+	// The bound networks are independent of each other and of the shared one.
+	remote, err := get(n2.Client(ipnet.IP4))
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.2", remote)
+
+	remote, err = get(n3.Client(ipnet.IP4))
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.3", remote)
+
+	_, err = get(bad.Client(ipnet.IP4))
+	require.Error(t, err)
+
+	remote, err = get(protocol.SharedSplitClient(ipnet.IP4))
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1", remote)
+}
+
+func TestBindingIsZero(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, protocol.Binding{}.IsZero())
+	require.False(t, protocol.Binding{Interface: "eth0", Address: netip.Addr{}}.IsZero())
+	require.False(t, protocol.Binding{Interface: "", Address: netip.MustParseAddr("::1")}.IsZero())
+}
+
+func TestWithBoundNetwork(t *testing.T) {
+	t.Parallel()
+
+	// The server reports 1.2.3.x if the request came from 127.0.0.x.
+	server := newSplitServer(ipnet.IP4, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		remote := netip.MustParseAddr(host).As4()
+		fmt.Fprintf(w, "1.2.3.%d", remote[3])
+	}))
+	t.Cleanup(server.Close)
+
+	n, err := protocol.NewBoundNetwork(protocol.Binding{Interface: "", Address: netip.MustParseAddr("127.0.0.2")})
+	require.NoError(t, err)
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	p := protocol.HTTP{ProviderName: "remote", URL: map[ipnet.Type]string{ipnet.IP4: server.URL}}
+
+	ip, ok := p.GetIP(protocol.WithBoundNetwork(context.Background(), n), mockPP, ipnet.IP4)
+	require.True(t, ok)
+	require.Equal(t, netip.MustParseAddr("1.2.3.2"), ip)
+
+	ip, ok = p.GetIP(context.Background(), mockPP, ipnet.IP4)
+	require.True(t, ok)
+	require.Equal(t, netip.MustParseAddr("1.2.3.1"), ip)
+}
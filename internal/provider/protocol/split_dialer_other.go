@@ -0,0 +1,11 @@
+//go:build !linux
+
+package protocol
+
+import "errors"
+
+const bindingSupported = false
+
+func bindSocket(_ uintptr, _ Binding) error {
+	return errors.ErrUnsupported
+}
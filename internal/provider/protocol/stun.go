@@ -0,0 +1,181 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// This file implements the minimum of RFC 5389 (STUN) to learn the mapped address.
+
+const (
+	stunHeaderLength          = 20
+	stunMagicCookie    uint32 = 0x2112A442
+	stunBindingRequest        = 0x0001
+	stunBindingSuccess        = 0x0101
+	stunBindingError          = 0x0111
+
+	stunAttrXORMappedAddress = 0x0020
+
+	stunFamilyIPv4 = 0x01
+	stunFamilyIPv6 = 0x02
+
+	// RFC 5389 Section 7.2.1 recommends an initial RTO of 500 ms and at most 7 transmissions.
+	stunInitialRTO       = 500 * time.Millisecond
+	stunMaxTransmissions = 7
+)
+
+type stunTransactionID = [12]byte
+
+func newSTUNBindingRequest(id stunTransactionID) []byte {
+	msg := make([]byte, stunHeaderLength)
+	binary.BigEndian.PutUint16(msg[0:2], stunBindingRequest)
+	binary.BigEndian.PutUint16(msg[2:4], 0) // no attributes
+	binary.BigEndian.PutUint32(msg[4:8], stunMagicCookie)
+	copy(msg[8:20], id[:])
+	return msg
+}
+
+// isSTUNResponseTo checks whether msg looks like a response to the transaction id.
+// Other packets are silently discarded, as required by RFC 5389.
+func isSTUNResponseTo(msg []byte, id stunTransactionID) bool {
+	return len(msg) >= stunHeaderLength &&
+		msg[0]&0xC0 == 0 && // the first two bits must be zeros
+		binary.BigEndian.Uint32(msg[4:8]) == stunMagicCookie &&
+		bytes.Equal(msg[8:20], id[:])
+}
+
+func parseSTUNXORMappedAddress(ppfmt pp.PP, value []byte, id stunTransactionID) (netip.Addr, bool) {
+	if len(value) < 4 {
+		ppfmt.Noticef(pp.EmojiImpossible, "Invalid STUN response: XOR-MAPPED-ADDRESS is too short")
+		return netip.Addr{}, false
+	}
+
+	// The key to XOR the address with: magic cookie followed by the transaction ID.
+	var key [16]byte
+	binary.BigEndian.PutUint32(key[0:4], stunMagicCookie)
+	copy(key[4:16], id[:])
+
+	var addrLength int
+	switch family := value[1]; family {
+	case stunFamilyIPv4:
+		addrLength = net.IPv4len
+	case stunFamilyIPv6:
+		addrLength = net.IPv6len
+	default:
+		ppfmt.Noticef(pp.EmojiImpossible, "Invalid STUN response: unknown address family %d", family)
+		return netip.Addr{}, false
+	}
+
+	if len(value) != 4+addrLength {
+		ppfmt.Noticef(pp.EmojiImpossible, "Invalid STUN response: XOR-MAPPED-ADDRESS has a wrong length")
+		return netip.Addr{}, false
+	}
+
+	addr := make([]byte, addrLength)
+	for i := range addr {
+		addr[i] = value[4+i] ^ key[i]
+	}
+
+	ip, _ := netip.AddrFromSlice(addr) // addrLength is either 4 or 16
+	return ip, true
+}
+
+func parseSTUNResponse(ppfmt pp.PP, msg []byte, id stunTransactionID) (netip.Addr, bool) {
+	switch msgType := binary.BigEndian.Uint16(msg[0:2]); msgType {
+	case stunBindingSuccess:
+	case stunBindingError:
+		ppfmt.Noticef(pp.EmojiError, "The STUN server returned an error response")
+		return netip.Addr{}, false
+	default:
+		ppfmt.Noticef(pp.EmojiImpossible, "Invalid STUN response: unexpected message type 0x%04x", msgType)
+		return netip.Addr{}, false
+	}
+
+	length := int(binary.BigEndian.Uint16(msg[2:4]))
+	if length%4 != 0 || stunHeaderLength+length != len(msg) {
+		ppfmt.Noticef(pp.EmojiImpossible, "Invalid STUN response: mismatched message length")
+		return netip.Addr{}, false
+	}
+
+	attrs := msg[stunHeaderLength:]
+	for len(attrs) >= 4 {
+		attrType := binary.BigEndian.Uint16(attrs[0:2])
+		attrLength := int(binary.BigEndian.Uint16(attrs[2:4]))
+		paddedLength := (attrLength + 3) &^ 3
+		if 4+paddedLength > len(attrs) {
+			ppfmt.Noticef(pp.EmojiImpossible, "Invalid STUN response: truncated attribute")
+			return netip.Addr{}, false
+		}
+
+		if attrType == stunAttrXORMappedAddress {
+			return parseSTUNXORMappedAddress(ppfmt, attrs[4:4+attrLength], id)
+		}
+
+		attrs = attrs[4+paddedLength:]
+	}
+
+	ppfmt.Noticef(pp.EmojiImpossible, "Invalid STUN response: no XOR-MAPPED-ADDRESS")
+	return netip.Addr{}, false
+}
+
+func getIPFromSTUN(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type, server string) (netip.Addr, bool) {
+	var invalidIP netip.Addr
+
+	var id stunTransactionID
+	if _, err := rand.Read(id[:]); err != nil {
+		ppfmt.Noticef(pp.EmojiImpossible, "Failed to generate a STUN transaction ID: %v", err)
+		return invalidIP, false
+	}
+	request := newSTUNBindingRequest(id)
+
+	conn, err := splitDialer(ctx, ipNet).DialContext(ctx, "udp", server)
+	if err != nil {
+		ppfmt.Noticef(pp.EmojiError, "Failed to connect to the STUN server %q: %v", server, err)
+		return invalidIP, false
+	}
+	defer conn.Close()
+
+	response, err := exchangeUDP(ctx, conn, request, stunInitialRTO, stunMaxTransmissions,
+		func(msg []byte) bool { return isSTUNResponseTo(msg, id) })
+	if err != nil {
+		ppfmt.Noticef(pp.EmojiError, "Failed to receive the STUN response from %q: %v", server, err)
+		return invalidIP, false
+	}
+
+	return parseSTUNResponse(ppfmt, response, id)
+}
+
+// STUN represents a generic detection protocol using a STUN Binding Request (RFC 5389).
+type STUN struct {
+	ProviderName string                // name of the protocol
+	Server       map[ipnet.Type]string // the STUN server in the form "host:port"
+}
+
+// Name of the detection protocol.
+func (p STUN) Name() string {
+	return p.ProviderName
+}
+
+// GetIP detects the IP address by sending a STUN Binding Request.
+func (p STUN) GetIP(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) (netip.Addr, bool) {
+	server, found := p.Server[ipNet]
+	if !found {
+		ppfmt.Noticef(pp.EmojiImpossible, "Unhandled IP network: %s", ipNet.Describe())
+		return netip.Addr{}, false
+	}
+
+	ip, ok := getIPFromSTUN(ctx, ppfmt, ipNet, server)
+	if !ok {
+		return netip.Addr{}, false
+	}
+
+	return ipNet.NormalizeDetectedIP(ppfmt, ip)
+}
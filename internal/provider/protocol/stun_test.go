@@ -0,0 +1,326 @@
+// vim: nowrap
+package protocol_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+func TestSTUNName(t *testing.T) {
+	t.Parallel()
+
+	p := protocol.STUN{
+		ProviderName: "very secret name",
+		Server:       nil,
+	}
+
+	require.Equal(t, "very secret name", p.Name())
+}
+
+const stunMagicCookie uint32 = 0x2112A442
+
+// stunAttr encodes a STUN attribute with padding.
+func stunAttr(attrType uint16, value []byte) []byte {
+	attr := make([]byte, 4, 4+len(value)+3)
+	binary.BigEndian.PutUint16(attr[0:2], attrType)
+	binary.BigEndian.PutUint16(attr[2:4], uint16(len(value)))
+	attr = append(attr, value...)
+	for len(attr)%4 != 0 {
+		attr = append(attr, 0)
+	}
+	return attr
+}
+
+// stunXORMappedAddress encodes the XOR-MAPPED-ADDRESS attribute for the request.
+func stunXORMappedAddress(req []byte, ip netip.Addr, port uint16) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint32(key[0:4], stunMagicCookie)
+	copy(key[4:16], req[8:20])
+
+	family := byte(0x01)
+	if ip.Is6() {
+		family = 0x02
+	}
+	addr := ip.AsSlice()
+	for i := range addr {
+		addr[i] ^= key[i]
+	}
+
+	value := []byte{0, family, 0, 0}
+	binary.BigEndian.PutUint16(value[2:4], port^uint16(stunMagicCookie>>16))
+	return stunAttr(0x0020, append(value, addr...))
+}
+
+// stunMessage encodes a STUN message in response to the request.
+func stunMessage(req []byte, msgType uint16, attrs ...[]byte) []byte {
+	msg := make([]byte, 20)
+	binary.BigEndian.PutUint16(msg[0:2], msgType)
+	copy(msg[4:20], req[4:20])
+	for _, attr := range attrs {
+		msg = append(msg, attr...)
+	}
+	binary.BigEndian.PutUint16(msg[2:4], uint16(len(msg)-20))
+	return msg
+}
+
+// newSTUNServer starts a STUN responder that replies to each request with the packets given by handler.
+func newSTUNServer(t *testing.T, ipNet ipnet.Type, handler func(req []byte) [][]byte) string {
+	t.Helper()
+
+	var conn net.PacketConn
+	var err error
+	switch ipNet {
+	case ipnet.IP4:
+		conn, err = net.ListenPacket("udp4", "127.0.0.1:0")
+	case ipnet.IP6:
+		conn, err = net.ListenPacket("udp6", "[::1]:0")
+	}
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			if err != nil || n < 20 || binary.BigEndian.Uint16(buf[0:2]) != 0x0001 || binary.BigEndian.Uint32(buf[4:8]) != stunMagicCookie {
+				continue
+			}
+			for _, packet := range handler(append([]byte{}, buf[:n]...)) {
+				_, _ = conn.WriteTo(packet, addr)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestSTUNGetIP(t *testing.T) {
+	t.Parallel()
+
+	ip4 := netip.MustParseAddr("1.2.3.4")
+	ip6 := netip.MustParseAddr("2606:4700:4700::1234")
+	invalidIP := netip.Addr{}
+
+	reply := func(ip netip.Addr) func([]byte) [][]byte {
+		return func(req []byte) [][]byte {
+			return [][]byte{stunMessage(req, 0x0101,
+				stunAttr(0x8022, []byte("test server")), // SOFTWARE
+				stunXORMappedAddress(req, ip, 54321),
+			)}
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		serverNet     ipnet.Type
+		ipNet         ipnet.Type
+		handler       func([]byte) [][]byte
+		expected      netip.Addr
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"4": {ipnet.IP4, ipnet.IP4, reply(ip4), ip4, nil},
+		"6": {ipnet.IP6, ipnet.IP6, reply(ip6), ip6, nil},
+		"4to6": {
+			ipnet.IP6, ipnet.IP4, reply(ip6), invalidIP,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Failed to connect to the STUN server %q: %v", gomock.Any(), gomock.Any())
+			},
+		},
+		"6to4": {
+			ipnet.IP4, ipnet.IP6, reply(ip4), invalidIP,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Failed to connect to the STUN server %q: %v", gomock.Any(), gomock.Any())
+			},
+		},
+		"mismatched-family": {
+			ipnet.IP4, ipnet.IP4, reply(ip6), invalidIP,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Detected IP address %s is not a valid IPv4 address", ip6.String())
+			},
+		},
+		"unrelated-packets": {
+			ipnet.IP4, ipnet.IP4,
+			func(req []byte) [][]byte {
+				other := append([]byte{}, req...)
+				other[19] ^= 0xFF // a different transaction
+				return [][]byte{
+					[]byte("garbage"),
+					stunMessage(other, 0x0101, stunXORMappedAddress(other, ip6, 1)),
+					stunMessage(req, 0x0101, stunXORMappedAddress(req, ip4, 1)),
+				}
+			},
+			ip4, nil,
+		},
+		"error-response": {
+			ipnet.IP4, ipnet.IP4,
+			func(req []byte) [][]byte { return [][]byte{stunMessage(req, 0x0111)} },
+			invalidIP,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "The STUN server returned an error response")
+			},
+		},
+		"unexpected-type": {
+			ipnet.IP4, ipnet.IP4,
+			func(req []byte) [][]byte { return [][]byte{stunMessage(req, 0x0001)} },
+			invalidIP,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiImpossible, "Invalid STUN response: unexpected message type 0x%04x", uint16(0x0001))
+			},
+		},
+		"no-xor-mapped-address": {
+			ipnet.IP4, ipnet.IP4,
+			func(req []byte) [][]byte {
+				return [][]byte{stunMessage(req, 0x0101, stunAttr(0x0001, []byte{0, 1, 0, 1, 1, 2, 3, 4}))}
+			},
+			invalidIP,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiImpossible, "Invalid STUN response: no XOR-MAPPED-ADDRESS")
+			},
+		},
+		"unknown-family": {
+			ipnet.IP4, ipnet.IP4,
+			func(req []byte) [][]byte {
+				return [][]byte{stunMessage(req, 0x0101, stunAttr(0x0020, []byte{0, 3, 0, 1, 1, 2, 3, 4}))}
+			},
+			invalidIP,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiImpossible, "Invalid STUN response: unknown address family %d", byte(3))
+			},
+		},
+		"wrong-address-length": {
+			ipnet.IP4, ipnet.IP4,
+			func(req []byte) [][]byte {
+				return [][]byte{stunMessage(req, 0x0101, stunAttr(0x0020, []byte{0, 1, 0, 1, 1, 2, 3, 4, 5, 6}))}
+			},
+			invalidIP,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiImpossible, "Invalid STUN response: XOR-MAPPED-ADDRESS has a wrong length")
+			},
+		},
+		"truncated-attribute": {
+			ipnet.IP4, ipnet.IP4,
+			func(req []byte) [][]byte {
+				msg := stunMessage(req, 0x0101, stunXORMappedAddress(req, ip4, 1))
+				binary.BigEndian.PutUint16(msg[22:24], 100)
+				return [][]byte{msg}
+			},
+			invalidIP,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiImpossible, "Invalid STUN response: truncated attribute")
+			},
+		},
+		"mismatched-length": {
+			ipnet.IP4, ipnet.IP4,
+			func(req []byte) [][]byte {
+				return [][]byte{append(stunMessage(req, 0x0101, stunXORMappedAddress(req, ip4, 1)), 0, 0, 0, 0)}
+			},
+			invalidIP,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiImpossible, "Invalid STUN response: mismatched message length")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := newSTUNServer(t, tc.serverNet, tc.handler)
+			p := protocol.STUN{
+				ProviderName: "",
+				Server: map[ipnet.Type]string{
+					tc.ipNet: server,
+				},
+			}
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			ip, ok := p.GetIP(ctx, mockPP, tc.ipNet)
+			require.Equal(t, tc.expected, ip)
+			require.Equal(t, tc.expected.IsValid(), ok)
+		})
+	}
+}
+
+func TestSTUNGetIPRetransmission(t *testing.T) {
+	t.Parallel()
+
+	ip := netip.MustParseAddr("1.2.3.4")
+
+	var count atomic.Int32
+	server := newSTUNServer(t, ipnet.IP4, func(req []byte) [][]byte {
+		if count.Add(1) == 1 {
+			return nil // the first request is lost
+		}
+		return [][]byte{stunMessage(req, 0x0101, stunXORMappedAddress(req, ip, 1))}
+	})
+	p := protocol.STUN{
+		ProviderName: "",
+		Server:       map[ipnet.Type]string{ipnet.IP4: server},
+	}
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+
+	detected, ok := p.GetIP(context.Background(), mockPP, ipnet.IP4)
+	require.True(t, ok)
+	require.Equal(t, ip, detected)
+	require.Equal(t, int32(2), count.Load())
+}
+
+func TestSTUNGetIPTimeout(t *testing.T) {
+	t.Parallel()
+
+	server := newSTUNServer(t, ipnet.IP4, func([]byte) [][]byte { return nil })
+	p := protocol.STUN{
+		ProviderName: "",
+		Server:       map[ipnet.Type]string{ipnet.IP4: server},
+	}
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Noticef(pp.EmojiError, "Failed to receive the STUN response from %q: %v", server, context.DeadlineExceeded)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	ip, ok := p.GetIP(ctx, mockPP, ipnet.IP4)
+	require.False(t, ok)
+	require.Zero(t, ip)
+}
+
+func TestSTUNGetIPUnhandled(t *testing.T) {
+	t.Parallel()
+
+	p := protocol.STUN{
+		ProviderName: "",
+		Server:       map[ipnet.Type]string{},
+	}
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Noticef(pp.EmojiImpossible, "Unhandled IP network: %s", "IPv4")
+
+	ip, ok := p.GetIP(context.Background(), mockPP, ipnet.IP4)
+	require.False(t, ok)
+	require.Zero(t, ip)
+}
@@ -0,0 +1,57 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// maxUDPMessageLength is the maximum number of bytes read from a UDP response.
+const maxUDPMessageLength = 1500
+
+var errNoResponse = errors.New("no response")
+
+// exchangeUDP sends the request over the connected UDP socket and waits for a packet
+// that is accepted by isResponse. Other packets are silently discarded. The request is
+// retransmitted with an exponential backoff starting with rto, up to maxTransmissions times.
+func exchangeUDP(ctx context.Context, conn net.Conn, request []byte,
+	rto time.Duration, maxTransmissions int, isResponse func([]byte) bool,
+) ([]byte, error) {
+	// Unblock the pending read as soon as the context is done.
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	buf := make([]byte, maxUDPMessageLength)
+	for range maxTransmissions {
+		if _, err := conn.Write(request); err != nil {
+			return nil, err
+		}
+
+		if err := conn.SetReadDeadline(time.Now().Add(rto)); err != nil {
+			return nil, err
+		}
+
+		for {
+			n, err := conn.Read(buf)
+			if ctx.Err() != nil {
+				return nil, context.Cause(ctx)
+			}
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				break // retransmit
+			}
+			if err != nil {
+				return nil, err
+			}
+			if isResponse(buf[:n]) {
+				return buf[:n], nil
+			}
+		}
+
+		rto *= 2
+	}
+
+	return nil, fmt.Errorf("%w after %d attempts", errNoResponse, maxTransmissions)
+}
@@ -0,0 +1,175 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+type quorum struct {
+	threshold int
+	providers []Provider
+}
+
+// NewQuorum creates a provider that runs all the given providers concurrently
+// and accepts an IP address only if at least threshold of them detected it.
+// If threshold is 0, a strict majority is required.
+func NewQuorum(ppfmt pp.PP, threshold int, providers []Provider) (Provider, bool) {
+	if len(providers) < 2 {
+		ppfmt.Noticef(pp.EmojiUserError,
+			`The provider "quorum" needs at least 2 providers, but only %d was given`, len(providers))
+		return nil, false
+	}
+
+	if threshold == 0 {
+		threshold = len(providers)/2 + 1
+	}
+
+	switch {
+	case threshold < 1 || threshold > len(providers):
+		ppfmt.Noticef(pp.EmojiUserError,
+			`The quorum (%d) of the provider "quorum" should be between 1 and the number of providers (%d)`,
+			threshold, len(providers))
+		return nil, false
+	case threshold*2 <= len(providers):
+		ppfmt.Noticef(pp.EmojiUserWarning,
+			`The quorum (%d) of the provider "quorum" is not a strict majority of the %d providers`,
+			threshold, len(providers))
+	}
+
+	return quorum{threshold: threshold, providers: providers}, true
+}
+
+// MustNewQuorum creates a quorum provider and panics if it fails.
+func MustNewQuorum(threshold int, providers []Provider) Provider {
+	var buf strings.Builder
+	p, ok := NewQuorum(pp.NewDefault(&buf), threshold, providers)
+	if !ok {
+		panic(buf.String())
+	}
+	return p
+}
+
+// Name of the detection protocol.
+func (p quorum) Name() string {
+	names := make([]string, 0, len(p.providers))
+	for _, sub := range p.providers {
+		names = append(names, Name(sub))
+	}
+	return fmt.Sprintf("quorum:%d:%s", p.threshold, strings.Join(names, ","))
+}
+
+// GetIP detects the IP address by voting.
+func (p quorum) GetIP(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) (netip.Addr, bool) {
+	ip, _, ok := p.GetIPWithNotes(ctx, ppfmt, ipNet)
+	return ip, ok
+}
+
+// GetIPWithNotes detects the IP address by voting. The notes describe
+// the providers that failed or disagreed with the outcome.
+func (p quorum) GetIPWithNotes(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) (netip.Addr, []string, bool) {
+	detection, ok := p.Detect(ctx, ppfmt, ipNet)
+	if !ok {
+		return netip.Addr{}, detection.Notes, false
+	}
+	return detection.IPs[0], detection.Notes, true
+}
+
+// Detect detects the IP address by voting. The notes describe the providers that failed
+// or disagreed with the outcome, and the dissenters are the names of these providers.
+// A disagreement that does not prevent a quorum is only logged in the verbose mode.
+func (p quorum) Detect(ctx context.Context, ppfmt pp.PP, ipNet ipnet.Type) (Detection, bool) {
+	type vote struct {
+		ip      netip.Addr
+		blocked []netip.Addr
+		ok      bool
+	}
+
+	// Each provider runs in its own goroutine with its own queued printer
+	// so that the logging remains in a deterministic order.
+	votes := make([]vote, len(p.providers))
+	queues := make([]pp.QueuedPP, len(p.providers))
+	var wg sync.WaitGroup
+	for i, sub := range p.providers {
+		queues[i] = pp.NewQueued(ppfmt)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Only the first IP address counts if the provider detected several addresses.
+			detection, ok := Detect(ctx, queues[i], sub, ipNet)
+			votes[i].blocked = detection.Blocked
+			if ok && len(detection.IPs) > 0 {
+				votes[i].ip, votes[i].ok = detection.IPs[0], true
+			}
+		}()
+	}
+	wg.Wait()
+	for _, q := range queues {
+		q.Flush()
+	}
+
+	var blocked []netip.Addr
+	for _, v := range votes {
+		blocked = append(blocked, v.blocked...)
+	}
+
+	count := map[netip.Addr]int{}
+	var winners []netip.Addr
+	for _, v := range votes {
+		if !v.ok {
+			continue
+		}
+		count[v.ip]++
+		if count[v.ip] == p.threshold {
+			winners = append(winners, v.ip)
+		}
+	}
+
+	describe := func(winner netip.Addr) ([]string, []string) {
+		var notes, dissenters []string
+		for i, v := range votes {
+			switch {
+			case !v.ok:
+				notes = append(notes, fmt.Sprintf("%s failed", Name(p.providers[i])))
+			case v.ip != winner:
+				notes = append(notes, fmt.Sprintf("%s detected %s", Name(p.providers[i]), v.ip.String()))
+			default:
+				continue
+			}
+			dissenters = append(dissenters, Name(p.providers[i]))
+		}
+		return notes, dissenters
+	}
+
+	switch len(winners) {
+	case 0:
+		ppfmt.Noticef(pp.EmojiError,
+			"Failed to reach a quorum of %d out of %d providers on the %s address",
+			p.threshold, len(p.providers), ipNet.Describe())
+		notes, dissenters := describe(netip.Addr{})
+		return Detection{IPs: nil, Notes: notes, Metadata: nil, Dissenters: dissenters, Blocked: blocked}, false
+
+	case 1:
+		winner := winners[0]
+		notes, dissenters := describe(winner)
+		if len(notes) > 0 {
+			ppfmt.Infof(pp.EmojiWarning,
+				"Only %d out of %d providers agreed on the %s address %s (%s)",
+				count[winner], len(p.providers), ipNet.Describe(), winner.String(), strings.Join(notes, "; "))
+		}
+		return Detection{IPs: []netip.Addr{winner}, Notes: notes, Metadata: nil, Dissenters: dissenters, Blocked: blocked}, true
+
+	default:
+		ppfmt.Noticef(pp.EmojiError,
+			"Failed to reach a quorum on the %s address because multiple addresses (%s) "+
+				"were each detected by at least %d providers",
+			ipNet.Describe(), pp.JoinMap(netip.Addr.String, winners), p.threshold)
+		notes, dissenters := describe(netip.Addr{})
+		return Detection{IPs: nil, Notes: notes, Metadata: nil, Dissenters: dissenters, Blocked: blocked}, false
+	}
+}
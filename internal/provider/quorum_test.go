@@ -0,0 +1,222 @@
+// vim: nowrap
+package provider_test
+
+import (
+	"context"
+	"net/netip"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+)
+
+func TestQuorumName(t *testing.T) {
+	t.Parallel()
+
+	p := provider.MustNewQuorum(0, []provider.Provider{
+		provider.NewCloudflareTrace(),
+		provider.NewCloudflareDOH(),
+		provider.MustNewCustomURL("https://1.1.1.1/"),
+	})
+	require.Equal(t, "quorum:2:cloudflare.trace,cloudflare.doh,url:(redacted)", provider.Name(p))
+}
+
+func TestNewQuorum(t *testing.T) {
+	t.Parallel()
+
+	trace := provider.NewCloudflareTrace()
+	doh := provider.NewCloudflareDOH()
+	local := provider.NewLocal()
+
+	for name, tc := range map[string]struct {
+		threshold     int
+		providers     []provider.Provider
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"majority":  {0, []provider.Provider{trace, doh, local}, true, nil},
+		"unanimous": {3, []provider.Provider{trace, doh, local}, true, nil},
+		"one": {
+			0, []provider.Provider{trace}, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider "quorum" needs at least 2 providers, but only %d was given`, 1)
+			},
+		},
+		"too-large": {
+			4, []provider.Provider{trace, doh, local}, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The quorum (%d) of the provider "quorum" should be between 1 and the number of providers (%d)`, 4, 3)
+			},
+		},
+		"not-majority": {
+			1, []provider.Provider{trace, doh}, true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserWarning, `The quorum (%d) of the provider "quorum" is not a strict majority of the %d providers`, 1, 2)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			p, ok := provider.NewQuorum(mockPP, tc.threshold, tc.providers)
+			require.Equal(t, tc.ok, ok)
+			if ok {
+				require.NotNil(t, p)
+			} else {
+				require.Nil(t, p)
+			}
+		})
+	}
+}
+
+func TestMustNewQuorum(t *testing.T) {
+	t.Parallel()
+
+	require.NotPanics(t, func() {
+		provider.MustNewQuorum(0, []provider.Provider{provider.NewCloudflareTrace(), provider.NewCloudflareDOH()})
+	})
+	require.Panics(t, func() {
+		provider.MustNewQuorum(0, []provider.Provider{provider.NewCloudflareTrace()})
+	})
+}
+
+func TestQuorumGetIPWithNotes(t *testing.T) {
+	t.Parallel()
+
+	ip1 := netip.MustParseAddr("1.1.1.1")
+	ip2 := netip.MustParseAddr("2.2.2.2")
+	invalidIP := netip.Addr{}
+
+	type vote struct {
+		ip netip.Addr
+		ok bool
+	}
+
+	for name, tc := range map[string]struct {
+		threshold     int
+		votes         []vote
+		expected      netip.Addr
+		notes         []string
+		ok            bool
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"unanimous": {
+			0, []vote{{ip1, true}, {ip1, true}, {ip1, true}},
+			ip1, nil, true, nil,
+		},
+		"disagreement": {
+			0, []vote{{ip1, true}, {ip2, true}, {ip1, true}},
+			ip1, []string{"p1 detected 2.2.2.2"}, true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiWarning, "Only %d out of %d providers agreed on the %s address %s (%s)", 2, 3, "IPv4", "1.1.1.1", "p1 detected 2.2.2.2")
+			},
+		},
+		"failure": {
+			0, []vote{{invalidIP, false}, {ip1, true}, {ip1, true}},
+			ip1, []string{"p0 failed"}, true,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Infof(pp.EmojiWarning, "Only %d out of %d providers agreed on the %s address %s (%s)", 2, 3, "IPv4", "1.1.1.1", "p0 failed")
+			},
+		},
+		"no-quorum": {
+			0, []vote{{invalidIP, false}, {ip2, true}, {ip1, true}},
+			invalidIP, []string{"p0 failed", "p1 detected 2.2.2.2", "p2 detected 1.1.1.1"}, false,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiError, "Failed to reach a quorum of %d out of %d providers on the %s address", 2, 3, "IPv4")
+			},
+		},
+		"tie": {
+			1, []vote{{ip2, true}, {ip1, true}},
+			invalidIP, []string{"p0 detected 2.2.2.2", "p1 detected 1.1.1.1"}, false,
+			func(m *mocks.MockPP) {
+				gomock.InOrder(
+					m.EXPECT().Noticef(pp.EmojiUserWarning, `The quorum (%d) of the provider "quorum" is not a strict majority of the %d providers`, 1, 2),
+					m.EXPECT().Noticef(pp.EmojiError, "Failed to reach a quorum on the %s address because multiple addresses (%s) were each detected by at least %d providers", "IPv4", "2.2.2.2, 1.1.1.1", 1),
+				)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+
+			providers := make([]provider.Provider, 0, len(tc.votes))
+			for i, v := range tc.votes {
+				m := mocks.NewMockProvider(mockCtrl)
+				m.EXPECT().Name().Return("p" + strconv.Itoa(i)).AnyTimes()
+				m.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(v.ip, v.ok)
+				providers = append(providers, m)
+			}
+
+			p, ok := provider.NewQuorum(mockPP, tc.threshold, providers)
+			require.True(t, ok)
+
+			ip, notes, ok := provider.GetIPWithNotes(context.Background(), mockPP, p, ipnet.IP4)
+			require.Equal(t, tc.expected, ip)
+			require.Equal(t, tc.notes, notes)
+			require.Equal(t, tc.ok, ok)
+		})
+	}
+}
+
+func TestQuorumDetect(t *testing.T) {
+	t.Parallel()
+
+	ip1 := netip.MustParseAddr("1.1.1.1")
+	ip2 := netip.MustParseAddr("2.2.2.2")
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Infof(pp.EmojiWarning, "Only %d out of %d providers agreed on the %s address %s (%s)", 2, 4, "IPv4", "1.1.1.1", "p1 failed; p2 detected 2.2.2.2")
+
+	providers := make([]provider.Provider, 0, 3)
+	for i, ip := range []netip.Addr{ip1, {}, ip2} {
+		m := mocks.NewMockProvider(mockCtrl)
+		m.EXPECT().Name().Return("p" + strconv.Itoa(i)).AnyTimes()
+		m.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(ip, ip.IsValid())
+		providers = append(providers, m)
+	}
+	providers = append(providers, provider.MustNewDebugConst("1.1.1.1"))
+
+	p := provider.MustNewQuorum(2, providers)
+	detection, ok := provider.Detect(context.Background(), mockPP, p, ipnet.IP4)
+	require.True(t, ok)
+	require.Equal(t, provider.Detection{
+		IPs:        []netip.Addr{ip1},
+		Notes:      []string{"p1 failed", "p2 detected 2.2.2.2"},
+		Metadata:   nil,
+		Dissenters: []string{"p1", "p2"},
+	}, detection)
+}
+
+func TestQuorumGetIP(t *testing.T) {
+	t.Parallel()
+
+	ip := netip.MustParseAddr("1.1.1.1")
+	p := provider.MustNewQuorum(0, []provider.Provider{
+		provider.MustNewDebugConst("1.1.1.1"),
+		provider.MustNewDebugConst("1.1.1.1"),
+	})
+
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	detected, ok := p.GetIP(context.Background(), mockPP, ipnet.IP4)
+	require.True(t, ok)
+	require.Equal(t, ip, detected)
+}
@@ -0,0 +1,37 @@
+package provider
+
+import (
+	"net"
+	"strings"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+// NewSTUN creates a STUN provider that asks the STUN server at hostPort.
+func NewSTUN(ppfmt pp.PP, hostPort string) (Provider, bool) {
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil || host == "" || port == "" {
+		ppfmt.Noticef(pp.EmojiUserError, `The provider "stun:%s" does not contain a valid host and port`, hostPort)
+		return nil, false
+	}
+
+	return protocol.STUN{
+		ProviderName: "stun:" + hostPort,
+		Server: map[ipnet.Type]string{
+			ipnet.IP4: hostPort,
+			ipnet.IP6: hostPort,
+		},
+	}, true
+}
+
+// MustNewSTUN creates a STUN provider and panics if it fails.
+func MustNewSTUN(hostPort string) Provider {
+	var buf strings.Builder
+	p, ok := NewSTUN(pp.NewDefault(&buf), hostPort)
+	if !ok {
+		panic(buf.String())
+	}
+	return p
+}
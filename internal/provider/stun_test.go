@@ -0,0 +1,59 @@
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+)
+
+func TestSTUNName(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "stun:stun.example.org:3478", provider.Name(provider.MustNewSTUN("stun.example.org:3478")))
+	require.Equal(t, "stun:[2001:db8::1]:3478", provider.Name(provider.MustNewSTUN("[2001:db8::1]:3478")))
+}
+
+func TestNewSTUN(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		input string
+		ok    bool
+	}{
+		{"stun.example.org:3478", true},
+		{"192.0.2.1:3478", true},
+		{"[2001:db8::1]:3478", true},
+		{"stun.example.org", false},
+		{"stun.example.org:", false},
+		{":3478", false},
+		{"2001:db8::1:3478", false},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if !tc.ok {
+				mockPP.EXPECT().Noticef(pp.EmojiUserError, `The provider "stun:%s" does not contain a valid host and port`, tc.input)
+			}
+			p, ok := provider.NewSTUN(mockPP, tc.input)
+			require.Equal(t, tc.ok, ok)
+			if ok {
+				require.NotNil(t, p)
+			} else {
+				require.Nil(t, p)
+			}
+
+			if tc.ok {
+				require.NotPanics(t, func() { provider.MustNewSTUN(tc.input) })
+			} else {
+				require.Panics(t, func() { provider.MustNewSTUN(tc.input) })
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+func isJSONNameChar(c byte) bool {
+	return c == '_' || c == '-' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// parseJSONPath parses a selector such as `$.wan.ipv4` or `$.interfaces[0]["ip address"]`
+// at the beginning of s and returns the rest of s.
+func parseJSONPath(s string) (protocol.JSONPath, string, bool) {
+	s, found := strings.CutPrefix(s, "$")
+	if !found {
+		return nil, "", false
+	}
+
+	path := protocol.JSONPath{}
+	for {
+		switch {
+		case strings.HasPrefix(s, "."):
+			i := 1
+			for i < len(s) && isJSONNameChar(s[i]) {
+				i++
+			}
+			if i == 1 {
+				return nil, "", false
+			}
+			path, s = append(path, s[1:i]), s[i:]
+
+		case strings.HasPrefix(s, `["`):
+			quoted, err := strconv.QuotedPrefix(s[1:])
+			if err != nil || !strings.HasPrefix(s[1+len(quoted):], "]") {
+				return nil, "", false
+			}
+			key, _ := strconv.Unquote(quoted)
+			path, s = append(path, key), s[1+len(quoted)+1:]
+
+		case strings.HasPrefix(s, "["):
+			index, rest, found := strings.Cut(s[1:], "]")
+			if !found {
+				return nil, "", false
+			}
+			if _, err := strconv.ParseUint(index, 10, 31); err != nil || (index != "0" && index[0] == '0') {
+				return nil, "", false
+			}
+			path, s = append(path, index), rest
+
+		default:
+			return path, s, true
+		}
+	}
+}
+
+// NewCustomURLJSON creates a provider that extracts the IP address from a JSON document
+// from "<selector>@<URL>", where the selector looks like "$.wan.ipv4".
+func NewCustomURLJSON(ppfmt pp.PP, spec string) (Provider, bool) {
+	const providerName = "url.json:(redacted)"
+
+	path, rest, ok := parseJSONPath(strings.TrimSpace(spec))
+	if !ok {
+		ppfmt.Noticef(pp.EmojiUserError,
+			`The provider %s does not start with a valid selector (such as "$.wan.ipv4")`, providerName)
+		return nil, false
+	}
+
+	rawURL, found := strings.CutPrefix(strings.TrimSpace(rest), "@")
+	if !found {
+		ppfmt.Noticef(pp.EmojiUserError,
+			`The provider %s is not in the form url.json:<selector>@<URL>`, providerName)
+		return nil, false
+	}
+	rawURL = strings.TrimSpace(rawURL)
+
+	u, err := url.Parse(rawURL)
+	if err != nil || !u.IsAbs() || u.Opaque != "" || u.Host == "" {
+		ppfmt.Noticef(pp.EmojiUserError, `The provider %s does not contain a valid URL`, providerName)
+		return nil, false
+	}
+
+	switch u.Scheme {
+	case "http":
+		ppfmt.Noticef(pp.EmojiUserWarning, "The provider %s uses HTTP; consider using HTTPS instead", providerName)
+
+	case "https":
+		// HTTPS is good!
+
+	default:
+		ppfmt.Noticef(pp.EmojiUserError, `The provider %s only supports HTTP and HTTPS`, providerName)
+		return nil, false
+	}
+
+	return protocol.JSON{
+		ProviderName: providerName,
+		Param: map[ipnet.Type]protocol.JSONParam{
+			ipnet.IP4: {rawURL, path},
+			ipnet.IP6: {rawURL, path},
+		},
+	}, true
+}
+
+// MustNewCustomURLJSON creates a JSON provider and panics if it fails.
+func MustNewCustomURLJSON(spec string) Provider {
+	var buf strings.Builder
+	p, ok := NewCustomURLJSON(pp.NewDefault(&buf), spec)
+	if !ok {
+		panic(buf.String())
+	}
+	return p
+}
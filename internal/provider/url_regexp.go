@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+// URLRegexpOptions are the options of the HTTP request and the regular expression
+// for a provider created by [NewCustomURLRegexp].
+type URLRegexpOptions struct {
+	Name    string            // name of the options, which is part of the provider name
+	Regexp  string            // regular expression whose first capturing group matches the IP address
+	Method  string            // HTTP method; empty means GET
+	Headers map[string]string // additional HTTP headers
+	Body    string            // HTTP request body; empty means no body
+}
+
+// NewCustomURLRegexp creates a provider that extracts the IP address from an HTTP response
+// with a regular expression.
+func NewCustomURLRegexp(ppfmt pp.PP, rawURL string, options URLRegexpOptions) (Provider, bool) {
+	providerName := "url.regexp:" + options.Name + "@(redacted)"
+
+	u, err := url.Parse(rawURL)
+	if err != nil || !u.IsAbs() || u.Opaque != "" || u.Host == "" {
+		ppfmt.Noticef(pp.EmojiUserError, `The provider %s does not contain a valid URL`, providerName)
+		return nil, false
+	}
+
+	switch u.Scheme {
+	case "http":
+		ppfmt.Noticef(pp.EmojiUserWarning, "The provider %s uses HTTP; consider using HTTPS instead", providerName)
+
+	case "https":
+		// HTTPS is good!
+
+	default:
+		ppfmt.Noticef(pp.EmojiUserError, `The provider %s only supports HTTP and HTTPS`, providerName)
+		return nil, false
+	}
+
+	re, err := regexp.Compile(options.Regexp)
+	if err != nil {
+		ppfmt.Noticef(pp.EmojiUserError, `The provider %s has an invalid regular expression: %v`, providerName, err)
+		return nil, false
+	}
+	if re.NumSubexp() < 1 {
+		ppfmt.Noticef(pp.EmojiUserError,
+			`The regular expression of the provider %s must have a capturing group for the IP address`, providerName)
+		return nil, false
+	}
+
+	method := strings.ToUpper(strings.TrimSpace(options.Method))
+	if method != "" && !httpguts.ValidHeaderFieldName(method) {
+		ppfmt.Noticef(pp.EmojiUserError, `The provider %s has an invalid HTTP method %q`, providerName, options.Method)
+		return nil, false
+	}
+
+	param := protocol.RegexpParam{
+		URL:     rawURL,
+		Regexp:  re,
+		Method:  method,
+		Headers: options.Headers,
+		Body:    options.Body,
+	}
+
+	return protocol.Regexp{
+		ProviderName: providerName,
+		Param: map[ipnet.Type]protocol.RegexpParam{
+			ipnet.IP4: param,
+			ipnet.IP6: param,
+		},
+	}, true
+}
+
+// MustNewCustomURLRegexp creates a regexp provider and panics if it fails.
+func MustNewCustomURLRegexp(rawURL string, options URLRegexpOptions) Provider {
+	var buf strings.Builder
+	p, ok := NewCustomURLRegexp(pp.NewDefault(&buf), rawURL, options)
+	if !ok {
+		panic(buf.String())
+	}
+	return p
+}
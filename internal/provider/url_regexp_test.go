@@ -0,0 +1,106 @@
+// vim: nowrap
+package provider_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/mocks"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
+	"github.com/favonia/cloudflare-ddns/internal/provider/protocol"
+)
+
+func TestCustomURLRegexpName(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "url.regexp:modem@(redacted)", provider.Name(provider.MustNewCustomURLRegexp("https://modem.lan/", provider.URLRegexpOptions{Name: "modem", Regexp: `(.*)`, Method: "", Headers: nil, Body: ""})))
+}
+
+func TestNewCustomURLRegexp(t *testing.T) {
+	t.Parallel()
+
+	const url = "https://modem.lan/status"
+	headers := map[string]string{"Authorization": "Basic c2VjcmV0"}
+
+	for name, tc := range map[string]struct {
+		url           string
+		options       provider.URLRegexpOptions
+		expected      *protocol.RegexpParam
+		prepareMockPP func(*mocks.MockPP)
+	}{
+		"get": {
+			url, provider.URLRegexpOptions{Name: "modem", Regexp: `WAN IP: ([0-9.]+)`, Method: "", Headers: nil, Body: ""},
+			&protocol.RegexpParam{URL: url, Regexp: regexp.MustCompile(`WAN IP: ([0-9.]+)`), Method: "", Headers: nil, Body: ""},
+			nil,
+		},
+		"post": {
+			url, provider.URLRegexpOptions{Name: "modem", Regexp: `"ip":"(.*?)"`, Method: " post ", Headers: headers, Body: "{}"},
+			&protocol.RegexpParam{URL: url, Regexp: regexp.MustCompile(`"ip":"(.*?)"`), Method: "POST", Headers: headers, Body: "{}"},
+			nil,
+		},
+		"http": {
+			"http://modem.lan/", provider.URLRegexpOptions{Name: "modem", Regexp: `(.*)`, Method: "", Headers: nil, Body: ""},
+			&protocol.RegexpParam{URL: "http://modem.lan/", Regexp: regexp.MustCompile(`(.*)`), Method: "", Headers: nil, Body: ""},
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserWarning, "The provider %s uses HTTP; consider using HTTPS instead", "url.regexp:modem@(redacted)")
+			},
+		},
+		"bad-url": {
+			"modem.lan", provider.URLRegexpOptions{Name: "modem", Regexp: `(.*)`, Method: "", Headers: nil, Body: ""}, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s does not contain a valid URL`, "url.regexp:modem@(redacted)")
+			},
+		},
+		"ftp": {
+			"ftp://modem.lan/", provider.URLRegexpOptions{Name: "modem", Regexp: `(.*)`, Method: "", Headers: nil, Body: ""}, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s only supports HTTP and HTTPS`, "url.regexp:modem@(redacted)")
+			},
+		},
+		"bad-regexp": {
+			url, provider.URLRegexpOptions{Name: "modem", Regexp: `(`, Method: "", Headers: nil, Body: ""}, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s has an invalid regular expression: %v`, "url.regexp:modem@(redacted)", gomock.Any())
+			},
+		},
+		"no-group": {
+			url, provider.URLRegexpOptions{Name: "modem", Regexp: `[0-9.]+`, Method: "", Headers: nil, Body: ""}, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The regular expression of the provider %s must have a capturing group for the IP address`, "url.regexp:modem@(redacted)")
+			},
+		},
+		"bad-method": {
+			url, provider.URLRegexpOptions{Name: "modem", Regexp: `(.*)`, Method: "GET IT", Headers: nil, Body: ""}, nil,
+			func(m *mocks.MockPP) {
+				m.EXPECT().Noticef(pp.EmojiUserError, `The provider %s has an invalid HTTP method %q`, "url.regexp:modem@(redacted)", "GET IT")
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockPP := mocks.NewMockPP(mockCtrl)
+			if tc.prepareMockPP != nil {
+				tc.prepareMockPP(mockPP)
+			}
+			p, ok := provider.NewCustomURLRegexp(mockPP, tc.url, tc.options)
+			if tc.expected == nil {
+				require.False(t, ok)
+				require.Nil(t, p)
+				require.Panics(t, func() { provider.MustNewCustomURLRegexp(tc.url, tc.options) })
+			} else {
+				require.True(t, ok)
+				require.Equal(t, protocol.Regexp{
+					ProviderName: "url.regexp:modem@(redacted)",
+					Param:        map[ipnet.Type]protocol.RegexpParam{ipnet.IP4: *tc.expected, ipnet.IP6: *tc.expected},
+				}, p)
+			}
+		})
+	}
+}
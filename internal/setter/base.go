@@ -19,13 +19,13 @@ import (
 
 // Setter uses [api.Handle] to update DNS records.
 type Setter interface {
-	// Set sets a particular domain to the given IP address.
+	// Set sets a particular domain to exactly the given IP addresses.
 	Set(
 		ctx context.Context,
 		ppfmt pp.PP,
 		IPNetwork ipnet.Type,
 		Domain domain.Domain,
-		IP netip.Addr,
+		IPs []netip.Addr,
 		expectedParams api.RecordParams,
 	) ResponseCode
 
@@ -38,6 +38,46 @@ type Setter interface {
 		expectedParams api.RecordParams,
 	) ResponseCode
 
+	// ListIPs returns the IP addresses of the DNS records of a particular domain managed by the updater,
+	// sorted and without duplicates.
+	ListIPs(
+		ctx context.Context,
+		ppfmt pp.PP,
+		IPNetwork ipnet.Type,
+		Domain domain.Domain,
+		expectedParams api.RecordParams,
+	) ([]netip.Addr, bool)
+
+	// SetSVCBHints sets the ipv4hint or ipv6hint of the HTTPS/SVCB records of a particular domain
+	// to exactly the given IP addresses.
+	SetSVCBHints(
+		ctx context.Context,
+		ppfmt pp.PP,
+		IPNetwork ipnet.Type,
+		Domain domain.Domain,
+		IPs []netip.Addr,
+	) ResponseCode
+
+	// SetSPF sets the ip4 or ip6 mechanisms of the SPF record of a particular domain
+	// to exactly the given IP addresses.
+	SetSPF(
+		ctx context.Context,
+		ppfmt pp.PP,
+		IPNetwork ipnet.Type,
+		Domain domain.Domain,
+		IPs []netip.Addr,
+	) ResponseCode
+
+	// SetHeartbeat sets the content of the heartbeat TXT record of a particular domain,
+	// creating the record if it does not exist yet.
+	SetHeartbeat(
+		ctx context.Context,
+		ppfmt pp.PP,
+		Domain domain.Domain,
+		Content string,
+		expectedParams api.RecordParams,
+	) ResponseCode
+
 	// SetWAFList keeps only IP ranges overlapping with detected IPs
 	// and makes sure there will be ranges overlapping with detected ones.
 	SetWAFList(
@@ -45,10 +85,18 @@ type Setter interface {
 		ppfmt pp.PP,
 		list api.WAFList,
 		listDescription string,
-		detected map[ipnet.Type]netip.Addr,
+		detected map[ipnet.Type][]netip.Addr,
 		itemComment string,
 	) ResponseCode
 
+	// ListWAFList returns the IP ranges in a list, creating the list if it does not exist yet.
+	ListWAFList(
+		ctx context.Context,
+		ppfmt pp.PP,
+		list api.WAFList,
+		listDescription string,
+	) ([]netip.Prefix, bool)
+
 	// FinalClearWAFList deletes or empties a list.
 	FinalClearWAFList(
 		ctx context.Context,
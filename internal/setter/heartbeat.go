@@ -0,0 +1,22 @@
+package setter
+
+import "strings"
+
+// heartbeatKeys are the keys of the fields of a heartbeat TXT record, in order,
+// such as "ips=192.0.2.1,2001:db8::1 time=2006-01-02T15:04:05Z version=1.15.0".
+var heartbeatKeys = [...]string{"ips=", "time=", "version="} //nolint:gochecknoglobals
+
+// isHeartbeat checks whether the content of a TXT record is in the format of heartbeat TXT records.
+func isHeartbeat(content string) bool {
+	text, _, ok := parseTXT(content)
+	fields := strings.Fields(text)
+	if !ok || len(fields) != len(heartbeatKeys) {
+		return false
+	}
+	for i, key := range heartbeatKeys {
+		if !strings.HasPrefix(fields[i], key) {
+			return false
+		}
+	}
+	return true
+}
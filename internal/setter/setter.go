@@ -3,6 +3,8 @@ package setter
 import (
 	"context"
 	"net/netip"
+	"slices"
+	"strings"
 
 	"github.com/favonia/cloudflare-ddns/internal/api"
 	"github.com/favonia/cloudflare-ddns/internal/domain"
@@ -11,13 +13,16 @@ import (
 )
 
 type setter struct {
-	Handle api.Handle
+	Handle    api.Handle
+	Ownership api.Ownership
 }
 
-// New creates a new Setter.
-func New(_ppfmt pp.PP, handle api.Handle) (Setter, bool) {
+// New creates a new Setter. Only DNS records owned by the updater according to ownership
+// will be updated or deleted.
+func New(_ppfmt pp.PP, handle api.Handle, ownership api.Ownership) (Setter, bool) {
 	return setter{
-		Handle: handle,
+		Handle:    handle,
+		Ownership: ownership,
 	}, true
 }
 
@@ -27,50 +32,73 @@ type Record struct {
 	api.RecordParams
 }
 
-// partitionRecords partitions record maps into matched and unmatched ones.
+// partitionRecords partitions records into matched, duplicate, and unmatched ones.
+// For each target IP, the first record with the IP is matched and the other records with the IP are duplicates.
+// The returned missing IPs are the targets without any matched records, in the original order.
 //
-// The target IP is assumed to be non-zero.
-func partitionRecords(rs []api.Record, target netip.Addr) (matchedIDs, unmatchedIDs []Record) {
+// The target IPs are assumed to be non-zero and distinct.
+func partitionRecords(rs []api.Record, targets []netip.Addr,
+) (missing []netip.Addr, duplicateIDs, unmatchedIDs []Record) {
+	matched := map[netip.Addr]bool{}
 	for _, r := range rs {
-		if r.IP == target {
-			matchedIDs = append(matchedIDs, Record{ID: r.ID, RecordParams: r.RecordParams})
-		} else {
+		switch {
+		case !slices.Contains(targets, r.IP):
 			unmatchedIDs = append(unmatchedIDs, Record{ID: r.ID, RecordParams: r.RecordParams})
+		case matched[r.IP]:
+			duplicateIDs = append(duplicateIDs, Record{ID: r.ID, RecordParams: r.RecordParams})
+		default:
+			matched[r.IP] = true
 		}
 	}
 
-	return matchedIDs, unmatchedIDs
+	for _, target := range targets {
+		if !matched[target] {
+			missing = append(missing, target)
+		}
+	}
+
+	return missing, duplicateIDs, unmatchedIDs
+}
+
+// partitionOwnedRecords partitions records into the ones owned by the updater and the others.
+// Only the IP addresses of the other records are returned.
+func (s setter) partitionOwnedRecords(rs []api.Record, expectedParams api.RecordParams,
+) (owned []api.Record, foreignIPs []netip.Addr) {
+	for _, r := range rs {
+		if s.Ownership.Owns(r.RecordParams, expectedParams) {
+			owned = append(owned, r)
+		} else {
+			foreignIPs = append(foreignIPs, r.IP)
+		}
+	}
+	return owned, foreignIPs
 }
 
-// Set updates the IP address of one domain to the given ip. The IP address (ip) must be non-zero.
+// Set updates the IP addresses of one domain to exactly the given ips.
+// The IP addresses (ips) must be non-zero and distinct, and there must be at least one of them.
 func (s setter) Set(ctx context.Context, ppfmt pp.PP,
-	ipnet ipnet.Type, domain domain.Domain, ip netip.Addr,
+	ipnet ipnet.Type, domain domain.Domain, ips []netip.Addr,
 	expectedParams api.RecordParams,
 ) ResponseCode {
 	recordType := ipnet.RecordType()
 	domainDescription := domain.Describe()
 
-	rs, cached, ok := s.Handle.ListRecords(ctx, ppfmt, ipnet, domain, expectedParams)
+	rs, cached, ok := s.Handle.ListRecords(ctx, ppfmt, ipnet, domain, expectedParams, s.Ownership)
 	if !ok {
 		return ResponseFailed
 	}
 
-	// The intention of these two lists is to find or create a good record and then delete everything else.
-	// We prefer recycling existing records (if possible) so that existing record attributes can be preserved.
-	unprocessedMatched, unprocessedUnmatched := partitionRecords(rs, ip)
-
-	// foundMatched remembers whether the correct DNS record is already present.
-	// Stale records may still exist even when foundMatched is true.
-	foundMatched := false
+	// Records not owned by the updater are never touched. Their IP addresses are considered published
+	// because Cloudflare does not allow adding another record with the same content.
+	rs, foreignIPs := s.partitionOwnedRecords(rs, expectedParams)
+	ips = slices.DeleteFunc(slices.Clone(ips), func(ip netip.Addr) bool { return slices.Contains(foreignIPs, ip) })
 
-	// If it's still not up to date, and there's a matched ID, use it and delete everything else.
-	if !foundMatched && len(unprocessedMatched) > 0 {
-		foundMatched = true
-		unprocessedMatched = unprocessedMatched[1:]
-	}
+	// The intention is to find or create a good record for each IP address and then delete everything else.
+	// We prefer recycling existing records (if possible) so that existing record attributes can be preserved.
+	unprocessedMissing, unprocessedDuplicates, unprocessedUnmatched := partitionRecords(rs, ips)
 
 	// If it's up to date and there are no other records, we are done!
-	if foundMatched && len(unprocessedMatched) == 0 && len(unprocessedUnmatched) == 0 {
+	if len(unprocessedMissing) == 0 && len(unprocessedDuplicates) == 0 && len(unprocessedUnmatched) == 0 {
 		if cached {
 			ppfmt.Infof(pp.EmojiAlreadyDone,
 				"The %s records of %s are already up to date (cached)",
@@ -83,37 +111,35 @@ func (s setter) Set(ctx context.Context, ppfmt pp.PP,
 		return ResponseNoop
 	}
 
-	// If somehow it's still not up to date, it means there are no matching records.
-	// This means we should update one stale record or create a new one with the desired IP address.
+	// For each IP address without matching records, we should update one stale record
+	// or create a new one with the desired IP address.
 	//
 	// Again, we prefer updating stale records instead of creating new ones so that we can
 	// preserve the current TTL and proxy setting.
-	if !foundMatched && len(unprocessedUnmatched) > 0 {
-		if ok := s.Handle.UpdateRecord(ctx, ppfmt, ipnet, domain, unprocessedUnmatched[0].ID, ip,
-			unprocessedUnmatched[0].RecordParams, expectedParams,
-		); !ok {
-			ppfmt.Noticef(pp.EmojiError,
-				"Failed to properly update %s records of %s; records might be inconsistent",
-				recordType, domainDescription)
-			return ResponseFailed
-		}
+	for _, ip := range unprocessedMissing {
+		if len(unprocessedUnmatched) > 0 {
+			if ok := s.Handle.UpdateRecord(ctx, ppfmt, ipnet, domain, unprocessedUnmatched[0].ID, ip,
+				unprocessedUnmatched[0].RecordParams, expectedParams,
+			); !ok {
+				ppfmt.Noticef(pp.EmojiError,
+					"Failed to properly update %s records of %s; records might be inconsistent",
+					recordType, domainDescription)
+				return ResponseFailed
+			}
 
-		// If the updating succeeds, we can move on to the next stage!
-		//
-		// Note that there can still be stale records at this point.
-		ppfmt.Noticef(pp.EmojiUpdate,
-			"Updated a stale %s record of %s (ID: %s)",
-			recordType, domainDescription, unprocessedUnmatched[0].ID)
+			// If the updating succeeds, we can move on to the next IP address!
+			//
+			// Note that there can still be stale records at this point.
+			ppfmt.Noticef(pp.EmojiUpdate,
+				"Updated a stale %s record of %s (ID: %s)",
+				recordType, domainDescription, unprocessedUnmatched[0].ID)
 
-		// Now it's up to date! Note that unprocessedMatched must be empty
-		// otherwise foundMatched would have been true.
-		foundMatched = true
-		unprocessedUnmatched = unprocessedUnmatched[1:]
-	}
+			unprocessedUnmatched = unprocessedUnmatched[1:]
+			continue
+		}
 
-	// If it's still not up to date at this point, it means there are no stale records to update.
-	// This leaves us no choices---we have to create a new record with the correct IP.
-	if !foundMatched {
+		// If there are no stale records to update, we have no choices---we have to
+		// create a new record with the correct IP.
 		id, ok := s.Handle.CreateRecord(ctx, ppfmt, ipnet, domain, ip, expectedParams)
 		if !ok {
 			ppfmt.Noticef(pp.EmojiError,
@@ -122,8 +148,7 @@ func (s setter) Set(ctx context.Context, ppfmt pp.PP,
 			return ResponseFailed
 		}
 
-		// Note that both unprocessedMatched and unprocessedUnmatched must be empty at this point
-		// and the records are updated.
+		// Note that unprocessedUnmatched must be empty at this point.
 		ppfmt.Noticef(pp.EmojiCreation,
 			"Added a new %s record of %s (ID: %s)", recordType, domainDescription, id)
 	}
@@ -143,7 +168,7 @@ func (s setter) Set(ctx context.Context, ppfmt pp.PP,
 
 	// We should also delete all duplicate records even if they are up to date.
 	// This has lower priority than deleting the stale records.
-	for _, r := range unprocessedMatched {
+	for _, r := range unprocessedDuplicates {
 		if ok := s.Handle.DeleteRecord(ctx, ppfmt, ipnet, domain, r.ID, api.RegularDelitionMode); ok {
 			ppfmt.Noticef(pp.EmojiDeletion,
 				"Deleted a duplicate %s record of %s (ID: %s)", recordType, domainDescription, r.ID)
@@ -156,17 +181,18 @@ func (s setter) Set(ctx context.Context, ppfmt pp.PP,
 	return ResponseUpdated
 }
 
-// FinalDelete deletes all managed DNS records.
+// FinalDelete deletes all managed DNS records owned by the updater.
 func (s setter) FinalDelete(ctx context.Context, ppfmt pp.PP, ipnet ipnet.Type, domain domain.Domain,
 	expectedParams api.RecordParams,
 ) ResponseCode {
 	recordType := ipnet.RecordType()
 	domainDescription := domain.Describe()
 
-	rs, cached, ok := s.Handle.ListRecords(ctx, ppfmt, ipnet, domain, expectedParams)
+	rs, cached, ok := s.Handle.ListRecords(ctx, ppfmt, ipnet, domain, expectedParams, s.Ownership)
 	if !ok {
 		return ResponseFailed
 	}
+	rs, _ = s.partitionOwnedRecords(rs, expectedParams)
 
 	// Sorting is not needed for correctness, but it will make the function deterministic.
 	unmatchedIDs := make([]api.ID, 0, len(rs))
@@ -209,12 +235,216 @@ func (s setter) FinalDelete(ctx context.Context, ppfmt pp.PP, ipnet ipnet.Type,
 	return ResponseUpdated
 }
 
+// ListIPs returns the IP addresses of the records of one domain owned by the updater.
+func (s setter) ListIPs(ctx context.Context, ppfmt pp.PP,
+	ipnet ipnet.Type, domain domain.Domain, expectedParams api.RecordParams,
+) ([]netip.Addr, bool) {
+	rs, _, ok := s.Handle.ListRecords(ctx, ppfmt, ipnet, domain, expectedParams, s.Ownership)
+	if !ok {
+		return nil, false
+	}
+
+	rs, _ = s.partitionOwnedRecords(rs, expectedParams)
+	ips := make([]netip.Addr, 0, len(rs))
+	for _, r := range rs {
+		ips = append(ips, r.IP)
+	}
+	slices.SortFunc(ips, netip.Addr.Compare)
+	return slices.Compact(ips), true
+}
+
+// sameIPs checks whether two lists contain the same IP addresses, ignoring the order.
+func sameIPs(ips1, ips2 []netip.Addr) bool {
+	ips1 = slices.Clone(ips1)
+	ips2 = slices.Clone(ips2)
+	slices.SortFunc(ips1, netip.Addr.Compare)
+	slices.SortFunc(ips2, netip.Addr.Compare)
+	return slices.Equal(slices.Compact(ips1), slices.Compact(ips2))
+}
+
+// SetSVCBHints updates the ipv4hint or ipv6hint of the HTTPS/SVCB records of one domain to exactly the given ips.
+// Only records in the service mode pointing to the domain itself are managed,
+// and only when they already have the hint. Other SvcParams are kept as they are.
+// The IP addresses (ips) must be non-zero and distinct, and there must be at least one of them.
+func (s setter) SetSVCBHints(ctx context.Context, ppfmt pp.PP,
+	ipnet ipnet.Type, domain domain.Domain, ips []netip.Addr,
+) ResponseCode {
+	hintKey := ipnet.SVCBHintKey()
+	domainDescription := domain.Describe()
+
+	rs, cached, ok := s.Handle.ListSVCBRecords(ctx, ppfmt, domain)
+	if !ok {
+		return ResponseFailed
+	}
+
+	var outdated []api.SVCBRecord
+	for _, r := range rs {
+		if r.Priority == 0 { // the alias mode does not have any SvcParams
+			continue
+		}
+		if target := strings.TrimSuffix(r.Target, "."); target != "" && !strings.EqualFold(target, domain.DNSNameASCII()) {
+			continue
+		}
+		current, found := api.GetSVCBHint(r.Params, hintKey)
+		if !found || sameIPs(current, ips) {
+			continue
+		}
+		r.Params = api.SetSVCBHint(r.Params, hintKey, ips)
+		outdated = append(outdated, r)
+	}
+
+	if len(outdated) == 0 {
+		if cached {
+			ppfmt.Infof(pp.EmojiAlreadyDone, "The %s of HTTPS/SVCB records of %s is already up to date (cached)",
+				hintKey, domainDescription)
+		} else {
+			ppfmt.Infof(pp.EmojiAlreadyDone, "The %s of HTTPS/SVCB records of %s is already up to date",
+				hintKey, domainDescription)
+		}
+		return ResponseNoop
+	}
+
+	allOK := true
+	for _, r := range outdated {
+		if !s.Handle.UpdateSVCBRecord(ctx, ppfmt, domain, r) {
+			allOK = false
+
+			if ctx.Err() != nil {
+				ppfmt.Infof(pp.EmojiTimeout,
+					"Setting %s of HTTPS/SVCB records of %s aborted by timeout or signals; records might be inconsistent",
+					hintKey, domainDescription)
+				return ResponseFailed
+			}
+			continue
+		}
+
+		ppfmt.Noticef(pp.EmojiUpdate, "Updated the %s of an %s record of %s (ID: %s)",
+			hintKey, r.Type, domainDescription, r.ID)
+	}
+	if !allOK {
+		ppfmt.Noticef(pp.EmojiError,
+			"Failed to properly update the %s of HTTPS/SVCB records of %s; records might be inconsistent",
+			hintKey, domainDescription)
+		return ResponseFailed
+	}
+
+	return ResponseUpdated
+}
+
+// SetSPF updates the ip4 or ip6 mechanisms of the SPF record of one domain to exactly the given ips.
+// Only the mechanisms with the qualifier "+" are managed, and all other terms are kept as they are.
+// The IP addresses (ips) must be non-zero and distinct, and there must be at least one of them.
+func (s setter) SetSPF(ctx context.Context, ppfmt pp.PP,
+	ipnet ipnet.Type, domain domain.Domain, ips []netip.Addr,
+) ResponseCode {
+	mechanism := ipnet.SPFMechanism()
+	domainDescription := domain.Describe()
+
+	rs, cached, ok := s.Handle.ListTXTRecords(ctx, ppfmt, domain)
+	if !ok {
+		return ResponseFailed
+	}
+
+	rs = slices.DeleteFunc(slices.Clone(rs), func(r api.TXTRecord) bool { return !isSPF(r.Content) })
+	switch len(rs) {
+	case 0:
+		ppfmt.Noticef(pp.EmojiUserError,
+			"Failed to find the SPF record of %s; please add a TXT record starting with %q", domainDescription, spfVersion)
+		return ResponseFailed
+	case 1:
+	default:
+		ppfmt.Noticef(pp.EmojiUserError,
+			"Found %d SPF records of %s; please keep only one of them", len(rs), domainDescription)
+		return ResponseFailed
+	}
+	r := rs[0]
+
+	if sameIPs(managedSPFIPs(r.Content, ipnet), ips) {
+		if cached {
+			ppfmt.Infof(pp.EmojiAlreadyDone, "The %s mechanisms of the SPF record of %s are already up to date (cached)",
+				mechanism, domainDescription)
+		} else {
+			ppfmt.Infof(pp.EmojiAlreadyDone, "The %s mechanisms of the SPF record of %s are already up to date",
+				mechanism, domainDescription)
+		}
+		return ResponseNoop
+	}
+
+	r.Content = rewriteSPF(r.Content, ipnet, ips)
+	if !s.Handle.UpdateTXTRecord(ctx, ppfmt, domain, r) {
+		ppfmt.Noticef(pp.EmojiError, "Failed to properly update the SPF record of %s", domainDescription)
+		return ResponseFailed
+	}
+
+	ppfmt.Noticef(pp.EmojiUpdate, "Updated the %s mechanisms of the SPF record of %s (ID: %s)",
+		mechanism, domainDescription, r.ID)
+	return ResponseUpdated
+}
+
+// SetHeartbeat updates the content of the heartbeat TXT record of one domain, creating the record if needed.
+// Only TXT records in the format of heartbeat records (see [isHeartbeat]) and owned by the updater
+// are considered, and other TXT records of the domain are left untouched. A new record is created
+// with the TTL, comment, and tags in expectedParams. If there are several heartbeat records,
+// only the first one is updated.
+func (s setter) SetHeartbeat(ctx context.Context, ppfmt pp.PP, domain domain.Domain, content string,
+	expectedParams api.RecordParams,
+) ResponseCode {
+	domainDescription := domain.Describe()
+
+	rs, cached, ok := s.Handle.ListTXTRecords(ctx, ppfmt, domain)
+	if !ok {
+		return ResponseFailed
+	}
+	rs = slices.DeleteFunc(slices.Clone(rs), func(r api.TXTRecord) bool {
+		params := api.RecordParams{TTL: expectedParams.TTL, Proxied: false, Comment: r.Comment, Tags: r.Tags}
+		return !isHeartbeat(r.Content) || !s.Ownership.Owns(params, expectedParams)
+	})
+
+	if len(rs) == 0 {
+		id, ok := s.Handle.CreateTXTRecord(ctx, ppfmt, domain, content, expectedParams)
+		if !ok {
+			ppfmt.Noticef(pp.EmojiError, "Failed to properly write the heartbeat TXT record of %s", domainDescription)
+			return ResponseFailed
+		}
+
+		ppfmt.Noticef(pp.EmojiCreation, "Added a new heartbeat TXT record of %s (ID: %s)", domainDescription, id)
+		return ResponseUpdated
+	}
+
+	r := rs[0]
+	if len(rs) > 1 {
+		ppfmt.Noticef(pp.EmojiUserWarning,
+			"Found %d heartbeat TXT records of %s; only the one with ID %s is updated",
+			len(rs), domainDescription, r.ID)
+	}
+
+	if text, _, _ := parseTXT(r.Content); text == content {
+		if cached {
+			ppfmt.Infof(pp.EmojiAlreadyDone, "The heartbeat TXT record of %s is already up to date (cached)",
+				domainDescription)
+		} else {
+			ppfmt.Infof(pp.EmojiAlreadyDone, "The heartbeat TXT record of %s is already up to date", domainDescription)
+		}
+		return ResponseNoop
+	}
+
+	r.Content = content
+	if !s.Handle.UpdateTXTRecord(ctx, ppfmt, domain, r) {
+		ppfmt.Noticef(pp.EmojiError, "Failed to properly write the heartbeat TXT record of %s", domainDescription)
+		return ResponseFailed
+	}
+
+	// The heartbeat is rewritten in most rounds, so the success is only shown in the verbose mode.
+	ppfmt.Infof(pp.EmojiUpdate, "Updated the heartbeat TXT record of %s (ID: %s)", domainDescription, r.ID)
+	return ResponseUpdated
+}
+
 // SetWAFList updates a WAF list.
 //
-// If detectedIP contains a zero (invalid) IP, it means the detection is attempted but failed
+// If detectedIP contains an empty set of IPs, it means the detection is attempted but failed
 // and all matching IP addresses should be preserved.
 func (s setter) SetWAFList(ctx context.Context, ppfmt pp.PP,
-	list api.WAFList, listDescription string, detectedIP map[ipnet.Type]netip.Addr, itemComment string,
+	list api.WAFList, listDescription string, detectedIP map[ipnet.Type][]netip.Addr, itemComment string,
 ) ResponseCode {
 	items, alreadyExisting, cached, ok := s.Handle.ListWAFListItems(ctx, ppfmt, list, listDescription)
 	if !ok {
@@ -227,23 +457,31 @@ func (s setter) SetWAFList(ctx context.Context, ppfmt pp.PP,
 	var itemsToDelete []api.WAFListItem
 	var itemsToCreate []netip.Prefix
 	for ipNet := range ipnet.All {
-		detectedIP, managed := detectedIP[ipNet]
-		covered := false
+		detectedIPs, managed := detectedIP[ipNet]
+		covered := make([]bool, len(detectedIPs))
 		for _, item := range items {
 			if ipNet.Matches(item.Prefix.Addr()) {
+				found := false
+				for i, ip := range detectedIPs {
+					if item.Prefix.Contains(ip) {
+						covered[i] = true
+						found = true
+					}
+				}
 				switch {
-				case item.Prefix.Contains(detectedIP):
-					covered = true
-				case managed && !detectedIP.IsValid():
+				case found:
+				case managed && len(detectedIPs) == 0:
 					// detection was attempted but failed; do nothing
 				default:
 					itemsToDelete = append(itemsToDelete, item)
 				}
 			}
 		}
-		if !covered && detectedIP.IsValid() {
-			itemsToCreate = append(itemsToCreate,
-				netip.PrefixFrom(detectedIP, api.WAFListMaxBitLen[ipNet]).Masked())
+		for i, ip := range detectedIPs {
+			if covered[i] || slices.ContainsFunc(itemsToCreate, func(p netip.Prefix) bool { return p.Contains(ip) }) {
+				continue
+			}
+			itemsToCreate = append(itemsToCreate, netip.PrefixFrom(ip, api.WAFListMaxBitLen[ipNet]).Masked())
 		}
 	}
 
@@ -283,6 +521,24 @@ func (s setter) SetWAFList(ctx context.Context, ppfmt pp.PP,
 	return ResponseUpdated
 }
 
+// ListWAFList calls [api.Handle.ListWAFListItems] and returns the IP ranges in the list.
+func (s setter) ListWAFList(ctx context.Context, ppfmt pp.PP, list api.WAFList, listDescription string,
+) ([]netip.Prefix, bool) {
+	items, alreadyExisting, _, ok := s.Handle.ListWAFListItems(ctx, ppfmt, list, listDescription)
+	if !ok {
+		return nil, false
+	}
+	if !alreadyExisting {
+		ppfmt.Noticef(pp.EmojiCreation, "Created a new list %s", list.Describe())
+	}
+
+	prefixes := make([]netip.Prefix, 0, len(items))
+	for _, item := range items {
+		prefixes = append(prefixes, item.Prefix)
+	}
+	return prefixes, true
+}
+
 // FinalClearWAFList calls [api.Handle.DeleteWAFList] or [api.Handle.ClearWAFList].
 func (s setter) FinalClearWAFList(ctx context.Context, ppfmt pp.PP, list api.WAFList, listDescription string,
 ) ResponseCode {
@@ -4,6 +4,7 @@ package setter_test
 import (
 	"context"
 	"net/netip"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -24,6 +25,13 @@ func wrapCancelAsDelete(cancel func()) func(context.Context, pp.PP, ipnet.Type,
 	}
 }
 
+func wrapCancelAsUpdateSVCB(cancel func()) func(context.Context, pp.PP, domain.Domain, api.SVCBRecord) bool {
+	return func(context.Context, pp.PP, domain.Domain, api.SVCBRecord) bool {
+		cancel()
+		return false
+	}
+}
+
 func TestSet(t *testing.T) {
 	t.Parallel()
 
@@ -37,6 +45,7 @@ func TestSet(t *testing.T) {
 	var (
 		ip1    = netip.MustParseAddr("::1")
 		ip2    = netip.MustParseAddr("::2")
+		ip3    = netip.MustParseAddr("::3")
 		params = api.RecordParams{
 			TTL:     api.TTLAuto,
 			Proxied: false,
@@ -45,193 +54,932 @@ func TestSet(t *testing.T) {
 	)
 
 	for name, tc := range map[string]struct {
-		ip           netip.Addr
+		ips          []netip.Addr
 		resp         setter.ResponseCode
 		prepareMocks func(ctx context.Context, cancel func(), p *mocks.MockPP, m *mocks.MockHandle)
 	}{
 		"0": {
-			ip1,
+			[]netip.Addr{ip1},
 			setter.ResponseUpdated,
 			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
 				gomock.InOrder(
-					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).Return([]api.Record{}, true, true),
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).Return([]api.Record{}, true, true),
 					h.EXPECT().CreateRecord(ctx, p, ipNetwork, domain, ip1, params).Return(record1, true),
 					p.EXPECT().Noticef(pp.EmojiCreation, "Added a new %s record of %s (ID: %s)", "AAAA", "sub.test.org", record1),
 				)
 			},
 		},
 		"0/create-fail": {
-			ip1,
+			[]netip.Addr{ip1},
 			setter.ResponseFailed,
 			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
 				gomock.InOrder(
-					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).Return([]api.Record{}, true, true),
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).Return([]api.Record{}, true, true),
 					h.EXPECT().CreateRecord(ctx, p, ipNetwork, domain, ip1, params).Return(record1, false),
 					p.EXPECT().Noticef(pp.EmojiError, "Failed to properly update %s records of %s; records might be inconsistent", "AAAA", "sub.test.org"),
 				)
 			},
 		},
 		"1unmatched": {
-			ip1,
+			[]netip.Addr{ip1},
+			setter.ResponseUpdated,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).
+						Return([]api.Record{{ID: record1, IP: ip2, RecordParams: params}}, true, true),
+					h.EXPECT().UpdateRecord(ctx, p, ipNetwork, domain, record1, ip1, params, params).Return(true),
+					p.EXPECT().Noticef(pp.EmojiUpdate, "Updated a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record1),
+				)
+			},
+		},
+		"1unmatched/update-fail": {
+			[]netip.Addr{ip1},
+			setter.ResponseFailed,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).
+						Return([]api.Record{{ID: record1, IP: ip2, RecordParams: params}}, true, true),
+					h.EXPECT().UpdateRecord(ctx, p, ipNetwork, domain, record1, ip1, params, params).Return(false),
+					p.EXPECT().Noticef(pp.EmojiError, "Failed to properly update %s records of %s; records might be inconsistent", "AAAA", "sub.test.org"),
+				)
+			},
+		},
+		"1matched": {
+			[]netip.Addr{ip1},
+			setter.ResponseNoop,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).
+						Return([]api.Record{{ID: record1, IP: ip1, RecordParams: params}}, true, true),
+					p.EXPECT().Infof(pp.EmojiAlreadyDone,
+						"The %s records of %s are already up to date (cached)", "AAAA", "sub.test.org"),
+				)
+			},
+		},
+		"1matched/not-cached": {
+			[]netip.Addr{ip1},
+			setter.ResponseNoop,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).Return([]api.Record{
+						{ID: record1, IP: ip1, RecordParams: params},
+					}, false, true),
+					p.EXPECT().Infof(pp.EmojiAlreadyDone, "The %s records of %s are already up to date", "AAAA", "sub.test.org"),
+				)
+			},
+		},
+		"3matched": {
+			[]netip.Addr{ip1},
+			setter.ResponseUpdated,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).Return([]api.Record{
+						{ID: record1, IP: ip1, RecordParams: params},
+						{ID: record2, IP: ip1, RecordParams: params},
+						{ID: record3, IP: ip1, RecordParams: params},
+					}, true, true),
+					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record2, api.RegularDelitionMode).Return(true),
+					p.EXPECT().Noticef(pp.EmojiDeletion, "Deleted a duplicate %s record of %s (ID: %s)", "AAAA", "sub.test.org", record2),
+					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record3, api.RegularDelitionMode).Return(true),
+					p.EXPECT().Noticef(pp.EmojiDeletion, "Deleted a duplicate %s record of %s (ID: %s)", "AAAA", "sub.test.org", record3),
+				)
+			},
+		},
+		"3matched/delete-fail/1": {
+			[]netip.Addr{ip1},
+			setter.ResponseUpdated,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).Return([]api.Record{
+						{ID: record1, IP: ip1, RecordParams: params},
+						{ID: record2, IP: ip1, RecordParams: params},
+						{ID: record3, IP: ip1, RecordParams: params},
+					}, true, true),
+					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record2, api.RegularDelitionMode).Return(false),
+					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record3, api.RegularDelitionMode).Return(true),
+					p.EXPECT().Noticef(pp.EmojiDeletion, "Deleted a duplicate %s record of %s (ID: %s)", "AAAA", "sub.test.org", record3),
+				)
+			},
+		},
+		"3matched/delete-fail/2": {
+			[]netip.Addr{ip1},
+			setter.ResponseUpdated,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).Return([]api.Record{
+						{ID: record1, IP: ip1, RecordParams: params},
+						{ID: record2, IP: ip1, RecordParams: params},
+						{ID: record3, IP: ip1, RecordParams: params},
+					}, true, true),
+					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record2, api.RegularDelitionMode).Return(true),
+					p.EXPECT().Noticef(pp.EmojiDeletion, "Deleted a duplicate %s record of %s (ID: %s)", "AAAA", "sub.test.org", record2),
+					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record3, api.RegularDelitionMode).Return(false),
+				)
+			},
+		},
+		"3matched/delete-timeout": {
+			[]netip.Addr{ip1},
+			setter.ResponseUpdated,
+			func(ctx context.Context, cancel func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).Return([]api.Record{
+						{ID: record1, IP: ip1, RecordParams: params},
+						{ID: record2, IP: ip1, RecordParams: params},
+					}, true, true),
+					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record2, api.RegularDelitionMode).Do(wrapCancelAsDelete(cancel)).Return(false),
+				)
+			},
+		},
+		"2unmatched": {
+			[]netip.Addr{ip1},
+			setter.ResponseUpdated,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).Return([]api.Record{
+						{ID: record1, IP: ip2, RecordParams: params},
+						{ID: record2, IP: ip2, RecordParams: params},
+					}, true, true),
+					h.EXPECT().UpdateRecord(ctx, p, ipNetwork, domain, record1, ip1, params, params).Return(true),
+					p.EXPECT().Noticef(pp.EmojiUpdate, "Updated a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record1),
+					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record2, api.RegularDelitionMode).Return(true),
+					p.EXPECT().Noticef(pp.EmojiDeletion, "Deleted a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record2),
+				)
+			},
+		},
+		"2unmatched/delete-timeout": {
+			[]netip.Addr{ip1},
+			setter.ResponseFailed,
+			func(ctx context.Context, cancel func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).Return([]api.Record{
+						{ID: record1, IP: ip2, RecordParams: params},
+						{ID: record2, IP: ip2, RecordParams: params},
+					}, true, true),
+					h.EXPECT().UpdateRecord(ctx, p, ipNetwork, domain, record1, ip1, params, params).Return(true),
+					p.EXPECT().Noticef(pp.EmojiUpdate, "Updated a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record1),
+					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record2, api.RegularDelitionMode).Do(wrapCancelAsDelete(cancel)).Return(false),
+					p.EXPECT().Noticef(pp.EmojiError, "Failed to properly update %s records of %s; records might be inconsistent", "AAAA", "sub.test.org"),
+				)
+			},
+		},
+		"2unmatched/update-fail": {
+			[]netip.Addr{ip1},
+			setter.ResponseFailed,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).Return([]api.Record{
+						{ID: record1, IP: ip2, RecordParams: params},
+						{ID: record2, IP: ip2, RecordParams: params},
+					}, true, true),
+					h.EXPECT().UpdateRecord(ctx, p, ipNetwork, domain, record1, ip1, params, params).Return(false),
+					p.EXPECT().Noticef(pp.EmojiError, "Failed to properly update %s records of %s; records might be inconsistent", "AAAA", "sub.test.org"),
+				)
+			},
+		},
+		"multi/matched": {
+			[]netip.Addr{ip1, ip2},
+			setter.ResponseNoop,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).
+						Return([]api.Record{{ID: record1, IP: ip2, RecordParams: params}, {ID: record2, IP: ip1, RecordParams: params}}, true, true),
+					p.EXPECT().Infof(pp.EmojiAlreadyDone, "The %s records of %s are already up to date (cached)", "AAAA", "sub.test.org"),
+				)
+			},
+		},
+		"multi/partial": {
+			[]netip.Addr{ip1, ip2},
+			setter.ResponseUpdated,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).
+						Return([]api.Record{{ID: record1, IP: ip1, RecordParams: params}, {ID: record2, IP: ip1, RecordParams: params}}, true, true),
+					h.EXPECT().CreateRecord(ctx, p, ipNetwork, domain, ip2, params).Return(record3, true),
+					p.EXPECT().Noticef(pp.EmojiCreation, "Added a new %s record of %s (ID: %s)", "AAAA", "sub.test.org", record3),
+					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record2, api.RegularDelitionMode).Return(true),
+					p.EXPECT().Noticef(pp.EmojiDeletion, "Deleted a duplicate %s record of %s (ID: %s)", "AAAA", "sub.test.org", record2),
+				)
+			},
+		},
+		"multi/recycle": {
+			[]netip.Addr{ip1, ip2},
+			setter.ResponseUpdated,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).
+						Return([]api.Record{{ID: record1, IP: ip3, RecordParams: params}, {ID: record2, IP: ip3, RecordParams: params}}, true, true),
+					h.EXPECT().UpdateRecord(ctx, p, ipNetwork, domain, record1, ip1, params, params).Return(true),
+					p.EXPECT().Noticef(pp.EmojiUpdate, "Updated a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record1),
+					h.EXPECT().UpdateRecord(ctx, p, ipNetwork, domain, record2, ip2, params, params).Return(true),
+					p.EXPECT().Noticef(pp.EmojiUpdate, "Updated a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record2),
+				)
+			},
+		},
+		"multi/shrink": {
+			[]netip.Addr{ip2},
 			setter.ResponseUpdated,
 			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
 				gomock.InOrder(
-					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).
-						Return([]api.Record{{ID: record1, IP: ip2, RecordParams: params}}, true, true),
-					h.EXPECT().UpdateRecord(ctx, p, ipNetwork, domain, record1, ip1, params, params).Return(true),
-					p.EXPECT().Noticef(pp.EmojiUpdate, "Updated a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record1),
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).
+						Return([]api.Record{{ID: record1, IP: ip1, RecordParams: params}, {ID: record2, IP: ip2, RecordParams: params}}, true, true),
+					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record1, api.RegularDelitionMode).Return(true),
+					p.EXPECT().Noticef(pp.EmojiDeletion, "Deleted a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record1),
+				)
+			},
+		},
+		"list-fail": {
+			[]netip.Addr{ip1},
+			setter.ResponseFailed,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).Return(nil, false, false)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockHandle := mocks.NewMockHandle(mockCtrl)
+			if tc.prepareMocks != nil {
+				tc.prepareMocks(ctx, cancel, mockPP, mockHandle)
+			}
+
+			s, ok := setter.New(mockPP, mockHandle, api.OwnAllRecords)
+			require.True(t, ok)
+
+			resp := s.Set(ctx, mockPP, ipNetwork, domain, tc.ips, params)
+			require.Equal(t, tc.resp, resp)
+		})
+	}
+}
+
+func TestSetWithOwnership(t *testing.T) {
+	t.Parallel()
+
+	const (
+		domain    = domain.FQDN("sub.test.org")
+		ipNetwork = ipnet.IP6
+		record1   = api.ID("record1")
+		record2   = api.ID("record2")
+		record3   = api.ID("record3")
+	)
+	var (
+		ip1    = netip.MustParseAddr("::1")
+		ip2    = netip.MustParseAddr("::2")
+		ip3    = netip.MustParseAddr("::3")
+		params = api.RecordParams{
+			TTL:     api.TTLAuto,
+			Proxied: false,
+			Comment: "hello",
+		}
+		foreignParams = api.RecordParams{
+			TTL:     api.TTLAuto,
+			Proxied: false,
+			Comment: "static",
+		}
+	)
+
+	for name, tc := range map[string]struct {
+		ips          []netip.Addr
+		resp         setter.ResponseCode
+		prepareMocks func(ctx context.Context, p *mocks.MockPP, m *mocks.MockHandle)
+	}{
+		"foreign-kept": {
+			[]netip.Addr{ip1},
+			setter.ResponseNoop,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnRecordsByComment).Return([]api.Record{
+						{ID: record1, IP: ip1, RecordParams: params},
+						{ID: record2, IP: ip2, RecordParams: foreignParams},
+					}, true, true),
+					p.EXPECT().Infof(pp.EmojiAlreadyDone, "The %s records of %s are already up to date (cached)", "AAAA", "sub.test.org"),
+				)
+			},
+		},
+		"foreign-not-updated": {
+			[]netip.Addr{ip1},
+			setter.ResponseUpdated,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnRecordsByComment).Return([]api.Record{
+						{ID: record2, IP: ip2, RecordParams: foreignParams},
+						{ID: record3, IP: ip3, RecordParams: params},
+					}, true, true),
+					h.EXPECT().UpdateRecord(ctx, p, ipNetwork, domain, record3, ip1, params, params).Return(true),
+					p.EXPECT().Noticef(pp.EmojiUpdate, "Updated a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record3),
+				)
+			},
+		},
+		"foreign-not-deleted": {
+			[]netip.Addr{ip1},
+			setter.ResponseUpdated,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnRecordsByComment).Return([]api.Record{
+						{ID: record2, IP: ip2, RecordParams: foreignParams},
+					}, true, true),
+					h.EXPECT().CreateRecord(ctx, p, ipNetwork, domain, ip1, params).Return(record1, true),
+					p.EXPECT().Noticef(pp.EmojiCreation, "Added a new %s record of %s (ID: %s)", "AAAA", "sub.test.org", record1),
+				)
+			},
+		},
+		"foreign-with-target": {
+			[]netip.Addr{ip1, ip2},
+			setter.ResponseUpdated,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnRecordsByComment).Return([]api.Record{
+						{ID: record2, IP: ip2, RecordParams: foreignParams},
+						{ID: record3, IP: ip3, RecordParams: params},
+					}, true, true),
+					h.EXPECT().UpdateRecord(ctx, p, ipNetwork, domain, record3, ip1, params, params).Return(true),
+					p.EXPECT().Noticef(pp.EmojiUpdate, "Updated a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record3),
+				)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			ctx := context.Background()
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockHandle := mocks.NewMockHandle(mockCtrl)
+			if tc.prepareMocks != nil {
+				tc.prepareMocks(ctx, mockPP, mockHandle)
+			}
+
+			s, ok := setter.New(mockPP, mockHandle, api.OwnRecordsByComment)
+			require.True(t, ok)
+
+			resp := s.Set(ctx, mockPP, ipNetwork, domain, tc.ips, params)
+			require.Equal(t, tc.resp, resp)
+		})
+	}
+}
+
+func TestFinalDelete(t *testing.T) {
+	t.Parallel()
+
+	const (
+		domain    = domain.FQDN("sub.test.org")
+		ipNetwork = ipnet.IP6
+		record1   = api.ID("record1")
+		record2   = api.ID("record2")
+		record3   = api.ID("record3")
+	)
+	var (
+		ip1       = netip.MustParseAddr("::1")
+		invalidIP = netip.Addr{}
+		params    = api.RecordParams{
+			TTL:     api.TTLAuto,
+			Proxied: false,
+			Comment: "hello",
+		}
+	)
+
+	for name, tc := range map[string]struct {
+		resp         setter.ResponseCode
+		prepareMocks func(ctx context.Context, cancel func(), p *mocks.MockPP, m *mocks.MockHandle)
+	}{
+		"0": {
+			setter.ResponseNoop,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).Return([]api.Record{}, true, true),
+					p.EXPECT().Infof(pp.EmojiAlreadyDone, "The %s records of %s were already deleted (cached)", "AAAA", "sub.test.org"),
+				)
+			},
+		},
+		"0/not-cached": {
+			setter.ResponseNoop,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).Return([]api.Record{}, false, true),
+					p.EXPECT().Infof(pp.EmojiAlreadyDone, "The %s records of %s were already deleted", "AAAA", "sub.test.org"),
+				)
+			},
+		},
+		"1unmatched": {
+			setter.ResponseUpdated,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).Return([]api.Record{
+						{ID: record1, IP: ip1, RecordParams: params},
+					}, true, true),
+					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record1, api.FinalDeletionMode).Return(true),
+					p.EXPECT().Noticef(pp.EmojiDeletion, "Deleted a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record1),
+				)
+			},
+		},
+		"1unmatched/delete-fail": {
+			setter.ResponseFailed,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).Return([]api.Record{
+						{ID: record1, IP: ip1, RecordParams: params},
+					}, true, true),
+					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record1, api.FinalDeletionMode).Return(false),
+					p.EXPECT().Noticef(pp.EmojiError, "Failed to properly delete %s records of %s; records might be inconsistent", "AAAA", "sub.test.org"),
+				)
+			},
+		},
+		"1unmatched/delete-timeout": {
+			setter.ResponseFailed,
+			func(ctx context.Context, cancel func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).Return([]api.Record{
+						{ID: record1, IP: ip1, RecordParams: params},
+					}, true, true),
+					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record1, api.FinalDeletionMode).Do(wrapCancelAsDelete(cancel)).Return(false),
+					p.EXPECT().Infof(pp.EmojiTimeout, "Deletion of %s records of %s aborted by timeout or signals; records might be inconsistent", "AAAA", "sub.test.org"),
+				)
+			},
+		},
+		"impossible-records": {
+			setter.ResponseUpdated,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).Return([]api.Record{
+						{ID: record1, IP: ip1, RecordParams: params},
+						{ID: record2, IP: invalidIP, RecordParams: params},
+					}, true, true),
+					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record1, api.FinalDeletionMode).Return(true),
+					p.EXPECT().Noticef(pp.EmojiDeletion, "Deleted a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record1),
+					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record2, api.FinalDeletionMode).Return(true),
+					p.EXPECT().Noticef(pp.EmojiDeletion, "Deleted a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record2),
+				)
+			},
+		},
+		"listfail": {
+			setter.ResponseFailed,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnAllRecords).Return(nil, false, false)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockHandle := mocks.NewMockHandle(mockCtrl)
+			if tc.prepareMocks != nil {
+				tc.prepareMocks(ctx, cancel, mockPP, mockHandle)
+			}
+
+			s, ok := setter.New(mockPP, mockHandle, api.OwnAllRecords)
+			require.True(t, ok)
+
+			resp := s.FinalDelete(ctx, mockPP, ipNetwork, domain, params)
+			require.Equal(t, tc.resp, resp)
+		})
+	}
+}
+
+func TestFinalDeleteWithOwnership(t *testing.T) {
+	t.Parallel()
+
+	const (
+		domain    = domain.FQDN("sub.test.org")
+		ipNetwork = ipnet.IP6
+		record1   = api.ID("record1")
+		record2   = api.ID("record2")
+	)
+	var (
+		ip1    = netip.MustParseAddr("::1")
+		ip2    = netip.MustParseAddr("::2")
+		params = api.RecordParams{
+			TTL:     api.TTLAuto,
+			Proxied: false,
+			Comment: "hello",
+		}
+		foreignParams = api.RecordParams{
+			TTL:     api.TTLAuto,
+			Proxied: false,
+			Comment: "static",
+		}
+	)
+
+	for name, tc := range map[string]struct {
+		resp         setter.ResponseCode
+		prepareMocks func(ctx context.Context, p *mocks.MockPP, m *mocks.MockHandle)
+	}{
+		"owned-deleted": {
+			setter.ResponseUpdated,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnRecordsByComment).Return([]api.Record{
+						{ID: record1, IP: ip1, RecordParams: params},
+						{ID: record2, IP: ip2, RecordParams: foreignParams},
+					}, true, true),
+					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record1, api.FinalDeletionMode).Return(true),
+					p.EXPECT().Noticef(pp.EmojiDeletion, "Deleted a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record1),
+				)
+			},
+		},
+		"foreign-only": {
+			setter.ResponseNoop,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnRecordsByComment).Return([]api.Record{
+						{ID: record2, IP: ip2, RecordParams: foreignParams},
+					}, false, true),
+					p.EXPECT().Infof(pp.EmojiAlreadyDone, "The %s records of %s were already deleted", "AAAA", "sub.test.org"),
+				)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			ctx := context.Background()
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockHandle := mocks.NewMockHandle(mockCtrl)
+			if tc.prepareMocks != nil {
+				tc.prepareMocks(ctx, mockPP, mockHandle)
+			}
+
+			s, ok := setter.New(mockPP, mockHandle, api.OwnRecordsByComment)
+			require.True(t, ok)
+
+			resp := s.FinalDelete(ctx, mockPP, ipNetwork, domain, params)
+			require.Equal(t, tc.resp, resp)
+		})
+	}
+}
+
+func TestListIPs(t *testing.T) {
+	t.Parallel()
+
+	const (
+		domain    = domain.FQDN("sub.test.org")
+		ipNetwork = ipnet.IP6
+	)
+	var (
+		ip1    = netip.MustParseAddr("::1")
+		ip2    = netip.MustParseAddr("::2")
+		params = api.RecordParams{
+			TTL:     api.TTLAuto,
+			Proxied: false,
+			Comment: "hello",
+		}
+		foreignParams = api.RecordParams{
+			TTL:     api.TTLAuto,
+			Proxied: false,
+			Comment: "static",
+		}
+	)
+
+	for name, tc := range map[string]struct {
+		ips          []netip.Addr
+		ok           bool
+		prepareMocks func(ctx context.Context, p *mocks.MockPP, m *mocks.MockHandle)
+	}{
+		"owned": {
+			[]netip.Addr{ip1, ip2},
+			true,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
+				h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnRecordsByComment).Return([]api.Record{
+					{ID: "record1", IP: ip2, RecordParams: params},
+					{ID: "record2", IP: ip1, RecordParams: params},
+					{ID: "record3", IP: ip2, RecordParams: params},
+				}, true, true)
+			},
+		},
+		"foreign": {
+			[]netip.Addr{ip1},
+			true,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
+				h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnRecordsByComment).Return([]api.Record{
+					{ID: "record1", IP: ip1, RecordParams: params},
+					{ID: "record2", IP: ip2, RecordParams: foreignParams},
+				}, false, true)
+			},
+		},
+		"list-fail": {
+			nil,
+			false,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
+				h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params, api.OwnRecordsByComment).Return(nil, false, false)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			ctx := context.Background()
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockHandle := mocks.NewMockHandle(mockCtrl)
+			if tc.prepareMocks != nil {
+				tc.prepareMocks(ctx, mockPP, mockHandle)
+			}
+
+			s, ok := setter.New(mockPP, mockHandle, api.OwnRecordsByComment)
+			require.True(t, ok)
+
+			ips, ok := s.ListIPs(ctx, mockPP, ipNetwork, domain, params)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.ips, ips)
+		})
+	}
+}
+
+func TestSetSVCBHints(t *testing.T) {
+	t.Parallel()
+
+	const (
+		domain    = domain.FQDN("sub.test.org")
+		ipNetwork = ipnet.IP4
+		record1   = api.ID("record1")
+		record2   = api.ID("record2")
+	)
+	var (
+		ip1 = netip.MustParseAddr("1.1.1.1")
+		ip2 = netip.MustParseAddr("2.2.2.2")
+	)
+
+	for name, tc := range map[string]struct {
+		ips          []netip.Addr
+		resp         setter.ResponseCode
+		prepareMocks func(ctx context.Context, cancel func(), p *mocks.MockPP, m *mocks.MockHandle)
+	}{
+		"uptodate": {
+			[]netip.Addr{ip1, ip2},
+			setter.ResponseNoop,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListSVCBRecords(ctx, p, domain).Return([]api.SVCBRecord{
+						{ID: record1, Type: "HTTPS", Priority: 1, Target: ".", Params: `alpn="h2" ipv4hint="2.2.2.2,1.1.1.1"`},
+					}, true, true),
+					p.EXPECT().Infof(pp.EmojiAlreadyDone, "The %s of HTTPS/SVCB records of %s is already up to date (cached)", "ipv4hint", "sub.test.org"),
+				)
+			},
+		},
+		"unmanaged": {
+			[]netip.Addr{ip1},
+			setter.ResponseNoop,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListSVCBRecords(ctx, p, domain).Return([]api.SVCBRecord{
+						{ID: record1, Type: "HTTPS", Priority: 0, Target: "other.test.org", Params: ""},
+						{ID: record2, Type: "HTTPS", Priority: 1, Target: "other.test.org", Params: `ipv4hint="2.2.2.2"`},
+						{ID: "record3", Type: "SVCB", Priority: 1, Target: ".", Params: `alpn="h2" ipv6hint="::1"`},
+					}, false, true),
+					p.EXPECT().Infof(pp.EmojiAlreadyDone, "The %s of HTTPS/SVCB records of %s is already up to date", "ipv4hint", "sub.test.org"),
+				)
+			},
+		},
+		"update": {
+			[]netip.Addr{ip1},
+			setter.ResponseUpdated,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListSVCBRecords(ctx, p, domain).Return([]api.SVCBRecord{
+						{ID: record1, Type: "HTTPS", Priority: 1, Target: ".", Params: `alpn="h2" ipv4hint="2.2.2.2" ech="abc"`, Tags: []string{"owner:web"}},
+						{ID: record2, Type: "SVCB", Priority: 2, Target: "sub.test.org.", Params: `ipv4hint="1.1.1.1"`},
+					}, true, true),
+					h.EXPECT().UpdateSVCBRecord(ctx, p, domain, api.SVCBRecord{ID: record1, Type: "HTTPS", Priority: 1, Target: ".", Params: `alpn="h2" ipv4hint="1.1.1.1" ech="abc"`, Tags: []string{"owner:web"}}).Return(true),
+					p.EXPECT().Noticef(pp.EmojiUpdate, "Updated the %s of an %s record of %s (ID: %s)", "ipv4hint", "HTTPS", "sub.test.org", record1),
+				)
+			},
+		},
+		"update-fail": {
+			[]netip.Addr{ip1},
+			setter.ResponseFailed,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListSVCBRecords(ctx, p, domain).Return([]api.SVCBRecord{
+						{ID: record1, Type: "HTTPS", Priority: 1, Target: ".", Params: `ipv4hint="2.2.2.2"`},
+						{ID: record2, Type: "HTTPS", Priority: 2, Target: ".", Params: `ipv4hint="2.2.2.2"`},
+					}, true, true),
+					h.EXPECT().UpdateSVCBRecord(ctx, p, domain, api.SVCBRecord{ID: record1, Type: "HTTPS", Priority: 1, Target: ".", Params: `ipv4hint="1.1.1.1"`}).Return(false),
+					h.EXPECT().UpdateSVCBRecord(ctx, p, domain, api.SVCBRecord{ID: record2, Type: "HTTPS", Priority: 2, Target: ".", Params: `ipv4hint="1.1.1.1"`}).Return(true),
+					p.EXPECT().Noticef(pp.EmojiUpdate, "Updated the %s of an %s record of %s (ID: %s)", "ipv4hint", "HTTPS", "sub.test.org", record2),
+					p.EXPECT().Noticef(pp.EmojiError, "Failed to properly update the %s of HTTPS/SVCB records of %s; records might be inconsistent", "ipv4hint", "sub.test.org"),
+				)
+			},
+		},
+		"update-timeout": {
+			[]netip.Addr{ip1},
+			setter.ResponseFailed,
+			func(ctx context.Context, cancel func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListSVCBRecords(ctx, p, domain).Return([]api.SVCBRecord{
+						{ID: record1, Type: "HTTPS", Priority: 1, Target: ".", Params: `ipv4hint="2.2.2.2"`},
+						{ID: record2, Type: "HTTPS", Priority: 2, Target: ".", Params: `ipv4hint="2.2.2.2"`},
+					}, true, true),
+					h.EXPECT().UpdateSVCBRecord(ctx, p, domain, gomock.Any()).Do(wrapCancelAsUpdateSVCB(cancel)).Return(false),
+					p.EXPECT().Infof(pp.EmojiTimeout, "Setting %s of HTTPS/SVCB records of %s aborted by timeout or signals; records might be inconsistent", "ipv4hint", "sub.test.org"),
+				)
+			},
+		},
+		"listfail": {
+			[]netip.Addr{ip1},
+			setter.ResponseFailed,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+				h.EXPECT().ListSVCBRecords(ctx, p, domain).Return(nil, false, false)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockHandle := mocks.NewMockHandle(mockCtrl)
+			if tc.prepareMocks != nil {
+				tc.prepareMocks(ctx, cancel, mockPP, mockHandle)
+			}
+
+			s, ok := setter.New(mockPP, mockHandle, api.OwnAllRecords)
+			require.True(t, ok)
+
+			resp := s.SetSVCBHints(ctx, mockPP, ipNetwork, domain, tc.ips)
+			require.Equal(t, tc.resp, resp)
+		})
+	}
+}
+
+func TestSetSPF(t *testing.T) {
+	t.Parallel()
+
+	const (
+		domain    = domain.FQDN("mail.test.org")
+		ipNetwork = ipnet.IP4
+		record1   = api.ID("record1")
+		record2   = api.ID("record2")
+	)
+	var (
+		ip1 = netip.MustParseAddr("1.1.1.1")
+		ip2 = netip.MustParseAddr("2.2.2.2")
+	)
+
+	for name, tc := range map[string]struct {
+		ips          []netip.Addr
+		resp         setter.ResponseCode
+		prepareMocks func(ctx context.Context, p *mocks.MockPP, m *mocks.MockHandle)
+	}{
+		"uptodate": {
+			[]netip.Addr{ip1, ip2},
+			setter.ResponseNoop,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{
+						{ID: record1, Content: "google-site-verification=abc"},
+						{ID: record2, Content: "v=spf1  ip4:2.2.2.2 mx +ip4:1.1.1.1 ip6:::1 -all"},
+					}, true, true),
+					p.EXPECT().Infof(pp.EmojiAlreadyDone, "The %s mechanisms of the SPF record of %s are already up to date (cached)", "ip4", "mail.test.org"),
 				)
 			},
 		},
-		"1unmatched/update-fail": {
-			ip1,
-			setter.ResponseFailed,
-			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+		"uptodate/not-cached": {
+			[]netip.Addr{ip1},
+			setter.ResponseNoop,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
 				gomock.InOrder(
-					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).
-						Return([]api.Record{{ID: record1, IP: ip2, RecordParams: params}}, true, true),
-					h.EXPECT().UpdateRecord(ctx, p, ipNetwork, domain, record1, ip1, params, params).Return(false),
-					p.EXPECT().Noticef(pp.EmojiError, "Failed to properly update %s records of %s; records might be inconsistent", "AAAA", "sub.test.org"),
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{
+						{ID: record1, Content: `"v=spf1 ip4:1.1.1.1 -all"`},
+					}, false, true),
+					p.EXPECT().Infof(pp.EmojiAlreadyDone, "The %s mechanisms of the SPF record of %s are already up to date", "ip4", "mail.test.org"),
 				)
 			},
 		},
-		"1matched": {
-			ip1,
-			setter.ResponseNoop,
-			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+		"update": {
+			[]netip.Addr{ip1, ip2},
+			setter.ResponseUpdated,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
 				gomock.InOrder(
-					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).
-						Return([]api.Record{{ID: record1, IP: ip1, RecordParams: params}}, true, true),
-					p.EXPECT().Infof(pp.EmojiAlreadyDone,
-						"The %s records of %s are already up to date (cached)", "AAAA", "sub.test.org"),
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{
+						{ID: record1, Content: "v=spf1 mx ip4:3.3.3.3 -ip4:4.4.4.4 ip6:::1 IP4:5.5.5.0/24 include:_spf.test.org ~all", Tags: []string{"owner:mail"}},
+					}, true, true),
+					h.EXPECT().UpdateTXTRecord(ctx, p, domain, api.TXTRecord{ID: record1, Content: "v=spf1 mx ip4:1.1.1.1 ip4:2.2.2.2 -ip4:4.4.4.4 ip6:::1 IP4:5.5.5.0/24 include:_spf.test.org ~all", Tags: []string{"owner:mail"}}).Return(true),
+					p.EXPECT().Noticef(pp.EmojiUpdate, "Updated the %s mechanisms of the SPF record of %s (ID: %s)", "ip4", "mail.test.org", record1),
 				)
 			},
 		},
-		"1matched/not-cached": {
-			ip1,
+		"uptodate/multi-string": {
+			[]netip.Addr{ip1, ip2},
 			setter.ResponseNoop,
-			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
 				gomock.InOrder(
-					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).Return([]api.Record{
-						{ID: record1, IP: ip1, RecordParams: params},
-					}, false, true),
-					p.EXPECT().Infof(pp.EmojiAlreadyDone, "The %s records of %s are already up to date", "AAAA", "sub.test.org"),
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{
+						{ID: record1, Content: `"v=spf1 ip4:1.1.1.1 ip4:2.2" ".2.2 ip4:3.3.3.0/24 -all"`},
+					}, true, true),
+					p.EXPECT().Infof(pp.EmojiAlreadyDone, "The %s mechanisms of the SPF record of %s are already up to date (cached)", "ip4", "mail.test.org"),
 				)
 			},
 		},
-		"3matched": {
-			ip1,
+		"update/multi-string": {
+			[]netip.Addr{ip1},
 			setter.ResponseUpdated,
-			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
 				gomock.InOrder(
-					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).Return([]api.Record{
-						{ID: record1, IP: ip1, RecordParams: params},
-						{ID: record2, IP: ip1, RecordParams: params},
-						{ID: record3, IP: ip1, RecordParams: params},
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{
+						{ID: record1, Content: `"v=spf1 ip4:2.2.2.2 exp=" "%{i}.\\\"x\\\".test.org -all"`},
 					}, true, true),
-					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record2, api.RegularDelitionMode).Return(true),
-					p.EXPECT().Noticef(pp.EmojiDeletion, "Deleted a duplicate %s record of %s (ID: %s)", "AAAA", "sub.test.org", record2),
-					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record3, api.RegularDelitionMode).Return(true),
-					p.EXPECT().Noticef(pp.EmojiDeletion, "Deleted a duplicate %s record of %s (ID: %s)", "AAAA", "sub.test.org", record3),
+					h.EXPECT().UpdateTXTRecord(ctx, p, domain, api.TXTRecord{ID: record1, Content: `"v=spf1 ip4:1.1.1.1 exp=%{i}.\\\"x\\\".test.org -all"`}).Return(true),
+					p.EXPECT().Noticef(pp.EmojiUpdate, "Updated the %s mechanisms of the SPF record of %s (ID: %s)", "ip4", "mail.test.org", record1),
 				)
 			},
 		},
-		"3matched/delete-fail/1": {
-			ip1,
+		"update/long": {
+			[]netip.Addr{ip1},
 			setter.ResponseUpdated,
-			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
 				gomock.InOrder(
-					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).Return([]api.Record{
-						{ID: record1, IP: ip1, RecordParams: params},
-						{ID: record2, IP: ip1, RecordParams: params},
-						{ID: record3, IP: ip1, RecordParams: params},
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{
+						{ID: record1, Content: `"v=spf1 ` + strings.Repeat("a ", 120) + `" "-all"`},
 					}, true, true),
-					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record2, api.RegularDelitionMode).Return(false),
-					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record3, api.RegularDelitionMode).Return(true),
-					p.EXPECT().Noticef(pp.EmojiDeletion, "Deleted a duplicate %s record of %s (ID: %s)", "AAAA", "sub.test.org", record3),
+					h.EXPECT().UpdateTXTRecord(ctx, p, domain, api.TXTRecord{ID: record1, Content: `"v=spf1 ip4:1.1.1.1 ` + strings.Repeat("a ", 118) + `" "a a -all"`}).Return(true),
+					p.EXPECT().Noticef(pp.EmojiUpdate, "Updated the %s mechanisms of the SPF record of %s (ID: %s)", "ip4", "mail.test.org", record1),
 				)
 			},
 		},
-		"3matched/delete-fail/2": {
-			ip1,
+		"update/insert": {
+			[]netip.Addr{ip1},
 			setter.ResponseUpdated,
-			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
 				gomock.InOrder(
-					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).Return([]api.Record{
-						{ID: record1, IP: ip1, RecordParams: params},
-						{ID: record2, IP: ip1, RecordParams: params},
-						{ID: record3, IP: ip1, RecordParams: params},
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{
+						{ID: record1, Content: `"v=spf1 mx -all"`},
 					}, true, true),
-					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record2, api.RegularDelitionMode).Return(true),
-					p.EXPECT().Noticef(pp.EmojiDeletion, "Deleted a duplicate %s record of %s (ID: %s)", "AAAA", "sub.test.org", record2),
-					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record3, api.RegularDelitionMode).Return(false),
+					h.EXPECT().UpdateTXTRecord(ctx, p, domain, api.TXTRecord{ID: record1, Content: `"v=spf1 ip4:1.1.1.1 mx -all"`}).Return(true),
+					p.EXPECT().Noticef(pp.EmojiUpdate, "Updated the %s mechanisms of the SPF record of %s (ID: %s)", "ip4", "mail.test.org", record1),
 				)
 			},
 		},
-		"3matched/delete-timeout": {
-			ip1,
-			setter.ResponseUpdated,
-			func(ctx context.Context, cancel func(), p *mocks.MockPP, h *mocks.MockHandle) {
+		"update-fail": {
+			[]netip.Addr{ip1},
+			setter.ResponseFailed,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
 				gomock.InOrder(
-					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).Return([]api.Record{
-						{ID: record1, IP: ip1, RecordParams: params},
-						{ID: record2, IP: ip1, RecordParams: params},
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{
+						{ID: record1, Content: "v=spf1 ip4:2.2.2.2 -all"},
 					}, true, true),
-					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record2, api.RegularDelitionMode).Do(wrapCancelAsDelete(cancel)).Return(false),
+					h.EXPECT().UpdateTXTRecord(ctx, p, domain, api.TXTRecord{ID: record1, Content: "v=spf1 ip4:1.1.1.1 -all"}).Return(false),
+					p.EXPECT().Noticef(pp.EmojiError, "Failed to properly update the SPF record of %s", "mail.test.org"),
 				)
 			},
 		},
-		"2unmatched": {
-			ip1,
-			setter.ResponseUpdated,
-			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+		"none": {
+			[]netip.Addr{ip1},
+			setter.ResponseFailed,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
 				gomock.InOrder(
-					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).Return([]api.Record{
-						{ID: record1, IP: ip2, RecordParams: params},
-						{ID: record2, IP: ip2, RecordParams: params},
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{
+						{ID: record1, Content: "v=spf10"},
 					}, true, true),
-					h.EXPECT().UpdateRecord(ctx, p, ipNetwork, domain, record1, ip1, params, params).Return(true),
-					p.EXPECT().Noticef(pp.EmojiUpdate, "Updated a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record1),
-					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record2, api.RegularDelitionMode).Return(true),
-					p.EXPECT().Noticef(pp.EmojiDeletion, "Deleted a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record2),
+					p.EXPECT().Noticef(pp.EmojiUserError, "Failed to find the SPF record of %s; please add a TXT record starting with %q", "mail.test.org", "v=spf1"),
 				)
 			},
 		},
-		"2unmatched/delete-timeout": {
-			ip1,
+		"none/malformed": {
+			[]netip.Addr{ip1},
 			setter.ResponseFailed,
-			func(ctx context.Context, cancel func(), p *mocks.MockPP, h *mocks.MockHandle) {
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
 				gomock.InOrder(
-					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).Return([]api.Record{
-						{ID: record1, IP: ip2, RecordParams: params},
-						{ID: record2, IP: ip2, RecordParams: params},
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{
+						{ID: record1, Content: `"v=spf1 -all" mx`},
 					}, true, true),
-					h.EXPECT().UpdateRecord(ctx, p, ipNetwork, domain, record1, ip1, params, params).Return(true),
-					p.EXPECT().Noticef(pp.EmojiUpdate, "Updated a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record1),
-					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record2, api.RegularDelitionMode).Do(wrapCancelAsDelete(cancel)).Return(false),
-					p.EXPECT().Noticef(pp.EmojiError, "Failed to properly update %s records of %s; records might be inconsistent", "AAAA", "sub.test.org"),
+					p.EXPECT().Noticef(pp.EmojiUserError, "Failed to find the SPF record of %s; please add a TXT record starting with %q", "mail.test.org", "v=spf1"),
 				)
 			},
 		},
-		"2unmatched/update-fail": {
-			ip1,
+		"multiple": {
+			[]netip.Addr{ip1},
 			setter.ResponseFailed,
-			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
 				gomock.InOrder(
-					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).Return([]api.Record{
-						{ID: record1, IP: ip2, RecordParams: params},
-						{ID: record2, IP: ip2, RecordParams: params},
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{
+						{ID: record1, Content: "v=spf1 -all"},
+						{ID: record2, Content: "V=SPF1 mx -all"},
 					}, true, true),
-					h.EXPECT().UpdateRecord(ctx, p, ipNetwork, domain, record1, ip1, params, params).Return(false),
-					p.EXPECT().Noticef(pp.EmojiError, "Failed to properly update %s records of %s; records might be inconsistent", "AAAA", "sub.test.org"),
+					p.EXPECT().Noticef(pp.EmojiUserError, "Found %d SPF records of %s; please keep only one of them", 2, "mail.test.org"),
 				)
 			},
 		},
-		"list-fail": {
-			ip1,
+		"listfail": {
+			[]netip.Addr{ip1},
 			setter.ResponseFailed,
-			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
-				h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).Return(nil, false, false)
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
+				h.EXPECT().ListTXTRecords(ctx, p, domain).Return(nil, false, false)
 			},
 		},
 	} {
@@ -239,121 +987,144 @@ func TestSet(t *testing.T) {
 			t.Parallel()
 			mockCtrl := gomock.NewController(t)
 
-			ctx, cancel := context.WithCancel(context.Background())
-			defer cancel()
+			ctx := context.Background()
 
 			mockPP := mocks.NewMockPP(mockCtrl)
 			mockHandle := mocks.NewMockHandle(mockCtrl)
 			if tc.prepareMocks != nil {
-				tc.prepareMocks(ctx, cancel, mockPP, mockHandle)
+				tc.prepareMocks(ctx, mockPP, mockHandle)
 			}
 
-			s, ok := setter.New(mockPP, mockHandle)
+			s, ok := setter.New(mockPP, mockHandle, api.OwnAllRecords)
 			require.True(t, ok)
 
-			resp := s.Set(ctx, mockPP, ipNetwork, domain, tc.ip, params)
+			resp := s.SetSPF(ctx, mockPP, ipNetwork, domain, tc.ips)
 			require.Equal(t, tc.resp, resp)
 		})
 	}
 }
 
-func TestFinalDelete(t *testing.T) {
+func TestSetHeartbeat(t *testing.T) {
 	t.Parallel()
 
 	const (
-		domain    = domain.FQDN("sub.test.org")
-		ipNetwork = ipnet.IP6
-		record1   = api.ID("record1")
-		record2   = api.ID("record2")
-		record3   = api.ID("record3")
-	)
-	var (
-		ip1       = netip.MustParseAddr("::1")
-		invalidIP = netip.Addr{}
-		params    = api.RecordParams{
-			TTL:     api.TTLAuto,
-			Proxied: false,
-			Comment: "hello",
-		}
+		domain  = domain.FQDN("_ddns.test.org")
+		record1 = api.ID("record1")
+		record2 = api.ID("record2")
+		content = "ips=1.1.1.1 time=2006-01-02T15:04:05Z version=1.0.0"
+		old     = "ips=2.2.2.2 time=2006-01-01T15:04:05Z version=1.0.0"
 	)
+	params := api.RecordParams{TTL: 300, Proxied: false, Comment: "hello", Tags: []string{"name:value"}}
 
 	for name, tc := range map[string]struct {
 		resp         setter.ResponseCode
-		prepareMocks func(ctx context.Context, cancel func(), p *mocks.MockPP, m *mocks.MockHandle)
+		prepareMocks func(ctx context.Context, p *mocks.MockPP, m *mocks.MockHandle)
 	}{
-		"0": {
-			setter.ResponseNoop,
-			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+		"create": {
+			setter.ResponseUpdated,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
 				gomock.InOrder(
-					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).Return([]api.Record{}, true, true),
-					p.EXPECT().Infof(pp.EmojiAlreadyDone, "The %s records of %s were already deleted (cached)", "AAAA", "sub.test.org"),
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{}, true, true),
+					h.EXPECT().CreateTXTRecord(ctx, p, domain, content, params).Return(record1, true),
+					p.EXPECT().Noticef(pp.EmojiCreation, "Added a new heartbeat TXT record of %s (ID: %s)", "_ddns.test.org", record1),
 				)
 			},
 		},
-		"0/not-cached": {
-			setter.ResponseNoop,
-			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+		"create-fail": {
+			setter.ResponseFailed,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
 				gomock.InOrder(
-					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).Return([]api.Record{}, false, true),
-					p.EXPECT().Infof(pp.EmojiAlreadyDone, "The %s records of %s were already deleted", "AAAA", "sub.test.org"),
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{}, true, true),
+					h.EXPECT().CreateTXTRecord(ctx, p, domain, content, params).Return(api.ID(""), false),
+					p.EXPECT().Noticef(pp.EmojiError, "Failed to properly write the heartbeat TXT record of %s", "_ddns.test.org"),
 				)
 			},
 		},
-		"1unmatched": {
+		"create/unrelated": {
 			setter.ResponseUpdated,
-			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
 				gomock.InOrder(
-					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).Return([]api.Record{
-						{ID: record1, IP: ip1, RecordParams: params},
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{
+						{ID: record1, Content: "google-site-verification=abc"},
+						{ID: record2, Content: "ips=1.1.1.1 time=2006-01-02T15:04:05Z"},
 					}, true, true),
-					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record1, api.FinalDeletionMode).Return(true),
-					p.EXPECT().Noticef(pp.EmojiDeletion, "Deleted a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record1),
+					h.EXPECT().CreateTXTRecord(ctx, p, domain, content, params).Return(record1, true),
+					p.EXPECT().Noticef(pp.EmojiCreation, "Added a new heartbeat TXT record of %s (ID: %s)", "_ddns.test.org", record1),
 				)
 			},
 		},
-		"1unmatched/delete-fail": {
-			setter.ResponseFailed,
-			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+		"update": {
+			setter.ResponseUpdated,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
 				gomock.InOrder(
-					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).Return([]api.Record{
-						{ID: record1, IP: ip1, RecordParams: params},
-					}, true, true),
-					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record1, api.FinalDeletionMode).Return(false),
-					p.EXPECT().Noticef(pp.EmojiError, "Failed to properly delete %s records of %s; records might be inconsistent", "AAAA", "sub.test.org"),
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{{ID: record1, Content: old}}, true, true),
+					h.EXPECT().UpdateTXTRecord(ctx, p, domain, api.TXTRecord{ID: record1, Content: content}).Return(true),
+					p.EXPECT().Infof(pp.EmojiUpdate, "Updated the heartbeat TXT record of %s (ID: %s)", "_ddns.test.org", record1),
 				)
 			},
 		},
-		"1unmatched/delete-timeout": {
-			setter.ResponseFailed,
-			func(ctx context.Context, cancel func(), p *mocks.MockPP, h *mocks.MockHandle) {
+		"update/multiple": {
+			setter.ResponseUpdated,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
 				gomock.InOrder(
-					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).Return([]api.Record{
-						{ID: record1, IP: ip1, RecordParams: params},
-					}, true, true),
-					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record1, api.FinalDeletionMode).Do(wrapCancelAsDelete(cancel)).Return(false),
-					p.EXPECT().Infof(pp.EmojiTimeout, "Deletion of %s records of %s aborted by timeout or signals; records might be inconsistent", "AAAA", "sub.test.org"),
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{{ID: record1, Content: old}, {ID: record2, Content: `"` + old + `"`}}, true, true),
+					p.EXPECT().Noticef(pp.EmojiUserWarning, "Found %d heartbeat TXT records of %s; only the one with ID %s is updated", 2, "_ddns.test.org", record1),
+					h.EXPECT().UpdateTXTRecord(ctx, p, domain, api.TXTRecord{ID: record1, Content: content}).Return(true),
+					p.EXPECT().Infof(pp.EmojiUpdate, "Updated the heartbeat TXT record of %s (ID: %s)", "_ddns.test.org", record1),
 				)
 			},
 		},
-		"impossible-records": {
+		"update/unrelated": {
 			setter.ResponseUpdated,
-			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
 				gomock.InOrder(
-					h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).Return([]api.Record{
-						{ID: record1, IP: ip1, RecordParams: params},
-						{ID: record2, IP: invalidIP, RecordParams: params},
-					}, true, true),
-					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record1, api.FinalDeletionMode).Return(true),
-					p.EXPECT().Noticef(pp.EmojiDeletion, "Deleted a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record1),
-					h.EXPECT().DeleteRecord(ctx, p, ipNetwork, domain, record2, api.FinalDeletionMode).Return(true),
-					p.EXPECT().Noticef(pp.EmojiDeletion, "Deleted a stale %s record of %s (ID: %s)", "AAAA", "sub.test.org", record2),
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{{ID: record1, Content: "v=spf1 -all"}, {ID: record2, Content: old}}, true, true),
+					h.EXPECT().UpdateTXTRecord(ctx, p, domain, api.TXTRecord{ID: record2, Content: content}).Return(true),
+					p.EXPECT().Infof(pp.EmojiUpdate, "Updated the heartbeat TXT record of %s (ID: %s)", "_ddns.test.org", record2),
+				)
+			},
+		},
+		"update-fail": {
+			setter.ResponseFailed,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{{ID: record1, Content: old}}, true, true),
+					h.EXPECT().UpdateTXTRecord(ctx, p, domain, api.TXTRecord{ID: record1, Content: content}).Return(false),
+					p.EXPECT().Noticef(pp.EmojiError, "Failed to properly write the heartbeat TXT record of %s", "_ddns.test.org"),
+				)
+			},
+		},
+		"uptodate": {
+			setter.ResponseNoop,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{{ID: record1, Content: content}}, true, true),
+					p.EXPECT().Infof(pp.EmojiAlreadyDone, "The heartbeat TXT record of %s is already up to date (cached)", "_ddns.test.org"),
+				)
+			},
+		},
+		"uptodate/not-cached": {
+			setter.ResponseNoop,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{{ID: record1, Content: content}}, false, true),
+					p.EXPECT().Infof(pp.EmojiAlreadyDone, "The heartbeat TXT record of %s is already up to date", "_ddns.test.org"),
+				)
+			},
+		},
+		"uptodate/quoted": {
+			setter.ResponseNoop,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{{ID: record1, Content: `"` + content + `"`}}, true, true),
+					p.EXPECT().Infof(pp.EmojiAlreadyDone, "The heartbeat TXT record of %s is already up to date (cached)", "_ddns.test.org"),
 				)
 			},
 		},
 		"listfail": {
 			setter.ResponseFailed,
-			func(ctx context.Context, _ func(), p *mocks.MockPP, h *mocks.MockHandle) {
-				h.EXPECT().ListRecords(ctx, p, ipNetwork, domain, params).Return(nil, false, false)
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
+				h.EXPECT().ListTXTRecords(ctx, p, domain).Return(nil, false, false)
 			},
 		},
 	} {
@@ -361,19 +1132,81 @@ func TestFinalDelete(t *testing.T) {
 			t.Parallel()
 			mockCtrl := gomock.NewController(t)
 
-			ctx, cancel := context.WithCancel(context.Background())
-			defer cancel()
+			ctx := context.Background()
 
 			mockPP := mocks.NewMockPP(mockCtrl)
 			mockHandle := mocks.NewMockHandle(mockCtrl)
 			if tc.prepareMocks != nil {
-				tc.prepareMocks(ctx, cancel, mockPP, mockHandle)
+				tc.prepareMocks(ctx, mockPP, mockHandle)
 			}
 
-			s, ok := setter.New(mockPP, mockHandle)
+			s, ok := setter.New(mockPP, mockHandle, api.OwnAllRecords)
 			require.True(t, ok)
 
-			resp := s.FinalDelete(ctx, mockPP, ipNetwork, domain, params)
+			resp := s.SetHeartbeat(ctx, mockPP, domain, content, params)
+			require.Equal(t, tc.resp, resp)
+		})
+	}
+}
+
+func TestSetHeartbeatWithOwnership(t *testing.T) {
+	t.Parallel()
+
+	const (
+		domain  = domain.FQDN("_ddns.test.org")
+		record1 = api.ID("record1")
+		record2 = api.ID("record2")
+		content = "ips=1.1.1.1 time=2006-01-02T15:04:05Z version=1.0.0"
+		old     = "ips=2.2.2.2 time=2006-01-01T15:04:05Z version=1.0.0"
+	)
+	params := api.RecordParams{TTL: api.TTLAuto, Proxied: false, Comment: "hello", Tags: nil}
+
+	for name, tc := range map[string]struct {
+		resp         setter.ResponseCode
+		prepareMocks func(ctx context.Context, p *mocks.MockPP, m *mocks.MockHandle)
+	}{
+		"owned": {
+			setter.ResponseUpdated,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{
+						{ID: record1, Content: old, Comment: "static"},
+						{ID: record2, Content: old, Comment: "hello"},
+					}, true, true),
+					h.EXPECT().UpdateTXTRecord(ctx, p, domain, api.TXTRecord{ID: record2, Content: content, Comment: "hello"}).Return(true),
+					p.EXPECT().Infof(pp.EmojiUpdate, "Updated the heartbeat TXT record of %s (ID: %s)", "_ddns.test.org", record2),
+				)
+			},
+		},
+		"foreign-only": {
+			setter.ResponseUpdated,
+			func(ctx context.Context, p *mocks.MockPP, h *mocks.MockHandle) {
+				gomock.InOrder(
+					h.EXPECT().ListTXTRecords(ctx, p, domain).Return([]api.TXTRecord{
+						{ID: record1, Content: old, Comment: "static"},
+					}, true, true),
+					h.EXPECT().CreateTXTRecord(ctx, p, domain, content, params).Return(record2, true),
+					p.EXPECT().Noticef(pp.EmojiCreation, "Added a new heartbeat TXT record of %s (ID: %s)", "_ddns.test.org", record2),
+				)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			ctx := context.Background()
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockHandle := mocks.NewMockHandle(mockCtrl)
+			if tc.prepareMocks != nil {
+				tc.prepareMocks(ctx, mockPP, mockHandle)
+			}
+
+			s, ok := setter.New(mockPP, mockHandle, api.OwnRecordsByComment)
+			require.True(t, ok)
+
+			resp := s.SetHeartbeat(ctx, mockPP, domain, content, params)
 			require.Equal(t, tc.resp, resp)
 		})
 	}
@@ -407,7 +1240,7 @@ func TestSetWAFList(t *testing.T) {
 	)
 
 	type items = []api.WAFListItem
-	type ipmap = map[ipnet.Type]netip.Addr
+	type ipmap = map[ipnet.Type][]netip.Addr
 
 	for name, tc := range map[string]struct {
 		detected     ipmap
@@ -415,7 +1248,7 @@ func TestSetWAFList(t *testing.T) {
 		prepareMocks func(ctx context.Context, cancel func(), p *mocks.MockPP, m *mocks.MockHandle)
 	}{
 		"created": {
-			ipmap{ipnet.IP4: ip4, ipnet.IP6: ip6},
+			ipmap{ipnet.IP4: {ip4}, ipnet.IP6: {ip6}},
 			setter.ResponseUpdated,
 			func(ctx context.Context, _ func(), p *mocks.MockPP, m *mocks.MockHandle) {
 				gomock.InOrder(
@@ -428,15 +1261,29 @@ func TestSetWAFList(t *testing.T) {
 				)
 			},
 		},
+		"created/multi": {
+			ipmap{ipnet.IP4: {ip4, netip.MustParseAddr("10.0.0.2")}, ipnet.IP6: {ip6, netip.MustParseAddr("2001:db8::2")}},
+			setter.ResponseUpdated,
+			func(ctx context.Context, _ func(), p *mocks.MockPP, m *mocks.MockHandle) {
+				gomock.InOrder(
+					m.EXPECT().ListWAFListItems(ctx, p, wafList, listDescription).Return(items{}, true, false, true),
+					m.EXPECT().CreateWAFListItems(ctx, p, wafList, listDescription, []netip.Prefix{prefix4.Prefix, netip.MustParsePrefix("10.0.0.2/32"), prefix6.Prefix}, "").Return(true),
+					p.EXPECT().Noticef(pp.EmojiCreation, "Added %s to the list %s", "10.0.0.1", wafListDescribed),
+					p.EXPECT().Noticef(pp.EmojiCreation, "Added %s to the list %s", "10.0.0.2", wafListDescribed),
+					p.EXPECT().Noticef(pp.EmojiCreation, "Added %s to the list %s", "2001:db8::/64", wafListDescribed),
+					m.EXPECT().DeleteWAFListItems(ctx, p, wafList, listDescription, []api.ID{}).Return(true),
+				)
+			},
+		},
 		"list-fail": {
-			ipmap{ipnet.IP4: ip4, ipnet.IP6: ip6},
+			ipmap{ipnet.IP4: {ip4}, ipnet.IP6: {ip6}},
 			setter.ResponseFailed,
 			func(ctx context.Context, _ func(), p *mocks.MockPP, m *mocks.MockHandle) {
 				m.EXPECT().ListWAFListItems(ctx, p, wafList, listDescription).Return(nil, false, false, false)
 			},
 		},
 		"skip-unknown": {
-			ipmap{ipnet.IP4: netip.Addr{}, ipnet.IP6: ip6},
+			ipmap{ipnet.IP4: nil, ipnet.IP6: {ip6}},
 			setter.ResponseNoop,
 			func(ctx context.Context, _ func(), p *mocks.MockPP, m *mocks.MockHandle) {
 				gomock.InOrder(
@@ -451,7 +1298,7 @@ func TestSetWAFList(t *testing.T) {
 			},
 		},
 		"noop": {
-			ipmap{ipnet.IP4: ip4, ipnet.IP6: ip6},
+			ipmap{ipnet.IP4: {ip4}, ipnet.IP6: {ip6}},
 			setter.ResponseNoop,
 			func(ctx context.Context, _ func(), p *mocks.MockPP, m *mocks.MockHandle) {
 				gomock.InOrder(
@@ -461,7 +1308,7 @@ func TestSetWAFList(t *testing.T) {
 			},
 		},
 		"noop/cached": {
-			ipmap{ipnet.IP4: ip4, ipnet.IP6: ip6},
+			ipmap{ipnet.IP4: {ip4}, ipnet.IP6: {ip6}},
 			setter.ResponseNoop,
 			func(ctx context.Context, _ func(), p *mocks.MockPP, m *mocks.MockHandle) {
 				gomock.InOrder(
@@ -471,7 +1318,7 @@ func TestSetWAFList(t *testing.T) {
 			},
 		},
 		"test1": {
-			ipmap{ipnet.IP4: ip4, ipnet.IP6: ip6},
+			ipmap{ipnet.IP4: {ip4}, ipnet.IP6: {ip6}},
 			setter.ResponseUpdated,
 			func(ctx context.Context, _ func(), p *mocks.MockPP, m *mocks.MockHandle) {
 				gomock.InOrder(
@@ -508,7 +1355,7 @@ func TestSetWAFList(t *testing.T) {
 			},
 		},
 		"test2": {
-			ipmap{ipnet.IP4: ip4, ipnet.IP6: ip6},
+			ipmap{ipnet.IP4: {ip4}, ipnet.IP6: {ip6}},
 			setter.ResponseUpdated,
 			func(ctx context.Context, _ func(), p *mocks.MockPP, m *mocks.MockHandle) {
 				gomock.InOrder(
@@ -541,7 +1388,7 @@ func TestSetWAFList(t *testing.T) {
 			},
 		},
 		"create-fail": {
-			ipmap{ipnet.IP4: ip4, ipnet.IP6: ip6},
+			ipmap{ipnet.IP4: {ip4}, ipnet.IP6: {ip6}},
 			setter.ResponseFailed,
 			func(ctx context.Context, _ func(), p *mocks.MockPP, m *mocks.MockHandle) {
 				gomock.InOrder(
@@ -552,7 +1399,7 @@ func TestSetWAFList(t *testing.T) {
 			},
 		},
 		"delete-fail": {
-			ipmap{ipnet.IP4: ip4, ipnet.IP6: ip6},
+			ipmap{ipnet.IP4: {ip4}, ipnet.IP6: {ip6}},
 			setter.ResponseFailed,
 			func(ctx context.Context, _ func(), p *mocks.MockPP, m *mocks.MockHandle) {
 				gomock.InOrder(
@@ -597,7 +1444,7 @@ func TestSetWAFList(t *testing.T) {
 				tc.prepareMocks(ctx, cancel, mockPP, mockHandle)
 			}
 
-			s, ok := setter.New(mockPP, mockHandle)
+			s, ok := setter.New(mockPP, mockHandle, api.OwnAllRecords)
 			require.True(t, ok)
 
 			resp := s.SetWAFList(ctx, mockPP, wafList, listDescription, tc.detected, "")
@@ -606,6 +1453,71 @@ func TestSetWAFList(t *testing.T) {
 	}
 }
 
+func TestListWAFList(t *testing.T) {
+	t.Parallel()
+
+	const listName = "list"
+	const listDescription = "My List"
+	wafList := api.WAFList{AccountID: "account", Name: listName}
+
+	prefix4 := netip.MustParsePrefix("10.0.0.1/32")
+	prefix6 := netip.MustParsePrefix("2001:db8::/64")
+
+	for name, tc := range map[string]struct {
+		prefixes     []netip.Prefix
+		ok           bool
+		prepareMocks func(ctx context.Context, p *mocks.MockPP, m *mocks.MockHandle)
+	}{
+		"existing": {
+			[]netip.Prefix{prefix4, prefix6},
+			true,
+			func(ctx context.Context, p *mocks.MockPP, m *mocks.MockHandle) {
+				m.EXPECT().ListWAFListItems(ctx, p, wafList, listDescription).Return([]api.WAFListItem{
+					{ID: "pre4", Prefix: prefix4},
+					{ID: "pre6", Prefix: prefix6},
+				}, true, false, true)
+			},
+		},
+		"created": {
+			[]netip.Prefix{},
+			true,
+			func(ctx context.Context, p *mocks.MockPP, m *mocks.MockHandle) {
+				gomock.InOrder(
+					m.EXPECT().ListWAFListItems(ctx, p, wafList, listDescription).Return(nil, false, false, true),
+					p.EXPECT().Noticef(pp.EmojiCreation, "Created a new list %s", "account/list"),
+				)
+			},
+		},
+		"list-fail": {
+			nil,
+			false,
+			func(ctx context.Context, p *mocks.MockPP, m *mocks.MockHandle) {
+				m.EXPECT().ListWAFListItems(ctx, p, wafList, listDescription).Return(nil, false, false, false)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			ctx := context.Background()
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockHandle := mocks.NewMockHandle(mockCtrl)
+			if tc.prepareMocks != nil {
+				tc.prepareMocks(ctx, mockPP, mockHandle)
+			}
+
+			s, ok := setter.New(mockPP, mockHandle, api.OwnAllRecords)
+			require.True(t, ok)
+
+			prefixes, ok := s.ListWAFList(ctx, mockPP, wafList, listDescription)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.prefixes, prefixes)
+		})
+	}
+}
+
 func TestFinalClearWAFListAsync(t *testing.T) {
 	t.Parallel()
 
@@ -655,7 +1567,7 @@ func TestFinalClearWAFListAsync(t *testing.T) {
 				tc.prepareMocks(ctx, cancel, mockPP, mockHandle)
 			}
 
-			s, ok := setter.New(mockPP, mockHandle)
+			s, ok := setter.New(mockPP, mockHandle, api.OwnAllRecords)
 			require.True(t, ok)
 
 			resp := s.FinalClearWAFList(ctx, mockPP, wafList, listDescription)
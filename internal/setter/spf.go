@@ -0,0 +1,146 @@
+package setter
+
+import (
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+)
+
+// spfVersion is the version tag that every SPF record (RFC 7208) starts with.
+const spfVersion = "v=spf1"
+
+// maxTXTStringLength is the maximum length of one character-string in a TXT record (RFC 1035).
+const maxTXTStringLength = 255
+
+// parseTXT parses the content of a TXT record. If the content is quoted, it may consist of
+// several quoted strings separated by spaces (such as `"v=spf1 ip4:192.0.2.1" " -all"`),
+// and the text is their concatenation (RFC 7208, Section 3.3) with escapes removed.
+// The second return value indicates whether the content was quoted, and the last one
+// indicates whether the content was well-formed.
+func parseTXT(content string) (string, bool, bool) {
+	if !strings.HasPrefix(content, `"`) {
+		return content, false, true
+	}
+
+	var text strings.Builder
+	rest := content
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			return text.String(), true, true
+		}
+		if rest[0] != '"' {
+			return "", true, false
+		}
+
+		closed := false
+		i := 1
+		for ; i < len(rest); i++ {
+			switch rest[i] {
+			case '\\':
+				if i+1 < len(rest) {
+					i++
+					text.WriteByte(rest[i])
+				}
+				continue
+			case '"':
+				closed = true
+			default:
+				text.WriteByte(rest[i])
+				continue
+			}
+			break
+		}
+		if !closed {
+			return "", true, false
+		}
+		rest = rest[i+1:]
+	}
+}
+
+// formatTXT is the inverse of [parseTXT]. If quoted is true, the text is split into quoted strings
+// of at most 255 characters each, with quotes and backslashes escaped.
+func formatTXT(text string, quoted bool) string {
+	if !quoted {
+		return text
+	}
+
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	var chunks []string
+	for len(text) > maxTXTStringLength {
+		chunks = append(chunks, `"`+escaper.Replace(text[:maxTXTStringLength])+`"`)
+		text = text[maxTXTStringLength:]
+	}
+	chunks = append(chunks, `"`+escaper.Replace(text)+`"`)
+	return strings.Join(chunks, " ")
+}
+
+// isSPF checks whether the content of a TXT record is an SPF record.
+func isSPF(content string) bool {
+	text, _, ok := parseTXT(content)
+	terms := strings.Fields(text)
+	return ok && len(terms) > 0 && strings.EqualFold(terms[0], spfVersion)
+}
+
+// parseManagedSPFTerm checks whether an SPF term is an ip4 or ip6 mechanism (depending on the IP network)
+// for a single IP address with the qualifier "+" (explicitly or implicitly), and returns the address.
+// Mechanisms with other qualifiers, such as "-ip4:192.0.2.1", and mechanisms for ranges of addresses,
+// such as "ip4:192.0.2.0/24", are not managed.
+func parseManagedSPFTerm(ipNet ipnet.Type, term string) (netip.Addr, bool) {
+	term = strings.TrimPrefix(term, "+")
+	name, value, found := strings.Cut(term, ":")
+	if !found || !strings.EqualFold(name, ipNet.SPFMechanism()) {
+		return netip.Addr{}, false
+	}
+	ip, err := netip.ParseAddr(value)
+	if err != nil || !ipNet.Matches(ip) {
+		return netip.Addr{}, false
+	}
+	return ip, true
+}
+
+// managedSPFIPs lists the IP addresses in the managed mechanisms (see [parseManagedSPFTerm]) of an SPF record.
+func managedSPFIPs(content string, ipNet ipnet.Type) []netip.Addr {
+	text, _, _ := parseTXT(content)
+
+	var ips []netip.Addr
+	for _, term := range strings.Fields(text) {
+		if ip, ok := parseManagedSPFTerm(ipNet, term); ok {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+// rewriteSPF replaces the managed mechanisms (see [parseManagedSPFTerm]) of an SPF record with
+// new mechanisms for the IP addresses, keeping all other terms in their original order.
+// The new mechanisms take the place of the first managed mechanism, or are placed right after
+// the version tag if there were none.
+func rewriteSPF(content string, ipNet ipnet.Type, ips []netip.Addr) string {
+	text, quoted, _ := parseTXT(content)
+	terms := strings.Fields(text)
+
+	mechanisms := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		mechanisms = append(mechanisms, ipNet.SPFMechanism()+":"+ip.String())
+	}
+
+	position := -1
+	kept := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if _, ok := parseManagedSPFTerm(ipNet, term); ok {
+			if position < 0 {
+				position = len(kept)
+			}
+			continue
+		}
+		kept = append(kept, term)
+	}
+	if position < 0 {
+		position = 1 // right after the version tag
+	}
+
+	return formatTXT(strings.Join(slices.Insert(kept, position, mechanisms...), " "), quoted)
+}
@@ -36,14 +36,23 @@ func NotifyContext(ctx context.Context) (context.Context, context.CancelFunc) {
 
 // WaitForSignalsUntil waits for a period of time. It returns true if it is interrupted by signals in [Signals].
 func (h Handle) WaitForSignalsUntil(ppfmt pp.PP, t time.Time) bool {
+	return h.WaitForSignalsOrEventsUntil(ppfmt, t, nil)
+}
+
+// WaitForSignalsOrEventsUntil is similar to [Handle.WaitForSignalsUntil] but also stops waiting
+// (and returns false) when a value is received from events. A nil channel means no events.
+func (h Handle) WaitForSignalsOrEventsUntil(ppfmt pp.PP, t time.Time, events <-chan struct{}) bool {
 	timer := time.NewTimer(time.Until(t))
-	for {
-		select {
-		case sig := <-h.channel:
-			ppfmt.Noticef(pp.EmojiSignal, "Caught signal: %v", sig)
-			return true
-		case <-timer.C:
-			return false
-		}
+	defer timer.Stop()
+
+	select {
+	case sig := <-h.channel:
+		ppfmt.Noticef(pp.EmojiSignal, "Caught signal: %v", sig)
+		return true
+	case <-events:
+		ppfmt.Infof(pp.EmojiNow, "Network addresses have changed; checking the IP addresses now")
+		return false
+	case <-timer.C:
+		return false
 	}
 }
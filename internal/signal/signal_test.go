@@ -100,3 +100,19 @@ func TestNotifyContext(t *testing.T) {
 		})
 	}
 }
+
+//nolint:paralleltest //signals are global
+func TestWaitForSignalsOrEventsUntil(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Infof(pp.EmojiNow, "Network addresses have changed; checking the IP addresses now")
+
+	events := make(chan struct{}, 1)
+	events <- struct{}{}
+
+	sig := signal.Setup()
+	start := time.Now()
+	res := sig.WaitForSignalsOrEventsUntil(mockPP, start.Add(time.Second), events)
+	require.False(t, res)
+	require.Less(t, time.Since(start), time.Second/2)
+}
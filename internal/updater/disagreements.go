@@ -0,0 +1,36 @@
+package updater
+
+import (
+	"slices"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+)
+
+// Disagreements remembers the providers that failed or disagreed in the last successful detection
+// of each provider (see [provider.Detection]) across rounds of updating, so that a lasting
+// disagreement is only notified when it starts or changes, not in every round.
+type Disagreements struct {
+	dissenters map[disagreementKey][]string
+}
+
+// disagreementKey identifies a provider (see [detectionKey]) for an IP network.
+type disagreementKey struct {
+	ipNet    ipnet.Type
+	provider string
+}
+
+// NewDisagreements creates a new, empty [Disagreements].
+func NewDisagreements() *Disagreements {
+	return &Disagreements{dissenters: map[disagreementKey][]string{}}
+}
+
+// changed records the dissenters of a successful detection and checks whether
+// they are different from the dissenters of the last successful detection.
+func (d *Disagreements) changed(ipNet ipnet.Type, provider string, dissenters []string) bool {
+	key := disagreementKey{ipNet: ipNet, provider: provider}
+	dissenters = slices.Sorted(slices.Values(dissenters))
+
+	last := d.dissenters[key]
+	d.dissenters[key] = dissenters
+	return !slices.Equal(last, dissenters)
+}
@@ -0,0 +1,91 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/favonia/cloudflare-ddns/internal/api"
+	"github.com/favonia/cloudflare-ddns/internal/config"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/monitor"
+	"github.com/favonia/cloudflare-ddns/internal/notifier"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/setter"
+)
+
+// Heartbeat remembers what was last written to the heartbeat TXT record (see [config.Config.HeartbeatDomain]),
+// so that the record is rewritten only when the IP addresses have changed or
+// [config.Config.HeartbeatInterval] has passed.
+type Heartbeat struct {
+	version string    // the version of the updater
+	ips     string    // the IP addresses in the last written record
+	written time.Time // when the record was last written
+}
+
+// NewHeartbeat creates a new [Heartbeat] for the updater of the given version.
+func NewHeartbeat(version string) *Heartbeat {
+	return &Heartbeat{version: version, ips: "", written: time.Time{}}
+}
+
+// describeHeartbeatIPs lists all detected IP addresses, IPv4 addresses first, separated by commas without spaces.
+func describeHeartbeatIPs(detectedIPs map[ipnet.Type][]netip.Addr) string {
+	var ips []string
+	for ipNet := range ipnet.All {
+		for _, ip := range detectedIPs[ipNet] {
+			ips = append(ips, ip.String())
+		}
+	}
+	return strings.Join(ips, ",")
+}
+
+// heartbeatContent generates the content of the heartbeat TXT record, such as
+// "ips=192.0.2.1,2001:db8::1 time=2006-01-02T15:04:05Z version=1.15.0".
+func heartbeatContent(ips string, now time.Time, version string) string {
+	if version == "" {
+		version = "unknown"
+	}
+	return fmt.Sprintf("ips=%s time=%s version=%s", ips, now.UTC().Format(time.RFC3339), version)
+}
+
+// write calls [setter.Setter.SetHeartbeat] with timeout if the heartbeat TXT record is enabled and due.
+// The record has the same TTL, comment, and tags as the DNS records of managed domains.
+func (h *Heartbeat) write(ctx context.Context, ppfmt pp.PP, c *config.Config, s setter.Setter,
+	detectedIPs map[ipnet.Type][]netip.Addr, now time.Time,
+) Message {
+	if c.HeartbeatDomain == nil {
+		return NewMessage()
+	}
+
+	ips := describeHeartbeatIPs(detectedIPs)
+	if ips == h.ips && !h.written.IsZero() && now.Sub(h.written) < c.HeartbeatInterval {
+		return NewMessage()
+	}
+
+	content := heartbeatContent(ips, now, h.version)
+	resp := wrapUpdateWithTimeout(ctx, ppfmt, c, func(ctx context.Context) setter.ResponseCode {
+		return s.SetHeartbeat(ctx, ppfmt, c.HeartbeatDomain, content, api.RecordParams{
+			TTL:     c.TTL,
+			Proxied: false,
+			Comment: c.RecordComment,
+			Tags:    c.RecordTags,
+		})
+	})
+	if resp == setter.ResponseFailed {
+		return Message{
+			MonitorMessage: monitor.Message{
+				OK:    false,
+				Lines: []string{fmt.Sprintf("Failed to write the heartbeat TXT record of %s", c.HeartbeatDomain.Describe())},
+			},
+			NotifierMessage: notifier.Message{
+				fmt.Sprintf("Failed to write the heartbeat TXT record of %s.", c.HeartbeatDomain.Describe()),
+			},
+		}
+	}
+
+	h.ips = ips
+	h.written = now
+	return NewMessage()
+}
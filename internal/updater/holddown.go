@@ -0,0 +1,113 @@
+package updater
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/favonia/cloudflare-ddns/internal/config"
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/monitor"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+)
+
+// pendingIPs is a new set of IP addresses waiting to be confirmed.
+type pendingIPs struct {
+	ips    []netip.Addr
+	rounds int       // the number of consecutive rounds in which ips were detected
+	since  time.Time // when ips were first detected
+}
+
+// HoldDown remembers the IP addresses in use and the new IP addresses waiting to be confirmed
+// across rounds of updating. A new set of IP addresses is used only after it has been detected in
+// [config.Config.HoldDownRounds] rounds in a row and has been stable for [config.Config.HoldDownPeriod].
+// When nothing is known about an IP network yet (for example, right after the updater starts),
+// the IP addresses already published in DNS records and WAF lists are taken as the ones in use,
+// so that restarting the updater does not skip the hold-down; if nothing is published,
+// the first set of detected IP addresses is used right away.
+type HoldDown struct {
+	confirmed map[holdDownKey][]netip.Addr
+	pending   map[holdDownKey]pendingIPs
+}
+
+// holdDownKey identifies the IP addresses detected by a provider for an IP network.
+type holdDownKey struct {
+	ipNet ipnet.Type
+	group int // the position of the group of domains using the provider (0 for the default provider)
+}
+
+// NewHoldDown creates a new, empty [HoldDown].
+func NewHoldDown() *HoldDown {
+	return &HoldDown{
+		confirmed: map[holdDownKey][]netip.Addr{},
+		pending:   map[holdDownKey]pendingIPs{},
+	}
+}
+
+// describeAddresses describes the IP addresses, such as "IPv4 address 1.2.3.4".
+func describeAddresses(ipNet ipnet.Type, ips []netip.Addr) string {
+	if len(ips) == 1 {
+		return fmt.Sprintf("%s address %s", ipNet.Describe(), ips[0].String())
+	}
+	return fmt.Sprintf("%s addresses %s", ipNet.Describe(), describeIPs(ips))
+}
+
+// confirm records the IP addresses detected by the provider of the group of domains
+// (see [groupDomains]) and checks whether they can be used now.
+// If not, it also returns a message describing the pending change. The function published
+// is called to get the IP addresses already in use when nothing has been recorded for the group.
+func (h *HoldDown) confirm(ppfmt pp.PP, c *config.Config, ipNet ipnet.Type, group int,
+	ips []netip.Addr, published func() []netip.Addr, now time.Time,
+) (bool, Message) {
+	key := holdDownKey{ipNet: ipNet, group: group}
+	enabled := c.HoldDownRounds > 1 || c.HoldDownPeriod > 0
+
+	last, found := h.confirmed[key]
+	if !found && enabled {
+		if seed := published(); len(seed) > 0 {
+			last, found = seed, true
+			h.confirmed[key] = seed
+		}
+	}
+	if !found || !enabled || slices.Equal(last, ips) {
+		h.confirmed[key] = ips
+		delete(h.pending, key)
+		return true, NewMessage()
+	}
+
+	p, found := h.pending[key]
+	if !found || !slices.Equal(p.ips, ips) {
+		p = pendingIPs{ips: ips, rounds: 0, since: now}
+	}
+	p.rounds++
+
+	stable := now.Sub(p.since)
+	if p.rounds >= c.HoldDownRounds && stable >= c.HoldDownPeriod {
+		ppfmt.Infof(pp.EmojiGood, "The new %s is confirmed", describeAddresses(ipNet, ips))
+		h.confirmed[key] = ips
+		delete(h.pending, key)
+		return true, NewMessage()
+	}
+	h.pending[key] = p
+
+	var progress []string
+	if c.HoldDownRounds > 1 {
+		progress = append(progress, fmt.Sprintf("detected in %d of %d rounds", p.rounds, c.HoldDownRounds))
+	}
+	if c.HoldDownPeriod > 0 {
+		progress = append(progress, fmt.Sprintf("stable for %v of %v", stable.Round(time.Second), c.HoldDownPeriod))
+	}
+
+	ppfmt.Infof(pp.EmojiAlarm, "Waiting to confirm the new %s (%s) before updating",
+		describeAddresses(ipNet, ips), strings.Join(progress, ", "))
+
+	msg := NewMessage()
+	msg.MonitorMessage = monitor.Message{
+		OK: true,
+		Lines: []string{fmt.Sprintf("Waiting to confirm %s (%s)",
+			describeAddresses(ipNet, ips), strings.Join(progress, ", "))},
+	}
+	return false, msg
+}
@@ -23,10 +23,28 @@ func (s setterResponses) register(d domain.Domain, code setter.ResponseCode) {
 	s[code] = append(s[code], d.Describe())
 }
 
-func generateDetectMessage(ipNet ipnet.Type, ok bool) Message {
+// describeIPs lists the IP addresses, separated by commas.
+func describeIPs(ips []netip.Addr) string {
+	return pp.JoinMap(netip.Addr.String, ips)
+}
+
+// generateDetectMessage describes the detection in the monitor and notifier messages.
+// The notes of a successful detection are always sent to monitors, but only to notifiers if notify is true.
+func generateDetectMessage(ipNet ipnet.Type, ips []netip.Addr, notes []string, notify, ok bool) Message {
 	switch {
 	default:
 		return NewMessage()
+	case !ok && len(notes) > 0:
+		return Message{
+			MonitorMessage: monitor.Message{
+				OK: false,
+				Lines: []string{fmt.Sprintf("Failed to detect %s address (%s)",
+					ipNet.Describe(), strings.Join(notes, "; "))},
+			},
+			NotifierMessage: notifier.Message{
+				fmt.Sprintf("Failed to detect the %s address (%s).", ipNet.Describe(), strings.Join(notes, "; ")),
+			},
+		}
 	case !ok:
 		return Message{
 			MonitorMessage: monitor.Message{
@@ -37,16 +55,31 @@ func generateDetectMessage(ipNet ipnet.Type, ok bool) Message {
 				fmt.Sprintf("Failed to detect the %s address.", ipNet.Describe()),
 			},
 		}
+	case len(notes) > 0:
+		msg := Message{
+			MonitorMessage: monitor.Message{
+				OK: true,
+				Lines: []string{fmt.Sprintf("Detected %s address %s (%s)",
+					ipNet.Describe(), describeIPs(ips), strings.Join(notes, "; "))},
+			},
+			NotifierMessage: notifier.NewMessage(),
+		}
+		if notify {
+			msg.NotifierMessage = notifier.Message{
+				fmt.Sprintf("Detected the %s address %s (%s).", ipNet.Describe(), describeIPs(ips), strings.Join(notes, "; ")),
+			}
+		}
+		return msg
 	}
 }
 
-func generateUpdateMonitorMessage(ipNet ipnet.Type, ip netip.Addr, s setterResponses) monitor.Message {
+func generateUpdateMonitorMessage(ipNet ipnet.Type, ips []netip.Addr, s setterResponses) monitor.Message {
 	if domains := s[setter.ResponseFailed]; len(domains) > 0 {
 		return monitor.Message{
 			OK: false,
 			Lines: []string{fmt.Sprintf(
 				"Failed to set %s (%s) of %s",
-				ipNet.RecordType(), ip.String(), pp.Join(domains),
+				ipNet.RecordType(), describeIPs(ips), pp.Join(domains),
 			)},
 		}
 	}
@@ -56,33 +89,40 @@ func generateUpdateMonitorMessage(ipNet ipnet.Type, ip netip.Addr, s setterRespo
 	if domains := s[setter.ResponseUpdating]; len(domains) > 0 {
 		successLines = append(successLines, fmt.Sprintf(
 			"Setting %s (%s) of %s",
-			ipNet.RecordType(), ip.String(), pp.Join(domains),
+			ipNet.RecordType(), describeIPs(ips), pp.Join(domains),
 		))
 	}
 
 	if domains := s[setter.ResponseUpdated]; len(domains) > 0 {
 		successLines = append(successLines, fmt.Sprintf(
 			"Set %s (%s) of %s",
-			ipNet.RecordType(), ip.String(), pp.Join(domains),
+			ipNet.RecordType(), describeIPs(ips), pp.Join(domains),
 		))
 	}
 
 	return monitor.Message{OK: true, Lines: successLines}
 }
 
-func generateUpdateNotifierMessage(ipNet ipnet.Type, ip netip.Addr, s setterResponses) notifier.Message {
+// generateUpdateNotifierMessage generates the notification. The route, if not empty,
+// describes how the detection was routed, such as "via SJC, US, warp=off".
+func generateUpdateNotifierMessage(ipNet ipnet.Type, ips []netip.Addr, route string, s setterResponses,
+) notifier.Message {
 	var fragments []string
+	describedIPs := pp.EnglishJoinMap(netip.Addr.String, ips)
+	if route != "" {
+		describedIPs = fmt.Sprintf("%s (%s)", describedIPs, route)
+	}
 
 	if domains := s[setter.ResponseFailed]; len(domains) > 0 {
 		fragments = append(fragments,
-			"Failed to properly update ", ipNet.RecordType(), " records of ", pp.EnglishJoin(domains), " with ", ip.String(),
+			"Failed to properly update ", ipNet.RecordType(), " records of ", pp.EnglishJoin(domains), " with ", describedIPs,
 		)
 	}
 
 	if domains := s[setter.ResponseUpdating]; len(domains) > 0 {
 		if len(fragments) == 0 {
 			fragments = append(fragments,
-				"Updating ", ipNet.RecordType(), " records of ", pp.EnglishJoin(domains), " with ", ip.String(),
+				"Updating ", ipNet.RecordType(), " records of ", pp.EnglishJoin(domains), " with ", describedIPs,
 			)
 		} else {
 			fragments = append(fragments,
@@ -94,7 +134,7 @@ func generateUpdateNotifierMessage(ipNet ipnet.Type, ip netip.Addr, s setterResp
 	if domains := s[setter.ResponseUpdated]; len(domains) > 0 {
 		if len(fragments) == 0 {
 			fragments = append(fragments,
-				"Updated ", ipNet.RecordType(), " records of ", pp.EnglishJoin(domains), " with ", ip.String(),
+				"Updated ", ipNet.RecordType(), " records of ", pp.EnglishJoin(domains), " with ", describedIPs,
 			)
 		} else {
 			fragments = append(fragments,
@@ -111,10 +151,10 @@ func generateUpdateNotifierMessage(ipNet ipnet.Type, ip netip.Addr, s setterResp
 	}
 }
 
-func generateUpdateMessage(ipNet ipnet.Type, ip netip.Addr, s setterResponses) Message {
+func generateUpdateMessage(ipNet ipnet.Type, ips []netip.Addr, route string, s setterResponses) Message {
 	return Message{
-		MonitorMessage:  generateUpdateMonitorMessage(ipNet, ip, s),
-		NotifierMessage: generateUpdateNotifierMessage(ipNet, ip, s),
+		MonitorMessage:  generateUpdateMonitorMessage(ipNet, ips, s),
+		NotifierMessage: generateUpdateNotifierMessage(ipNet, ips, route, s),
 	}
 }
 
@@ -0,0 +1,63 @@
+package updater
+
+import (
+	"fmt"
+	"net/netip"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/monitor"
+	"github.com/favonia/cloudflare-ddns/internal/notifier"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/setter"
+)
+
+func generateUpdateSPFMonitorMessage(ipNet ipnet.Type, ips []netip.Addr, s setterResponses) monitor.Message {
+	if domains := s[setter.ResponseFailed]; len(domains) > 0 {
+		return monitor.Message{
+			OK: false,
+			Lines: []string{fmt.Sprintf(
+				"Failed to set %s (%s) of SPF records of %s",
+				ipNet.SPFMechanism(), describeIPs(ips), pp.Join(domains),
+			)},
+		}
+	}
+
+	if domains := s[setter.ResponseUpdated]; len(domains) > 0 {
+		return monitor.Message{
+			OK: true,
+			Lines: []string{fmt.Sprintf(
+				"Set %s (%s) of SPF records of %s",
+				ipNet.SPFMechanism(), describeIPs(ips), pp.Join(domains),
+			)},
+		}
+	}
+
+	return monitor.NewMessage()
+}
+
+func generateUpdateSPFNotifierMessage(ipNet ipnet.Type, ips []netip.Addr, s setterResponses) notifier.Message {
+	var msg notifier.Message
+
+	if domains := s[setter.ResponseFailed]; len(domains) > 0 {
+		msg = append(msg, fmt.Sprintf(
+			"Failed to properly update %s mechanisms of SPF records of %s with %s.",
+			ipNet.SPFMechanism(), pp.EnglishJoin(domains), pp.EnglishJoinMap(netip.Addr.String, ips),
+		))
+	}
+
+	if domains := s[setter.ResponseUpdated]; len(domains) > 0 {
+		msg = append(msg, fmt.Sprintf(
+			"Updated %s mechanisms of SPF records of %s with %s.",
+			ipNet.SPFMechanism(), pp.EnglishJoin(domains), pp.EnglishJoinMap(netip.Addr.String, ips),
+		))
+	}
+
+	return msg
+}
+
+func generateUpdateSPFMessage(ipNet ipnet.Type, ips []netip.Addr, s setterResponses) Message {
+	return Message{
+		MonitorMessage:  generateUpdateSPFMonitorMessage(ipNet, ips, s),
+		NotifierMessage: generateUpdateSPFNotifierMessage(ipNet, ips, s),
+	}
+}
@@ -0,0 +1,64 @@
+package updater
+
+import (
+	"fmt"
+	"net/netip"
+
+	"github.com/favonia/cloudflare-ddns/internal/ipnet"
+	"github.com/favonia/cloudflare-ddns/internal/monitor"
+	"github.com/favonia/cloudflare-ddns/internal/notifier"
+	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/setter"
+)
+
+func generateUpdateSVCBHintsMonitorMessage(ipNet ipnet.Type, ips []netip.Addr, s setterResponses) monitor.Message {
+	if domains := s[setter.ResponseFailed]; len(domains) > 0 {
+		return monitor.Message{
+			OK: false,
+			Lines: []string{fmt.Sprintf(
+				"Failed to set %s (%s) of HTTPS/SVCB records of %s",
+				ipNet.SVCBHintKey(), describeIPs(ips), pp.Join(domains),
+			)},
+		}
+	}
+
+	if domains := s[setter.ResponseUpdated]; len(domains) > 0 {
+		return monitor.Message{
+			OK: true,
+			Lines: []string{fmt.Sprintf(
+				"Set %s (%s) of HTTPS/SVCB records of %s",
+				ipNet.SVCBHintKey(), describeIPs(ips), pp.Join(domains),
+			)},
+		}
+	}
+
+	return monitor.NewMessage()
+}
+
+func generateUpdateSVCBHintsNotifierMessage(ipNet ipnet.Type, ips []netip.Addr, s setterResponses,
+) notifier.Message {
+	var msg notifier.Message
+
+	if domains := s[setter.ResponseFailed]; len(domains) > 0 {
+		msg = append(msg, fmt.Sprintf(
+			"Failed to properly update %s of HTTPS/SVCB records of %s with %s.",
+			ipNet.SVCBHintKey(), pp.EnglishJoin(domains), pp.EnglishJoinMap(netip.Addr.String, ips),
+		))
+	}
+
+	if domains := s[setter.ResponseUpdated]; len(domains) > 0 {
+		msg = append(msg, fmt.Sprintf(
+			"Updated %s of HTTPS/SVCB records of %s with %s.",
+			ipNet.SVCBHintKey(), pp.EnglishJoin(domains), pp.EnglishJoinMap(netip.Addr.String, ips),
+		))
+	}
+
+	return msg
+}
+
+func generateUpdateSVCBHintsMessage(ipNet ipnet.Type, ips []netip.Addr, s setterResponses) Message {
+	return Message{
+		MonitorMessage:  generateUpdateSVCBHintsMonitorMessage(ipNet, ips, s),
+		NotifierMessage: generateUpdateSVCBHintsNotifierMessage(ipNet, ips, s),
+	}
+}
@@ -5,10 +5,15 @@ package updater
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/netip"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/favonia/cloudflare-ddns/internal/api"
 	"github.com/favonia/cloudflare-ddns/internal/config"
+	"github.com/favonia/cloudflare-ddns/internal/domain"
 	"github.com/favonia/cloudflare-ddns/internal/ipnet"
 	"github.com/favonia/cloudflare-ddns/internal/pp"
 	"github.com/favonia/cloudflare-ddns/internal/provider"
@@ -22,16 +27,62 @@ func getMessageIDForDetection(ipNet ipnet.Type) pp.ID {
 	}[ipNet]
 }
 
-func detectIP(ctx context.Context, ppfmt pp.PP, c *config.Config, ipNet ipnet.Type) (netip.Addr, Message) {
+func getMessageIDForBlockedIP(ipNet ipnet.Type) pp.ID {
+	return map[ipnet.Type]pp.ID{
+		ipnet.IP4: pp.MessageIP4BlockedByPolicy,
+		ipnet.IP6: pp.MessageIP6BlockedByPolicy,
+	}[ipNet]
+}
+
+// describeProviderSetting describes where the provider p of the IP network was configured.
+func describeProviderSetting(ipNet ipnet.Type, p provider.Provider, isDefault bool) string {
+	if isDefault {
+		return fmt.Sprintf("IP%d_PROVIDER=%s", ipNet.Int(), provider.Name(p))
+	}
+	return fmt.Sprintf("the provider %s in IP%d_DOMAIN_PROVIDERS", provider.Name(p), ipNet.Int())
+}
+
+// detectIPs detects the IP addresses with the provider p, which is the default provider if isDefault is true.
+// It also returns a description of how the request of the detection was routed
+// (see [provider.DescribeMetadata]), which may be empty. The notes of a successful detection are
+// only notified when the providers that failed or disagreed are different from those recorded in d
+// under the key (see [detectionKey]).
+func detectIPs(ctx context.Context, ppfmt pp.PP, c *config.Config, d *Disagreements, ipNet ipnet.Type,
+	key string, p provider.Provider, isDefault bool,
+) ([]netip.Addr, string, Message) {
 	ctx, cancel := context.WithTimeoutCause(ctx, c.DetectionTimeout, errTimeout)
 	defer cancel()
 
-	ip, ok := c.Provider[ipNet].GetIP(ctx, ppfmt, ipNet)
+	detection, ok := provider.Detect(provider.WithPolicy(ctx, c.AllowedIPs[ipNet]), ppfmt, p, ipNet)
+	ips, notes := detection.IPs, detection.Notes
+	route := provider.DescribeMetadata(detection.Metadata)
+
+	switch {
+	case !ok && len(detection.Blocked) > 0:
+		ppfmt.Noticef(pp.EmojiError, "Failed to detect the %s address", ipNet.Describe())
 
-	if ok {
-		ppfmt.Infof(pp.EmojiInternet, "Detected the %s address %v", ipNet.Describe(), ip)
+		blocked := detection.Blocked[0]
+		kind := ipnet.DescribeSpecialUse(blocked)
+		if kind == "" {
+			kind = "a public address"
+		}
+		ppfmt.NoticeOncef(getMessageIDForBlockedIP(ipNet), pp.EmojiHint,
+			"The address %s is %s. If it is the right address to use, please adjust IP%d_ALLOWED; "+
+				"otherwise, please check whether %s is configured correctly",
+			blocked.String(), kind, ipNet.Int(), describeProviderSetting(ipNet, p, isDefault))
+
+	case ok:
+		switch {
+		case len(ips) > 1:
+			ppfmt.Infof(pp.EmojiInternet, "Detected the %s addresses %s", ipNet.Describe(), describeIPs(ips))
+		case route != "":
+			ppfmt.Infof(pp.EmojiInternet, "Detected the %s address %v (%s)", ipNet.Describe(), ips[0], route)
+		default:
+			ppfmt.Infof(pp.EmojiInternet, "Detected the %s address %v", ipNet.Describe(), ips[0])
+		}
 		ppfmt.Suppress(getMessageIDForDetection(ipNet))
-	} else {
+
+	default:
 		ppfmt.Noticef(pp.EmojiError, "Failed to detect the %s address", ipNet.Describe())
 
 		switch ipNet {
@@ -53,7 +104,8 @@ func detectIP(ctx context.Context, ppfmt pp.PP, c *config.Config, ipNet ipnet.Ty
 			)
 		}
 	}
-	return ip, generateDetectMessage(ipNet, ok)
+	notify := !ok || d.changed(ipNet, key, detection.Dissenters)
+	return ips, route, generateDetectMessage(ipNet, ips, notes, notify, ok)
 }
 
 var errTimeout = errors.New("timeout")
@@ -76,26 +128,147 @@ func wrapUpdateWithTimeout(ctx context.Context, ppfmt pp.PP, c *config.Config,
 	return resp
 }
 
-// setIP extracts relevant settings from the configuration and calls [setter.Setter.Set] with timeout.
-// ip must be non-zero.
-func setIP(ctx context.Context, ppfmt pp.PP,
-	c *config.Config, s setter.Setter, ipNet ipnet.Type, ip netip.Addr,
+// applySuffix combines the prefix of each IP address with the suffix.
+// The result is sorted and without duplicates.
+func applySuffix(ips []netip.Addr, suffix netip.Prefix) []netip.Addr {
+	result := make([]netip.Addr, 0, len(ips))
+	for _, ip := range ips {
+		result = append(result, ipnet.ApplySuffix(ip, suffix))
+	}
+	slices.SortFunc(result, netip.Addr.Compare)
+	return slices.Compact(result)
+}
+
+// setIPs extracts relevant settings from the configuration and calls [setter.Setter.Set] with timeout
+// for each of the domains. ips must be non-empty. For IPv6, a domain with a suffix in [config.Config.IP6Suffixes]
+// gets the addresses combining the prefixes of ips and the suffix. The route returned by [detectIPs]
+// is mentioned in the notifications. If [config.Config.UpdateSVCBHints] is set, it also calls
+// [setter.Setter.SetSVCBHints] with the same addresses for each of the domains.
+func setIPs(ctx context.Context, ppfmt pp.PP,
+	c *config.Config, s setter.Setter, ipNet ipnet.Type, ips []netip.Addr, route string, domains []domain.Domain,
 ) Message {
-	resps := emptySetterResponses()
+	// The responses are grouped by the actual sets of IP addresses, with the detected one first.
+	keys := []string{describeIPs(ips)}
+	sets := map[string][]netip.Addr{keys[0]: ips}
+	resps := map[string]setterResponses{keys[0]: emptySetterResponses()}
+	hintResps := map[string]setterResponses{keys[0]: emptySetterResponses()}
+
+	for _, domain := range domains {
+		domainIPs := ips
+		if suffix, ok := c.IP6Suffixes[domain]; ok && ipNet == ipnet.IP6 {
+			domainIPs = applySuffix(ips, suffix)
+		}
+		key := describeIPs(domainIPs)
+		if _, ok := resps[key]; !ok {
+			keys = append(keys, key)
+			sets[key] = domainIPs
+			resps[key] = emptySetterResponses()
+			hintResps[key] = emptySetterResponses()
+		}
 
-	for _, domain := range c.Domains[ipNet] {
-		resps.register(domain,
+		resps[key].register(domain,
 			wrapUpdateWithTimeout(ctx, ppfmt, c, func(ctx context.Context) setter.ResponseCode {
-				return s.Set(ctx, ppfmt, ipNet, domain, ip, api.RecordParams{
+				return s.Set(ctx, ppfmt, ipNet, domain, domainIPs, api.RecordParams{
 					TTL:     c.TTL,
 					Proxied: c.Proxied[domain],
 					Comment: c.RecordComment,
+					Tags:    c.RecordTags,
 				})
 			}),
 		)
+
+		if c.UpdateSVCBHints {
+			hintResps[key].register(domain,
+				wrapUpdateWithTimeout(ctx, ppfmt, c, func(ctx context.Context) setter.ResponseCode {
+					return s.SetSVCBHints(ctx, ppfmt, ipNet, domain, domainIPs)
+				}),
+			)
+		}
+	}
+
+	msgs := make([]Message, 0, 2*len(keys))
+	for _, key := range keys {
+		msgs = append(msgs, generateUpdateMessage(ipNet, sets[key], route, resps[key]))
+	}
+	for _, key := range keys {
+		msgs = append(msgs, generateUpdateSVCBHintsMessage(ipNet, sets[key], hintResps[key]))
+	}
+	return MergeMessages(msgs...)
+}
+
+// publishedIPs returns the IP addresses of the network already published for the domains
+// and, if isDefault is true, in the WAF lists, sorted and without duplicates. It is used to seed
+// [HoldDown] after a restart. Domains with a suffix in [config.Config.IP6Suffixes] are skipped
+// because their addresses are not the detected ones. A range in a WAF list stands for the detected
+// addresses it covers, or for its first address if it covers none of them. Failures are ignored.
+func publishedIPs(ctx context.Context, ppfmt pp.PP, c *config.Config, s setter.Setter,
+	ipNet ipnet.Type, ips []netip.Addr, domains []domain.Domain, isDefault bool,
+) []netip.Addr {
+	var published []netip.Addr
+
+	for _, domain := range domains {
+		if _, ok := c.IP6Suffixes[domain]; ok && ipNet == ipnet.IP6 {
+			continue
+		}
+		ctx, cancel := context.WithTimeoutCause(ctx, c.UpdateTimeout, errTimeout)
+		domainIPs, ok := s.ListIPs(ctx, ppfmt, ipNet, domain, api.RecordParams{
+			TTL:     c.TTL,
+			Proxied: c.Proxied[domain],
+			Comment: c.RecordComment,
+			Tags:    c.RecordTags,
+		})
+		cancel()
+		if ok {
+			published = append(published, domainIPs...)
+		}
+	}
+
+	if isDefault {
+		for _, l := range c.WAFLists {
+			ctx, cancel := context.WithTimeoutCause(ctx, c.UpdateTimeout, errTimeout)
+			prefixes, ok := s.ListWAFList(ctx, ppfmt, l, c.WAFListDescription)
+			cancel()
+			if !ok {
+				continue
+			}
+			for _, prefix := range prefixes {
+				if !ipNet.Matches(prefix.Addr()) {
+					continue
+				}
+				covered := false
+				for _, ip := range ips {
+					if prefix.Contains(ip) {
+						published = append(published, ip)
+						covered = true
+					}
+				}
+				if !covered {
+					published = append(published, prefix.Addr())
+				}
+			}
+		}
+	}
+
+	slices.SortFunc(published, netip.Addr.Compare)
+	return slices.Compact(published)
+}
+
+// setSPFRecords extracts relevant settings from the configuration and calls [setter.Setter.SetSPF] with timeout
+// for each of the domains in [config.Config.SPFDomains]. ips must be non-empty.
+func setSPFRecords(ctx context.Context, ppfmt pp.PP,
+	c *config.Config, s setter.Setter, ipNet ipnet.Type, ips []netip.Addr,
+) Message {
+	resps := emptySetterResponses()
+
+	for _, domain := range c.SPFDomains {
+		resps.register(domain,
+			wrapUpdateWithTimeout(ctx, ppfmt, c, func(ctx context.Context) setter.ResponseCode {
+				return s.SetSPF(ctx, ppfmt, ipNet, domain, ips)
+			}),
+		)
 	}
 
-	return generateUpdateMessage(ipNet, ip, resps)
+	return generateUpdateSPFMessage(ipNet, ips, resps)
 }
 
 // finalDeleteIP extracts relevant settings from the configuration
@@ -110,6 +283,7 @@ func finalDeleteIP(ctx context.Context, ppfmt pp.PP, c *config.Config, s setter.
 					TTL:     c.TTL,
 					Proxied: c.Proxied[domain],
 					Comment: c.RecordComment,
+					Tags:    c.RecordTags,
 				})
 			}),
 		)
@@ -120,14 +294,14 @@ func finalDeleteIP(ctx context.Context, ppfmt pp.PP, c *config.Config, s setter.
 
 // setWAFList extracts relevant settings from the configuration and calls [setter.Setter.SetWAFList] with timeout.
 func setWAFLists(ctx context.Context, ppfmt pp.PP,
-	c *config.Config, s setter.Setter, detectedIP map[ipnet.Type]netip.Addr,
+	c *config.Config, s setter.Setter, detectedIPs map[ipnet.Type][]netip.Addr,
 ) Message {
 	resps := emptySetterWAFListResponses()
 
 	for _, l := range c.WAFLists {
 		resps.register(l.Describe(),
 			wrapUpdateWithTimeout(ctx, ppfmt, c, func(ctx context.Context) setter.ResponseCode {
-				return s.SetWAFList(ctx, ppfmt, l, c.WAFListDescription, detectedIP, "")
+				return s.SetWAFList(ctx, ppfmt, l, c.WAFListDescription, detectedIPs, "")
 			}),
 		)
 	}
@@ -151,24 +325,127 @@ func finalClearWAFLists(ctx context.Context, ppfmt pp.PP, c *config.Config, s se
 	return generateFinalClearWAFListsMessage(resps)
 }
 
+// domainGroup is a group of domains using the same provider.
+type domainGroup struct {
+	provider provider.Provider
+	domains  []domain.Domain
+}
+
+// groupDomains groups the domains of the IP network by their providers (see [config.Config.DomainProviders]),
+// with the default provider first. Each group is identified by its position, not by the name of its provider,
+// because different providers may share the same (redacted) name.
+func groupDomains(c *config.Config, ipNet ipnet.Type) []domainGroup {
+	groups := []domainGroup{{provider: c.Provider[ipNet], domains: nil}}
+
+	custom := map[domain.Domain]bool{}
+	for _, dp := range c.DomainProviders[ipNet] {
+		groups = append(groups, domainGroup{provider: dp.Provider, domains: dp.Domains})
+		for _, dom := range dp.Domains {
+			custom[dom] = true
+		}
+	}
+
+	for _, dom := range c.Domains[ipNet] {
+		if !custom[dom] {
+			groups[0].domains = append(groups[0].domains, dom)
+		}
+	}
+
+	return groups
+}
+
+// detection is the outcome of [detectIPs], kept so that a provider runs at most once in a round.
+type detection struct {
+	ips   []netip.Addr
+	route string
+	ok    bool
+}
+
+// detectionKey identifies the provider of the i-th group (see [groupDomains]) within a round.
+// Providers are identified by their normalized names, so that the same provider written in
+// different ways (or used both as the default provider and in a rule) runs only once.
+// A provider with a secret (such as "url:(redacted)") cannot be identified by its name,
+// and is thus only identified by its group; rules written the same way already share a group.
+func detectionKey(p provider.Provider, i int) string {
+	name := provider.Name(p)
+	if strings.Contains(name, "(redacted)") {
+		return fmt.Sprintf("%s#%d", name, i)
+	}
+	return name
+}
+
 // UpdateIPs detect IP addresses and update DNS records of managed domains.
-func UpdateIPs(ctx context.Context, ppfmt pp.PP, c *config.Config, s setter.Setter) Message {
+// New IP addresses are held down by h according to the configuration, and lasting disagreements
+// among the providers are remembered in d so that they are not notified in every round.
+// After a successful round, the heartbeat TXT record is written via hb if it is enabled.
+func UpdateIPs(ctx context.Context, ppfmt pp.PP, c *config.Config, s setter.Setter,
+	h *HoldDown, d *Disagreements, hb *Heartbeat,
+) Message {
 	var msgs []Message
-	detectedIP := map[ipnet.Type]netip.Addr{}
+	detectedIPs := map[ipnet.Type][]netip.Addr{}  // by the default providers, for WAF lists
+	heartbeatIPs := map[ipnet.Type][]netip.Addr{} // by all providers, for the heartbeat
 	numManagedNetworks := 0
-	numValidIPs := 0
-	for ipNet, provider := range ipnet.Bindings(c.Provider) {
-		if provider != nil {
-			numManagedNetworks++
-			ip, msg := detectIP(ctx, ppfmt, c, ipNet)
-			detectedIP[ipNet] = ip
-			msgs = append(msgs, msg)
+	numValidIPs := 0        // by the default providers
+	numValidDetections := 0 // by all providers
+	for ipNet, p := range ipnet.Bindings(c.Provider) {
+		if p == nil {
+			continue
+		}
+
+		numManagedNetworks++
+		detectedIPs[ipNet] = nil
+
+		// Each provider runs at most once in a round, even when several groups use it.
+		detections := map[string]detection{}
+
+		for i, group := range groupDomains(c, ipNet) {
+			isDefault := i == 0
+
+			// Note: The default provider is still needed for WAF lists and SPF records
+			// even if all domains use other providers.
+			if isDefault && len(group.domains) == 0 && len(c.WAFLists) == 0 && len(c.SPFDomains) == 0 &&
+				len(c.DomainProviders[ipNet]) > 0 {
+				continue
+			}
+
+			key := detectionKey(group.provider, i)
+			result, found := detections[key]
+			if !found {
+				ips, route, msg := detectIPs(ctx, ppfmt, c, d, ipNet, key, group.provider, isDefault)
+				msgs = append(msgs, msg)
+				result = detection{ips: ips, route: route, ok: msg.MonitorMessage.OK}
+				detections[key] = result
+			}
+			ips, route := result.ips, result.route
 
 			// Note: If we can't detect the new IP address,
 			// it's probably better to leave existing records alone.
-			if msg.MonitorMessage.OK {
+			if !result.ok {
+				continue
+			}
+
+			// Note: Pending IP addresses are treated as undetected, so that existing records stay.
+			published := func() []netip.Addr {
+				return publishedIPs(ctx, ppfmt, c, s, ipNet, ips, group.domains, isDefault)
+			}
+			if ok, msg := h.confirm(ppfmt, c, ipNet, i, ips, published, time.Now()); !ok {
+				msgs = append(msgs, msg)
+				continue
+			}
+
+			numValidDetections++
+			for _, ip := range ips {
+				if !slices.Contains(heartbeatIPs[ipNet], ip) {
+					heartbeatIPs[ipNet] = append(heartbeatIPs[ipNet], ip)
+				}
+			}
+			if isDefault {
+				detectedIPs[ipNet] = ips
 				numValidIPs++
-				msgs = append(msgs, setIP(ctx, ppfmt, c, s, ipNet, ip))
+			}
+			msgs = append(msgs, setIPs(ctx, ppfmt, c, s, ipNet, ips, route, group.domains))
+			if isDefault {
+				msgs = append(msgs, setSPFRecords(ctx, ppfmt, c, s, ipNet, ips))
 			}
 		}
 	}
@@ -178,10 +455,16 @@ func UpdateIPs(ctx context.Context, ppfmt pp.PP, c *config.Config, s setter.Sett
 
 	// Update WAF lists
 	if !(numManagedNetworks == 2 && numValidIPs == 0) {
-		msgs = append(msgs, setWAFLists(ctx, ppfmt, c, s, detectedIP))
+		msgs = append(msgs, setWAFLists(ctx, ppfmt, c, s, detectedIPs))
 	}
 
-	return MergeMessages(msgs...)
+	// Write the heartbeat only when everything went well
+	msg := MergeMessages(msgs...)
+	if numValidDetections > 0 && msg.MonitorMessage.OK {
+		msg = MergeMessages(msg, hb.write(ctx, ppfmt, c, s, heartbeatIPs, time.Now()))
+	}
+
+	return msg
 }
 
 // FinalDeleteIPs removes all DNS records of managed domains.
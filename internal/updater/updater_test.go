@@ -3,6 +3,7 @@ package updater_test
 
 import (
 	"context"
+	"fmt"
 	"net/netip"
 	"testing"
 	"time"
@@ -18,6 +19,7 @@ import (
 	"github.com/favonia/cloudflare-ddns/internal/monitor"
 	"github.com/favonia/cloudflare-ddns/internal/notifier"
 	"github.com/favonia/cloudflare-ddns/internal/pp"
+	"github.com/favonia/cloudflare-ddns/internal/provider"
 	"github.com/favonia/cloudflare-ddns/internal/setter"
 	"github.com/favonia/cloudflare-ddns/internal/updater"
 )
@@ -27,10 +29,13 @@ const (
 	wafListDescription string = "hello list"
 )
 
+//nolint:gochecknoglobals
+var recordTags = []string{"owner:ddns"}
+
 type (
 	providerEnablers = map[ipnet.Type]bool
 	mockProviders    = map[ipnet.Type]*mocks.MockProvider
-	detectedIPs      = map[ipnet.Type]netip.Addr
+	detectedIPs      = map[ipnet.Type][]netip.Addr
 )
 
 const (
@@ -55,6 +60,7 @@ func initConfig() *config.Config {
 		domain6:   false,
 	}
 	conf.RecordComment = recordComment
+	conf.RecordTags = recordTags
 	conf.WAFListDescription = wafListDescription
 	conf.DetectionTimeout = time.Second
 	conf.UpdateTimeout = time.Second
@@ -72,6 +78,7 @@ func TestUpdateIPsMultiple(t *testing.T) {
 		TTL:     api.TTLAuto,
 		Proxied: false,
 		Comment: recordComment,
+		Tags:    recordTags,
 	}
 
 	domains := map[ipnet.Type][]domain.Domain{
@@ -85,7 +92,7 @@ func TestUpdateIPsMultiple(t *testing.T) {
 	lists := []api.WAFList{list1, list2, list3, list4}
 
 	ip4 := netip.MustParseAddr("127.0.0.1")
-	type detected = map[ipnet.Type]netip.Addr
+	type detected = map[ipnet.Type][]netip.Addr
 
 	for name, tc := range map[string]struct {
 		ok               bool
@@ -110,14 +117,14 @@ func TestUpdateIPsMultiple(t *testing.T) {
 					pv[ipnet.IP4].EXPECT().GetIP(gomock.Any(), p, ipnet.IP4).Return(ip4, true),
 					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4),
 					p.EXPECT().Suppress(pp.MessageIP4DetectionFails),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello1"), ip4, params).Return(setter.ResponseUpdating),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello2"), ip4, params).Return(setter.ResponseFailed),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello3"), ip4, params).Return(setter.ResponseNoop),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello4"), ip4, params).Return(setter.ResponseUpdated),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list1, wafListDescription, detected{ipnet.IP4: ip4}, "").Return(setter.ResponseUpdating),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list2, wafListDescription, detected{ipnet.IP4: ip4}, "").Return(setter.ResponseFailed),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list3, wafListDescription, detected{ipnet.IP4: ip4}, "").Return(setter.ResponseNoop),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list4, wafListDescription, detected{ipnet.IP4: ip4}, "").Return(setter.ResponseUpdated),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello1"), []netip.Addr{ip4}, params).Return(setter.ResponseUpdating),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello2"), []netip.Addr{ip4}, params).Return(setter.ResponseFailed),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello3"), []netip.Addr{ip4}, params).Return(setter.ResponseNoop),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello4"), []netip.Addr{ip4}, params).Return(setter.ResponseUpdated),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list1, wafListDescription, detected{ipnet.IP4: {ip4}}, "").Return(setter.ResponseUpdating),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list2, wafListDescription, detected{ipnet.IP4: {ip4}}, "").Return(setter.ResponseFailed),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list3, wafListDescription, detected{ipnet.IP4: {ip4}}, "").Return(setter.ResponseNoop),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list4, wafListDescription, detected{ipnet.IP4: {ip4}}, "").Return(setter.ResponseUpdated),
 				)
 			},
 		},
@@ -138,14 +145,14 @@ func TestUpdateIPsMultiple(t *testing.T) {
 					pv[ipnet.IP4].EXPECT().GetIP(gomock.Any(), p, ipnet.IP4).Return(ip4, true),
 					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4),
 					p.EXPECT().Suppress(pp.MessageIP4DetectionFails),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello1"), ip4, params).Return(setter.ResponseUpdated),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello2"), ip4, params).Return(setter.ResponseNoop),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello3"), ip4, params).Return(setter.ResponseUpdated),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello4"), ip4, params).Return(setter.ResponseUpdated),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list1, wafListDescription, detected{ipnet.IP4: ip4}, "").Return(setter.ResponseUpdating),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list2, wafListDescription, detected{ipnet.IP4: ip4}, "").Return(setter.ResponseNoop),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list3, wafListDescription, detected{ipnet.IP4: ip4}, "").Return(setter.ResponseUpdated),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list4, wafListDescription, detected{ipnet.IP4: ip4}, "").Return(setter.ResponseUpdated),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello1"), []netip.Addr{ip4}, params).Return(setter.ResponseUpdated),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello2"), []netip.Addr{ip4}, params).Return(setter.ResponseNoop),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello3"), []netip.Addr{ip4}, params).Return(setter.ResponseUpdated),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello4"), []netip.Addr{ip4}, params).Return(setter.ResponseUpdated),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list1, wafListDescription, detected{ipnet.IP4: {ip4}}, "").Return(setter.ResponseUpdating),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list2, wafListDescription, detected{ipnet.IP4: {ip4}}, "").Return(setter.ResponseNoop),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list3, wafListDescription, detected{ipnet.IP4: {ip4}}, "").Return(setter.ResponseUpdated),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list4, wafListDescription, detected{ipnet.IP4: {ip4}}, "").Return(setter.ResponseUpdated),
 				)
 			},
 		},
@@ -164,6 +171,7 @@ func TestUpdateIPsMultiple(t *testing.T) {
 			mockProviders := make(mockProviders)
 			for ipnet := range tc.providerEnablers {
 				mockProvider := mocks.NewMockProvider(mockCtrl)
+				mockProvider.EXPECT().Name().Return("p").AnyTimes()
 				conf.Provider[ipnet] = mockProvider
 				mockProviders[ipnet] = mockProvider
 			}
@@ -172,7 +180,7 @@ func TestUpdateIPsMultiple(t *testing.T) {
 				tc.prepareMocks(mockPP, mockProviders, mockSetter)
 			}
 
-			resp := updater.UpdateIPs(ctx, mockPP, conf, mockSetter)
+			resp := updater.UpdateIPs(ctx, mockPP, conf, mockSetter, updater.NewHoldDown(), updater.NewDisagreements(), updater.NewHeartbeat(""))
 			require.Equal(t, updater.Message{
 				MonitorMessage: monitor.Message{
 					OK:    tc.ok,
@@ -191,6 +199,7 @@ func TestFinalDeleteIPsMultiple(t *testing.T) {
 		TTL:     api.TTLAuto,
 		Proxied: false,
 		Comment: recordComment,
+		Tags:    recordTags,
 	}
 
 	domains := map[ipnet.Type][]domain.Domain{
@@ -293,6 +302,7 @@ func TestUpdateIPs(t *testing.T) {
 		TTL:     api.TTLAuto,
 		Proxied: false,
 		Comment: recordComment,
+		Tags:    recordTags,
 	}
 
 	domains := map[ipnet.Type][]domain.Domain{
@@ -320,8 +330,8 @@ func TestUpdateIPs(t *testing.T) {
 					pv[ipnet.IP4].EXPECT().GetIP(gomock.Any(), p, ipnet.IP4).Return(ip4, true),
 					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4),
 					p.EXPECT().Suppress(pp.MessageIP4DetectionFails),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), ip4, params).Return(setter.ResponseNoop),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: ip4}, "").Return(setter.ResponseNoop),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), []netip.Addr{ip4}, params).Return(setter.ResponseNoop),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: {ip4}}, "").Return(setter.ResponseNoop),
 				)
 			},
 		},
@@ -335,8 +345,8 @@ func TestUpdateIPs(t *testing.T) {
 					pv[ipnet.IP4].EXPECT().GetIP(gomock.Any(), p, ipnet.IP4).Return(ip4, true),
 					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4),
 					p.EXPECT().Suppress(pp.MessageIP4DetectionFails),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), ip4, params).Return(setter.ResponseFailed),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: ip4}, "").Return(setter.ResponseFailed),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), []netip.Addr{ip4}, params).Return(setter.ResponseFailed),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: {ip4}}, "").Return(setter.ResponseFailed),
 				)
 			},
 		},
@@ -350,8 +360,8 @@ func TestUpdateIPs(t *testing.T) {
 					pv[ipnet.IP4].EXPECT().GetIP(gomock.Any(), p, ipnet.IP4).Return(ip4, true),
 					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4),
 					p.EXPECT().Suppress(pp.MessageIP4DetectionFails),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), ip4, params).Return(setter.ResponseUpdating),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: ip4}, "").Return(setter.ResponseUpdating),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), []netip.Addr{ip4}, params).Return(setter.ResponseUpdating),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: {ip4}}, "").Return(setter.ResponseUpdating),
 				)
 			},
 		},
@@ -365,8 +375,8 @@ func TestUpdateIPs(t *testing.T) {
 					pv[ipnet.IP6].EXPECT().GetIP(gomock.Any(), p, ipnet.IP6).Return(ip6, true),
 					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv6", ip6),
 					p.EXPECT().Suppress(pp.MessageIP6DetectionFails),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP6, domain.FQDN("ip6.hello"), ip6, params).Return(setter.ResponseUpdated),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP6: ip6}, "").Return(setter.ResponseUpdated),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP6, domain.FQDN("ip6.hello"), []netip.Addr{ip6}, params).Return(setter.ResponseUpdated),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP6: {ip6}}, "").Return(setter.ResponseUpdated),
 				)
 			},
 		},
@@ -380,8 +390,8 @@ func TestUpdateIPs(t *testing.T) {
 					pv[ipnet.IP6].EXPECT().GetIP(gomock.Any(), p, ipnet.IP6).Return(ip6, true),
 					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv6", ip6),
 					p.EXPECT().Suppress(pp.MessageIP6DetectionFails),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP6, domain.FQDN("ip6.hello"), ip6, params).Return(setter.ResponseFailed),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP6: ip6}, "").Return(setter.ResponseFailed),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP6, domain.FQDN("ip6.hello"), []netip.Addr{ip6}, params).Return(setter.ResponseFailed),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP6: {ip6}}, "").Return(setter.ResponseFailed),
 				)
 			},
 		},
@@ -393,12 +403,12 @@ func TestUpdateIPs(t *testing.T) {
 					pv[ipnet.IP4].EXPECT().GetIP(gomock.Any(), p, ipnet.IP4).Return(ip4, true),
 					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4),
 					p.EXPECT().Suppress(pp.MessageIP4DetectionFails),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), ip4, params).Return(setter.ResponseNoop),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), []netip.Addr{ip4}, params).Return(setter.ResponseNoop),
 					pv[ipnet.IP6].EXPECT().GetIP(gomock.Any(), p, ipnet.IP6).Return(ip6, true),
 					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv6", ip6),
 					p.EXPECT().Suppress(pp.MessageIP6DetectionFails),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP6, domain.FQDN("ip6.hello"), ip6, params).Return(setter.ResponseNoop),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: ip4, ipnet.IP6: ip6}, "").Return(setter.ResponseNoop),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP6, domain.FQDN("ip6.hello"), []netip.Addr{ip6}, params).Return(setter.ResponseNoop),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: {ip4}, ipnet.IP6: {ip6}}, "").Return(setter.ResponseNoop),
 				)
 			},
 		},
@@ -412,12 +422,12 @@ func TestUpdateIPs(t *testing.T) {
 					pv[ipnet.IP4].EXPECT().GetIP(gomock.Any(), p, ipnet.IP4).Return(ip4, true),
 					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4),
 					p.EXPECT().Suppress(pp.MessageIP4DetectionFails),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), ip4, params).Return(setter.ResponseFailed),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), []netip.Addr{ip4}, params).Return(setter.ResponseFailed),
 					pv[ipnet.IP6].EXPECT().GetIP(gomock.Any(), p, ipnet.IP6).Return(ip6, true),
 					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv6", ip6),
 					p.EXPECT().Suppress(pp.MessageIP6DetectionFails),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP6, domain.FQDN("ip6.hello"), ip6, params).Return(setter.ResponseNoop),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: ip4, ipnet.IP6: ip6}, "").Return(setter.ResponseNoop),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP6, domain.FQDN("ip6.hello"), []netip.Addr{ip6}, params).Return(setter.ResponseNoop),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: {ip4}, ipnet.IP6: {ip6}}, "").Return(setter.ResponseNoop),
 				)
 			},
 		},
@@ -431,12 +441,12 @@ func TestUpdateIPs(t *testing.T) {
 					pv[ipnet.IP4].EXPECT().GetIP(gomock.Any(), p, ipnet.IP4).Return(ip4, true),
 					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4),
 					p.EXPECT().Suppress(pp.MessageIP4DetectionFails),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), ip4, params).Return(setter.ResponseNoop),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), []netip.Addr{ip4}, params).Return(setter.ResponseNoop),
 					pv[ipnet.IP6].EXPECT().GetIP(gomock.Any(), p, ipnet.IP6).Return(ip6, true),
 					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv6", ip6),
 					p.EXPECT().Suppress(pp.MessageIP6DetectionFails),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP6, domain.FQDN("ip6.hello"), ip6, params).Return(setter.ResponseFailed),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: ip4, ipnet.IP6: ip6}, "").Return(setter.ResponseNoop),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP6, domain.FQDN("ip6.hello"), []netip.Addr{ip6}, params).Return(setter.ResponseFailed),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: {ip4}, ipnet.IP6: {ip6}}, "").Return(setter.ResponseNoop),
 				)
 			},
 		},
@@ -450,12 +460,12 @@ func TestUpdateIPs(t *testing.T) {
 					pv[ipnet.IP4].EXPECT().GetIP(gomock.Any(), p, ipnet.IP4).Return(ip4, true),
 					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4),
 					p.EXPECT().Suppress(pp.MessageIP4DetectionFails),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), ip4, params).Return(setter.ResponseNoop),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), []netip.Addr{ip4}, params).Return(setter.ResponseNoop),
 					pv[ipnet.IP6].EXPECT().GetIP(gomock.Any(), p, ipnet.IP6).Return(ip6, true),
 					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv6", ip6),
 					p.EXPECT().Suppress(pp.MessageIP6DetectionFails),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP6, domain.FQDN("ip6.hello"), ip6, params).Return(setter.ResponseNoop),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: ip4, ipnet.IP6: ip6}, "").Return(setter.ResponseFailed),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP6, domain.FQDN("ip6.hello"), []netip.Addr{ip6}, params).Return(setter.ResponseNoop),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: {ip4}, ipnet.IP6: {ip6}}, "").Return(setter.ResponseFailed),
 				)
 			},
 		},
@@ -472,8 +482,8 @@ func TestUpdateIPs(t *testing.T) {
 					pv[ipnet.IP6].EXPECT().GetIP(gomock.Any(), p, ipnet.IP6).Return(ip6, true),
 					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv6", ip6),
 					p.EXPECT().Suppress(pp.MessageIP6DetectionFails),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP6, domain.FQDN("ip6.hello"), ip6, params).Return(setter.ResponseNoop),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: netip.Addr{}, ipnet.IP6: ip6}, ""),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP6, domain.FQDN("ip6.hello"), []netip.Addr{ip6}, params).Return(setter.ResponseNoop),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: nil, ipnet.IP6: {ip6}}, ""),
 				)
 			},
 		},
@@ -487,11 +497,11 @@ func TestUpdateIPs(t *testing.T) {
 					pv[ipnet.IP4].EXPECT().GetIP(gomock.Any(), p, ipnet.IP4).Return(ip4, true),
 					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4),
 					p.EXPECT().Suppress(pp.MessageIP4DetectionFails),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), ip4, params).Return(setter.ResponseNoop),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), []netip.Addr{ip4}, params).Return(setter.ResponseNoop),
 					pv[ipnet.IP6].EXPECT().GetIP(gomock.Any(), p, ipnet.IP6).Return(netip.Addr{}, false),
 					p.EXPECT().Noticef(pp.EmojiError, "Failed to detect the %s address", "IPv6"),
 					hintIP6DetectionFails(p),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: ip4, ipnet.IP6: netip.Addr{}}, ""),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: {ip4}, ipnet.IP6: nil}, ""),
 				)
 			},
 		},
@@ -527,7 +537,7 @@ func TestUpdateIPs(t *testing.T) {
 					p.EXPECT().Noticef(pp.EmojiError, "Failed to detect the %s address", "IPv4"),
 					p.EXPECT().NoticeOncef(pp.MessageIP4DetectionFails, pp.EmojiHint, "If your network does not support IPv4, you can disable it with IP4_PROVIDER=none"),
 					p.EXPECT().NoticeOncef(pp.MessageDetectionTimeouts, pp.EmojiHint, "If your network is experiencing high latency, consider increasing DETECTION_TIMEOUT=%v", time.Second),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: netip.Addr{}}, "").Return(setter.ResponseNoop),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: nil}, "").Return(setter.ResponseNoop),
 				)
 			},
 		},
@@ -541,13 +551,13 @@ func TestUpdateIPs(t *testing.T) {
 					pv[ipnet.IP4].EXPECT().GetIP(gomock.Any(), p, ipnet.IP4).Return(ip4, true),
 					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4),
 					p.EXPECT().Suppress(pp.MessageIP4DetectionFails),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), ip4, params).DoAndReturn(
-						func(context.Context, pp.PP, ipnet.Type, domain.Domain, netip.Addr, api.RecordParams) setter.ResponseCode {
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), []netip.Addr{ip4}, params).DoAndReturn(
+						func(context.Context, pp.PP, ipnet.Type, domain.Domain, []netip.Addr, api.RecordParams) setter.ResponseCode {
 							time.Sleep(2 * time.Second)
 							return setter.ResponseFailed
 						}),
 					p.EXPECT().NoticeOncef(pp.MessageUpdateTimeouts, pp.EmojiHint, "If your network is experiencing high latency, consider increasing UPDATE_TIMEOUT=%v", time.Second),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: ip4}, "").Return(setter.ResponseNoop),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: {ip4}}, "").Return(setter.ResponseNoop),
 				)
 			},
 		},
@@ -561,8 +571,8 @@ func TestUpdateIPs(t *testing.T) {
 					pv[ipnet.IP4].EXPECT().GetIP(gomock.Any(), p, ipnet.IP4).Return(ip4, true),
 					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4),
 					p.EXPECT().Suppress(pp.MessageIP4DetectionFails),
-					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), ip4, params).Return(setter.ResponseNoop),
-					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: ip4}, "").DoAndReturn(
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain.FQDN("ip4.hello"), []netip.Addr{ip4}, params).Return(setter.ResponseNoop),
+					s.EXPECT().SetWAFList(gomock.Any(), p, list, wafListDescription, detectedIPs{ipnet.IP4: {ip4}}, "").DoAndReturn(
 						func(context.Context, pp.PP, api.WAFList, string, detectedIPs, string) setter.ResponseCode {
 							time.Sleep(2 * time.Second)
 							return setter.ResponseFailed
@@ -587,6 +597,7 @@ func TestUpdateIPs(t *testing.T) {
 			mockProviders := make(mockProviders)
 			for ipnet := range tc.providerEnablers {
 				mockProvider := mocks.NewMockProvider(mockCtrl)
+				mockProvider.EXPECT().Name().Return("p").AnyTimes()
 				conf.Provider[ipnet] = mockProvider
 				mockProviders[ipnet] = mockProvider
 			}
@@ -594,7 +605,90 @@ func TestUpdateIPs(t *testing.T) {
 			if tc.prepareMocks != nil {
 				tc.prepareMocks(mockPP, mockProviders, mockSetter)
 			}
-			resp := updater.UpdateIPs(ctx, mockPP, conf, mockSetter)
+			resp := updater.UpdateIPs(ctx, mockPP, conf, mockSetter, updater.NewHoldDown(), updater.NewDisagreements(), updater.NewHeartbeat(""))
+			require.Equal(t, updater.Message{
+				MonitorMessage: monitor.Message{
+					OK:    tc.ok,
+					Lines: tc.monitorMessages,
+				},
+				NotifierMessage: notifier.Message(tc.notifierMessages),
+			}, resp)
+		})
+	}
+}
+
+func TestUpdateIPsWithNotes(t *testing.T) {
+	t.Parallel()
+
+	params := api.RecordParams{
+		TTL:     api.TTLAuto,
+		Proxied: false,
+		Comment: recordComment,
+		Tags:    recordTags,
+	}
+
+	ip4 := netip.MustParseAddr("127.0.0.1")
+	ip4Other := netip.MustParseAddr("127.0.0.2")
+
+	for name, tc := range map[string]struct {
+		votes            []netip.Addr
+		ok               bool
+		monitorMessages  []string
+		notifierMessages []string
+		prepareMocks     func(*mocks.MockPP, *mocks.MockSetter)
+	}{
+		"disagreement": {
+			[]netip.Addr{ip4, ip4Other, ip4},
+			true,
+			[]string{"Detected IPv4 address 127.0.0.1 (p1 detected 127.0.0.2)"},
+			[]string{"Detected the IPv4 address 127.0.0.1 (p1 detected 127.0.0.2)."},
+			func(p *mocks.MockPP, s *mocks.MockSetter) {
+				gomock.InOrder(
+					p.EXPECT().Infof(pp.EmojiWarning, "Only %d out of %d providers agreed on the %s address %s (%s)", 2, 3, "IPv4", "127.0.0.1", "p1 detected 127.0.0.2"),
+					p.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4),
+					p.EXPECT().Suppress(pp.MessageIP4DetectionFails),
+					s.EXPECT().Set(gomock.Any(), p, ipnet.IP4, domain4, []netip.Addr{ip4}, params).Return(setter.ResponseNoop),
+				)
+			},
+		},
+		"no-quorum": {
+			[]netip.Addr{ip4, ip4Other, {}},
+			false,
+			[]string{"Failed to detect IPv4 address (p0 detected 127.0.0.1; p1 detected 127.0.0.2; p2 failed)"},
+			[]string{"Failed to detect the IPv4 address (p0 detected 127.0.0.1; p1 detected 127.0.0.2; p2 failed)."},
+			func(p *mocks.MockPP, _ *mocks.MockSetter) {
+				gomock.InOrder(
+					p.EXPECT().Noticef(pp.EmojiError, "Failed to reach a quorum of %d out of %d providers on the %s address", 2, 3, "IPv4"),
+					p.EXPECT().Noticef(pp.EmojiError, "Failed to detect the %s address", "IPv4"),
+					p.EXPECT().NoticeOncef(pp.MessageIP4DetectionFails, pp.EmojiHint, "If your network does not support IPv4, you can disable it with IP4_PROVIDER=none"),
+				)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			ctx := context.Background()
+
+			providers := make([]provider.Provider, 0, len(tc.votes))
+			for i, ip := range tc.votes {
+				mockProvider := mocks.NewMockProvider(mockCtrl)
+				mockProvider.EXPECT().Name().Return(fmt.Sprintf("p%d", i)).AnyTimes()
+				mockProvider.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(ip, ip.IsValid())
+				providers = append(providers, mockProvider)
+			}
+
+			conf := initConfig()
+			conf.Domains = map[ipnet.Type][]domain.Domain{ipnet.IP4: {domain4}}
+			conf.Provider[ipnet.IP4] = provider.MustNewQuorum(0, providers)
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockSetter := mocks.NewMockSetter(mockCtrl)
+			if tc.prepareMocks != nil {
+				tc.prepareMocks(mockPP, mockSetter)
+			}
+			resp := updater.UpdateIPs(ctx, mockPP, conf, mockSetter, updater.NewHoldDown(), updater.NewDisagreements(), updater.NewHeartbeat(""))
 			require.Equal(t, updater.Message{
 				MonitorMessage: monitor.Message{
 					OK:    tc.ok,
@@ -606,6 +700,61 @@ func TestUpdateIPs(t *testing.T) {
 	}
 }
 
+func TestUpdateIPsWithLastingDisagreement(t *testing.T) {
+	t.Parallel()
+
+	ip4 := netip.MustParseAddr("127.0.0.1")
+	ip4Other := netip.MustParseAddr("127.0.0.2")
+	ip4Another := netip.MustParseAddr("127.0.0.3")
+
+	mockCtrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	providers := make([]provider.Provider, 0, 3)
+	mockProviders := make([]*mocks.MockProvider, 0, 3)
+	for i := range 3 {
+		mockProvider := mocks.NewMockProvider(mockCtrl)
+		mockProvider.EXPECT().Name().Return(fmt.Sprintf("p%d", i)).AnyTimes()
+		providers = append(providers, mockProvider)
+		mockProviders = append(mockProviders, mockProvider)
+	}
+
+	conf := initConfig()
+	conf.Domains = map[ipnet.Type][]domain.Domain{ipnet.IP4: {domain4}}
+	conf.Provider[ipnet.IP4] = provider.MustNewQuorum(0, providers)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Infof(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	mockPP.EXPECT().Suppress(pp.MessageIP4DetectionFails).AnyTimes()
+	mockSetter := mocks.NewMockSetter(mockCtrl)
+	mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domain4, []netip.Addr{ip4}, gomock.Any()).
+		Return(setter.ResponseNoop).AnyTimes()
+
+	disagreements := updater.NewDisagreements()
+	for round, tc := range []struct {
+		votes    []netip.Addr
+		notifier notifier.Message
+	}{
+		{[]netip.Addr{ip4, ip4Other, ip4}, notifier.Message{"Detected the IPv4 address 127.0.0.1 (p1 detected 127.0.0.2)."}},
+		// The same provider keeps disagreeing, which should not be notified again.
+		{[]netip.Addr{ip4, ip4Another, ip4}, notifier.NewMessage()},
+		{[]netip.Addr{ip4, ip4Other, ip4}, notifier.NewMessage()},
+		// A different provider disagrees.
+		{[]netip.Addr{ip4, ip4, ip4Other}, notifier.Message{"Detected the IPv4 address 127.0.0.1 (p2 detected 127.0.0.2)."}},
+		// Everyone agrees again.
+		{[]netip.Addr{ip4, ip4, ip4}, notifier.NewMessage()},
+		{[]netip.Addr{ip4, ip4, ip4Other}, notifier.Message{"Detected the IPv4 address 127.0.0.1 (p2 detected 127.0.0.2)."}},
+	} {
+		for i, ip := range tc.votes {
+			mockProviders[i].EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(ip, true)
+		}
+
+		resp := updater.UpdateIPs(ctx, mockPP, conf, mockSetter, updater.NewHoldDown(), disagreements, updater.NewHeartbeat(""))
+		require.True(t, resp.MonitorMessage.OK, "round %d", round)
+		require.Equal(t, tc.notifier, resp.NotifierMessage, "round %d", round)
+	}
+}
+
 func TestFinalDeleteIPs(t *testing.T) {
 	t.Parallel()
 
@@ -613,6 +762,7 @@ func TestFinalDeleteIPs(t *testing.T) {
 		TTL:     api.TTLAuto,
 		Proxied: false,
 		Comment: recordComment,
+		Tags:    recordTags,
 	}
 
 	domains := map[ipnet.Type][]domain.Domain{
@@ -830,3 +980,887 @@ func TestFinalDeleteIPs(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateIPsWithIP6Suffixes(t *testing.T) {
+	t.Parallel()
+
+	params := api.RecordParams{
+		TTL:     api.TTLAuto,
+		Proxied: false,
+		Comment: recordComment,
+		Tags:    recordTags,
+	}
+
+	const domainNAS = domain.FQDN("nas.hello")
+	ip6 := netip.MustParseAddr("2001:db8::1")
+	ip6NAS := netip.MustParseAddr("2001:db8::1:2:3:4")
+
+	mockCtrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	mockProvider := mocks.NewMockProvider(mockCtrl)
+	mockProvider.EXPECT().Name().Return("p").AnyTimes()
+	mockProvider.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP6).Return(ip6, true)
+
+	conf := initConfig()
+	conf.Domains = map[ipnet.Type][]domain.Domain{ipnet.IP6: {domain6, domainNAS}}
+	conf.IP6Suffixes = map[domain.Domain]netip.Prefix{domainNAS: netip.MustParsePrefix("::1:2:3:4/64")}
+	conf.Provider[ipnet.IP6] = mockProvider
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockSetter := mocks.NewMockSetter(mockCtrl)
+	gomock.InOrder(
+		mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv6", ip6),
+		mockPP.EXPECT().Suppress(pp.MessageIP6DetectionFails),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP6, domain6, []netip.Addr{ip6}, params).Return(setter.ResponseUpdated),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP6, domainNAS, []netip.Addr{ip6NAS}, params).Return(setter.ResponseUpdated),
+	)
+
+	resp := updater.UpdateIPs(ctx, mockPP, conf, mockSetter, updater.NewHoldDown(), updater.NewDisagreements(), updater.NewHeartbeat(""))
+	require.Equal(t, updater.Message{
+		MonitorMessage: monitor.Message{
+			OK: true,
+			Lines: []string{
+				"Set AAAA (2001:db8::1) of ip6.hello",
+				"Set AAAA (2001:db8::1:2:3:4) of nas.hello",
+			},
+		},
+		NotifierMessage: notifier.Message{
+			"Updated AAAA records of ip6.hello with 2001:db8::1.",
+			"Updated AAAA records of nas.hello with 2001:db8::1:2:3:4.",
+		},
+	}, resp)
+}
+
+func TestUpdateIPsWithDomainProviders(t *testing.T) {
+	t.Parallel()
+
+	params := api.RecordParams{
+		TTL:     api.TTLAuto,
+		Proxied: false,
+		Comment: recordComment,
+		Tags:    recordTags,
+	}
+
+	const (
+		domainLAN = domain.FQDN("lan.hello")
+		domainNAS = domain.FQDN("nas.hello")
+	)
+	ip4 := netip.MustParseAddr("1.1.1.1")
+	ip4LAN := netip.MustParseAddr("192.168.1.1")
+
+	mockCtrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	mockProvider := mocks.NewMockProvider(mockCtrl)
+	mockProvider.EXPECT().Name().Return("p").AnyTimes()
+	mockProviderLAN := mocks.NewMockProvider(mockCtrl)
+	mockProviderLAN.EXPECT().Name().Return("lan").AnyTimes()
+
+	conf := initConfig()
+	conf.Domains = map[ipnet.Type][]domain.Domain{ipnet.IP4: {domain4, domainLAN, domainNAS}}
+	conf.Provider[ipnet.IP4] = mockProvider
+	conf.DomainProviders = map[ipnet.Type][]config.DomainProvider{
+		ipnet.IP4: {{Provider: mockProviderLAN, Domains: []domain.Domain{domainLAN, domainNAS}}},
+	}
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockSetter := mocks.NewMockSetter(mockCtrl)
+	gomock.InOrder(
+		mockProvider.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(ip4, true),
+		mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4),
+		mockPP.EXPECT().Suppress(pp.MessageIP4DetectionFails),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domain4, []netip.Addr{ip4}, params).Return(setter.ResponseUpdated),
+		mockProviderLAN.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(ip4LAN, true),
+		mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4LAN),
+		mockPP.EXPECT().Suppress(pp.MessageIP4DetectionFails),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domainLAN, []netip.Addr{ip4LAN}, params).Return(setter.ResponseUpdated),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domainNAS, []netip.Addr{ip4LAN}, params).Return(setter.ResponseUpdated),
+	)
+
+	resp := updater.UpdateIPs(ctx, mockPP, conf, mockSetter, updater.NewHoldDown(), updater.NewDisagreements(), updater.NewHeartbeat(""))
+	require.Equal(t, updater.Message{
+		MonitorMessage: monitor.Message{
+			OK: true,
+			Lines: []string{
+				"Set A (1.1.1.1) of ip4.hello",
+				"Set A (192.168.1.1) of lan.hello, nas.hello",
+			},
+		},
+		NotifierMessage: notifier.Message{
+			"Updated A records of ip4.hello with 1.1.1.1.",
+			"Updated A records of lan.hello and nas.hello with 192.168.1.1.",
+		},
+	}, resp)
+}
+
+func TestUpdateIPsWithDomainProvidersOfSameName(t *testing.T) {
+	t.Parallel()
+
+	params := api.RecordParams{
+		TTL:     api.TTLAuto,
+		Proxied: false,
+		Comment: recordComment,
+		Tags:    recordTags,
+	}
+
+	const (
+		domainWAN1 = domain.FQDN("wan1.hello")
+		domainWAN2 = domain.FQDN("wan2.hello")
+	)
+	ip4WAN1 := netip.MustParseAddr("1.1.1.1")
+	ip4WAN2 := netip.MustParseAddr("2.2.2.2")
+
+	mockCtrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	// Two different url: providers share the same redacted name.
+	mockProviderWAN1 := mocks.NewMockProvider(mockCtrl)
+	mockProviderWAN1.EXPECT().Name().Return("url:(redacted)").AnyTimes()
+	mockProviderWAN2 := mocks.NewMockProvider(mockCtrl)
+	mockProviderWAN2.EXPECT().Name().Return("url:(redacted)").AnyTimes()
+
+	conf := initConfig()
+	conf.Domains = map[ipnet.Type][]domain.Domain{ipnet.IP4: {domainWAN1, domainWAN2}}
+	conf.Provider[ipnet.IP4] = mockProviderWAN1
+	conf.DomainProviders = map[ipnet.Type][]config.DomainProvider{
+		ipnet.IP4: {{Provider: mockProviderWAN2, Domains: []domain.Domain{domainWAN2}}},
+	}
+	conf.HoldDownRounds = 2
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockSetter := mocks.NewMockSetter(mockCtrl)
+	gomock.InOrder(
+		mockProviderWAN1.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(ip4WAN1, true),
+		mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4WAN1),
+		mockPP.EXPECT().Suppress(pp.MessageIP4DetectionFails),
+		mockSetter.EXPECT().ListIPs(gomock.Any(), mockPP, ipnet.IP4, domainWAN1, params).Return(nil, true),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domainWAN1, []netip.Addr{ip4WAN1}, params).Return(setter.ResponseUpdated),
+		mockProviderWAN2.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(ip4WAN2, true),
+		mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4WAN2),
+		mockPP.EXPECT().Suppress(pp.MessageIP4DetectionFails),
+		mockSetter.EXPECT().ListIPs(gomock.Any(), mockPP, ipnet.IP4, domainWAN2, params).Return(nil, true),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domainWAN2, []netip.Addr{ip4WAN2}, params).Return(setter.ResponseUpdated),
+	)
+
+	// The hold-down state is kept for each provider, so that the address of wan2
+	// is not mistaken as a change of the address of wan1.
+	resp := updater.UpdateIPs(ctx, mockPP, conf, mockSetter, updater.NewHoldDown(), updater.NewDisagreements(), updater.NewHeartbeat(""))
+	require.Equal(t, updater.Message{
+		MonitorMessage: monitor.Message{
+			OK: true,
+			Lines: []string{
+				"Set A (1.1.1.1) of wan1.hello",
+				"Set A (2.2.2.2) of wan2.hello",
+			},
+		},
+		NotifierMessage: notifier.Message{
+			"Updated A records of wan1.hello with 1.1.1.1.",
+			"Updated A records of wan2.hello with 2.2.2.2.",
+		},
+	}, resp)
+}
+
+func TestUpdateIPsWithDomainProvidersSharingDetection(t *testing.T) {
+	t.Parallel()
+
+	params := api.RecordParams{
+		TTL:     api.TTLAuto,
+		Proxied: false,
+		Comment: recordComment,
+		Tags:    recordTags,
+	}
+
+	const (
+		domainTrace = domain.FQDN("trace.hello")
+		domainWAN1  = domain.FQDN("wan1.hello")
+		domainWAN2  = domain.FQDN("wan2.hello")
+	)
+	ip4 := netip.MustParseAddr("1.1.1.1")
+	ip4WAN1 := netip.MustParseAddr("2.2.2.2")
+	ip4WAN2 := netip.MustParseAddr("3.3.3.3")
+
+	mockCtrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	// The rule uses the default provider written in a different way, so it should not run again.
+	mockProvider := mocks.NewMockProvider(mockCtrl)
+	mockProvider.EXPECT().Name().Return("cloudflare.trace").AnyTimes()
+	mockProviderTrace := mocks.NewMockProvider(mockCtrl)
+	mockProviderTrace.EXPECT().Name().Return("cloudflare.trace").AnyTimes()
+	// Providers with secrets cannot be told apart by their names, so both should run.
+	mockProviderWAN1 := mocks.NewMockProvider(mockCtrl)
+	mockProviderWAN1.EXPECT().Name().Return("url:(redacted)").AnyTimes()
+	mockProviderWAN2 := mocks.NewMockProvider(mockCtrl)
+	mockProviderWAN2.EXPECT().Name().Return("url:(redacted)").AnyTimes()
+
+	conf := initConfig()
+	conf.Domains = map[ipnet.Type][]domain.Domain{ipnet.IP4: {domain4, domainTrace, domainWAN1, domainWAN2}}
+	conf.Provider[ipnet.IP4] = mockProvider
+	conf.DomainProviders = map[ipnet.Type][]config.DomainProvider{
+		ipnet.IP4: {
+			{Provider: mockProviderTrace, Domains: []domain.Domain{domainTrace}},
+			{Provider: mockProviderWAN1, Domains: []domain.Domain{domainWAN1}},
+			{Provider: mockProviderWAN2, Domains: []domain.Domain{domainWAN2}},
+		},
+	}
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockSetter := mocks.NewMockSetter(mockCtrl)
+	gomock.InOrder(
+		mockProvider.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(ip4, true),
+		mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4),
+		mockPP.EXPECT().Suppress(pp.MessageIP4DetectionFails),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domain4, []netip.Addr{ip4}, params).Return(setter.ResponseNoop),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domainTrace, []netip.Addr{ip4}, params).Return(setter.ResponseNoop),
+		mockProviderWAN1.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(ip4WAN1, true),
+		mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4WAN1),
+		mockPP.EXPECT().Suppress(pp.MessageIP4DetectionFails),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domainWAN1, []netip.Addr{ip4WAN1}, params).Return(setter.ResponseNoop),
+		mockProviderWAN2.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(ip4WAN2, true),
+		mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4WAN2),
+		mockPP.EXPECT().Suppress(pp.MessageIP4DetectionFails),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domainWAN2, []netip.Addr{ip4WAN2}, params).Return(setter.ResponseNoop),
+	)
+
+	resp := updater.UpdateIPs(ctx, mockPP, conf, mockSetter, updater.NewHoldDown(), updater.NewDisagreements(), updater.NewHeartbeat(""))
+	require.Equal(t, updater.NewMessage(), resp)
+}
+
+// multiProvider is a [provider.MultiProvider] detecting fixed IP addresses.
+type multiProvider []netip.Addr
+
+func (multiProvider) Name() string { return "multi" }
+
+func (m multiProvider) GetIP(context.Context, pp.PP, ipnet.Type) (netip.Addr, bool) {
+	return m[0], true
+}
+
+func (m multiProvider) GetIPs(context.Context, pp.PP, ipnet.Type) ([]netip.Addr, bool) {
+	return m, true
+}
+
+func TestUpdateIPsWithMultipleIPs(t *testing.T) {
+	t.Parallel()
+
+	params := api.RecordParams{
+		TTL:     api.TTLAuto,
+		Proxied: false,
+		Comment: recordComment,
+		Tags:    recordTags,
+	}
+
+	const domainNAS = domain.FQDN("nas.hello")
+	ip6s := []netip.Addr{netip.MustParseAddr("2001:db8::1"), netip.MustParseAddr("2001:db8:1::1")}
+	ip6sNAS := []netip.Addr{netip.MustParseAddr("2001:db8::1:2:3:4"), netip.MustParseAddr("2001:db8:1::1:2:3:4")}
+
+	mockCtrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	conf := initConfig()
+	conf.Domains = map[ipnet.Type][]domain.Domain{ipnet.IP6: {domain6, domainNAS}}
+	conf.IP6Suffixes = map[domain.Domain]netip.Prefix{domainNAS: netip.MustParsePrefix("::1:2:3:4/64")}
+	conf.Provider[ipnet.IP6] = multiProvider{ip6s[0], ip6s[1], netip.MustParseAddr("fd00::1")}
+	conf.AllowedIPs = map[ipnet.Type]ipnet.Policy{ipnet.IP6: {Public: false, Prefixes: []netip.Prefix{netip.MustParsePrefix("2001:db8::/32")}}}
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockSetter := mocks.NewMockSetter(mockCtrl)
+	gomock.InOrder(
+		mockPP.EXPECT().Noticef(pp.EmojiError, "Detected %s address %s is not allowed by IP%d_ALLOWED=%s", "IPv6", "fd00::1", 6, "2001:db8::/32"),
+		mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s addresses %s", "IPv6", "2001:db8::1, 2001:db8:1::1"),
+		mockPP.EXPECT().Suppress(pp.MessageIP6DetectionFails),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP6, domain6, ip6s, params).Return(setter.ResponseUpdated),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP6, domainNAS, ip6sNAS, params).Return(setter.ResponseNoop),
+	)
+
+	resp := updater.UpdateIPs(ctx, mockPP, conf, mockSetter, updater.NewHoldDown(), updater.NewDisagreements(), updater.NewHeartbeat(""))
+	require.Equal(t, updater.Message{
+		MonitorMessage: monitor.Message{
+			OK:    true,
+			Lines: []string{"Set AAAA (2001:db8::1, 2001:db8:1::1) of ip6.hello"},
+		},
+		NotifierMessage: notifier.Message{"Updated AAAA records of ip6.hello with 2001:db8::1 and 2001:db8:1::1."},
+	}, resp)
+}
+
+// metadataProvider is a [provider.MetadataProvider] detecting a fixed IP address.
+type metadataProvider netip.Addr
+
+func (metadataProvider) Name() string { return "metadata" }
+
+func (m metadataProvider) GetIP(context.Context, pp.PP, ipnet.Type) (netip.Addr, bool) {
+	return netip.Addr(m), true
+}
+
+func (m metadataProvider) GetIPWithMetadata(context.Context, pp.PP, ipnet.Type) (netip.Addr, provider.Metadata, bool) {
+	return netip.Addr(m), provider.Metadata{"colo": "SJC", "loc": "US", "warp": "off", "tls": "TLSv1.3"}, true
+}
+
+func TestUpdateIPsWithMetadata(t *testing.T) {
+	t.Parallel()
+
+	params := api.RecordParams{
+		TTL:     api.TTLAuto,
+		Proxied: false,
+		Comment: recordComment,
+		Tags:    recordTags,
+	}
+
+	ip4 := netip.MustParseAddr("127.0.0.1")
+
+	mockCtrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	conf := initConfig()
+	conf.Domains = map[ipnet.Type][]domain.Domain{ipnet.IP4: {domain4}}
+	conf.Provider[ipnet.IP4] = metadataProvider(ip4)
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockSetter := mocks.NewMockSetter(mockCtrl)
+	gomock.InOrder(
+		mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v (%s)", "IPv4", ip4, "via SJC, US, warp=off"),
+		mockPP.EXPECT().Suppress(pp.MessageIP4DetectionFails),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domain4, []netip.Addr{ip4}, params).Return(setter.ResponseUpdated),
+	)
+
+	resp := updater.UpdateIPs(ctx, mockPP, conf, mockSetter, updater.NewHoldDown(), updater.NewDisagreements(), updater.NewHeartbeat(""))
+	require.Equal(t, updater.Message{
+		MonitorMessage: monitor.Message{
+			OK:    true,
+			Lines: []string{"Set A (127.0.0.1) of ip4.hello"},
+		},
+		NotifierMessage: notifier.Message{"Updated A records of ip4.hello with 127.0.0.1 (via SJC, US, warp=off)."},
+	}, resp)
+}
+
+func TestUpdateIPsWithSVCBHints(t *testing.T) {
+	t.Parallel()
+
+	params := api.RecordParams{
+		TTL:     api.TTLAuto,
+		Proxied: false,
+		Comment: recordComment,
+		Tags:    recordTags,
+	}
+
+	ip4 := netip.MustParseAddr("127.0.0.1")
+
+	for name, tc := range map[string]struct {
+		resp1, resp2 setter.ResponseCode
+		expected     updater.Message
+	}{
+		"updated": {
+			setter.ResponseUpdated, setter.ResponseNoop,
+			updater.Message{
+				MonitorMessage: monitor.Message{
+					OK:    true,
+					Lines: []string{"Set A (127.0.0.1) of ip4.hello, ip4.hello1", "Set ipv4hint (127.0.0.1) of HTTPS/SVCB records of ip4.hello"},
+				},
+				NotifierMessage: notifier.Message{
+					"Updated A records of ip4.hello and ip4.hello1 with 127.0.0.1.",
+					"Updated ipv4hint of HTTPS/SVCB records of ip4.hello with 127.0.0.1.",
+				},
+			},
+		},
+		"failed": {
+			setter.ResponseFailed, setter.ResponseUpdated,
+			updater.Message{
+				MonitorMessage: monitor.Message{
+					OK:    false,
+					Lines: []string{"Failed to set ipv4hint (127.0.0.1) of HTTPS/SVCB records of ip4.hello"},
+				},
+				NotifierMessage: notifier.Message{
+					"Updated A records of ip4.hello and ip4.hello1 with 127.0.0.1.",
+					"Failed to properly update ipv4hint of HTTPS/SVCB records of ip4.hello with 127.0.0.1.",
+					"Updated ipv4hint of HTTPS/SVCB records of ip4.hello1 with 127.0.0.1.",
+				},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			ctx := context.Background()
+
+			mockProvider := mocks.NewMockProvider(mockCtrl)
+			mockProvider.EXPECT().Name().Return("local").AnyTimes()
+			mockProvider.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(ip4, true)
+
+			conf := initConfig()
+			conf.Domains = map[ipnet.Type][]domain.Domain{ipnet.IP4: {domain4, domain4_1}}
+			conf.Provider[ipnet.IP4] = mockProvider
+			conf.UpdateSVCBHints = true
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockSetter := mocks.NewMockSetter(mockCtrl)
+			gomock.InOrder(
+				mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4),
+				mockPP.EXPECT().Suppress(pp.MessageIP4DetectionFails),
+				mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domain4, []netip.Addr{ip4}, params).Return(setter.ResponseUpdated),
+				mockSetter.EXPECT().SetSVCBHints(gomock.Any(), mockPP, ipnet.IP4, domain4, []netip.Addr{ip4}).Return(tc.resp1),
+				mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domain4_1, []netip.Addr{ip4}, params).Return(setter.ResponseUpdated),
+				mockSetter.EXPECT().SetSVCBHints(gomock.Any(), mockPP, ipnet.IP4, domain4_1, []netip.Addr{ip4}).Return(tc.resp2),
+			)
+
+			resp := updater.UpdateIPs(ctx, mockPP, conf, mockSetter, updater.NewHoldDown(), updater.NewDisagreements(), updater.NewHeartbeat(""))
+			require.Equal(t, tc.expected, resp)
+		})
+	}
+}
+
+func TestUpdateIPsWithSPF(t *testing.T) {
+	t.Parallel()
+
+	params := api.RecordParams{
+		TTL:     api.TTLAuto,
+		Proxied: false,
+		Comment: recordComment,
+		Tags:    recordTags,
+	}
+
+	ip4 := netip.MustParseAddr("127.0.0.1")
+
+	for name, tc := range map[string]struct {
+		resp1, resp2 setter.ResponseCode
+		expected     updater.Message
+	}{
+		"updated": {
+			setter.ResponseUpdated, setter.ResponseNoop,
+			updater.Message{
+				MonitorMessage: monitor.Message{
+					OK:    true,
+					Lines: []string{"Set A (127.0.0.1) of ip4.hello", "Set ip4 (127.0.0.1) of SPF records of ip4.hello"},
+				},
+				NotifierMessage: notifier.Message{
+					"Updated A records of ip4.hello with 127.0.0.1.",
+					"Updated ip4 mechanisms of SPF records of ip4.hello with 127.0.0.1.",
+				},
+			},
+		},
+		"failed": {
+			setter.ResponseFailed, setter.ResponseUpdated,
+			updater.Message{
+				MonitorMessage: monitor.Message{
+					OK:    false,
+					Lines: []string{"Failed to set ip4 (127.0.0.1) of SPF records of ip4.hello"},
+				},
+				NotifierMessage: notifier.Message{
+					"Updated A records of ip4.hello with 127.0.0.1.",
+					"Failed to properly update ip4 mechanisms of SPF records of ip4.hello with 127.0.0.1.",
+					"Updated ip4 mechanisms of SPF records of ip4.hello1 with 127.0.0.1.",
+				},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			ctx := context.Background()
+
+			mockProvider := mocks.NewMockProvider(mockCtrl)
+			mockProvider.EXPECT().Name().Return("local").AnyTimes()
+			mockProvider.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(ip4, true)
+
+			conf := initConfig()
+			conf.Domains = map[ipnet.Type][]domain.Domain{ipnet.IP4: {domain4}}
+			conf.Provider[ipnet.IP4] = mockProvider
+			conf.SPFDomains = []domain.Domain{domain4, domain4_1}
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockSetter := mocks.NewMockSetter(mockCtrl)
+			gomock.InOrder(
+				mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4),
+				mockPP.EXPECT().Suppress(pp.MessageIP4DetectionFails),
+				mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domain4, []netip.Addr{ip4}, params).Return(setter.ResponseUpdated),
+				mockSetter.EXPECT().SetSPF(gomock.Any(), mockPP, ipnet.IP4, domain4, []netip.Addr{ip4}).Return(tc.resp1),
+				mockSetter.EXPECT().SetSPF(gomock.Any(), mockPP, ipnet.IP4, domain4_1, []netip.Addr{ip4}).Return(tc.resp2),
+			)
+
+			resp := updater.UpdateIPs(ctx, mockPP, conf, mockSetter, updater.NewHoldDown(), updater.NewDisagreements(), updater.NewHeartbeat(""))
+			require.Equal(t, tc.expected, resp)
+		})
+	}
+}
+
+func TestUpdateIPsWithHeartbeat(t *testing.T) {
+	t.Parallel()
+
+	params := api.RecordParams{
+		TTL:     api.TTLAuto,
+		Proxied: false,
+		Comment: recordComment,
+		Tags:    recordTags,
+	}
+
+	ip4 := netip.MustParseAddr("127.0.0.1")
+	heartbeatDomain := domain.FQDN("_ddns.ip4.hello")
+	heartbeatContent := gomock.Regex(`^ips=127\.0\.0\.1 time=\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z version=1\.0\.0$`)
+
+	for name, tc := range map[string]struct {
+		interval time.Duration
+		resps    []setter.ResponseCode // responses of SetHeartbeat in the rounds in which it is called
+		expected updater.Message       // the message of the last round
+	}{
+		"every-round": {
+			0,
+			[]setter.ResponseCode{setter.ResponseUpdated, setter.ResponseUpdated},
+			updater.NewMessage(),
+		},
+		"interval": {
+			time.Hour,
+			[]setter.ResponseCode{setter.ResponseUpdated},
+			updater.NewMessage(),
+		},
+		"failed": {
+			time.Hour,
+			[]setter.ResponseCode{setter.ResponseFailed, setter.ResponseFailed},
+			updater.Message{
+				MonitorMessage: monitor.Message{
+					OK:    false,
+					Lines: []string{"Failed to write the heartbeat TXT record of _ddns.ip4.hello"},
+				},
+				NotifierMessage: notifier.Message{"Failed to write the heartbeat TXT record of _ddns.ip4.hello."},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			ctx := context.Background()
+
+			mockProvider := mocks.NewMockProvider(mockCtrl)
+			mockProvider.EXPECT().Name().Return("local").AnyTimes()
+
+			conf := initConfig()
+			conf.Domains = map[ipnet.Type][]domain.Domain{ipnet.IP4: {domain4}}
+			conf.Provider[ipnet.IP4] = mockProvider
+			conf.HeartbeatDomain = heartbeatDomain
+			conf.HeartbeatInterval = tc.interval
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4).Times(2)
+			mockPP.EXPECT().Suppress(pp.MessageIP4DetectionFails).Times(2)
+			mockSetter := mocks.NewMockSetter(mockCtrl)
+			heartbeat := updater.NewHeartbeat("1.0.0")
+
+			var msg updater.Message
+			for round := range 2 {
+				calls := []any{
+					mockProvider.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(ip4, true),
+					mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domain4, []netip.Addr{ip4}, params).Return(setter.ResponseNoop),
+				}
+				if round < len(tc.resps) {
+					calls = append(calls,
+						mockSetter.EXPECT().SetHeartbeat(gomock.Any(), mockPP, heartbeatDomain, heartbeatContent, params).Return(tc.resps[round]))
+				}
+				gomock.InOrder(calls...)
+
+				msg = updater.UpdateIPs(ctx, mockPP, conf, mockSetter, updater.NewHoldDown(), updater.NewDisagreements(), heartbeat)
+			}
+			require.Equal(t, tc.expected, msg)
+		})
+	}
+}
+
+func TestUpdateIPsWithHeartbeatSkipped(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	mockProvider := mocks.NewMockProvider(mockCtrl)
+	mockProvider.EXPECT().Name().Return("local").AnyTimes()
+	mockProvider.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(netip.Addr{}, false)
+
+	conf := initConfig()
+	conf.Domains = map[ipnet.Type][]domain.Domain{ipnet.IP4: {domain4}}
+	conf.Provider[ipnet.IP4] = mockProvider
+	conf.HeartbeatDomain = domain.FQDN("_ddns.ip4.hello")
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockPP.EXPECT().Noticef(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	mockPP.EXPECT().NoticeOncef(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	mockSetter := mocks.NewMockSetter(mockCtrl)
+
+	// No heartbeat should be written when the detection fails.
+	msg := updater.UpdateIPs(ctx, mockPP, conf, mockSetter, updater.NewHoldDown(), updater.NewDisagreements(), updater.NewHeartbeat("1.0.0"))
+	require.False(t, msg.MonitorMessage.OK)
+}
+
+func TestUpdateIPsWithHeartbeatDomainProviders(t *testing.T) {
+	t.Parallel()
+
+	params := api.RecordParams{
+		TTL:     api.TTLAuto,
+		Proxied: false,
+		Comment: recordComment,
+		Tags:    recordTags,
+	}
+
+	const domainNAS = domain.FQDN("nas.hello")
+	ip4NAS := netip.MustParseAddr("2.2.2.2")
+	heartbeatDomain := domain.FQDN("_ddns.ip4.hello")
+
+	mockCtrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	// The default provider is not used because all domains use other providers.
+	mockProvider := mocks.NewMockProvider(mockCtrl)
+	mockProvider.EXPECT().Name().Return("local").AnyTimes()
+	mockProviderNAS := mocks.NewMockProvider(mockCtrl)
+	mockProviderNAS.EXPECT().Name().Return("url:(redacted)").AnyTimes()
+
+	conf := initConfig()
+	conf.Domains = map[ipnet.Type][]domain.Domain{ipnet.IP4: {domainNAS}}
+	conf.Provider[ipnet.IP4] = mockProvider
+	conf.DomainProviders = map[ipnet.Type][]config.DomainProvider{
+		ipnet.IP4: {{Provider: mockProviderNAS, Domains: []domain.Domain{domainNAS}}},
+	}
+	conf.HeartbeatDomain = heartbeatDomain
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockSetter := mocks.NewMockSetter(mockCtrl)
+	gomock.InOrder(
+		mockProviderNAS.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(ip4NAS, true),
+		mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", ip4NAS),
+		mockPP.EXPECT().Suppress(pp.MessageIP4DetectionFails),
+		mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domainNAS, []netip.Addr{ip4NAS}, params).Return(setter.ResponseNoop),
+		mockSetter.EXPECT().SetHeartbeat(gomock.Any(), mockPP, heartbeatDomain,
+			gomock.Regex(`^ips=2\.2\.2\.2 time=\S+ version=1\.0\.0$`), params).Return(setter.ResponseUpdated),
+	)
+
+	// The heartbeat lists the addresses detected by the other providers.
+	msg := updater.UpdateIPs(ctx, mockPP, conf, mockSetter, updater.NewHoldDown(), updater.NewDisagreements(), updater.NewHeartbeat("1.0.0"))
+	require.Equal(t, updater.NewMessage(), msg)
+}
+
+func TestUpdateIPsWithAllowedIPs(t *testing.T) {
+	t.Parallel()
+
+	ip4 := netip.MustParseAddr("192.168.1.10")
+
+	mockCtrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	mockProvider := mocks.NewMockProvider(mockCtrl)
+	mockProvider.EXPECT().Name().Return("local").AnyTimes()
+	mockProvider.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(ip4, true)
+
+	conf := initConfig()
+	conf.Domains = map[ipnet.Type][]domain.Domain{ipnet.IP4: {domain4}}
+	conf.Provider[ipnet.IP4] = mockProvider
+	conf.AllowedIPs = map[ipnet.Type]ipnet.Policy{ipnet.IP4: {Public: true, Prefixes: nil}}
+
+	mockPP := mocks.NewMockPP(mockCtrl)
+	mockSetter := mocks.NewMockSetter(mockCtrl)
+	gomock.InOrder(
+		mockPP.EXPECT().Noticef(pp.EmojiError, "Detected %s address %s is not allowed by IP%d_ALLOWED=%s", "IPv4", "192.168.1.10", 4, "public"),
+		mockPP.EXPECT().Noticef(pp.EmojiError, "Failed to detect the %s address", "IPv4"),
+		mockPP.EXPECT().NoticeOncef(pp.MessageIP4BlockedByPolicy, pp.EmojiHint, "The address %s is %s. If it is the right address to use, please adjust IP%d_ALLOWED; otherwise, please check whether %s is configured correctly", "192.168.1.10", "a private address", 4, "IP4_PROVIDER=local"),
+	)
+
+	resp := updater.UpdateIPs(ctx, mockPP, conf, mockSetter, updater.NewHoldDown(), updater.NewDisagreements(), updater.NewHeartbeat(""))
+	require.Equal(t, updater.Message{
+		MonitorMessage:  monitor.Message{OK: false, Lines: []string{"Failed to detect IPv4 address"}},
+		NotifierMessage: notifier.Message{"Failed to detect the IPv4 address."},
+	}, resp)
+}
+
+func TestUpdateIPsWithHoldDown(t *testing.T) {
+	t.Parallel()
+
+	params := api.RecordParams{
+		TTL:     api.TTLAuto,
+		Proxied: false,
+		Comment: recordComment,
+		Tags:    recordTags,
+	}
+
+	ip4 := netip.MustParseAddr("1.1.1.1")
+	ip4New := netip.MustParseAddr("2.2.2.2")
+
+	for name, tc := range map[string]struct {
+		rounds   int
+		period   time.Duration
+		progress string
+		thirdIP  netip.Addr
+		expected []updater.Message
+	}{
+		"rounds": {
+			2, 0, "detected in 1 of 2 rounds", ip4New,
+			[]updater.Message{
+				{
+					MonitorMessage:  monitor.Message{OK: true, Lines: []string{"Waiting to confirm IPv4 address 2.2.2.2 (detected in 1 of 2 rounds)"}},
+					NotifierMessage: nil,
+				},
+				{
+					MonitorMessage:  monitor.Message{OK: true, Lines: []string{"Set A (2.2.2.2) of ip4.hello"}},
+					NotifierMessage: notifier.Message{"Updated A records of ip4.hello with 2.2.2.2."},
+				},
+			},
+		},
+		"period": {
+			1, time.Hour, "stable for 0s of 1h0m0s", ip4New,
+			[]updater.Message{
+				{
+					MonitorMessage:  monitor.Message{OK: true, Lines: []string{"Waiting to confirm IPv4 address 2.2.2.2 (stable for 0s of 1h0m0s)"}},
+					NotifierMessage: nil,
+				},
+				// The third round is checked separately because the stable period depends on the clock.
+			},
+		},
+		"reverted": {
+			2, 0, "detected in 1 of 2 rounds", ip4,
+			[]updater.Message{
+				{
+					MonitorMessage:  monitor.Message{OK: true, Lines: []string{"Waiting to confirm IPv4 address 2.2.2.2 (detected in 1 of 2 rounds)"}},
+					NotifierMessage: nil,
+				},
+				{
+					MonitorMessage:  monitor.Message{OK: true, Lines: nil},
+					NotifierMessage: nil,
+				},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			ctx := context.Background()
+
+			mockProvider := mocks.NewMockProvider(mockCtrl)
+			mockProvider.EXPECT().Name().Return("p").AnyTimes()
+
+			conf := initConfig()
+			conf.Domains = map[ipnet.Type][]domain.Domain{ipnet.IP4: {domain4}}
+			conf.Provider[ipnet.IP4] = mockProvider
+			conf.HoldDownRounds = tc.rounds
+			conf.HoldDownPeriod = tc.period
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", gomock.Any()).AnyTimes()
+			mockPP.EXPECT().Suppress(pp.MessageIP4DetectionFails).AnyTimes()
+			mockSetter := mocks.NewMockSetter(mockCtrl)
+			holdDown := updater.NewHoldDown()
+
+			// The first address is used right away when nothing is published yet.
+			gomock.InOrder(
+				mockProvider.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(ip4, true),
+				mockSetter.EXPECT().ListIPs(gomock.Any(), mockPP, ipnet.IP4, domain4, params).Return(nil, true),
+				mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domain4, []netip.Addr{ip4}, params).Return(setter.ResponseNoop),
+			)
+			updater.UpdateIPs(ctx, mockPP, conf, mockSetter, holdDown, updater.NewDisagreements(), updater.NewHeartbeat(""))
+
+			// The new address is held down.
+			gomock.InOrder(
+				mockProvider.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(ip4New, true),
+				mockPP.EXPECT().Infof(pp.EmojiAlarm, "Waiting to confirm the new %s (%s) before updating", "IPv4 address 2.2.2.2", tc.progress),
+			)
+			require.Equal(t, tc.expected[0], updater.UpdateIPs(ctx, mockPP, conf, mockSetter, holdDown, updater.NewDisagreements(), updater.NewHeartbeat("")))
+
+			mockProvider.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(tc.thirdIP, true)
+			switch {
+			case tc.thirdIP == ip4:
+				mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domain4, []netip.Addr{ip4}, params).Return(setter.ResponseNoop)
+			case tc.period > 0:
+				mockPP.EXPECT().Infof(pp.EmojiAlarm, "Waiting to confirm the new %s (%s) before updating", "IPv4 address 2.2.2.2", gomock.Any())
+			default:
+				gomock.InOrder(
+					mockPP.EXPECT().Infof(pp.EmojiGood, "The new %s is confirmed", "IPv4 address 2.2.2.2"),
+					mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domain4, []netip.Addr{ip4New}, params).Return(setter.ResponseUpdated),
+				)
+			}
+			resp := updater.UpdateIPs(ctx, mockPP, conf, mockSetter, holdDown, updater.NewDisagreements(), updater.NewHeartbeat(""))
+			if tc.period > 0 {
+				require.Len(t, resp.MonitorMessage.Lines, 1)
+				require.Contains(t, resp.MonitorMessage.Lines[0], "Waiting to confirm IPv4 address 2.2.2.2")
+			} else {
+				require.Equal(t, tc.expected[1], resp)
+			}
+		})
+	}
+}
+
+func TestUpdateIPsWithHoldDownAfterRestart(t *testing.T) {
+	t.Parallel()
+
+	params := api.RecordParams{
+		TTL:     api.TTLAuto,
+		Proxied: false,
+		Comment: recordComment,
+		Tags:    recordTags,
+	}
+
+	ip4 := netip.MustParseAddr("1.1.1.1")
+	ip4New := netip.MustParseAddr("2.2.2.2")
+	list := api.WAFList{AccountID: "12341234", Name: "list"}
+
+	waiting := updater.Message{
+		MonitorMessage:  monitor.Message{OK: true, Lines: []string{"Waiting to confirm IPv4 address 2.2.2.2 (detected in 1 of 2 rounds)"}},
+		NotifierMessage: nil,
+	}
+
+	for name, tc := range map[string]struct {
+		recordIPs  []netip.Addr
+		listItems  []netip.Prefix
+		detectedIP netip.Addr
+		expected   updater.Message
+	}{
+		"records/old": {
+			[]netip.Addr{ip4}, []netip.Prefix{}, ip4New, waiting,
+		},
+		"records/same": {
+			[]netip.Addr{ip4New}, []netip.Prefix{}, ip4New,
+			updater.Message{
+				MonitorMessage:  monitor.Message{OK: true, Lines: nil},
+				NotifierMessage: nil,
+			},
+		},
+		"list/old": {
+			[]netip.Addr{}, []netip.Prefix{netip.MustParsePrefix("1.1.1.1/32")}, ip4New, waiting,
+		},
+		"list/covering": {
+			[]netip.Addr{}, []netip.Prefix{netip.MustParsePrefix("2.2.0.0/16")}, ip4New,
+			updater.Message{
+				MonitorMessage:  monitor.Message{OK: true, Lines: nil},
+				NotifierMessage: nil,
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			ctx := context.Background()
+
+			mockProvider := mocks.NewMockProvider(mockCtrl)
+			mockProvider.EXPECT().Name().Return("p").AnyTimes()
+
+			conf := initConfig()
+			conf.Domains = map[ipnet.Type][]domain.Domain{ipnet.IP4: {domain4}}
+			conf.Provider[ipnet.IP4] = mockProvider
+			conf.WAFLists = []api.WAFList{list}
+			conf.HoldDownRounds = 2
+
+			mockPP := mocks.NewMockPP(mockCtrl)
+			mockSetter := mocks.NewMockSetter(mockCtrl)
+			mockProvider.EXPECT().GetIP(gomock.Any(), gomock.Any(), ipnet.IP4).Return(tc.detectedIP, true)
+			mockPP.EXPECT().Infof(pp.EmojiInternet, "Detected the %s address %v", "IPv4", tc.detectedIP)
+			mockPP.EXPECT().Suppress(pp.MessageIP4DetectionFails)
+			mockSetter.EXPECT().ListIPs(gomock.Any(), mockPP, ipnet.IP4, domain4, params).Return(tc.recordIPs, true)
+			mockSetter.EXPECT().ListWAFList(gomock.Any(), mockPP, list, wafListDescription).Return(tc.listItems, true)
+			if tc.expected.MonitorMessage.Lines == nil {
+				mockSetter.EXPECT().Set(gomock.Any(), mockPP, ipnet.IP4, domain4, []netip.Addr{tc.detectedIP}, params).Return(setter.ResponseNoop)
+				mockSetter.EXPECT().SetWAFList(gomock.Any(), mockPP, list, wafListDescription, map[ipnet.Type][]netip.Addr{ipnet.IP4: {tc.detectedIP}}, "").Return(setter.ResponseNoop)
+			} else {
+				mockPP.EXPECT().Infof(pp.EmojiAlarm, "Waiting to confirm the new %s (%s) before updating", "IPv4 address 2.2.2.2", "detected in 1 of 2 rounds")
+				mockSetter.EXPECT().SetWAFList(gomock.Any(), mockPP, list, wafListDescription, map[ipnet.Type][]netip.Addr{ipnet.IP4: nil}, "").Return(setter.ResponseNoop)
+			}
+
+			// A fresh hold-down, as if the updater has just been restarted.
+			resp := updater.UpdateIPs(ctx, mockPP, conf, mockSetter, updater.NewHoldDown(), updater.NewDisagreements(), updater.NewHeartbeat(""))
+			require.Equal(t, tc.expected, resp)
+		})
+	}
+}